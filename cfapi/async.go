@@ -0,0 +1,127 @@
+package cfapi
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/cf/api/resources"
+	"code.cloudfoundry.org/cli/cf/errors"
+	"code.cloudfoundry.org/cli/cf/models"
+	"code.cloudfoundry.org/cli/cf/net"
+)
+
+// AsyncPollingInterval - Time to wait between polls of async
+// jobs and of service instance operations in progress
+var AsyncPollingInterval = 5 * time.Second
+
+// AsyncTimeout - Time after which waiting for an async job or a service
+// instance operation started by this library or the copy managers fails
+var AsyncTimeout = 15 * time.Minute
+
+// AsyncTimeoutError - Returned when an async job or a service instance
+// operation did not complete within the time waited for it
+type AsyncTimeoutError struct {
+	Operation string
+	Timeout   time.Duration
+}
+
+// Error -
+func (e *AsyncTimeoutError) Error() string {
+	return fmt.Sprintf("Timed out after %s waiting for %s to complete.", e.Timeout, e.Operation)
+}
+
+// AsyncFailedError - Returned when an async job or a service instance
+// operation failed. The reason is the description reported by the
+// Cloud Controller or the service broker.
+type AsyncFailedError struct {
+	Operation string
+	Reason    string
+}
+
+// Error -
+func (e *AsyncFailedError) Error() string {
+	return fmt.Sprintf("The %s failed: %s", e.Operation, e.Reason)
+}
+
+// WaitForJob - Polls the async job with the given GUID until it finished.
+// A timeout of 0 waits until the job finished or failed.
+func (s *CfCliSession) WaitForJob(jobGUID string, timeout time.Duration) error {
+
+	operation := fmt.Sprintf("job with GUID '%s'", jobGUID)
+	url := fmt.Sprintf("%s/v2/jobs/%s", s.config.APIEndpoint(), jobGUID)
+
+	return s.poll(operation, timeout, func() (bool, error) {
+
+		job := net.JobResource{}
+		if err := s.ccGateway.GetResource(url, &job); err != nil {
+			return false, err
+		}
+		switch job.Entity.Status {
+		case net.JobFinished:
+			return true, nil
+		case net.JobFailed:
+			return true, &AsyncFailedError{
+				Operation: operation,
+				Reason:    job.Entity.ErrorDetails.Description,
+			}
+		}
+		return false, nil
+	})
+}
+
+// WaitForServiceInstance - Polls the last operation of the service instance
+// with the given GUID until it is no longer in progress and returns it. If
+// the instance no longer exists it is assumed to have been deleted and a
+// succeeded delete operation is returned. A timeout of 0 waits until the
+// operation succeeded or failed.
+func (s *CfCliSession) WaitForServiceInstance(serviceInstanceGUID string,
+	timeout time.Duration) (lastOperation models.LastOperationFields, err error) {
+
+	url := fmt.Sprintf("%s/v2/service_instances/%s", s.config.APIEndpoint(), serviceInstanceGUID)
+
+	err = s.poll(
+		fmt.Sprintf("operation on service instance with GUID '%s'", serviceInstanceGUID), timeout,
+		func() (bool, error) {
+
+			instance := resources.ServiceInstanceResource{}
+			if err := s.ccGateway.GetResource(url, &instance); err != nil {
+				if _, ok := err.(*errors.HTTPNotFoundError); ok {
+					lastOperation = models.LastOperationFields{Type: "delete", State: "succeeded"}
+					return true, nil
+				}
+				return false, err
+			}
+			lastOperation = instance.ToFields().LastOperation
+
+			switch strings.ToLower(lastOperation.State) {
+			case "in progress":
+				return false, nil
+			case "failed":
+				return true, &AsyncFailedError{
+					Operation: fmt.Sprintf("%s of service instance with GUID '%s'", lastOperation.Type, serviceInstanceGUID),
+					Reason:    lastOperation.Description,
+				}
+			}
+			return true, nil
+		})
+	return
+}
+
+// poll - Calls the given function every polling interval until it
+// returns that the operation completed or the timeout is exceeded
+func (s *CfCliSession) poll(operation string, timeout time.Duration, completed func() (bool, error)) error {
+
+	start := time.Now()
+	for {
+		done, err := completed()
+		if done || err != nil {
+			return err
+		}
+		if timeout > 0 && time.Since(start) >= timeout {
+			return &AsyncTimeoutError{Operation: operation, Timeout: timeout}
+		}
+		s.logger.DebugMessage("Waiting %s for %s to complete.", AsyncPollingInterval, operation)
+		time.Sleep(AsyncPollingInterval)
+	}
+}
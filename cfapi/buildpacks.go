@@ -0,0 +1,58 @@
+package cfapi
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code.cloudfoundry.org/cli/cf/api"
+	"code.cloudfoundry.org/cli/cf/api/resources"
+	"code.cloudfoundry.org/cli/cf/api/stacks"
+)
+
+// Buildpacks -
+func (s *CfCliSession) Buildpacks() api.BuildpackRepository {
+	return api.NewCloudControllerBuildpackRepository(s.config, s.ccGateway)
+}
+
+// Stacks -
+func (s *CfCliSession) Stacks() stacks.StackRepository {
+	return stacks.NewCloudControllerStackRepository(s.config, s.ccGateway)
+}
+
+// UploadBuildpack - Uploads the given zip file as the bits of a buildpack.
+// The multipart form is streamed from the file as it is for droplets. The
+// Cloud Controller only accepts files named with a ".zip" extension so the
+// extension is appended to the name uploaded if the file does not have it.
+func (s *CfCliSession) UploadBuildpack(buildpackGUID string, buildpack *os.File) error {
+
+	filename := filepath.Base(buildpack.Name())
+	if !strings.HasSuffix(strings.ToLower(filename), ".zip") {
+		filename += ".zip"
+	}
+	stream, err := newFileUploadStream(buildpack, "buildpack", filename,
+		func(r io.Reader, size int64) io.Reader {
+			return newProgressReader(r, s.progress, ProgressUploadBuildpack, buildpackGUID, 0, size)
+		})
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	url := fmt.Sprintf("%s/v2/buildpacks/%s/bits", s.config.APIEndpoint(), buildpackGUID)
+	request, err := s.ccGateway.NewRequest("PUT", url, s.config.AccessToken(), stream)
+	if err != nil {
+		return err
+	}
+	request.HTTPReq.Header.Set("Content-Type", stream.ContentType())
+	request.HTTPReq.ContentLength = stream.ContentLength()
+
+	resource := resources.BuildpackResource{}
+	if _, err = s.ccGateway.PerformRequestForJSONResponse(request, &resource); err != nil {
+		return err
+	}
+	s.logger.DebugMessage("Uploaded bits of buildpack '%s' as '%s'.", resource.Entity.Name, resource.Entity.Filename)
+	return nil
+}
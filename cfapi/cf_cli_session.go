@@ -3,13 +3,11 @@ package cfapi
 import (
 	"crypto/tls"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
-	"github.com/mitchellh/ioprogress"
-
 	"code.cloudfoundry.org/cli/cf/api"
 	"code.cloudfoundry.org/cli/cf/api/appevents"
 	"code.cloudfoundry.org/cli/cf/api/applicationbits"
@@ -18,7 +16,6 @@ import (
 	"code.cloudfoundry.org/cli/cf/api/organizations"
 	"code.cloudfoundry.org/cli/cf/api/spaces"
 	"code.cloudfoundry.org/cli/cf/configuration/coreconfig"
-	"code.cloudfoundry.org/cli/cf/errors"
 	"code.cloudfoundry.org/cli/cf/i18n"
 	"code.cloudfoundry.org/cli/cf/models"
 	"code.cloudfoundry.org/cli/cf/net"
@@ -38,6 +35,13 @@ type CfCliSession struct {
 	httpClient *http.Client
 
 	uaa authentication.UAARepository
+
+	progress ProgressReporter
+
+	// versions of the APIs served by the foundation once
+	// they have been detected and the mutex guarding them
+	apiInfo      *APIInfo
+	apiInfoMutex sync.Mutex
 }
 
 // NewCfCliSessionProvider -
@@ -64,6 +68,10 @@ func (p *CfCliSessionProvider) NewCfSession(
 		}),
 		sslDisabled, logger).(*CfCliSession)
 
+	// The endpoint is set ahead of the refresh so that recorded
+	// exchanges of the refresh have the endpoint's scheme
+	cfCliSession.config.SetAPIEndpoint(apiEndPoint)
+
 	acr := coreconfig.APIConfigRefresher{
 		EndpointRepo: api.NewEndpointRepository(cfCliSession.ccGateway),
 		Config:       cfCliSession.config,
@@ -112,8 +120,9 @@ func (p *CfCliSessionProvider) createCfSession(
 	logger *Logger) CfSession {
 
 	session := &CfCliSession{
-		logger: logger,
-		config: config,
+		logger:   logger,
+		config:   config,
+		progress: NewTerminalProgressReporter(os.Stdout),
 		httpClient: &http.Client{
 			Transport: &http.Transport{
 				TLSClientConfig: &tls.Config{InsecureSkipVerify: sslDisabled},
@@ -126,10 +135,16 @@ func (p *CfCliSessionProvider) createCfSession(
 		i18n.T = i18n.Init(session.config.(i18n.LocalReader))
 	}
 
+	tracePrinter := logger.TracePrinter
+	if recorder := logger.HTTPRecorder(); recorder != nil {
+		tracePrinter = NewRecordingPrinter(tracePrinter, recorder, logger, config)
+		session.httpClient.Transport = NewRecordingTransport(session.httpClient.Transport, recorder, logger.Redactor())
+	}
+
 	envDialTimeout := os.Getenv("CF_DIAL_TIMEOUT")
-	session.ccGateway = net.NewCloudControllerGateway(session.config, time.Now, logger.UI, logger.TracePrinter, envDialTimeout)
-	session.uaaGateway = net.NewUAAGateway(session.config, logger.UI, logger.TracePrinter, envDialTimeout)
-	session.uaa = authentication.NewUAARepository(session.uaaGateway, session.config, net.NewRequestDumper(logger.TracePrinter))
+	session.ccGateway = net.NewCloudControllerGateway(session.config, time.Now, logger.UI, tracePrinter, envDialTimeout)
+	session.uaaGateway = net.NewUAAGateway(session.config, logger.UI, tracePrinter, envDialTimeout)
+	session.uaa = authentication.NewUAARepository(session.uaaGateway, session.config, net.NewRequestDumper(tracePrinter))
 
 	session.ccGateway.SetTokenRefresher(session.uaa)
 	session.uaaGateway.SetTokenRefresher(session.uaa)
@@ -147,6 +162,15 @@ func (s *CfCliSession) GetSessionLogger() *Logger {
 	return s.logger
 }
 
+// SetProgressReporter - Sets the reporter receiving the progress of
+// transfers of app content. A nil reporter discards the progress.
+func (s *CfCliSession) SetProgressReporter(reporter ProgressReporter) {
+	if reporter == nil {
+		reporter = NewSilentProgressReporter()
+	}
+	s.progress = reporter
+}
+
 // HasTarget -
 func (s *CfCliSession) HasTarget() bool {
 	return s.config.HasOrganization() && s.config.HasSpace()
@@ -275,106 +299,3 @@ func (s *CfCliSession) GetServiceCredentials(serviceBinding models.ServiceBindin
 	}
 	return serviceBindingDetail, nil
 }
-
-// DownloadAppContent -
-func (s *CfCliSession) DownloadAppContent(appGUID string, outputFile *os.File, asDroplet bool) (err error) {
-
-	var url string
-	if asDroplet {
-		url = fmt.Sprintf("%s/v2/apps/%s/droplet/download", s.config.APIEndpoint(), appGUID)
-	} else {
-		url = fmt.Sprintf("%s/v2/apps/%s/download", s.config.APIEndpoint(), appGUID)
-	}
-	request, err := s.ccGateway.NewRequest("GET", url, s.config.AccessToken(), nil)
-	if err != nil {
-		return
-	}
-
-	response, err := s.httpClient.Do(request.HTTPReq)
-	if err != nil {
-		if _, ok := err.(*errors.InvalidTokenError); !ok {
-			// Handle token refresh error
-			var newToken string
-			newToken, err = s.uaa.RefreshAuthToken()
-			if err == nil {
-				request.HTTPReq.Header.Set("Authorization", newToken)
-				response, err = s.httpClient.Do(request.HTTPReq)
-			}
-		}
-		if err != nil {
-			return
-		}
-	}
-	defer response.Body.Close()
-	if response.ContentLength > 0 {
-		progressReader := &ioprogress.Reader{
-			Reader:   response.Body,
-			Size:     response.ContentLength,
-			DrawFunc: ioprogress.DrawTerminalf(os.Stdout, drawProgressBar()),
-		}
-		_, err = io.Copy(outputFile, progressReader)
-	} else {
-		_, err = io.Copy(outputFile, response.Body)
-	}
-	return
-}
-
-// UploadDroplet -
-func (s *CfCliSession) UploadDroplet(appGUID string, contentType string, dropletUploadRequest *os.File) error {
-
-	fileStats, err := dropletUploadRequest.Stat()
-	if err != nil {
-		return err
-	}
-	fileSize := fileStats.Size()
-
-	progressReader := readSeekerWrapper{
-		seeker: dropletUploadRequest,
-		reader: &ioprogress.Reader{
-			Reader:   dropletUploadRequest,
-			Size:     fileSize,
-			DrawFunc: ioprogress.DrawTerminalf(os.Stdout, drawProgressBar()),
-		},
-	}
-	_, _ = progressReader.Seek(0, 0)
-
-	url := fmt.Sprintf("%s/v2/apps/%s/droplet/upload", s.config.APIEndpoint(), appGUID)
-	request, err := s.ccGateway.NewRequest("PUT", url, s.config.AccessToken(), progressReader)
-	if err != nil {
-		return err
-	}
-	request.HTTPReq.Header.Set("Content-Type", contentType)
-	request.HTTPReq.ContentLength = fileSize
-
-	response := make(map[string]interface{})
-	_, err = s.ccGateway.PerformRequestForJSONResponse(request, &response)
-	s.logger.DebugMessage("Response from droplet upload: %# v", response)
-
-	return err
-}
-
-// drawProgressBar -
-func drawProgressBar() ioprogress.DrawTextFormatFunc {
-
-	bar := ioprogress.DrawTextFormatBar(60)
-	return func(progress, total int64) string {
-		return fmt.Sprintf(
-			"  %s %s",
-			bar(progress, total),
-			ioprogress.DrawTextFormatBytes(progress, total))
-	}
-}
-
-// readSeakerWrapper -
-type readSeekerWrapper struct {
-	seeker io.ReadSeeker
-	reader io.Reader
-}
-
-func (r readSeekerWrapper) Read(p []byte) (int, error) {
-	return r.reader.Read(p)
-}
-
-func (r readSeekerWrapper) Seek(offset int64, whence int) (next int64, err error) {
-	return r.seeker.Seek(offset, whence)
-}
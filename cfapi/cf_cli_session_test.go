@@ -0,0 +1,1018 @@
+package cfapi_test
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/cf/models"
+	"github.com/mevansam/cf-cli-api/cfapi"
+	"github.com/mevansam/cf-cli-api/cfapi/fakecc"
+	"github.com/mevansam/cf-cli-api/cfapi/replay"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CF CLI Session Tests", func() {
+
+	var (
+		err      error
+		tempDir  string
+		replayer *replay.Replayer
+		session  cfapi.CfSession
+	)
+
+	// newReplaySession - Creates a session targeting space1 of org1
+	// that is served the interactions of the given fixture
+	newReplaySession := func(fixture, spaceGUID string) {
+
+		replayer, err = replay.LoadReplayer(filepath.Join("fixtures", fixture))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		config, err := json.Marshal(map[string]interface{}{
+			"ConfigVersion":         3,
+			"Target":                replayer.URL(),
+			"APIVersion":            "2.75.0",
+			"AuthorizationEndpoint": replayer.URL(),
+			"UaaEndpoint":           replayer.URL(),
+			"AccessToken":           "bearer " + replay.ScrubbedToken,
+			"RefreshToken":          replay.ScrubbedToken,
+			"OrganizationFields":    map[string]interface{}{"GUID": "00000000-0000-4000-8000-999999999999", "Name": "org1"},
+			"SpaceFields":           map[string]interface{}{"GUID": spaceGUID, "Name": "space1"},
+			"SSLDisabled":           true,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		configPath := filepath.Join(tempDir, "config.json")
+		Expect(ioutil.WriteFile(configPath, config, 0600)).To(Succeed())
+
+		session, err = cfapi.NewCfCliSessionProvider().NewCfSessionFromFilepath(
+			configPath, true, cfapi.NewLogger(false, "false"))
+		Expect(err).ShouldNot(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		tempDir, err = ioutil.TempDir("", "session")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		Expect(replayer.Unmatched()).To(BeEmpty())
+		replayer.Close()
+		os.RemoveAll(tempDir)
+	})
+
+	Context("Events", func() {
+
+		BeforeEach(func() {
+			newReplaySession("events.json", "00000000-0000-4000-8000-000000000001")
+		})
+
+		It("Should group the events in a space by their source", func() {
+
+			events, err := session.GetAllEventsInSpace(time.Date(2017, 5, 1, 10, 0, 0, 0, time.UTC), true)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(len(events)).To(Equal(3))
+
+			app1 := events["00000000-0000-4000-8000-000000000002"]
+			Expect(app1.Name).To(Equal("app1"))
+			Expect(app1.Type).To(Equal("app"))
+			Expect(len(app1.EventList)).To(Equal(3))
+			Expect(app1.EventList[0].Name).To(Equal("audit.app.create"))
+			Expect(app1.EventList[1].Description).To(Equal("instances: 2, state: STARTED"))
+			Expect(app1.EventList[2].Name).To(Equal("app.crash"))
+			Expect(app1.EventList[2].Description).To(Equal(
+				"index: 1, reason: CRASHED, exit_description: out of memory, exit_status: 137"))
+
+			Expect(events["00000000-0000-4000-8000-000000000007"].Name).To(Equal("app2"))
+			Expect(events["00000000-0000-4000-8000-000000000009"].Type).To(Equal("service_instance"))
+		})
+		It("Should return the events of an app after a given time", func() {
+
+			event, err := session.GetAllEventsForApp("00000000-0000-4000-8000-000000000002",
+				time.Date(2017, 5, 1, 10, 1, 0, 0, time.UTC), false)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(event.Name).To(Equal("app1"))
+			Expect(len(event.EventList)).To(Equal(2))
+			Expect(event.EventList[0].Name).To(Equal("audit.app.update"))
+			Expect(event.EventList[0].Timestamp).To(Equal(time.Date(2017, 5, 1, 10, 2, 0, 0, time.UTC)))
+		})
+	})
+
+	Context("Service credentials", func() {
+
+		BeforeEach(func() {
+			newReplaySession("service_credentials.json", "00000000-0000-4000-8000-000000000005")
+		})
+
+		It("Should return the credentials of a service binding", func() {
+
+			detail, err := session.GetServiceCredentials(models.ServiceBindingFields{
+				GUID: "00000000-0000-4000-8000-000000000001",
+				URL:  "/v2/service_bindings/00000000-0000-4000-8000-000000000001",
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(detail.Entity.AppGUID).To(Equal("00000000-0000-4000-8000-000000000002"))
+			Expect(detail.Entity.ServiceInstanceGUID).To(Equal("00000000-0000-4000-8000-000000000003"))
+			Expect(detail.Entity.Credentials).To(HaveKey("hostname"))
+			Expect(detail.Entity.Credentials).To(HaveKeyWithValue("password", cfapi.RedactedValue))
+		})
+	})
+
+	Context("Application environment", func() {
+
+		BeforeEach(func() {
+			newReplaySession("app_env.json", "00000000-0000-4000-8000-000000000003")
+		})
+
+		It("Should parse the services and the application the system provides", func() {
+
+			env, err := session.GetAppEnvironment("00000000-0000-4000-8000-000000000001")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(env.SystemRedacted).To(BeFalse())
+			Expect(env.Environment).To(HaveKeyWithValue("LOG_LEVEL", "debug"))
+			Expect(env.RunningEnvGroup).To(HaveKeyWithValue("HTTP_PROXY", "http://proxy.example.com:3128"))
+			Expect(env.StagingEnvGroup).To(HaveKeyWithValue("BP_DEBUG", "true"))
+
+			Expect(len(env.VCAPServices["p-mysql"])).To(Equal(1))
+			mysql, ok := env.VCAPServices.Find("mysql1")
+			Expect(ok).To(BeTrue())
+			Expect(mysql.Plan).To(Equal("100mb"))
+			Expect(mysql.Tags).To(Equal([]string{"mysql", "relational"}))
+			Expect(mysql.Credentials).To(HaveKeyWithValue("username", "dbuser"))
+
+			logger, ok := env.VCAPServices.Find("logger")
+			Expect(ok).To(BeTrue())
+			Expect(logger.Label).To(Equal("user-provided"))
+			Expect(logger.SyslogDrain).To(Equal("syslog://logs.example.com:514"))
+
+			_, ok = env.VCAPServices.Find("unknown")
+			Expect(ok).To(BeFalse())
+
+			Expect(env.VCAPApplication.ApplicationName).To(Equal("app1"))
+			Expect(env.VCAPApplication.ApplicationURIs).To(Equal([]string{"app1.apps.example.com"}))
+			Expect(env.VCAPApplication.SpaceName).To(Equal("space1"))
+			Expect(env.VCAPApplication.OrganizationName).To(Equal("org1"))
+			Expect(env.VCAPApplication.Limits.Mem).To(Equal(int64(512)))
+		})
+		It("Should flag a redacted environment and parse values encoded as strings", func() {
+
+			env, err := session.GetAppEnvironment("00000000-0000-4000-8000-000000000002")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(env.SystemRedacted).To(BeTrue())
+			Expect(env.VCAPServices).To(BeEmpty())
+			Expect(env.Environment).To(BeEmpty())
+			Expect(env.VCAPApplication.ApplicationName).To(Equal("app2"))
+			Expect(env.VCAPApplication.Limits.Mem).To(Equal(int64(256)))
+		})
+	})
+
+	Context("Application content", func() {
+
+		BeforeEach(func() {
+			newReplaySession("app_content.json", "00000000-0000-4000-8000-000000000003")
+		})
+
+		It("Should download the bits and the droplet of an app", func() {
+
+			for asDroplet, expected := range map[bool]string{
+				false: "PK\x03\x04app1 package bits\xff\x00",
+				true:  "\x1f\x8b\x08\x00app1 droplet\xff\x00",
+			} {
+				outputFile, err := ioutil.TempFile(tempDir, "content")
+				Expect(err).ShouldNot(HaveOccurred())
+
+				err = session.DownloadAppContent("00000000-0000-4000-8000-000000000001", outputFile, asDroplet)
+				outputFile.Close()
+				Expect(err).ShouldNot(HaveOccurred())
+
+				content, err := ioutil.ReadFile(outputFile.Name())
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(string(content)).To(Equal(expected))
+			}
+		})
+	})
+})
+
+var _ = Describe("CF CLI Session Downloads", func() {
+
+	var (
+		err     error
+		fake    *fakecc.FakeCC
+		session cfapi.CfSession
+
+		appGUID    string
+		content    []byte
+		outputFile *os.File
+	)
+
+	BeforeEach(func() {
+		cfapi.DownloadRetryWait = time.Millisecond
+
+		fake = fakecc.New()
+		fake.AddUser("admin", "admin-password")
+		spaceGUID := fake.AddSpace(fake.AddOrg("org1"), "space1")
+
+		content = []byte("\x1f\x8b\x08\x00a droplet large enough to be downloaded in parts\xff\x00")
+		appGUID = fake.AddApp(spaceGUID, "app1", nil)
+		fake.SetAppDroplet(appGUID, content)
+
+		session, err = cfapi.NewCfCliSessionProvider().NewCfSession(fake.URL(),
+			"admin", "admin-password", "org1", "space1", true, cfapi.NewLogger(false, "false"))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		outputFile, err = ioutil.TempFile("", "droplet")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		outputFile.Close()
+		os.Remove(outputFile.Name())
+		fake.Close()
+	})
+
+	It("Should resume an interrupted download from where it stopped", func() {
+
+		fake.InterruptDownloads = 2
+
+		err = session.DownloadAppContent(appGUID, outputFile, true)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		downloaded, err := ioutil.ReadFile(outputFile.Name())
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(downloaded).To(Equal(content))
+
+		ranges := 0
+		for _, r := range fake.Requests() {
+			if r == "GET /blobstore/droplets/"+appGUID {
+				ranges++
+			}
+		}
+		Expect(ranges).To(Equal(3))
+	})
+	It("Should refresh the token when it expires before an interrupted download is resumed", func() {
+
+		fake.InterruptDownloads = 1
+		fake.ExpireTokensOnInterrupt = true
+
+		err = session.DownloadAppContent(appGUID, outputFile, true)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		downloaded, err := ioutil.ReadFile(outputFile.Name())
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(downloaded).To(Equal(content))
+
+		requests := []string{}
+		for _, r := range fake.Requests() {
+			if strings.HasSuffix(r, "/download") || r == "POST /oauth/token" || strings.HasPrefix(r, "GET /blobstore/") {
+				requests = append(requests, r)
+			}
+		}
+		download := fmt.Sprintf("GET /v3/droplets/droplet-%s/download", appGUID)
+		blobstore := "GET /blobstore/droplets/" + appGUID
+		Expect(requests[len(requests)-6:]).To(Equal([]string{
+			download, blobstore, download, "POST /oauth/token", download, blobstore,
+		}))
+	})
+	It("Should download again when a resumed download does not continue where it stopped", func() {
+
+		fake.InterruptDownloads = 1
+		fake.MisalignRanges = 1
+
+		err = session.DownloadAppContent(appGUID, outputFile, true)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		downloaded, err := ioutil.ReadFile(outputFile.Name())
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(downloaded).To(Equal(content))
+
+		ranges := 0
+		for _, r := range fake.Requests() {
+			if r == "GET /blobstore/droplets/"+appGUID {
+				ranges++
+			}
+		}
+		Expect(ranges).To(Equal(3))
+	})
+	It("Should report the progress of a resumed download to the session's reporter", func() {
+
+		progress := &progressRecorder{}
+		session.SetProgressReporter(progress)
+		fake.InterruptDownloads = 1
+
+		err = session.DownloadAppContent(appGUID, outputFile, true)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		total := int64(len(content))
+		Expect(progress.operations).To(ConsistOf(cfapi.ProgressDownloadDroplet))
+		Expect(progress.done[0]).To(Equal(total / 2))
+		Expect(progress.done[len(progress.done)-1]).To(Equal(total))
+		Expect(progress.totals).To(ConsistOf(total))
+	})
+	It("Should fail once the download was interrupted more often than retried", func() {
+
+		fake.InterruptDownloads = cfapi.DownloadRetries + 1
+
+		err = session.DownloadAppContent(appGUID, outputFile, true)
+		Expect(err).Should(HaveOccurred())
+	})
+	It("Should return a typed error when the content does not match its checksum", func() {
+
+		fake.CorruptDownloads = true
+
+		err = session.DownloadAppContent(appGUID, outputFile, true)
+		Expect(err).Should(HaveOccurred())
+
+		mismatch, ok := err.(*cfapi.ChecksumMismatchError)
+		Expect(ok).Should(BeTrue())
+		Expect(mismatch.AppGUID).To(Equal(appGUID))
+		Expect(mismatch.Algorithm).To(Equal("sha256"))
+		Expect(mismatch.Actual).ToNot(Equal(mismatch.Expected))
+	})
+	It("Should verify content downloaded via the v2 API against the checksum the v2 API reports", func() {
+
+		fake.CorruptDownloads = true
+
+		for _, noV3Downloads := range []bool{false, true} {
+			fake.V2Only, fake.NoV3Downloads = !noV3Downloads, noV3Downloads
+
+			session, err := cfapi.NewCfCliSessionProvider().NewCfSession(fake.URL(),
+				"admin", "admin-password", "org1", "space1", true, cfapi.NewLogger(false, "false"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(outputFile.Truncate(0)).To(Succeed())
+			_, err = outputFile.Seek(0, io.SeekStart)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			err = session.DownloadAppContent(appGUID, outputFile, true)
+			Expect(err).Should(HaveOccurred())
+
+			mismatch, ok := err.(*cfapi.ChecksumMismatchError)
+			Expect(ok).Should(BeTrue())
+			Expect(mismatch.Algorithm).To(Equal("sha1"))
+			Expect(mismatch.Expected).To(Equal(fmt.Sprintf("%x", sha1.Sum(content))))
+			session.Close()
+		}
+	})
+	It("Should stream a droplet upload and resend it once the token has been refreshed", func() {
+
+		progress := &progressRecorder{}
+		session.SetProgressReporter(progress)
+
+		droplet := []byte("\x1f\x8b\x08\x00an uploaded droplet\xff\x00")
+		_, err = outputFile.Write(droplet)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		fake.ExpireTokens()
+		err = session.UploadDroplet(appGUID, outputFile)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		uploaded, ok := fake.AppDroplet(appGUID)
+		Expect(ok).Should(BeTrue())
+		Expect(uploaded).To(Equal(droplet))
+
+		uploads := 0
+		for _, r := range fake.Requests() {
+			if r == "PUT /v2/apps/"+appGUID+"/droplet/upload" {
+				uploads++
+			}
+		}
+		Expect(uploads).To(Equal(2))
+
+		Expect(progress.operations).To(ConsistOf(cfapi.ProgressUploadDroplet))
+		Expect(progress.totals).To(ConsistOf(int64(len(droplet))))
+		Expect(progress.done[len(progress.done)-1]).To(Equal(int64(len(droplet))))
+	})
+	It("Should return the error when a droplet upload fails", func() {
+
+		_, err = outputFile.Write([]byte("droplet"))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		err = session.UploadDroplet("unknown-app-guid", outputFile)
+		Expect(err).Should(HaveOccurred())
+	})
+	It("Should stream a buildpack upload reporting its progress", func() {
+
+		progress := &progressRecorder{}
+		session.SetProgressReporter(progress)
+
+		buildpack, err := session.Buildpacks().Create("go_buildpack", nil, nil, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		bits := []byte("PK\x03\x04an uploaded buildpack\x00\xff")
+		_, err = outputFile.Write(bits)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		err = session.UploadBuildpack(buildpack.GUID, outputFile)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		uploaded, ok := fake.BuildpackBits(buildpack.GUID)
+		Expect(ok).Should(BeTrue())
+		Expect(uploaded).To(Equal(bits))
+
+		buildpack, err = session.Buildpacks().FindByName("go_buildpack")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(buildpack.Filename).To(Equal(filepath.Base(outputFile.Name()) + ".zip"))
+
+		Expect(progress.operations).To(ConsistOf(cfapi.ProgressUploadBuildpack))
+		Expect(progress.done[len(progress.done)-1]).To(Equal(int64(len(bits))))
+	})
+})
+
+var _ = Describe("CF CLI Session Async Operations", func() {
+
+	var (
+		err     error
+		fake    *fakecc.FakeCC
+		session cfapi.CfSession
+
+		appGUID  string
+		planGUID string
+	)
+
+	BeforeEach(func() {
+		cfapi.AsyncPollingInterval = time.Millisecond
+
+		fake = fakecc.New()
+		fake.AsyncPolls = 3
+		fake.AddUser("admin", "admin-password")
+		spaceGUID := fake.AddSpace(fake.AddOrg("org1"), "space1")
+		appGUID = fake.AddApp(spaceGUID, "app1", nil)
+
+		_, plans := fake.AddServiceOffering("p-mysql", "100mb")
+		planGUID = plans[0]
+
+		session, err = cfapi.NewCfCliSessionProvider().NewCfSession(fake.URL(),
+			"admin", "admin-password", "org1", "space1", true, cfapi.NewLogger(false, "false"))
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		fake.Close()
+	})
+
+	// createServiceInstance - Starts provisioning a service instance and returns it
+	createServiceInstance := func() models.ServiceInstance {
+		Expect(session.Services().CreateServiceInstance("mysql1", planGUID, nil, nil)).To(Succeed())
+		serviceInstance, err := session.Services().FindInstanceByName("mysql1")
+		Expect(err).ShouldNot(HaveOccurred())
+		return serviceInstance
+	}
+
+	It("Should wait until a service instance has been provisioned", func() {
+
+		serviceInstance := createServiceInstance()
+		Expect(session.ServiceBindings().Create(serviceInstance.GUID, appGUID, nil)).ToNot(Succeed())
+
+		lastOperation, err := session.WaitForServiceInstance(serviceInstance.GUID, time.Minute)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(lastOperation.Type).To(Equal("create"))
+		Expect(lastOperation.State).To(Equal("succeeded"))
+
+		Expect(session.ServiceBindings().Create(serviceInstance.GUID, appGUID, nil)).To(Succeed())
+	})
+	It("Should return the reason a service instance operation failed", func() {
+
+		fake.FailNextServiceOperation("Service broker is unavailable")
+		serviceInstance := createServiceInstance()
+
+		lastOperation, err := session.WaitForServiceInstance(serviceInstance.GUID, time.Minute)
+		Expect(err).Should(HaveOccurred())
+		Expect(lastOperation.State).To(Equal("failed"))
+
+		failed, ok := err.(*cfapi.AsyncFailedError)
+		Expect(ok).Should(BeTrue())
+		Expect(failed.Reason).To(Equal("Service broker is unavailable"))
+	})
+	It("Should time out waiting for a service instance operation", func() {
+
+		fake.AsyncPolls = 1000
+		serviceInstance := createServiceInstance()
+
+		_, err = session.WaitForServiceInstance(serviceInstance.GUID, 20*time.Millisecond)
+		Expect(err).Should(HaveOccurred())
+
+		timeout, ok := err.(*cfapi.AsyncTimeoutError)
+		Expect(ok).Should(BeTrue())
+		Expect(timeout.Timeout).To(Equal(20 * time.Millisecond))
+	})
+	It("Should return a succeeded delete once the service instance is gone", func() {
+
+		lastOperation, err := session.WaitForServiceInstance("unknown-service-instance-guid", time.Minute)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(lastOperation.Type).To(Equal("delete"))
+		Expect(lastOperation.State).To(Equal("succeeded"))
+	})
+	It("Should return the reason the job processing an uploaded droplet failed", func() {
+
+		dropletFile, err := ioutil.TempFile("", "droplet")
+		Expect(err).ShouldNot(HaveOccurred())
+		defer func() {
+			dropletFile.Close()
+			os.Remove(dropletFile.Name())
+		}()
+		_, err = dropletFile.Write([]byte("droplet"))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		fake.FailNextJob("Droplet could not be processed")
+		err = session.UploadDroplet(appGUID, dropletFile)
+		Expect(err).Should(HaveOccurred())
+
+		failed, ok := err.(*cfapi.AsyncFailedError)
+		Expect(ok).Should(BeTrue())
+		Expect(failed.Reason).To(Equal("Droplet could not be processed"))
+	})
+})
+
+var _ = Describe("CF CLI Session API Detection", func() {
+
+	var (
+		fake *fakecc.FakeCC
+
+		spaceGUID string
+		appGUID   string
+	)
+
+	BeforeEach(func() {
+		fake = fakecc.New()
+		fake.AddUser("admin", "admin-password")
+		spaceGUID = fake.AddSpace(fake.AddOrg("org1"), "space1")
+		appGUID = fake.AddApp(spaceGUID, "app1", nil)
+		fake.SetAppPackage(appGUID, []byte("app1 bits"))
+
+		fake.AddEvent(fakecc.Event{
+			Type:      "audit.app.create",
+			Actee:     appGUID,
+			ActeeType: "app",
+			ActeeName: "app1",
+			SpaceGUID: spaceGUID,
+			Timestamp: time.Date(2017, 5, 1, 10, 1, 0, 0, time.UTC),
+		})
+	})
+	AfterEach(func() {
+		fake.Close()
+	})
+
+	// newSession - Returns a session for the fake and the number
+	// of requests the fake received while it was created
+	newSession := func() (cfapi.CfSession, int) {
+		session, err := cfapi.NewCfCliSessionProvider().NewCfSession(fake.URL(),
+			"admin", "admin-password", "org1", "space1", true, cfapi.NewLogger(false, "false"))
+		Expect(err).ShouldNot(HaveOccurred())
+		return session, len(fake.Requests())
+	}
+
+	// requested - Returns the paths of the requests received since the given count
+	requested := func(since int) []string {
+		paths := []string{}
+		for _, r := range fake.Requests()[since:] {
+			paths = append(paths, strings.SplitN(r, "?", 2)[0])
+		}
+		return paths
+	}
+
+	It("Should use the v3 API once the API root advertises it", func() {
+
+		session, since := newSession()
+
+		info, err := session.GetAPIInfo()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(info.V2Version).To(Equal("2.75.0"))
+		Expect(info.SupportsV3()).To(BeTrue())
+
+		events, err := session.GetAllEventsInSpace(time.Date(2017, 5, 1, 10, 0, 0, 0, time.UTC), true)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(events[appGUID].Name).To(Equal("app1"))
+		Expect(events[appGUID].EventList[0].Name).To(Equal("audit.app.create"))
+
+		outputFile, err := ioutil.TempFile("", "bits")
+		Expect(err).ShouldNot(HaveOccurred())
+		defer func() {
+			outputFile.Close()
+			os.Remove(outputFile.Name())
+		}()
+		Expect(session.DownloadAppContent(appGUID, outputFile, false)).To(Succeed())
+
+		paths := requested(since)
+		Expect(paths).To(ContainElement("GET /v3/audit_events"))
+		Expect(paths).To(ContainElement(fmt.Sprintf("GET /v3/packages/package-%s/download", appGUID)))
+		Expect(paths).ToNot(ContainElement("GET /v2/events"))
+
+		// The API root is requested only once per session
+		roots := 0
+		for _, p := range paths {
+			if p == "GET /" {
+				roots++
+			}
+		}
+		Expect(roots).To(Equal(1))
+	})
+	It("Should download content via the v2 API when the v3 API does not serve downloads", func() {
+
+		fake.NoV3Downloads = true
+		fake.SetAppDroplet(appGUID, []byte("app1 droplet"))
+		session, since := newSession()
+
+		outputFile, err := ioutil.TempFile("", "content")
+		Expect(err).ShouldNot(HaveOccurred())
+		defer func() {
+			outputFile.Close()
+			os.Remove(outputFile.Name())
+		}()
+		for _, asDroplet := range []bool{false, true} {
+			Expect(outputFile.Truncate(0)).To(Succeed())
+			_, err = outputFile.Seek(0, io.SeekStart)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(session.DownloadAppContent(appGUID, outputFile, asDroplet)).To(Succeed())
+
+			content, err := ioutil.ReadFile(outputFile.Name())
+			Expect(err).ShouldNot(HaveOccurred())
+			if asDroplet {
+				Expect(string(content)).To(Equal("app1 droplet"))
+			} else {
+				Expect(string(content)).To(Equal("app1 bits"))
+			}
+		}
+
+		paths := requested(since)
+		Expect(paths).To(ContainElement(fmt.Sprintf("GET /v3/packages/package-%s/download", appGUID)))
+		Expect(paths).To(ContainElement(fmt.Sprintf("GET /v2/apps/%s/download", appGUID)))
+		Expect(paths).To(ContainElement(fmt.Sprintf("GET /v3/droplets/droplet-%s/download", appGUID)))
+		Expect(paths).To(ContainElement(fmt.Sprintf("GET /v2/apps/%s/droplet/download", appGUID)))
+	})
+	It("Should fall back to the v2 API when the v3 API is not served", func() {
+
+		fake.V2Only = true
+		session, since := newSession()
+
+		info, err := session.GetAPIInfo()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(info.V2Version).To(Equal("2.75.0"))
+		Expect(info.SupportsV3()).To(BeFalse())
+
+		events, err := session.GetAllEventsForApp(appGUID, time.Date(2017, 5, 1, 10, 0, 0, 0, time.UTC), true)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(len(events.EventList)).To(Equal(1))
+
+		outputFile, err := ioutil.TempFile("", "bits")
+		Expect(err).ShouldNot(HaveOccurred())
+		defer func() {
+			outputFile.Close()
+			os.Remove(outputFile.Name())
+		}()
+		Expect(session.DownloadAppContent(appGUID, outputFile, false)).To(Succeed())
+
+		content, err := ioutil.ReadFile(outputFile.Name())
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(string(content)).To(Equal("app1 bits"))
+
+		for _, p := range requested(since) {
+			Expect(p).ToNot(HavePrefix("GET /v3/"))
+		}
+	})
+})
+
+var _ = Describe("CF CLI Session App Instances", func() {
+
+	var (
+		err     error
+		fake    *fakecc.FakeCC
+		session cfapi.CfSession
+
+		appGUID string
+	)
+
+	BeforeEach(func() {
+		cfapi.AsyncPollingInterval = time.Millisecond
+
+		fake = fakecc.New()
+		fake.AsyncPolls = 3
+		fake.AddUser("admin", "admin-password")
+		spaceGUID := fake.AddSpace(fake.AddOrg("org1"), "space1")
+		appGUID = fake.AddApp(spaceGUID, "app1", map[string]interface{}{"instances": 2, "memory": 512})
+		fake.SetAppPackage(appGUID, []byte("app1 bits"))
+
+		session, err = cfapi.NewCfCliSessionProvider().NewCfSession(fake.URL(),
+			"admin", "admin-password", "org1", "space1", true, cfapi.NewLogger(false, "false"))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		state := "STARTED"
+		_, err = session.Applications().Update(appGUID, models.AppParams{State: &state})
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		fake.Close()
+	})
+
+	It("Should wait until the instances are running and return their usage", func() {
+
+		instances, err := session.GetAppInstances(appGUID)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(len(instances)).To(Equal(2))
+		Expect(instances[0].State).To(Equal(cfapi.AppInstanceStarting))
+
+		instances, err = session.WaitForAppInstances(appGUID, 2, time.Minute)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(len(instances)).To(Equal(2))
+		for i, instance := range instances {
+			Expect(instance.Index).To(Equal(i))
+			Expect(instance.State).To(Equal(cfapi.AppInstanceRunning))
+			Expect(instance.Host).ToNot(BeEmpty())
+			Expect(instance.Port).ToNot(BeZero())
+			Expect(instance.MemQuota).To(Equal(int64(512 * 1024 * 1024)))
+			Expect(instance.MemUsage).To(BeNumerically(">", 0))
+			Expect(instance.CPUUsage).To(BeNumerically(">", 0))
+		}
+	})
+	It("Should report the instances which crashed with their reasons", func() {
+
+		fake.AsyncPolls = 0
+		fake.CrashAppInstance(appGUID, 1, "out of memory")
+
+		instances, err := session.WaitForAppInstances(appGUID, 2, time.Minute)
+		Expect(err).Should(HaveOccurred())
+		Expect(len(instances)).To(Equal(2))
+
+		crashed, ok := err.(*cfapi.AppInstancesCrashedError)
+		Expect(ok).Should(BeTrue())
+		Expect(len(crashed.Crashed)).To(Equal(1))
+		Expect(crashed.Crashed[0].Index).To(Equal(1))
+		Expect(crashed.Crashed[0].Details).To(Equal("out of memory"))
+		Expect(err.Error()).To(ContainSubstring("#1 (out of memory)"))
+
+		// The instance which is running suffices
+		_, err = session.WaitForAppInstances(appGUID, 1, time.Minute)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+	It("Should time out waiting for instances which keep starting", func() {
+
+		fake.AsyncPolls = 1000
+
+		_, err := session.WaitForAppInstances(appGUID, 1, 20*time.Millisecond)
+		Expect(err).Should(HaveOccurred())
+
+		timeout, ok := err.(*cfapi.AsyncTimeoutError)
+		Expect(ok).Should(BeTrue())
+		Expect(timeout.Timeout).To(Equal(20 * time.Millisecond))
+	})
+})
+
+var _ = Describe("CF CLI Session App Lifecycle", func() {
+
+	var (
+		err     error
+		fake    *fakecc.FakeCC
+		session cfapi.CfSession
+
+		appGUID string
+	)
+
+	BeforeEach(func() {
+		cfapi.AsyncPollingInterval = time.Millisecond
+
+		fake = fakecc.New()
+		fake.AsyncPolls = 3
+		fake.AddUser("admin", "admin-password")
+		spaceGUID := fake.AddSpace(fake.AddOrg("org1"), "space1")
+		appGUID = fake.AddApp(spaceGUID, "app1", map[string]interface{}{"instances": 2, "memory": 512})
+		fake.SetAppPackage(appGUID, []byte("app1 bits"))
+
+		session, err = cfapi.NewCfCliSessionProvider().NewCfSession(fake.URL(),
+			"admin", "admin-password", "org1", "space1", true, cfapi.NewLogger(false, "false"))
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		fake.Close()
+	})
+
+	It("Should start an app and wait until all its instances are running", func() {
+
+		instances, err := session.StartApp(appGUID, time.Minute)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(len(instances)).To(Equal(2))
+		for _, instance := range instances {
+			Expect(instance.State).To(Equal(cfapi.AppInstanceRunning))
+		}
+
+		polled := len(fake.Requests())
+		Expect(session.StopApp(appGUID, time.Minute)).To(Succeed())
+		entity, _ := fake.Entity("apps", appGUID)
+		Expect(entity["state"]).To(Equal("STOPPED"))
+
+		// the instances are reported as running for AsyncPolls
+		// polls and are gone once the app has been stopped
+		instancesRequests := 0
+		for _, r := range fake.Requests()[polled:] {
+			if r == fmt.Sprintf("GET /v2/apps/%s/instances", appGUID) {
+				instancesRequests++
+			}
+		}
+		Expect(instancesRequests).To(Equal(fake.AsyncPolls + 1))
+
+		instances, err = session.RestartApp(appGUID, time.Minute)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(len(instances)).To(Equal(2))
+	})
+	It("Should return the details of a failed staging", func() {
+
+		fake.FailNextStaging("BuildpackCompileFailed", "App staging failed in the buildpack compile phase")
+
+		_, err := session.StartApp(appGUID, time.Minute)
+		Expect(err).Should(HaveOccurred())
+
+		staging, ok := err.(*cfapi.AppStagingFailedError)
+		Expect(ok).Should(BeTrue())
+		Expect(staging.AppGUID).To(Equal(appGUID))
+		Expect(staging.Reason).To(Equal("BuildpackCompileFailed"))
+		Expect(staging.Description).To(Equal("App staging failed in the buildpack compile phase"))
+
+		// Restaging stages the app again and starts it
+		instances, err := session.RestageApp(appGUID, time.Minute)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(len(instances)).To(Equal(2))
+	})
+	It("Should scale an app and wait for the instances of a started app", func() {
+
+		count, memory := 3, int64(1024)
+		instances, err := session.ScaleApp(appGUID, cfapi.AppScale{Instances: &count, MemoryInMB: &memory}, time.Minute)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(len(instances)).To(Equal(0))
+
+		_, err = session.StartApp(appGUID, time.Minute)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		count = 1
+		instances, err = session.ScaleApp(appGUID, cfapi.AppScale{Instances: &count}, time.Minute)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(len(instances)).To(Equal(1))
+		Expect(instances[0].MemQuota).To(Equal(int64(1024 * 1024 * 1024)))
+	})
+	It("Should fail when an instance crashed or the app did not start in time", func() {
+
+		fake.AsyncPolls = 1000
+
+		_, err := session.StartApp(appGUID, 20*time.Millisecond)
+		Expect(err).Should(HaveOccurred())
+
+		timeout, ok := err.(*cfapi.AsyncTimeoutError)
+		Expect(ok).Should(BeTrue())
+		Expect(timeout.Timeout).To(Equal(20 * time.Millisecond))
+
+		fake.AsyncPolls = 0
+		fake.CrashAppInstance(appGUID, 0, "failed health check")
+
+		_, err = session.StartApp(appGUID, time.Minute)
+		_, ok = err.(*cfapi.AppInstancesCrashedError)
+		Expect(ok).Should(BeTrue())
+	})
+})
+
+var _ = Describe("CF CLI Session Logs", func() {
+
+	var (
+		err     error
+		fake    *fakecc.FakeCC
+		session cfapi.CfSession
+
+		appGUID string
+	)
+
+	BeforeEach(func() {
+		cfapi.AsyncPollingInterval = time.Millisecond
+		cfapi.LogPollingInterval = time.Millisecond
+		cfapi.LogCacheReadLimit = 1000
+
+		fake = fakecc.New()
+		fake.AddUser("admin", "admin-password")
+		spaceGUID := fake.AddSpace(fake.AddOrg("org1"), "space1")
+		appGUID = fake.AddApp(spaceGUID, "app1", nil)
+		fake.SetAppPackage(appGUID, []byte("app1 bits"))
+	})
+	AfterEach(func() {
+		fake.Close()
+	})
+
+	newSession := func() cfapi.CfSession {
+		session, err := cfapi.NewCfCliSessionProvider().NewCfSession(fake.URL(),
+			"admin", "admin-password", "org1", "space1", true, cfapi.NewLogger(false, "false"))
+		Expect(err).ShouldNot(HaveOccurred())
+		return session
+	}
+
+	It("Should discover the log cache and return the most recent logs in order", func() {
+		session = newSession()
+
+		info, err := session.GetAPIInfo()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(info.LogCacheURL).To(Equal(fake.URL()))
+		Expect(info.DopplerURL).To(HavePrefix("ws://"))
+
+		fake.AddAppLog(appGUID, "APP/PROC/WEB", "0", "line 1", false)
+		fake.AddAppLog(appGUID, "APP/PROC/WEB", "1", "line 2", true)
+		fake.AddAppLog(appGUID, "RTR", "0", "line 3", false)
+
+		logs, err := session.GetRecentLogs(appGUID, 2)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(len(logs)).To(Equal(2))
+		Expect(logs[0].Message).To(Equal("line 2"))
+		Expect(logs[0].SourceType).To(Equal("APP/PROC/WEB"))
+		Expect(logs[0].InstanceID).To(Equal("1"))
+		Expect(logs[0].IsError).To(BeTrue())
+		Expect(logs[0].String()).To(ContainSubstring("[APP/PROC/WEB/1] ERR line 2"))
+		Expect(logs[1].Message).To(Equal("line 3"))
+
+		// Reading logs refreshes an expired access token
+		fake.ExpireTokens()
+		logs, err = session.GetRecentLogs(appGUID, 0)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(len(logs)).To(Equal(3))
+	})
+	It("Should return the staging output of an app which failed to stage", func() {
+		session = newSession()
+
+		fake.FailNextStaging("BuildpackCompileFailed", "Failed to compile droplet")
+		_, err = session.StartApp(appGUID, time.Minute)
+		Expect(err).Should(HaveOccurred())
+
+		logs, err := session.GetRecentLogs(appGUID, 100)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(len(logs)).To(BeNumerically(">", 0))
+		Expect(logs[len(logs)-1].SourceType).To(Equal("STG"))
+		Expect(logs[len(logs)-1].Message).To(Equal("Failed to compile droplet"))
+		Expect(logs[len(logs)-1].IsError).To(BeTrue())
+	})
+	It("Should stream only the logs logged after streaming started", func() {
+		session = newSession()
+		fake.AddAppLog(appGUID, "APP/PROC/WEB", "0", "before", false)
+
+		stop := make(chan struct{})
+		logs, errs := session.StreamLogs(appGUID, stop)
+
+		time.Sleep(10 * time.Millisecond)
+		fake.AddAppLog(appGUID, "APP/PROC/WEB", "0", "after 1", false)
+		fake.AddAppLog(appGUID, "APP/PROC/WEB", "0", "after 2", false)
+
+		var l cfapi.AppLog
+		Eventually(logs, time.Second).Should(Receive(&l))
+		Expect(l.Message).To(Equal("after 1"))
+		Eventually(logs, time.Second).Should(Receive(&l))
+		Expect(l.Message).To(Equal("after 2"))
+		Consistently(logs, 20*time.Millisecond).ShouldNot(Receive())
+
+		close(stop)
+		Eventually(logs, time.Second).Should(BeClosed())
+		Eventually(errs, time.Second).Should(BeClosed())
+	})
+	It("Should stream all logs logged at the same time when a read returns only some of them", func() {
+		cfapi.LogCacheReadLimit = 3
+		session = newSession()
+
+		stop := make(chan struct{})
+		defer close(stop)
+		logs, _ := session.StreamLogs(appGUID, stop)
+
+		time.Sleep(10 * time.Millisecond)
+		fake.AddAppLog(appGUID, "APP/PROC/WEB", "0", "line 1", false)
+		fake.AddSimultaneousAppLogs(appGUID, "APP/PROC/WEB", "0", "line 2", "line 3", "line 4")
+		fake.AddAppLog(appGUID, "APP/PROC/WEB", "0", "line 5", false)
+
+		for _, message := range []string{"line 1", "line 2", "line 3", "line 4", "line 5"} {
+			var l cfapi.AppLog
+			Eventually(logs, time.Second).Should(Receive(&l))
+			Expect(l.Message).To(Equal(message))
+		}
+		Consistently(logs, 20*time.Millisecond).ShouldNot(Receive())
+	})
+	It("Should fail to read logs if the foundation has no log cache", func() {
+		fake.V2Only = true
+		session = newSession()
+
+		_, err = session.GetRecentLogs(appGUID, 100)
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("does not advertise a log cache endpoint"))
+
+		_, errs := session.StreamLogs(appGUID, make(chan struct{}))
+		Eventually(errs, time.Second).Should(Receive(&err))
+		Expect(err).Should(HaveOccurred())
+	})
+})
+
+// progressRecorder - Records the distinct operations and totals
+// reported and the progress of each report
+type progressRecorder struct {
+	operations []cfapi.ProgressOperation
+	totals     []int64
+	done       []int64
+}
+
+func (r *progressRecorder) Progress(operation cfapi.ProgressOperation, appGUID string, done, total int64) {
+	if len(r.operations) == 0 || r.operations[len(r.operations)-1] != operation {
+		r.operations = append(r.operations, operation)
+	}
+	if len(r.totals) == 0 || r.totals[len(r.totals)-1] != total {
+		r.totals = append(r.totals, total)
+	}
+	r.done = append(r.done, done)
+}
@@ -8,8 +8,14 @@ import (
 	"code.cloudfoundry.org/cli/cf/api/appevents"
 	"code.cloudfoundry.org/cli/cf/api/applicationbits"
 	"code.cloudfoundry.org/cli/cf/api/applications"
+	"code.cloudfoundry.org/cli/cf/api/environmentvariablegroups"
+	"code.cloudfoundry.org/cli/cf/api/featureflags"
 	"code.cloudfoundry.org/cli/cf/api/organizations"
+	"code.cloudfoundry.org/cli/cf/api/quotas"
+	"code.cloudfoundry.org/cli/cf/api/securitygroups"
+	"code.cloudfoundry.org/cli/cf/api/spacequotas"
 	"code.cloudfoundry.org/cli/cf/api/spaces"
+	"code.cloudfoundry.org/cli/cf/api/stacks"
 	"code.cloudfoundry.org/cli/cf/models"
 )
 
@@ -35,6 +41,7 @@ type CfSession interface {
 	Close()
 
 	GetSessionLogger() *Logger
+	SetProgressReporter(reporter ProgressReporter)
 
 	HasTarget() bool
 
@@ -57,6 +64,8 @@ type CfSession interface {
 	UserProvidedServices() api.UserProvidedServiceInstanceRepository
 	ServiceKeys() api.ServiceKeyRepository
 	ServiceBindings() api.ServiceBindingRepository
+	ServiceBrokers() api.ServiceBrokerRepository
+	ServicePlanVisibilities() api.ServicePlanVisibilityRepository
 
 	AppSummary() api.AppSummaryRepository
 	Applications() applications.Repository
@@ -64,11 +73,90 @@ type CfSession interface {
 	AppEvents() appevents.Repository
 	Routes() api.RouteRepository
 	Domains() api.DomainRepository
+	RouterGroups() api.RoutingAPIRepository
+	Quotas() quotas.QuotaRepository
+	SpaceQuotas() spacequotas.SpaceQuotaRepository
+	SecurityGroups() securitygroups.SecurityGroupRepo
+	Buildpacks() api.BuildpackRepository
+	Stacks() stacks.StackRepository
+	FeatureFlags() featureflags.FeatureFlagRepository
+	EnvironmentVariableGroups() environmentvariablegroups.Repository
 
 	GetAllEventsInSpace(from time.Time, inclusive bool) (events map[string]CfEvent, err error)
 	GetAllEventsForApp(appGUID string, from time.Time, inclusive bool) (event CfEvent, err error)
 	GetServiceCredentials(models.ServiceBindingFields) (*ServiceBindingDetail, error)
+	GetAppEnvironment(appGUID string) (*AppEnvironment, error)
+	GetOrgQuotaUsage(orgGUID string) (QuotaUsage, error)
+	GetSpaceQuotaUsage(spaceGUID string) (QuotaUsage, error)
+
+	BindSecurityGroupToSpace(securityGroupGUID, spaceGUID string, lifecycle SecurityGroupLifecycle) error
+	UnbindSecurityGroupFromSpace(securityGroupGUID, spaceGUID string, lifecycle SecurityGroupLifecycle) error
+	GetSpaceSecurityGroups(spaceGUID string, lifecycle SecurityGroupLifecycle) ([]models.SecurityGroupFields, error)
+
+	GetOrgUsers(orgGUID string) ([]UserRoles, error)
+	GetSpaceUsers(spaceGUID string) ([]UserRoles, error)
+	SetOrgRole(orgGUID, username, origin string, role models.Role) error
+	UnsetOrgRole(orgGUID, username, origin string, role models.Role) error
+	SetSpaceRole(spaceGUID, username, origin string, role models.Role) error
+	UnsetSpaceRole(spaceGUID, username, origin string, role models.Role) error
+
+	EnsureOrg(name string, options OrgOptions) (org models.OrganizationFields, created bool, err error)
+	EnsureSpace(orgGUID, name string, options SpaceOptions) (space models.SpaceFields, created bool, err error)
+	PreviewOrgDelete(orgGUID string) (DeletePreview, error)
+	PreviewSpaceDelete(spaceGUID string) (DeletePreview, error)
+	DeleteOrg(orgGUID string) error
+	DeleteSpace(spaceGUID string) error
+
+	SnapshotFoundationSettings() (FoundationSettings, error)
+
+	GetServiceBrokerCatalog(brokerGUID string) (models.ServiceBroker, error)
+	EnableServicePlanAccess(planGUID, orgGUID string) error
+	DisableServicePlanAccess(planGUID, orgGUID string) error
+
+	CreatePrivateDomain(orgGUID, name string) (models.DomainFields, error)
+	CreateSharedDomain(name, routerGroupName string) (models.DomainFields, error)
+	DeleteDomain(domain models.DomainFields) error
+	GetPrivateDomainSharedOrgs(domainGUID string) ([]models.OrganizationFields, error)
+	SetPrivateDomainSharedOrgs(domainGUID string, orgGUIDs []string) error
+
+	CreateRoute(spaceGUID string, domain models.DomainFields, host, path string, port int, randomPort bool) (models.Route, error)
+	RouteReserved(domain models.DomainFields, host, path string, port int) (bool, error)
+	MapRoute(routeGUID, appGUID string, appPort int) error
+	GetRouteMappings(routeGUID string) ([]RouteMapping, error)
 
 	DownloadAppContent(appGUID string, outputFile *os.File, asDroplet bool) error
-	UploadDroplet(appGUID string, contentType string, dropletUploadRequest *os.File) error
+	UploadDroplet(appGUID string, droplet *os.File) error
+	UploadBuildpack(buildpackGUID string, buildpack *os.File) error
+
+	WaitForJob(jobGUID string, timeout time.Duration) error
+	WaitForServiceInstance(serviceInstanceGUID string, timeout time.Duration) (models.LastOperationFields, error)
+
+	GetAppInstances(appGUID string) ([]AppInstance, error)
+	WaitForAppInstances(appGUID string, running int, timeout time.Duration) ([]AppInstance, error)
+
+	StartApp(appGUID string, timeout time.Duration) ([]AppInstance, error)
+	StopApp(appGUID string, timeout time.Duration) error
+	RestartApp(appGUID string, timeout time.Duration) ([]AppInstance, error)
+	RestageApp(appGUID string, timeout time.Duration) ([]AppInstance, error)
+	ScaleApp(appGUID string, scale AppScale, timeout time.Duration) ([]AppInstance, error)
+
+	GetRecentLogs(appGUID string, limit int) ([]AppLog, error)
+	StreamLogs(appGUID string, stop <-chan struct{}) (<-chan AppLog, <-chan error)
+
+	// Cloud Controller v3 APIs
+
+	GetAPIInfo() (APIInfo, error)
+
+	GetV3App(appGUID string) (V3App, error)
+	GetV3AppsInSpace(spaceGUID string) ([]V3App, error)
+	GetV3Packages(appGUID string) ([]V3Package, error)
+	GetV3Droplets(appGUID string) ([]V3Droplet, error)
+	GetV3CurrentDroplet(appGUID string) (V3Droplet, error)
+	SetV3CurrentDroplet(appGUID, dropletGUID string) error
+	CreateV3Build(packageGUID string) (V3Build, error)
+	GetV3Build(buildGUID string) (V3Build, error)
+	GetV3Processes(appGUID string) ([]V3Process, error)
+	CreateV3Deployment(appGUID, dropletGUID string) (V3Deployment, error)
+	GetV3Deployment(deploymentGUID string) (V3Deployment, error)
+	GetV3AuditEvents(filter V3AuditEventFilter) ([]V3AuditEvent, error)
 }
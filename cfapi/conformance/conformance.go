@@ -0,0 +1,166 @@
+// Package conformance provides a Ginkgo test suite that verifies a
+// CfSession implementation behaves like the CLI backed session. An
+// implementation runs the suite by providing a Harness which seeds its
+// backend with the suite's fixture and creates sessions for it.
+//
+//	var _ = conformance.DescribeCfSession("My session", myHarness)
+package conformance
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/cli/cf/models"
+	"github.com/mevansam/cf-cli-api/cfapi"
+)
+
+// Fixture - The state a harness must seed before creating a session
+type Fixture struct {
+	OrgName   string
+	SpaceName string
+
+	// AppName - An app in the space with the given
+	// application bits and droplet
+	AppName    string
+	AppBits    []byte
+	AppDroplet []byte
+
+	// ServiceInstanceName - A service instance in the space bound to
+	// the app. The binding has the given credentials.
+	ServiceInstanceName string
+	Credentials         map[string]interface{}
+
+	// Events - Audit events to seed in the order given
+	Events []Event
+
+	// Users - Users of identity providers roles can be granted to
+	Users []User
+
+	// IsolationSegments - Names of isolation segments no org is entitled to
+	IsolationSegments []string
+
+	// Stacks - The stacks of the foundation in the order created
+	Stacks []models.Stack
+
+	// RouterGroups - The router groups of the routing API in the order created
+	RouterGroups []models.RouterGroup
+
+	// BrokerCatalog - The services served by a broker which is
+	// not registered in the order listed by its catalog
+	BrokerCatalog []BrokerService
+}
+
+// BrokerService - A service with its plans served by the broker at the URL
+type BrokerService struct {
+	URL   string
+	Label string
+	Plans []string
+}
+
+// User - A user of the identity provider of the given origin
+type User struct {
+	Username string
+	Origin   string
+}
+
+// Event - An audit event of the app or the service instance of the fixture
+type Event struct {
+	Type      string
+	ActeeType string
+	Timestamp time.Time
+	Metadata  map[string]interface{}
+}
+
+// Seeded - The identifiers of the seeded fixture
+type Seeded struct {
+	AppGUID             string
+	ServiceInstanceGUID string
+	ServiceBinding      models.ServiceBindingFields
+}
+
+// Harness - Seeds a backend with a fixture and creates sessions for it
+type Harness interface {
+
+	// Start - Seeds the fixture and returns a session for it. The
+	// session may or may not already target the fixture's space.
+	Start(fixture *Fixture) (cfapi.CfSession, *Seeded, error)
+
+	// Stop - Releases the resources of the last start
+	Stop()
+}
+
+// NewFixture - Returns the fixture the suite verifies
+func NewFixture() *Fixture {
+
+	start := time.Date(2017, 5, 1, 10, 0, 0, 0, time.UTC)
+	return &Fixture{
+		OrgName:   "conformance-org",
+		SpaceName: "conformance-space",
+
+		AppName:    "conformance-app",
+		AppBits:    []byte("PK\x03\x04conformance application bits\x00\xff"),
+		AppDroplet: []byte("\x1f\x8b\x08\x00conformance droplet\x00\xff"),
+
+		ServiceInstanceName: "conformance-db",
+		Credentials: map[string]interface{}{
+			"hostname": "10.0.16.71",
+			"username": "dbuser",
+			"password": "dbpassword",
+		},
+
+		Events: []Event{
+			{
+				Type:      "audit.app.create",
+				ActeeType: "app",
+				Timestamp: start.Add(time.Minute),
+				Metadata: map[string]interface{}{
+					"request": map[string]interface{}{"name": "conformance-app", "instances": 1, "memory": 256},
+				},
+			},
+			{
+				Type:      "audit.service_instance.create",
+				ActeeType: "service_instance",
+				Timestamp: start.Add(2 * time.Minute),
+				Metadata: map[string]interface{}{
+					"request": map[string]interface{}{"name": "conformance-db"},
+				},
+			},
+			{
+				Type:      "audit.app.update",
+				ActeeType: "app",
+				Timestamp: start.Add(3 * time.Minute),
+				Metadata: map[string]interface{}{
+					"request": map[string]interface{}{"instances": 2, "state": "STARTED"},
+				},
+			},
+			{
+				Type:      "app.crash",
+				ActeeType: "app",
+				Timestamp: start.Add(4 * time.Minute),
+				Metadata: map[string]interface{}{
+					"index": 1, "reason": "CRASHED", "exit_status": 137,
+				},
+			},
+		},
+
+		Users: []User{
+			{Username: "developer", Origin: "uaa"},
+			{Username: "manager", Origin: "ldap"},
+		},
+
+		IsolationSegments: []string{"conformance-segment"},
+
+		Stacks: []models.Stack{
+			{Name: "cflinuxfs3", Description: "Cloud Foundry Linux-based filesystem"},
+			{Name: "windows2016", Description: "Windows Server 2016"},
+		},
+
+		RouterGroups: []models.RouterGroup{
+			{Name: "default-tcp", Type: "tcp"},
+		},
+
+		BrokerCatalog: []BrokerService{
+			{URL: "https://broker.example.com", Label: "p-redis", Plans: []string{"shared-vm", "dedicated-vm"}},
+			{URL: "https://broker.example.com", Label: "p-rabbitmq", Plans: []string{"standard"}},
+		},
+	}
+}
@@ -0,0 +1,1128 @@
+package conformance
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/cf/models"
+	"github.com/mevansam/cf-cli-api/cfapi"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// DescribeCfSession - Describes the behaviour every CfSession
+// implementation must conform to. The harness is started before
+// and stopped after each spec.
+func DescribeCfSession(name string, harness Harness) bool {
+
+	return Describe(name+" CfSession conformance", func() {
+
+		var (
+			err     error
+			tempDir string
+
+			fixture *Fixture
+			seeded  *Seeded
+			session cfapi.CfSession
+		)
+
+		BeforeEach(func() {
+			tempDir, err = ioutil.TempDir("", "conformance")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			fixture = NewFixture()
+			session, seeded, err = harness.Start(fixture)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(session).ToNot(BeNil())
+			Expect(seeded).ToNot(BeNil())
+
+			Expect(session.SetSessionTarget(fixture.OrgName, fixture.SpaceName)).To(Succeed())
+		})
+		AfterEach(func() {
+			session.Close()
+			harness.Stop()
+			os.RemoveAll(tempDir)
+		})
+
+		// download - Downloads app content to a file and returns it
+		download := func(appGUID string, asDroplet bool) ([]byte, error) {
+			outputFile, err := ioutil.TempFile(tempDir, "download")
+			Expect(err).ShouldNot(HaveOccurred())
+			defer outputFile.Close()
+
+			if err = session.DownloadAppContent(appGUID, outputFile, asDroplet); err != nil {
+				return nil, err
+			}
+			return ioutil.ReadFile(outputFile.Name())
+		}
+
+		Context("Targeting", func() {
+
+			It("Should target the org and space by name", func() {
+				Expect(session.HasTarget()).To(BeTrue())
+				Expect(session.GetSessionOrg().Name).To(Equal(fixture.OrgName))
+				Expect(session.GetSessionOrg().GUID).ToNot(BeEmpty())
+				Expect(session.GetSessionSpace().Name).To(Equal(fixture.SpaceName))
+				Expect(session.GetSessionSpace().GUID).ToNot(BeEmpty())
+			})
+			It("Should fail to target an unknown space and keep the current target", func() {
+				space := session.GetSessionSpace()
+
+				Expect(session.SetSessionTarget(fixture.OrgName, "unknown-space")).ToNot(Succeed())
+				Expect(session.SetSessionTarget("unknown-org", fixture.SpaceName)).ToNot(Succeed())
+				Expect(session.GetSessionSpace()).To(Equal(space))
+			})
+			It("Should set the org and space of the target", func() {
+				org := models.OrganizationFields{GUID: "other-org-guid", Name: "other-org"}
+				space := models.SpaceFields{GUID: "other-space-guid", Name: "other-space"}
+
+				session.SetSessionOrg(org)
+				session.SetSessionSpace(space)
+				Expect(session.GetSessionOrg().GUID).To(Equal(org.GUID))
+				Expect(session.GetSessionOrg().Name).To(Equal(org.Name))
+				Expect(session.GetSessionSpace().GUID).To(Equal(space.GUID))
+				Expect(session.GetSessionSpace().Name).To(Equal(space.Name))
+			})
+		})
+
+		Context("Events", func() {
+
+			It("Should group the events of the space by their source", func() {
+				events, err := session.GetAllEventsInSpace(fixture.Events[0].Timestamp, true)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(events)).To(Equal(2))
+
+				app, ok := events[seeded.AppGUID]
+				Expect(ok).To(BeTrue())
+				Expect(app.GUID).To(Equal(seeded.AppGUID))
+				Expect(app.Name).To(Equal(fixture.AppName))
+				Expect(app.Type).To(Equal("app"))
+
+				names := []string{}
+				for _, e := range app.EventList {
+					names = append(names, e.Name)
+				}
+				Expect(names).To(Equal([]string{"audit.app.create", "audit.app.update", "app.crash"}))
+				Expect(app.EventList[0].Description).To(Equal("instances: 1, memory: 256"))
+				Expect(app.EventList[1].Description).To(Equal("instances: 2, state: STARTED"))
+				Expect(app.EventList[2].Description).To(Equal("index: 1, reason: CRASHED, exit_status: 137"))
+
+				serviceInstance, ok := events[seeded.ServiceInstanceGUID]
+				Expect(ok).To(BeTrue())
+				Expect(serviceInstance.Name).To(Equal(fixture.ServiceInstanceName))
+				Expect(serviceInstance.Type).To(Equal("service_instance"))
+			})
+			It("Should exclude events at the given time unless inclusive", func() {
+				events, err := session.GetAllEventsInSpace(fixture.Events[0].Timestamp, false)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(events[seeded.AppGUID].EventList)).To(Equal(2))
+
+				events, err = session.GetAllEventsInSpace(fixture.Events[3].Timestamp.Add(time.Minute), true)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(events).To(BeEmpty())
+			})
+			It("Should return the events of an app", func() {
+				event, err := session.GetAllEventsForApp(seeded.AppGUID, fixture.Events[2].Timestamp, true)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(event.GUID).To(Equal(seeded.AppGUID))
+				Expect(event.Name).To(Equal(fixture.AppName))
+				Expect(len(event.EventList)).To(Equal(2))
+				Expect(event.EventList[0].Timestamp.Equal(fixture.Events[2].Timestamp)).To(BeTrue())
+
+				event, err = session.GetAllEventsForApp(seeded.AppGUID, fixture.Events[2].Timestamp, false)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(event.EventList)).To(Equal(1))
+				Expect(event.EventList[0].Name).To(Equal("app.crash"))
+			})
+		})
+
+		Context("Service credentials", func() {
+
+			It("Should return the credentials of a service binding", func() {
+				detail, err := session.GetServiceCredentials(seeded.ServiceBinding)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(detail.Entity.AppGUID).To(Equal(seeded.AppGUID))
+				Expect(detail.Entity.ServiceInstanceGUID).To(Equal(seeded.ServiceInstanceGUID))
+				Expect(detail.Entity.Credentials).To(Equal(fixture.Credentials))
+			})
+			It("Should fail for an unknown service binding", func() {
+				_, err := session.GetServiceCredentials(models.ServiceBindingFields{
+					GUID: "00000000-0000-4000-8000-999999999999",
+					URL:  "/v2/service_bindings/00000000-0000-4000-8000-999999999999",
+				})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("Application environment", func() {
+
+			It("Should return the services bound to the app and its description", func() {
+				env, err := session.GetAppEnvironment(seeded.AppGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(env.SystemRedacted).To(BeFalse())
+
+				service, ok := env.VCAPServices.Find(fixture.ServiceInstanceName)
+				Expect(ok).To(BeTrue())
+				Expect(service.Label).To(Equal("p-mysql"))
+				Expect(service.Plan).To(Equal("100mb"))
+				Expect(service.Credentials).To(Equal(fixture.Credentials))
+				Expect(env.VCAPServices["p-mysql"]).To(HaveLen(1))
+
+				Expect(env.VCAPApplication.ApplicationID).To(Equal(seeded.AppGUID))
+				Expect(env.VCAPApplication.ApplicationName).To(Equal(fixture.AppName))
+				Expect(env.VCAPApplication.SpaceName).To(Equal(fixture.SpaceName))
+				Expect(env.VCAPApplication.OrganizationName).To(Equal(fixture.OrgName))
+			})
+			It("Should fail for an unknown app", func() {
+				_, err := session.GetAppEnvironment("00000000-0000-4000-8000-999999999999")
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("Async operations", func() {
+
+			It("Should return the last operation of a service instance once it completed", func() {
+				lastOperation, err := session.WaitForServiceInstance(seeded.ServiceInstanceGUID, time.Minute)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(lastOperation.Type).To(Equal("create"))
+				Expect(lastOperation.State).To(Equal("succeeded"))
+			})
+			It("Should return a succeeded delete for a service instance which does not exist", func() {
+				lastOperation, err := session.WaitForServiceInstance("00000000-0000-4000-8000-999999999999", time.Minute)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(lastOperation.Type).To(Equal("delete"))
+				Expect(lastOperation.State).To(Equal("succeeded"))
+			})
+		})
+
+		Context("App instances", func() {
+
+			It("Should wait until the instances of a started app are running", func() {
+				state := "STARTED"
+				_, err := session.Applications().Update(seeded.AppGUID, models.AppParams{State: &state})
+				Expect(err).ShouldNot(HaveOccurred())
+
+				instances, err := session.WaitForAppInstances(seeded.AppGUID, 1, time.Minute)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(instances)).To(Equal(1))
+				Expect(instances[0].Index).To(Equal(0))
+				Expect(instances[0].State).To(Equal(cfapi.AppInstanceRunning))
+
+				instances, err = session.GetAppInstances(seeded.AppGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(instances[0].State).To(Equal(cfapi.AppInstanceRunning))
+				Expect(instances[0].Since.IsZero()).To(BeFalse())
+			})
+			It("Should fail to return the instances of a stopped app", func() {
+				_, err := session.GetAppInstances(seeded.AppGUID)
+				Expect(err).To(HaveOccurred())
+
+				_, err = session.WaitForAppInstances(seeded.AppGUID, 1, time.Minute)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("App lifecycle", func() {
+
+			It("Should start, scale, restart and stop an app", func() {
+				instances, err := session.StartApp(seeded.AppGUID, time.Minute)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(instances)).To(Equal(1))
+				Expect(instances[0].State).To(Equal(cfapi.AppInstanceRunning))
+
+				count := 2
+				instances, err = session.ScaleApp(seeded.AppGUID, cfapi.AppScale{Instances: &count}, time.Minute)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(instances)).To(Equal(2))
+
+				instances, err = session.RestartApp(seeded.AppGUID, time.Minute)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(instances)).To(Equal(2))
+
+				Expect(session.StopApp(seeded.AppGUID, time.Minute)).To(Succeed())
+				app, err := session.Applications().GetApp(seeded.AppGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(strings.ToLower(app.State)).To(Equal(models.ApplicationStateStopped))
+			})
+			It("Should restage and start a stopped app", func() {
+				instances, err := session.RestageApp(seeded.AppGUID, time.Minute)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(instances)).To(Equal(1))
+
+				app, err := session.Applications().GetApp(seeded.AppGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(strings.ToLower(app.State)).To(Equal(models.ApplicationStateStarted))
+				Expect(app.PackageState).To(Equal(cfapi.PackageStaged))
+			})
+		})
+
+		Context("App logs", func() {
+
+			It("Should return the staging output of an app as its recent logs", func() {
+				_, err := session.RestageApp(seeded.AppGUID, time.Minute)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				logs, err := session.GetRecentLogs(seeded.AppGUID, 100)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(logs)).To(BeNumerically(">", 0))
+				for i, l := range logs {
+					Expect(l.SourceType).To(Equal("STG"))
+					if i > 0 {
+						Expect(l.Timestamp.After(logs[i-1].Timestamp)).To(BeTrue())
+					}
+				}
+
+				logs, err = session.GetRecentLogs(seeded.AppGUID, 1)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(logs)).To(Equal(1))
+			})
+			It("Should stream the logs of an app until stopped", func() {
+				stop := make(chan struct{})
+				logs, errs := session.StreamLogs(seeded.AppGUID, stop)
+
+				_, err := session.RestageApp(seeded.AppGUID, time.Minute)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				var l cfapi.AppLog
+				Eventually(logs, time.Second).Should(Receive(&l))
+				Expect(l.SourceType).To(Equal("STG"))
+
+				close(stop)
+				Eventually(errs, time.Second).Should(BeClosed())
+			})
+		})
+
+		Context("Quotas", func() {
+
+			It("Should create, update and assign an org quota", func() {
+				quota, err := session.Quotas().FindByName("default")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(quota.GUID).ToNot(BeEmpty())
+
+				Expect(session.Quotas().Create(models.QuotaFields{
+					Name:             "small",
+					MemoryLimit:      512,
+					RoutesLimit:      10,
+					ServicesLimit:    0,
+					AppInstanceLimit: cfapi.Unlimited,
+				})).To(Succeed())
+				Expect(session.Quotas().Create(models.QuotaFields{Name: "small"})).ToNot(Succeed())
+
+				quota, err = session.Quotas().FindByName("small")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(quota.MemoryLimit).To(Equal(int64(512)))
+
+				quota.MemoryLimit = 256
+				Expect(session.Quotas().Update(quota)).To(Succeed())
+
+				quotas, err := session.Quotas().FindAll()
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(quotas)).To(Equal(2))
+
+				orgGUID := session.GetSessionOrg().GUID
+				Expect(session.Quotas().AssignQuotaToOrg(orgGUID, quota.GUID)).To(Succeed())
+
+				_, err = session.StartApp(seeded.AppGUID, time.Minute)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				usage, err := session.GetOrgQuotaUsage(orgGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(usage.QuotaName).To(Equal("small"))
+				Expect(usage.MemoryLimit).To(Equal(int64(256)))
+				Expect(usage.MemoryUsed).To(BeNumerically(">", 0))
+				Expect(usage.AppInstancesUsed).To(Equal(1))
+				Expect(usage.ServicesUsed).To(Equal(1))
+				Expect(usage.Exceeded()).To(ContainElement("services"))
+			})
+			It("Should create and assign a space quota", func() {
+				orgGUID := session.GetSessionOrg().GUID
+				spaceGUID := session.GetSessionSpace().GUID
+
+				usage, err := session.GetSpaceQuotaUsage(spaceGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(usage.QuotaGUID).To(BeEmpty())
+				Expect(usage.MemoryLimit).To(Equal(int64(cfapi.Unlimited)))
+				Expect(usage.Exceeded()).To(BeEmpty())
+
+				Expect(session.SpaceQuotas().Create(models.SpaceQuota{
+					Name:                "tiny",
+					OrgGUID:             orgGUID,
+					MemoryLimit:         128,
+					InstanceMemoryLimit: cfapi.Unlimited,
+					RoutesLimit:         cfapi.Unlimited,
+					ServicesLimit:       cfapi.Unlimited,
+					AppInstanceLimit:    1,
+				})).To(Succeed())
+
+				quota, err := session.SpaceQuotas().FindByName("tiny")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(quota.OrgGUID).To(Equal(orgGUID))
+
+				Expect(session.SpaceQuotas().AssociateSpaceWithQuota(spaceGUID, quota.GUID)).To(Succeed())
+
+				count := 2
+				_, err = session.ScaleApp(seeded.AppGUID, cfapi.AppScale{Instances: &count}, time.Minute)
+				Expect(err).ShouldNot(HaveOccurred())
+				_, err = session.StartApp(seeded.AppGUID, time.Minute)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				usage, err = session.GetSpaceQuotaUsage(spaceGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(usage.QuotaName).To(Equal("tiny"))
+				Expect(usage.AppInstancesUsed).To(Equal(2))
+				Expect(usage.Exceeded()).To(ContainElement("app instances"))
+
+				Expect(session.SpaceQuotas().UnassignQuotaFromSpace(spaceGUID, quota.GUID)).To(Succeed())
+				usage, err = session.GetSpaceQuotaUsage(spaceGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(usage.QuotaGUID).To(BeEmpty())
+
+				Expect(session.SpaceQuotas().Delete(quota.GUID)).To(Succeed())
+				_, err = session.SpaceQuotas().FindByName("tiny")
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("Security groups", func() {
+
+			It("Should create, update and bind a security group", func() {
+				rules, err := cfapi.ParseSecurityGroupRules([]byte(
+					`[{"protocol": "tcp", "destination": "10.0.0.0/8", "ports": "443"}]`))
+				Expect(err).ShouldNot(HaveOccurred())
+
+				Expect(session.SecurityGroups().Create("internal", rules)).To(Succeed())
+				Expect(session.SecurityGroups().Create("internal", rules)).ToNot(Succeed())
+
+				group, err := session.SecurityGroups().Read("internal")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(cfapi.DiffSecurityGroupRules(group.Rules, rules).HasChanges()).To(BeFalse())
+
+				rules = append(rules, map[string]interface{}{"protocol": "udp", "destination": "10.0.0.2", "ports": "53"})
+				diff := cfapi.DiffSecurityGroupRules(group.Rules, rules)
+				Expect(len(diff.Added)).To(Equal(1))
+				Expect(diff.Removed).To(BeEmpty())
+
+				Expect(session.SecurityGroups().Update(group.GUID, rules)).To(Succeed())
+				group, err = session.SecurityGroups().Read("internal")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(group.Rules)).To(Equal(2))
+
+				spaceGUID := session.GetSessionSpace().GUID
+				Expect(session.BindSecurityGroupToSpace(group.GUID, spaceGUID, cfapi.SecurityGroupRunning)).To(Succeed())
+				Expect(session.BindSecurityGroupToSpace(group.GUID, spaceGUID, cfapi.SecurityGroupStaging)).To(Succeed())
+
+				group, err = session.SecurityGroups().Read("internal")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(group.Spaces)).To(Equal(1))
+				Expect(group.Spaces[0].GUID).To(Equal(spaceGUID))
+
+				staging, err := session.GetSpaceSecurityGroups(spaceGUID, cfapi.SecurityGroupStaging)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(staging)).To(Equal(1))
+				Expect(staging[0].Name).To(Equal("internal"))
+
+				Expect(session.UnbindSecurityGroupFromSpace(group.GUID, spaceGUID, cfapi.SecurityGroupRunning)).To(Succeed())
+				running, err := session.GetSpaceSecurityGroups(spaceGUID, cfapi.SecurityGroupRunning)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(running).To(BeEmpty())
+
+				groups, err := session.SecurityGroups().FindAll()
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(groups)).To(Equal(1))
+
+				Expect(session.SecurityGroups().Delete(group.GUID)).To(Succeed())
+				_, err = session.SecurityGroups().Read("internal")
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("User roles", func() {
+
+			It("Should grant and revoke org and space roles", func() {
+				orgGUID := session.GetSessionOrg().GUID
+				spaceGUID := session.GetSessionSpace().GUID
+
+				Expect(session.SetOrgRole(orgGUID, "manager", "ldap", models.RoleOrgManager)).To(Succeed())
+				Expect(session.SetSpaceRole(spaceGUID, "developer", "", models.RoleSpaceDeveloper)).To(Succeed())
+				Expect(session.SetSpaceRole(spaceGUID, "developer", "uaa", models.RoleSpaceAuditor)).To(Succeed())
+				Expect(session.SetSpaceRole(spaceGUID, "nobody", "uaa", models.RoleSpaceAuditor)).ToNot(Succeed())
+				Expect(session.SetSpaceRole(spaceGUID, "manager", "uaa", models.RoleSpaceAuditor)).ToNot(Succeed())
+				Expect(session.SetOrgRole(orgGUID, "developer", "uaa", models.RoleSpaceDeveloper)).ToNot(Succeed())
+
+				users, err := session.GetOrgUsers(orgGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(users)).To(Equal(2))
+				Expect(users[0].Username).To(Equal("developer"))
+				Expect(users[0].Roles).To(Equal([]models.Role{models.RoleOrgUser}))
+				Expect(users[1].Username).To(Equal("manager"))
+				Expect(users[1].HasRole(models.RoleOrgManager)).To(BeTrue())
+
+				users, err = session.GetSpaceUsers(spaceGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(users)).To(Equal(1))
+				Expect(users[0].Roles).To(Equal([]models.Role{models.RoleSpaceDeveloper, models.RoleSpaceAuditor}))
+
+				Expect(session.UnsetOrgRole(orgGUID, "developer", "", models.RoleOrgUser)).ToNot(Succeed())
+				Expect(session.UnsetSpaceRole(spaceGUID, "developer", "", models.RoleSpaceDeveloper)).To(Succeed())
+				Expect(session.UnsetSpaceRole(spaceGUID, "developer", "", models.RoleSpaceAuditor)).To(Succeed())
+				Expect(session.UnsetOrgRole(orgGUID, "developer", "", models.RoleOrgUser)).To(Succeed())
+				Expect(session.UnsetOrgRole(orgGUID, "manager", "ldap", models.RoleOrgManager)).To(Succeed())
+
+				users, err = session.GetOrgUsers(orgGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(users)).To(Equal(1))
+				Expect(users[0].Roles).To(Equal([]models.Role{models.RoleOrgUser}))
+				users, err = session.GetSpaceUsers(spaceGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(users).To(BeEmpty())
+			})
+		})
+
+		Context("Org and space lifecycle", func() {
+
+			It("Should ensure orgs and spaces exist", func() {
+				segment := fixture.IsolationSegments[0]
+
+				org, created, err := session.EnsureOrg("ensured-org", cfapi.OrgOptions{
+					QuotaName:            "default",
+					IsolationSegmentName: segment,
+					Roles:                []cfapi.UserRole{{Username: "manager", Origin: "ldap", Role: models.RoleOrgManager}},
+				})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(created).To(BeTrue())
+				Expect(org.Name).To(Equal("ensured-org"))
+
+				again, created, err := session.EnsureOrg("ensured-org", cfapi.OrgOptions{})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(created).To(BeFalse())
+				Expect(again.GUID).To(Equal(org.GUID))
+
+				_, _, err = session.EnsureOrg("unknown-quota-org", cfapi.OrgOptions{QuotaName: "unknown"})
+				Expect(err).To(HaveOccurred())
+				_, err = session.Organizations().FindByName("unknown-quota-org")
+				Expect(err).To(HaveOccurred())
+
+				usage, err := session.GetOrgQuotaUsage(org.GUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(usage.QuotaName).To(Equal("default"))
+
+				space, created, err := session.EnsureSpace(org.GUID, "ensured-space", cfapi.SpaceOptions{
+					IsolationSegmentName: segment,
+					Roles:                []cfapi.UserRole{{Username: "developer", Role: models.RoleSpaceDeveloper}},
+				})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(created).To(BeTrue())
+
+				_, created, err = session.EnsureSpace(org.GUID, "ensured-space", cfapi.SpaceOptions{})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(created).To(BeFalse())
+
+				users, err := session.GetSpaceUsers(space.GUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(users)).To(Equal(1))
+				Expect(users[0].Username).To(Equal("developer"))
+				users, err = session.GetOrgUsers(org.GUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(users)).To(Equal(2))
+
+				// the org of the session's space is not entitled to the segment
+				_, _, err = session.EnsureSpace(session.GetSessionOrg().GUID, fixture.SpaceName,
+					cfapi.SpaceOptions{IsolationSegmentName: segment})
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("Should preview and cascade the deletion of orgs and spaces", func() {
+				orgGUID := session.GetSessionOrg().GUID
+				spaceGUID := session.GetSessionSpace().GUID
+
+				preview, err := session.PreviewSpaceDelete(spaceGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(preview.Spaces).To(BeEmpty())
+				Expect(preview.Apps).To(Equal([]string{fixture.AppName}))
+				Expect(preview.ServiceInstances).To(Equal([]string{fixture.ServiceInstanceName}))
+
+				preview, err = session.PreviewOrgDelete(orgGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(preview.Spaces).To(Equal([]string{fixture.SpaceName}))
+				Expect(preview.Apps).To(Equal([]string{fixture.SpaceName + "/" + fixture.AppName}))
+				Expect(preview.String()).To(ContainSubstring("service instance " +
+					fixture.SpaceName + "/" + fixture.ServiceInstanceName))
+
+				Expect(session.DeleteSpace(spaceGUID)).To(Succeed())
+				Expect(session.HasTarget()).To(BeFalse())
+				_, err = session.PreviewSpaceDelete(spaceGUID)
+				Expect(err).To(HaveOccurred())
+
+				preview, err = session.PreviewOrgDelete(orgGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(preview.IsEmpty()).To(BeTrue())
+
+				Expect(session.DeleteOrg(orgGUID)).To(Succeed())
+				Expect(session.GetSessionOrg().GUID).To(BeEmpty())
+				_, err = session.Organizations().FindByName(fixture.OrgName)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("Buildpacks and stacks", func() {
+
+			It("Should list stacks", func() {
+				stacks, err := session.Stacks().FindAll()
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(stacks)).To(Equal(len(fixture.Stacks)))
+				for i, st := range stacks {
+					Expect(st.Name).To(Equal(fixture.Stacks[i].Name))
+					Expect(st.Description).To(Equal(fixture.Stacks[i].Description))
+				}
+
+				stack, err := session.Stacks().FindByName(fixture.Stacks[1].Name)
+				Expect(err).ShouldNot(HaveOccurred())
+				byGUID, err := session.Stacks().FindByGUID(stack.GUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(byGUID).To(Equal(stack))
+
+				_, err = session.Stacks().FindByName("unknown-stack")
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("Should create, reorder, disable, upload and delete buildpacks", func() {
+				repo := session.Buildpacks()
+				names := func() []string {
+					result := []string{}
+					Expect(repo.ListBuildpacks(func(bp models.Buildpack) bool {
+						result = append(result, bp.Name)
+						Expect(*bp.Position).To(Equal(len(result)))
+						return true
+					})).To(Succeed())
+					return result
+				}
+
+				for _, name := range []string{"java_buildpack", "go_buildpack", "ruby_buildpack"} {
+					_, err := repo.Create(name, nil, nil, nil)
+					Expect(err).ShouldNot(HaveOccurred())
+				}
+				position := 1
+				nodejs, err := repo.Create("nodejs_buildpack", &position, nil, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(*nodejs.Position).To(Equal(1))
+				Expect(*nodejs.Enabled).To(BeTrue())
+				Expect(*nodejs.Locked).To(BeFalse())
+				Expect(names()).To(Equal([]string{"nodejs_buildpack", "java_buildpack", "go_buildpack", "ruby_buildpack"}))
+
+				_, err = repo.Create("go_buildpack", nil, nil, nil)
+				Expect(err).To(HaveOccurred())
+
+				goBuildpack, err := repo.FindByName("go_buildpack")
+				Expect(err).ShouldNot(HaveOccurred())
+				position, disabled := 10, false
+				goBuildpack.Position, goBuildpack.Enabled = &position, &disabled
+				goBuildpack, err = repo.Update(goBuildpack)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(*goBuildpack.Position).To(Equal(4))
+				Expect(*goBuildpack.Enabled).To(BeFalse())
+				Expect(names()).To(Equal([]string{"nodejs_buildpack", "java_buildpack", "ruby_buildpack", "go_buildpack"}))
+
+				bits, err := ioutil.TempFile(tempDir, "go_buildpack")
+				Expect(err).ShouldNot(HaveOccurred())
+				defer bits.Close()
+				_, err = bits.Write([]byte("PK\x03\x04go buildpack\x00\xff"))
+				Expect(err).ShouldNot(HaveOccurred())
+
+				Expect(session.UploadBuildpack(goBuildpack.GUID, bits)).To(Succeed())
+				goBuildpack, err = repo.FindByName("go_buildpack")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(goBuildpack.Filename).To(HaveSuffix(".zip"))
+
+				locked := true
+				nodejs.Locked = &locked
+				_, err = repo.Update(nodejs)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(session.UploadBuildpack(nodejs.GUID, bits)).ToNot(Succeed())
+
+				Expect(repo.Delete(nodejs.GUID)).To(Succeed())
+				Expect(names()).To(Equal([]string{"java_buildpack", "ruby_buildpack", "go_buildpack"}))
+				_, err = repo.FindByName("nodejs_buildpack")
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("Feature flags and environment variable groups", func() {
+
+			It("Should list, get and set feature flags", func() {
+				flags, err := session.FeatureFlags().List()
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(flags).ToNot(BeEmpty())
+				for i := 1; i < len(flags); i++ {
+					Expect(flags[i-1].Name < flags[i].Name).To(BeTrue())
+				}
+
+				flag, err := session.FeatureFlags().FindByName("diego_docker")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(flag.Enabled).To(BeFalse())
+
+				Expect(session.FeatureFlags().Update("diego_docker", true)).To(Succeed())
+				flag, err = session.FeatureFlags().FindByName("diego_docker")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(flag.Enabled).To(BeTrue())
+
+				_, err = session.FeatureFlags().FindByName("unknown_flag")
+				Expect(err).To(HaveOccurred())
+				Expect(session.FeatureFlags().Update("unknown_flag", true)).ToNot(Succeed())
+			})
+
+			It("Should set the environment variable groups of apps", func() {
+				groups := session.EnvironmentVariableGroups()
+				Expect(groups.SetRunning(`{"JAVA_OPTS":"-Xss256k","PORT_OFFSET":2}`)).To(Succeed())
+				Expect(groups.SetStaging(`{"BP_DEBUG":"true"}`)).To(Succeed())
+				Expect(groups.SetStaging(`not json`)).ToNot(Succeed())
+
+				running, err := groups.ListRunning()
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(running).To(ConsistOf(
+					models.EnvironmentVariable{Name: "JAVA_OPTS", Value: "-Xss256k"},
+					models.EnvironmentVariable{Name: "PORT_OFFSET", Value: "2"},
+				))
+				staging, err := groups.ListStaging()
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(staging).To(ConsistOf(models.EnvironmentVariable{Name: "BP_DEBUG", Value: "true"}))
+
+				env, err := session.GetAppEnvironment(seeded.AppGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(env.RunningEnvGroup).To(HaveKeyWithValue("JAVA_OPTS", "-Xss256k"))
+				Expect(env.StagingEnvGroup).To(HaveKeyWithValue("BP_DEBUG", "true"))
+			})
+
+			It("Should snapshot the foundation settings and detect drift", func() {
+				baseline, err := session.SnapshotFoundationSettings()
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(baseline.FeatureFlags).To(HaveKeyWithValue("app_scaling", true))
+				Expect(baseline.RunningEnvGroup).To(BeEmpty())
+
+				Expect(session.FeatureFlags().Update("app_scaling", false)).To(Succeed())
+				Expect(session.EnvironmentVariableGroups().SetRunning(`{"LOG_LEVEL":"debug"}`)).To(Succeed())
+
+				current, err := session.SnapshotFoundationSettings()
+				Expect(err).ShouldNot(HaveOccurred())
+				diff := cfapi.DiffFoundationSettings(baseline, current)
+				Expect(diff.HasChanges()).To(BeTrue())
+				Expect(diff.Changes).To(Equal([]cfapi.FoundationSettingChange{
+					{Setting: "feature flag app_scaling", Baseline: true, Other: false},
+					{Setting: "running env var LOG_LEVEL", Other: "debug"},
+				}))
+				Expect(cfapi.DiffFoundationSettings(current, current).HasChanges()).To(BeFalse())
+			})
+		})
+
+		Context("Domains and router groups", func() {
+
+			It("Should list router groups and bind shared domains to them", func() {
+				groups := []models.RouterGroup{}
+				Expect(session.RouterGroups().ListRouterGroups(func(g models.RouterGroup) bool {
+					groups = append(groups, g)
+					return true
+				})).To(Succeed())
+				Expect(len(groups)).To(Equal(len(fixture.RouterGroups)))
+				for i, g := range groups {
+					Expect(g.GUID).ToNot(BeEmpty())
+					Expect(g.Name).To(Equal(fixture.RouterGroups[i].Name))
+					Expect(g.Type).To(Equal(fixture.RouterGroups[i].Type))
+				}
+
+				tcp, err := session.CreateSharedDomain("tcp.example.com", groups[0].Name)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(tcp.Shared).To(BeTrue())
+				Expect(tcp.RouterGroupGUID).To(Equal(groups[0].GUID))
+				Expect(tcp.RouterGroupType).To(Equal("tcp"))
+
+				web, err := session.CreateSharedDomain("apps.example.com", "")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(web.RouterGroupGUID).To(BeEmpty())
+
+				_, err = session.CreateSharedDomain("other.example.com", "unknown-group")
+				Expect(err).To(HaveOccurred())
+				_, err = session.CreateSharedDomain("tcp.example.com", "")
+				Expect(err).To(HaveOccurred())
+
+				Expect(session.DeleteDomain(tcp)).To(Succeed())
+				_, err = session.Domains().FindSharedByName("tcp.example.com")
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("Should create, share and delete private domains", func() {
+				org := session.GetSessionOrg()
+				for _, name := range []string{"other-org", "third-org"} {
+					Expect(session.Organizations().Create(models.Organization{
+						OrganizationFields: models.OrganizationFields{Name: name},
+					})).To(Succeed())
+				}
+				other, err := session.Organizations().FindByName("other-org")
+				Expect(err).ShouldNot(HaveOccurred())
+				third, err := session.Organizations().FindByName("third-org")
+				Expect(err).ShouldNot(HaveOccurred())
+
+				domain, err := session.CreatePrivateDomain(org.GUID, "private.example.com")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(domain.Shared).To(BeFalse())
+				Expect(domain.OwningOrganizationGUID).To(Equal(org.GUID))
+				_, err = session.CreatePrivateDomain(other.GUID, "private.example.com")
+				Expect(err).To(HaveOccurred())
+
+				sharedOrgNames := func() []string {
+					orgs, err := session.GetPrivateDomainSharedOrgs(domain.GUID)
+					Expect(err).ShouldNot(HaveOccurred())
+					names := []string{}
+					for _, o := range orgs {
+						names = append(names, o.Name)
+					}
+					return names
+				}
+				Expect(sharedOrgNames()).To(BeEmpty())
+
+				Expect(session.SetPrivateDomainSharedOrgs(domain.GUID, []string{other.GUID, third.GUID})).To(Succeed())
+				Expect(sharedOrgNames()).To(Equal([]string{"other-org", "third-org"}))
+				_, err = session.Domains().FindByNameInOrg("private.example.com", third.GUID)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				Expect(session.SetPrivateDomainSharedOrgs(domain.GUID, []string{third.GUID})).To(Succeed())
+				Expect(sharedOrgNames()).To(Equal([]string{"third-org"}))
+				_, err = session.Domains().FindByNameInOrg("private.example.com", other.GUID)
+				Expect(err).To(HaveOccurred())
+
+				Expect(session.DeleteDomain(domain)).To(Succeed())
+				_, err = session.GetPrivateDomainSharedOrgs(domain.GUID)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("Routes", func() {
+
+			It("Should create HTTP routes with paths and TCP routes with explicit or random ports", func() {
+				space := session.GetSessionSpace()
+				web, err := session.CreateSharedDomain("apps.example.com", "")
+				Expect(err).ShouldNot(HaveOccurred())
+				tcp, err := session.CreateSharedDomain("tcp.example.com", fixture.RouterGroups[0].Name)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				route, err := session.CreateRoute(space.GUID, web, "shop", "cart", 0, false)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(route.Host).To(Equal("shop"))
+				Expect(route.Path).To(Equal("/cart"))
+				Expect(route.Port).To(Equal(0))
+
+				found, err := session.Routes().Find("shop", web, "/cart", 0)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(found.GUID).To(Equal(route.GUID))
+				_, err = session.CreateRoute(space.GUID, web, "shop", "/cart", 0, false)
+				Expect(err).To(HaveOccurred())
+
+				reserved, err := session.RouteReserved(web, "shop", "/cart", 0)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(reserved).To(BeTrue())
+				reserved, err = session.RouteReserved(web, "shop", "", 0)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(reserved).To(BeFalse())
+
+				fixed, err := session.CreateRoute(space.GUID, tcp, "", "", 5000, false)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(fixed.Port).To(Equal(5000))
+				random, err := session.CreateRoute(space.GUID, tcp, "", "", 0, true)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(random.Port).ToNot(BeZero())
+				Expect(random.Port).ToNot(Equal(fixed.Port))
+
+				reserved, err = session.RouteReserved(tcp, "", "", 5000)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(reserved).To(BeTrue())
+				reserved, err = session.RouteReserved(tcp, "", "", 5001)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(reserved).To(BeFalse())
+
+				_, err = session.CreateRoute(space.GUID, tcp, "shop", "", 5001, false)
+				Expect(err).To(HaveOccurred())
+				_, err = session.CreateRoute(space.GUID, tcp, "", "", 0, false)
+				Expect(err).To(HaveOccurred())
+				_, err = session.CreateRoute(space.GUID, web, "shop", "", 8080, false)
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("Should map routes to apps on the given app ports", func() {
+				web, err := session.CreateSharedDomain("apps.example.com", "")
+				Expect(err).ShouldNot(HaveOccurred())
+				route, err := session.CreateRoute(session.GetSessionSpace().GUID, web, "mapped", "", 0, false)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				Expect(session.MapRoute(route.GUID, seeded.AppGUID, 9090)).To(Succeed())
+				mappings, err := session.GetRouteMappings(route.GUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(mappings).To(Equal([]cfapi.RouteMapping{
+					{RouteGUID: route.GUID, AppGUID: seeded.AppGUID, AppPort: 9090},
+				}))
+				Expect(session.MapRoute(route.GUID, seeded.AppGUID, 9090)).ToNot(Succeed())
+
+				Expect(session.Routes().Unbind(route.GUID, seeded.AppGUID)).To(Succeed())
+				mappings, err = session.GetRouteMappings(route.GUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(mappings).To(BeEmpty())
+
+				Expect(session.MapRoute(route.GUID, seeded.AppGUID, 0)).To(Succeed())
+				mappings, err = session.GetRouteMappings(route.GUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(mappings).To(Equal([]cfapi.RouteMapping{
+					{RouteGUID: route.GUID, AppGUID: seeded.AppGUID, AppPort: 8080},
+				}))
+			})
+		})
+
+		Context("Service brokers and service access", func() {
+
+			var broker models.ServiceBroker
+
+			BeforeEach(func() {
+				url := fixture.BrokerCatalog[0].URL
+				Expect(session.ServiceBrokers().Create("conformance-broker", url, "broker", "broker-password", "")).To(Succeed())
+				broker, err = session.ServiceBrokers().FindByName("conformance-broker")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(broker.URL).To(Equal(url))
+			})
+
+			It("Should register, rename and delete brokers and list their catalog", func() {
+				Expect(session.ServiceBrokers().Create("conformance-broker", "https://other.example.com", "u", "p", "")).ToNot(Succeed())
+				Expect(session.ServiceBrokers().Create("other-broker", broker.URL, "u", "p", "")).ToNot(Succeed())
+
+				catalog, err := session.GetServiceBrokerCatalog(broker.GUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(catalog.Name).To(Equal("conformance-broker"))
+				Expect(len(catalog.Services)).To(Equal(len(fixture.BrokerCatalog)))
+				for i, sv := range catalog.Services {
+					Expect(sv.Label).To(Equal(fixture.BrokerCatalog[i].Label))
+					Expect(sv.BrokerGUID).To(Equal(broker.GUID))
+					Expect(len(sv.Plans)).To(Equal(len(fixture.BrokerCatalog[i].Plans)))
+					for j, plan := range sv.Plans {
+						Expect(plan.Name).To(Equal(fixture.BrokerCatalog[i].Plans[j]))
+						Expect(plan.Public).To(BeFalse())
+						Expect(plan.OrgNames).To(BeEmpty())
+					}
+				}
+
+				Expect(session.ServiceBrokers().Rename(broker.GUID, "renamed-broker")).To(Succeed())
+				renamed, err := session.ServiceBrokers().FindByGUID(broker.GUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(renamed.Name).To(Equal("renamed-broker"))
+
+				brokers := []string{}
+				Expect(session.ServiceBrokers().ListServiceBrokers(func(b models.ServiceBroker) bool {
+					brokers = append(brokers, b.Name)
+					return true
+				})).To(Succeed())
+				Expect(brokers).To(Equal([]string{"renamed-broker"}))
+
+				Expect(session.ServiceBrokers().Delete(broker.GUID)).To(Succeed())
+				_, err = session.GetServiceBrokerCatalog(broker.GUID)
+				Expect(err).To(HaveOccurred())
+				services, err := session.Services().FindServiceOfferingsByLabel(fixture.BrokerCatalog[0].Label)
+				Expect(err).To(HaveOccurred())
+				Expect(services).To(BeEmpty())
+			})
+
+			It("Should not delete a broker whose services have instances", func() {
+				catalog, err := session.GetServiceBrokerCatalog(broker.GUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(session.Services().CreateServiceInstance("conformance-redis",
+					catalog.Services[0].Plans[0].GUID, nil, nil)).To(Succeed())
+				Expect(session.ServiceBrokers().Delete(broker.GUID)).ToNot(Succeed())
+			})
+
+			It("Should enable and disable the access of orgs to service plans", func() {
+				org := session.GetSessionOrg()
+				Expect(session.Organizations().Create(models.Organization{
+					OrganizationFields: models.OrganizationFields{Name: "other-org"},
+				})).To(Succeed())
+				other, err := session.Organizations().FindByName("other-org")
+				Expect(err).ShouldNot(HaveOccurred())
+
+				catalog, err := session.GetServiceBrokerCatalog(broker.GUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				shared, dedicated := catalog.Services[0].Plans[0], catalog.Services[0].Plans[1]
+				plans := func() []models.ServicePlanFields {
+					catalog, err := session.GetServiceBrokerCatalog(broker.GUID)
+					Expect(err).ShouldNot(HaveOccurred())
+					return catalog.Services[0].Plans
+				}
+
+				Expect(session.EnableServicePlanAccess(shared.GUID, org.GUID)).To(Succeed())
+				Expect(session.EnableServicePlanAccess(shared.GUID, other.GUID)).To(Succeed())
+				Expect(session.EnableServicePlanAccess(shared.GUID, other.GUID)).To(Succeed())
+				Expect(session.EnableServicePlanAccess(dedicated.GUID, "")).To(Succeed())
+				Expect(plans()[0].OrgNames).To(Equal([]string{fixture.OrgName, "other-org"}))
+				Expect(plans()[1].Public).To(BeTrue())
+
+				visibilities, err := session.ServicePlanVisibilities().Search(map[string]string{"service_plan_guid": shared.GUID})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(visibilities).To(HaveLen(2))
+
+				Expect(session.DisableServicePlanAccess(dedicated.GUID, org.GUID)).ToNot(Succeed())
+				Expect(session.DisableServicePlanAccess(shared.GUID, other.GUID)).To(Succeed())
+				Expect(plans()[0].OrgNames).To(Equal([]string{fixture.OrgName}))
+
+				Expect(session.DisableServicePlanAccess(dedicated.GUID, "")).To(Succeed())
+				Expect(session.DisableServicePlanAccess(shared.GUID, "")).To(Succeed())
+				Expect(plans()[0].OrgNames).To(BeEmpty())
+				Expect(plans()[1].Public).To(BeFalse())
+
+				Expect(session.EnableServicePlanAccess("00000000-0000-4000-8000-999999999999", org.GUID)).ToNot(Succeed())
+			})
+		})
+
+		Context("Application content", func() {
+
+			It("Should download the application bits and droplet", func() {
+				content, err := download(seeded.AppGUID, false)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(content).To(Equal(fixture.AppBits))
+
+				content, err = download(seeded.AppGUID, true)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(content).To(Equal(fixture.AppDroplet))
+			})
+			It("Should fail to download the content of an unknown app", func() {
+				_, err := download("00000000-0000-4000-8000-999999999999", false)
+				Expect(err).To(HaveOccurred())
+			})
+			It("Should upload a droplet", func() {
+				droplet := []byte("\x1f\x8b\x08\x00uploaded droplet\x00\xff")
+
+				dropletFile, err := ioutil.TempFile(tempDir, "droplet")
+				Expect(err).ShouldNot(HaveOccurred())
+				defer dropletFile.Close()
+
+				_, err = dropletFile.Write(droplet)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				Expect(session.UploadDroplet(seeded.AppGUID, dropletFile)).To(Succeed())
+
+				content, err := download(seeded.AppGUID, true)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(bytes.Equal(content, droplet)).To(BeTrue())
+			})
+		})
+
+		Context("Cloud Controller v3", func() {
+
+			It("Should advertise the v2 and v3 APIs", func() {
+				info, err := session.GetAPIInfo()
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(info.V2Version).ToNot(BeEmpty())
+				Expect(info.SupportsV3()).To(BeTrue())
+			})
+			It("Should return the apps in a space", func() {
+				app, err := session.GetV3App(seeded.AppGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(app.Name).To(Equal(fixture.AppName))
+				Expect(app.SpaceGUID).To(Equal(session.GetSessionSpace().GUID))
+				Expect(app.LifecycleType).To(Equal("buildpack"))
+
+				apps, err := session.GetV3AppsInSpace(session.GetSessionSpace().GUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(apps)).To(Equal(1))
+				Expect(apps[0].GUID).To(Equal(seeded.AppGUID))
+
+				_, err = session.GetV3App("00000000-0000-4000-8000-999999999999")
+				Expect(err).To(HaveOccurred())
+			})
+			It("Should return the package and droplets of an app with their checksums", func() {
+				packages, err := session.GetV3Packages(seeded.AppGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(packages)).To(Equal(1))
+				Expect(packages[0].State).To(Equal("READY"))
+				Expect(packages[0].Checksum.Type).To(Equal("sha256"))
+				Expect(packages[0].Checksum.Value).To(Equal(sha256Hex(fixture.AppBits)))
+
+				current, err := session.GetV3CurrentDroplet(seeded.AppGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(current.State).To(Equal("STAGED"))
+				Expect(current.Checksum.Value).To(Equal(sha256Hex(fixture.AppDroplet)))
+
+				droplets, err := session.GetV3Droplets(seeded.AppGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(droplets)).To(Equal(1))
+				Expect(droplets[0].GUID).To(Equal(current.GUID))
+
+				Expect(session.SetV3CurrentDroplet(seeded.AppGUID, current.GUID)).To(Succeed())
+				Expect(session.SetV3CurrentDroplet(seeded.AppGUID, "unknown-droplet-guid")).ToNot(Succeed())
+			})
+			It("Should stage a package and deploy the droplet built", func() {
+				packages, err := session.GetV3Packages(seeded.AppGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				build, err := session.CreateV3Build(packages[0].GUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(build.PackageGUID).To(Equal(packages[0].GUID))
+
+				build, err = session.GetV3Build(build.GUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(build.State).To(Equal("STAGED"))
+				Expect(build.DropletGUID).ToNot(BeEmpty())
+
+				content, err := download(seeded.AppGUID, true)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(content).To(Equal(fixture.AppBits))
+
+				deployment, err := session.CreateV3Deployment(seeded.AppGUID, build.DropletGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(deployment.AppGUID).To(Equal(seeded.AppGUID))
+
+				deployment, err = session.GetV3Deployment(deployment.GUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(deployment.DropletGUID).To(Equal(build.DropletGUID))
+				Expect(deployment.StatusValue).To(Equal("FINALIZED"))
+
+				app, err := session.GetV3App(seeded.AppGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(app.State).To(Equal("STARTED"))
+			})
+			It("Should return the web process of an app", func() {
+				processes, err := session.GetV3Processes(seeded.AppGUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(processes)).To(Equal(1))
+				Expect(processes[0].Type).To(Equal("web"))
+				Expect(processes[0].HealthCheckType).To(Equal("port"))
+
+				_, err = session.GetV3Processes("00000000-0000-4000-8000-999999999999")
+				Expect(err).To(HaveOccurred())
+			})
+			It("Should return the audit events selected by a filter", func() {
+				events, err := session.GetV3AuditEvents(cfapi.V3AuditEventFilter{
+					SpaceGUIDs: []string{session.GetSessionSpace().GUID},
+					From:       fixture.Events[0].Timestamp,
+					Inclusive:  true,
+				})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(events)).To(Equal(len(fixture.Events)))
+				Expect(events[0].Type).To(Equal("audit.app.create"))
+				Expect(events[0].TargetGUID).To(Equal(seeded.AppGUID))
+				Expect(events[0].CreatedAt.Equal(fixture.Events[0].Timestamp)).To(BeTrue())
+
+				events, err = session.GetV3AuditEvents(cfapi.V3AuditEventFilter{
+					TargetGUIDs: []string{seeded.AppGUID},
+					Types:       []string{"app.crash"},
+					From:        fixture.Events[0].Timestamp,
+				})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(events)).To(Equal(1))
+				Expect(events[0].Data["exit_status"]).To(BeNumerically("==", 137))
+			})
+		})
+	})
+}
+
+// sha256Hex - Returns the hex encoded SHA-256 digest of the given content
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
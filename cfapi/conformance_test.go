@@ -0,0 +1,167 @@
+package cfapi_test
+
+import (
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/cli/cf/models"
+	"github.com/mevansam/cf-cli-api/cfapi"
+	"github.com/mevansam/cf-cli-api/cfapi/conformance"
+	"github.com/mevansam/cf-cli-api/cfapi/fakecc"
+	"github.com/mevansam/cf-cli-api/cfapi/mocks"
+)
+
+// fakeCCHarness - Runs the conformance suite against the
+// CLI backed session talking to a fake Cloud Controller
+type fakeCCHarness struct {
+	fake *fakecc.FakeCC
+}
+
+func (h *fakeCCHarness) Start(fixture *conformance.Fixture) (cfapi.CfSession, *conformance.Seeded, error) {
+
+	// Instances of started apps are starting for
+	// a poll so waiting for them must not be slow
+	cfapi.AsyncPollingInterval = time.Millisecond
+	cfapi.LogPollingInterval = time.Millisecond
+
+	h.fake = fakecc.New()
+	h.fake.AddUser("admin", "admin-password")
+
+	orgGUID := h.fake.AddOrg(fixture.OrgName)
+	spaceGUID := h.fake.AddSpace(orgGUID, fixture.SpaceName)
+
+	appGUID := h.fake.AddApp(spaceGUID, fixture.AppName, nil)
+	h.fake.SetAppPackage(appGUID, fixture.AppBits)
+	h.fake.SetAppDroplet(appGUID, fixture.AppDroplet)
+
+	_, plans := h.fake.AddServiceOffering("p-mysql", "100mb")
+	serviceInstanceGUID := h.fake.AddServiceInstance(spaceGUID, fixture.ServiceInstanceName, plans[0], fixture.Credentials)
+	bindingGUID := h.fake.BindService(serviceInstanceGUID, appGUID)
+
+	for _, e := range fixture.Events {
+		event := fakecc.Event{
+			Type:      e.Type,
+			ActeeType: e.ActeeType,
+			SpaceGUID: spaceGUID,
+			Timestamp: e.Timestamp,
+			Metadata:  e.Metadata,
+		}
+		if e.ActeeType == "app" {
+			event.Actee, event.ActeeName = appGUID, fixture.AppName
+		} else {
+			event.Actee, event.ActeeName = serviceInstanceGUID, fixture.ServiceInstanceName
+		}
+		h.fake.AddEvent(event)
+	}
+	for _, u := range fixture.Users {
+		if u.Origin == "uaa" {
+			h.fake.AddUser(u.Username, "")
+		} else {
+			h.fake.AddExternalUser(u.Username, u.Origin)
+		}
+	}
+	for _, name := range fixture.IsolationSegments {
+		h.fake.AddIsolationSegment(name)
+	}
+	for _, st := range fixture.Stacks {
+		h.fake.AddStack(st.Name, st.Description)
+	}
+	for _, g := range fixture.RouterGroups {
+		h.fake.AddRouterGroup(g.Name, g.Type)
+	}
+	for _, sv := range fixture.BrokerCatalog {
+		h.fake.AddServiceBrokerCatalog(sv.URL, sv.Label, sv.Plans...)
+	}
+
+	session, err := cfapi.NewCfCliSessionProvider().NewCfSession(h.fake.URL(),
+		"admin", "admin-password", fixture.OrgName, fixture.SpaceName, true, cfapi.NewLogger(false, "false"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, &conformance.Seeded{
+		AppGUID:             appGUID,
+		ServiceInstanceGUID: serviceInstanceGUID,
+		ServiceBinding: models.ServiceBindingFields{
+			GUID: bindingGUID,
+			URL:  fmt.Sprintf("/v2/service_bindings/%s", bindingGUID),
+		},
+	}, nil
+}
+
+func (h *fakeCCHarness) Stop() {
+	h.fake.Close()
+}
+
+// memoryHarness - Runs the conformance suite against
+// the in-memory session used by unit tests
+type memoryHarness struct{}
+
+func (h *memoryHarness) Start(fixture *conformance.Fixture) (cfapi.CfSession, *conformance.Seeded, error) {
+
+	cfapi.LogPollingInterval = time.Millisecond
+
+	state := mock_test.NewMemoryState()
+
+	orgGUID := state.AddOrg(fixture.OrgName)
+	spaceGUID := state.AddSpace(orgGUID, fixture.SpaceName)
+
+	appGUID := state.AddApp(spaceGUID, fixture.AppName, models.ApplicationFields{})
+	state.SetAppBits(appGUID, fixture.AppBits)
+	state.SetAppDroplet(appGUID, fixture.AppDroplet)
+
+	_, plans := state.AddServiceOffering("p-mysql", "100mb")
+	serviceInstanceGUID := state.AddServiceInstance(spaceGUID, fixture.ServiceInstanceName, plans[0], fixture.Credentials)
+	bindingGUID := state.BindService(serviceInstanceGUID, appGUID)
+
+	for _, e := range fixture.Events {
+		event := mock_test.MemoryEvent{
+			Type:      e.Type,
+			ActeeType: e.ActeeType,
+			SpaceGUID: spaceGUID,
+			Timestamp: e.Timestamp,
+			Metadata:  e.Metadata,
+		}
+		if e.ActeeType == "app" {
+			event.Actee, event.ActeeName = appGUID, fixture.AppName
+		} else {
+			event.Actee, event.ActeeName = serviceInstanceGUID, fixture.ServiceInstanceName
+		}
+		state.AddEvent(event)
+	}
+	for _, u := range fixture.Users {
+		state.AddUser(u.Username, u.Origin)
+	}
+	for _, name := range fixture.IsolationSegments {
+		state.AddIsolationSegment(name)
+	}
+	for _, st := range fixture.Stacks {
+		state.AddStack(st.Name, st.Description)
+	}
+	for _, g := range fixture.RouterGroups {
+		state.AddRouterGroup(g.Name, g.Type)
+	}
+	for _, sv := range fixture.BrokerCatalog {
+		state.AddServiceBrokerCatalog(sv.URL, sv.Label, sv.Plans...)
+	}
+
+	provider := &mock_test.MemorySessionProvider{State: state}
+	session, err := provider.NewCfSession("https://api.example.com",
+		"admin", "admin-password", fixture.OrgName, fixture.SpaceName, true, cfapi.NewLogger(false, "false"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, &conformance.Seeded{
+		AppGUID:             appGUID,
+		ServiceInstanceGUID: serviceInstanceGUID,
+		ServiceBinding: models.ServiceBindingFields{
+			GUID: bindingGUID,
+			URL:  fmt.Sprintf("/v2/service_bindings/%s", bindingGUID),
+		},
+	}, nil
+}
+
+func (h *memoryHarness) Stop() {
+}
+
+var _ = conformance.DescribeCfSession("CF CLI session", &fakeCCHarness{})
+var _ = conformance.DescribeCfSession("In-memory session", &memoryHarness{})
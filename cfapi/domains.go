@@ -0,0 +1,115 @@
+package cfapi
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/cli/cf/api"
+	"code.cloudfoundry.org/cli/cf/api/resources"
+	"code.cloudfoundry.org/cli/cf/models"
+)
+
+// RouterGroups - Returns the repository listing the router groups of the
+// routing API. TCP router groups are those with the type "tcp".
+func (s *CfCliSession) RouterGroups() api.RoutingAPIRepository {
+	return api.NewRoutingAPIRepository(s.config, s.ccGateway)
+}
+
+// CreatePrivateDomain - Creates a domain owned by the given org
+func (s *CfCliSession) CreatePrivateDomain(orgGUID, name string) (models.DomainFields, error) {
+
+	domain, err := s.Domains().Create(name, orgGUID)
+	if err != nil {
+		return domain, err
+	}
+	s.logger.DebugMessage("Created private domain '%s' with GUID '%s'.", name, domain.GUID)
+	return domain, nil
+}
+
+// CreateSharedDomain - Creates a domain shared by all orgs. If a router
+// group is named the domain is bound to it so that its routes are routed
+// by the group's routers i.e. TCP routes for a TCP router group.
+func (s *CfCliSession) CreateSharedDomain(name, routerGroupName string) (models.DomainFields, error) {
+
+	var routerGroupGUID string
+	if len(routerGroupName) > 0 {
+		if err := s.RouterGroups().ListRouterGroups(func(group models.RouterGroup) bool {
+			if group.Name == routerGroupName {
+				routerGroupGUID = group.GUID
+				return false
+			}
+			return true
+		}); err != nil {
+			return models.DomainFields{}, err
+		}
+		if len(routerGroupGUID) == 0 {
+			return models.DomainFields{}, fmt.Errorf("Unable to create shared domain '%s' as router group '%s' does not exist.",
+				name, routerGroupName)
+		}
+	}
+
+	if err := s.Domains().CreateSharedDomain(name, routerGroupGUID); err != nil {
+		return models.DomainFields{}, err
+	}
+	domain, err := s.Domains().FindSharedByName(name)
+	if err != nil {
+		return domain, err
+	}
+	s.logger.DebugMessage("Created shared domain '%s' with GUID '%s'.", name, domain.GUID)
+	return domain, nil
+}
+
+// DeleteDomain - Deletes a private or shared domain along with its routes
+func (s *CfCliSession) DeleteDomain(domain models.DomainFields) error {
+	if domain.Shared {
+		return s.Domains().DeleteSharedDomain(domain.GUID)
+	}
+	return s.Domains().Delete(domain.GUID)
+}
+
+// GetPrivateDomainSharedOrgs - Returns the orgs other than the
+// owning org a private domain is shared with
+func (s *CfCliSession) GetPrivateDomainSharedOrgs(domainGUID string) ([]models.OrganizationFields, error) {
+
+	orgs := []models.OrganizationFields{}
+	err := s.ccGateway.ListPaginatedResources(s.config.APIEndpoint(),
+		fmt.Sprintf("/v2/private_domains/%s/shared_organizations", domainGUID), resources.OrganizationResource{},
+		func(resource interface{}) bool {
+			orgs = append(orgs, resource.(resources.OrganizationResource).ToFields())
+			return true
+		})
+	return orgs, err
+}
+
+// SetPrivateDomainSharedOrgs - Shares a private domain with the given orgs
+// and stops sharing it with any other orgs it is currently shared with
+func (s *CfCliSession) SetPrivateDomainSharedOrgs(domainGUID string, orgGUIDs []string) error {
+
+	current, err := s.GetPrivateDomainSharedOrgs(domainGUID)
+	if err != nil {
+		return err
+	}
+	shared := make(map[string]bool)
+	for _, o := range current {
+		shared[o.GUID] = true
+	}
+
+	desired := make(map[string]bool)
+	for _, orgGUID := range orgGUIDs {
+		desired[orgGUID] = true
+		if !shared[orgGUID] {
+			if err = s.Organizations().SharePrivateDomain(orgGUID, domainGUID); err != nil {
+				return err
+			}
+			s.logger.DebugMessage("Shared private domain '%s' with org '%s'.", domainGUID, orgGUID)
+		}
+	}
+	for _, o := range current {
+		if !desired[o.GUID] {
+			if err = s.Organizations().UnsharePrivateDomain(o.GUID, domainGUID); err != nil {
+				return err
+			}
+			s.logger.DebugMessage("Unshared private domain '%s' from org '%s'.", domainGUID, o.Name)
+		}
+	}
+	return nil
+}
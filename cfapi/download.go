@@ -0,0 +1,321 @@
+package cfapi
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/cf/errors"
+)
+
+// DownloadRetries - Number of times an interrupted download of
+// app content is resumed before the download fails
+var DownloadRetries = 5
+
+// DownloadRetryWait - Time to wait before resuming an interrupted download
+var DownloadRetryWait = 2 * time.Second
+
+// ChecksumMismatchError - Returned when downloaded app content does not
+// match the checksum the Cloud Controller reports for the content
+type ChecksumMismatchError struct {
+	AppGUID   string
+	Algorithm string
+	Expected  string
+	Actual    string
+}
+
+// Error -
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf(
+		"Downloaded content of app with GUID '%s' has %s checksum '%s' but '%s' was expected.",
+		e.AppGUID, e.Algorithm, e.Actual, e.Expected)
+}
+
+// DownloadAppContent - Downloads the bits or the droplet of an app to
+// the given file. Interrupted downloads are resumed from where they
+// stopped. If the foundation serves the v3 API the content is
+// downloaded via the app's latest ready package or current droplet.
+// The v2 download is used instead if the foundation does not serve v3
+// downloads. The content is verified against the checksum the API it
+// was downloaded from reports for it.
+func (s *CfCliSession) DownloadAppContent(appGUID string, outputFile *os.File, asDroplet bool) (err error) {
+
+	var (
+		info     APIInfo
+		url      string
+		checksum V3Checksum
+		hasher   hash.Hash
+
+		start, written int64
+	)
+
+	operation := ProgressDownloadBits
+	if asDroplet {
+		operation = ProgressDownloadDroplet
+	}
+
+	if info, err = s.GetAPIInfo(); err != nil {
+		return
+	}
+	v2URL := fmt.Sprintf("%s/v2/apps/%s/download", s.config.APIEndpoint(), appGUID)
+	if asDroplet {
+		v2URL = fmt.Sprintf("%s/v2/apps/%s/droplet/download", s.config.APIEndpoint(), appGUID)
+	}
+	if info.SupportsV3() {
+		if url, checksum, err = s.getV3Content(appGUID, asDroplet); err != nil {
+			return
+		}
+	}
+	if len(url) == 0 {
+		url = v2URL
+		if checksum, err = s.getV2Checksum(appGUID, asDroplet); err != nil {
+			return
+		}
+	}
+	hasher = s.newContentHash(appGUID, checksum)
+
+	// Content is written from the current offset of the output file
+	if start, err = outputFile.Seek(0, io.SeekCurrent); err != nil {
+		return
+	}
+
+	for attempt := 0; ; attempt++ {
+
+		var retry bool
+		if retry, err = s.downloadFrom(url, appGUID, operation, outputFile, start, &written, hasher); err == nil {
+			break
+		}
+		if _, ok := err.(*errors.HTTPNotFoundError); ok && url != v2URL && written == 0 {
+			// Older v3 foundations do not serve package and droplet
+			// downloads. The v2 download is verified against the
+			// checksum the v2 API reports for the app's content.
+			s.logger.DebugMessage("Downloading content of app with GUID '%s' via the v2 API as the v3 download is not served.", appGUID)
+			if checksum, err = s.getV2Checksum(appGUID, asDroplet); err != nil {
+				return
+			}
+			url, hasher = v2URL, s.newContentHash(appGUID, checksum)
+			attempt--
+			continue
+		}
+		if !retry || attempt >= DownloadRetries {
+			return
+		}
+		s.logger.DebugMessage("Download of content of app with GUID '%s' was interrupted after %d bytes: %s",
+			appGUID, written, err.Error())
+
+		time.Sleep(DownloadRetryWait)
+	}
+
+	if hasher != nil {
+		actual := hex.EncodeToString(hasher.Sum(nil))
+		if !strings.EqualFold(actual, checksum.Value) {
+			return &ChecksumMismatchError{
+				AppGUID:   appGUID,
+				Algorithm: checksum.Type,
+				Expected:  checksum.Value,
+				Actual:    actual,
+			}
+		}
+		s.logger.DebugMessage("Verified %s checksum '%s' of content of app with GUID '%s'.",
+			checksum.Type, actual, appGUID)
+	}
+	return
+}
+
+// downloadFrom - Downloads content to the output file resuming from
+// the number of bytes already written. Returns whether the download
+// can be retried if it did not complete.
+func (s *CfCliSession) downloadFrom(url, appGUID string, operation ProgressOperation,
+	outputFile *os.File, start int64, written *int64, hasher hash.Hash) (bool, error) {
+
+	request, err := s.ccGateway.NewRequest("GET", url, s.config.AccessToken(), nil)
+	if err != nil {
+		return false, err
+	}
+	if *written > 0 {
+		request.HTTPReq.Header.Set("Range", fmt.Sprintf("bytes=%d-", *written))
+	}
+
+	response, err := s.httpClient.Do(request.HTTPReq)
+	if err != nil {
+		// Dropped connections and other transport errors are retried
+		return true, err
+	}
+	if response.StatusCode == http.StatusUnauthorized {
+		// The token expired during the download so the
+		// range is requested again with a refreshed token
+		response.Body.Close()
+
+		var newToken string
+		if newToken, err = s.uaa.RefreshAuthToken(); err != nil {
+			return false, err
+		}
+		request.HTTPReq.Header.Set("Authorization", newToken)
+		if response, err = s.httpClient.Do(request.HTTPReq); err != nil {
+			return true, err
+		}
+	}
+	defer response.Body.Close()
+
+	switch {
+	case response.StatusCode == http.StatusPartialContent:
+		var offset int64
+		contentRange := response.Header.Get("Content-Range")
+		if _, err = fmt.Sscanf(contentRange, "bytes %d-", &offset); err != nil || offset != *written {
+			// The content cannot be appended if it does not continue
+			// where the download stopped so it is downloaded again
+			if err = outputFile.Truncate(start); err != nil {
+				return false, err
+			}
+			*written = 0
+			return true, fmt.Errorf("Unable to resume download of content of app with GUID '%s' as the server returned range '%s'.",
+				appGUID, contentRange)
+		}
+	case response.StatusCode < 300:
+		if *written > 0 {
+			// The server does not support range
+			// requests so start over from the beginning
+			s.logger.DebugMessage("Restarting download of content of app with GUID '%s' as the server does not support resuming it.", appGUID)
+			if err = outputFile.Truncate(start); err != nil {
+				return false, err
+			}
+			*written = 0
+		}
+	case response.StatusCode == http.StatusNotFound:
+		return false, errors.NewHTTPError(response.StatusCode, "",
+			fmt.Sprintf("Unable to download content of app with GUID '%s' as it was not found.", appGUID))
+	default:
+		return false, fmt.Errorf("Unable to download content of app with GUID '%s' as the server responded with status '%s'.",
+			appGUID, response.Status)
+	}
+
+	if _, err = outputFile.Seek(start+*written, io.SeekStart); err != nil {
+		return false, err
+	}
+	if hasher != nil && *written == 0 {
+		hasher.Reset()
+	}
+
+	total := int64(-1)
+	if response.ContentLength >= 0 {
+		total = *written + response.ContentLength
+	}
+	reader := newProgressReader(response.Body, s.progress, operation, appGUID, *written, total)
+
+	writer := io.Writer(outputFile)
+	if hasher != nil {
+		writer = io.MultiWriter(outputFile, hasher)
+	}
+	n, err := io.Copy(writer, reader)
+	*written += n
+	if err != nil {
+		return true, err
+	}
+	if response.ContentLength > 0 && n < response.ContentLength {
+		return true, io.ErrUnexpectedEOF
+	}
+	return false, nil
+}
+
+// getV3Content - Returns the v3 download URL and the checksum of the
+// current droplet or of the latest ready package of an app. An empty
+// URL is returned if the app has no such droplet or package.
+func (s *CfCliSession) getV3Content(appGUID string, asDroplet bool) (string, V3Checksum, error) {
+
+	var (
+		guid, kind string
+		checksum   V3Checksum
+		err        error
+	)
+
+	if asDroplet {
+		var droplet V3Droplet
+		if droplet, err = s.GetV3CurrentDroplet(appGUID); err == nil {
+			guid, kind, checksum = droplet.GUID, "droplets", droplet.Checksum
+		}
+	} else {
+		packages := struct {
+			Resources []v3PackageResource `json:"resources"`
+		}{}
+		err = s.ccGateway.GetResource(
+			fmt.Sprintf("%s/v3/apps/%s/packages?order_by=-created_at&per_page=1&states=READY", s.config.APIEndpoint(), appGUID), &packages)
+		if err == nil && len(packages.Resources) > 0 {
+			pkg := packages.Resources[0].ToModel()
+			guid, kind, checksum = pkg.GUID, "packages", pkg.Checksum
+		}
+	}
+	if err != nil {
+		if _, ok := err.(*errors.HTTPNotFoundError); ok {
+			return "", checksum, nil
+		}
+		return "", checksum, err
+	}
+	if len(guid) == 0 {
+		return "", checksum, nil
+	}
+	if len(checksum.Value) == 0 {
+		s.logger.DebugMessage("Content of app with GUID '%s' will not be verified as no checksum was found for it.", appGUID)
+	}
+	return fmt.Sprintf("%s/v3/%s/%s/download", s.config.APIEndpoint(), kind, guid), checksum, nil
+}
+
+// getV2Checksum - Returns the checksum the v2 API reports for the
+// bits or the droplet of an app. The checksum is empty if the app
+// does not report one.
+func (s *CfCliSession) getV2Checksum(appGUID string, asDroplet bool) (V3Checksum, error) {
+
+	app := struct {
+		Entity struct {
+			PackageHash string `json:"package_hash"`
+			DropletHash string `json:"droplet_hash"`
+		} `json:"entity"`
+	}{}
+	err := s.ccGateway.GetResource(fmt.Sprintf("%s/v2/apps/%s", s.config.APIEndpoint(), appGUID), &app)
+	if err != nil {
+		return V3Checksum{}, err
+	}
+	checksum := V3Checksum{Type: "sha1", Value: app.Entity.PackageHash}
+	if asDroplet {
+		checksum.Value = app.Entity.DropletHash
+	}
+	if len(checksum.Value) == 0 {
+		s.logger.DebugMessage("Content of app with GUID '%s' will not be verified as no checksum was found for it.", appGUID)
+	}
+	return checksum, nil
+}
+
+// newContentHash - Returns the hash the content of an app is verified
+// with or nil if the content cannot be verified against the checksum
+func (s *CfCliSession) newContentHash(appGUID string, checksum V3Checksum) hash.Hash {
+
+	if len(checksum.Value) == 0 {
+		return nil
+	}
+	hasher := newHash(checksum.Type)
+	if hasher == nil {
+		s.logger.DebugMessage("Content of app with GUID '%s' will not be verified as checksum type '%s' is not supported.",
+			appGUID, checksum.Type)
+	}
+	return hasher
+}
+
+// newHash - Returns a hash for the given v3 checksum type
+func newHash(checksumType string) hash.Hash {
+	switch strings.ToLower(checksumType) {
+	case "sha256":
+		return sha256.New()
+	case "sha1":
+		return sha1.New()
+	case "md5":
+		return md5.New()
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package cfapi
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type appEnvResource struct {
+	Environment map[string]interface{} `json:"environment_json"`
+	Staging     map[string]interface{} `json:"staging_env_json"`
+	Running     map[string]interface{} `json:"running_env_json"`
+	System      map[string]interface{} `json:"system_env_json"`
+	Application map[string]interface{} `json:"application_env_json"`
+}
+
+// ToModel -
+func (r appEnvResource) ToModel() (*AppEnvironment, error) {
+
+	env := &AppEnvironment{
+		Environment:     nonNilMap(r.Environment),
+		StagingEnvGroup: nonNilMap(r.Staging),
+		RunningEnvGroup: nonNilMap(r.Running),
+		System:          nonNilMap(r.System),
+		Application:     nonNilMap(r.Application),
+		VCAPServices:    VCAPServices{},
+	}
+
+	// Users who may not read the credentials of the services
+	// bound to an app only see a redaction message instead
+	if _, ok := env.System["redacted_message"]; ok {
+		env.SystemRedacted = true
+	} else if err := decodeEnvValue(env.System["VCAP_SERVICES"], &env.VCAPServices); err != nil {
+		return nil, fmt.Errorf("Unable to parse VCAP_SERVICES: %s", err.Error())
+	}
+	if err := decodeEnvValue(env.Application["VCAP_APPLICATION"], &env.VCAPApplication); err != nil {
+		return nil, fmt.Errorf("Unable to parse VCAP_APPLICATION: %s", err.Error())
+	}
+	return env, nil
+}
+
+// GetAppEnvironment - Returns the environment of an app including the
+// environment variable groups and the variables provided by the system
+func (s *CfCliSession) GetAppEnvironment(appGUID string) (*AppEnvironment, error) {
+	resource := appEnvResource{}
+	url := fmt.Sprintf("%s/v2/apps/%s/env", s.config.APIEndpoint(), appGUID)
+	if err := s.ccGateway.GetResource(url, &resource); err != nil {
+		return nil, err
+	}
+	return resource.ToModel()
+}
+
+// decodeEnvValue - Decodes a value of the environment which is
+// either a JSON object or a string holding the encoded object
+func decodeEnvValue(value interface{}, target interface{}) error {
+
+	var (
+		data []byte
+		err  error
+	)
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		data = []byte(v)
+	default:
+		if data, err = json.Marshal(v); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(data, target)
+}
+
+func nonNilMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return map[string]interface{}{}
+	}
+	return m
+}
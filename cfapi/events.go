@@ -36,12 +36,26 @@ type eventResource struct {
 	}
 }
 
-// GetAllEventsInSpace -
+// GetAllEventsInSpace - Returns the events in the session's space grouped
+// by their source. The v3 audit events are returned if the foundation
+// serves the v3 API.
 func (s *CfCliSession) GetAllEventsInSpace(from time.Time, inclusive bool) (events map[string]CfEvent, err error) {
 
-	events = make(map[string]CfEvent)
 	spaceGUID := s.GetSessionSpace().GUID
 
+	info, err := s.GetAPIInfo()
+	if err != nil {
+		return
+	}
+	if info.SupportsV3() {
+		return s.getV3EventsBySource(V3AuditEventFilter{
+			SpaceGUIDs: []string{spaceGUID},
+			From:       from,
+			Inclusive:  inclusive,
+		})
+	}
+	events = make(map[string]CfEvent)
+
 	var timeFilter string
 	if inclusive {
 		timeFilter = url.QueryEscape(fmt.Sprintf("timestamp>=%s", from.Format("2006-01-02 15:04:05-07:00")))
@@ -60,18 +74,13 @@ func (s *CfCliSession) GetAllEventsInSpace(from time.Time, inclusive bool) (even
 
 			eventResource := resource.(eventResource)
 
-			metadata := generic.NewMap(eventResource.Entity.Metadata)
-			if metadata.Has("request") {
-				metadata = generic.NewMap(metadata.Get("request"))
-			}
-
 			eventFields := models.EventFields{
 				GUID:        eventResource.Metadata.GUID,
 				Name:        eventResource.Entity.Type,
 				Timestamp:   eventResource.Entity.Timestamp,
 				Actor:       eventResource.Entity.Actor,
 				ActorName:   eventResource.Entity.ActorName,
-				Description: formatDescription(metadata, knownMetadataKeys),
+				Description: EventDescription(eventResource.Entity.Metadata),
 			}
 
 			sourceGUID := eventResource.Entity.Actee
@@ -97,9 +106,27 @@ func (s *CfCliSession) GetAllEventsInSpace(from time.Time, inclusive bool) (even
 	return
 }
 
-// GetAllEventsForApp -
+// GetAllEventsForApp - Returns the events of an app. The v3 audit
+// events are returned if the foundation serves the v3 API.
 func (s *CfCliSession) GetAllEventsForApp(appGUID string, from time.Time, inclusive bool) (cfEvent CfEvent, err error) {
 
+	info, err := s.GetAPIInfo()
+	if err != nil {
+		return
+	}
+	if info.SupportsV3() {
+		var events map[string]CfEvent
+		if events, err = s.getV3EventsBySource(V3AuditEventFilter{
+			TargetGUIDs: []string{appGUID},
+			From:        from,
+			Inclusive:   inclusive,
+		}); err != nil {
+			return
+		}
+		cfEvent = events[appGUID]
+		return
+	}
+
 	var timeFilter string
 	if inclusive {
 		timeFilter = url.QueryEscape(fmt.Sprintf("timestamp>=%s", from.Format("2006-01-02 15:04:05-07:00")))
@@ -117,18 +144,13 @@ func (s *CfCliSession) GetAllEventsForApp(appGUID string, from time.Time, inclus
 
 			eventResource := resource.(eventResource)
 
-			metadata := generic.NewMap(eventResource.Entity.Metadata)
-			if metadata.Has("request") {
-				metadata = generic.NewMap(metadata.Get("request"))
-			}
-
 			eventFields := models.EventFields{
 				GUID:        eventResource.Metadata.GUID,
 				Name:        eventResource.Entity.Type,
 				Timestamp:   eventResource.Entity.Timestamp,
 				Actor:       eventResource.Entity.Actor,
 				ActorName:   eventResource.Entity.ActorName,
-				Description: formatDescription(metadata, knownMetadataKeys),
+				Description: EventDescription(eventResource.Entity.Metadata),
 			}
 
 			if len(cfEvent.GUID) == 0 {
@@ -147,6 +169,50 @@ func (s *CfCliSession) GetAllEventsForApp(appGUID string, from time.Time, inclus
 	return
 }
 
+// getV3EventsBySource - Returns the audit events selected
+// by the filter grouped by the resource they occurred on
+func (s *CfCliSession) getV3EventsBySource(filter V3AuditEventFilter) (map[string]CfEvent, error) {
+
+	auditEvents, err := s.GetV3AuditEvents(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(map[string]CfEvent)
+	for _, e := range auditEvents {
+
+		event, exists := events[e.TargetGUID]
+		if !exists {
+			event = CfEvent{
+				GUID: e.TargetGUID,
+				Name: e.TargetName,
+				Type: e.TargetType,
+			}
+		}
+		event.EventList = append(event.EventList, models.EventFields{
+			GUID:        e.GUID,
+			Name:        e.Type,
+			Timestamp:   e.CreatedAt,
+			Actor:       e.ActorGUID,
+			ActorName:   e.ActorName,
+			Description: EventDescription(e.Data),
+		})
+		events[e.TargetGUID] = event
+	}
+	return events, nil
+}
+
+// EventDescription - Returns the description of an event from its metadata
+// in the format of the CLI's 'cf events' command
+func EventDescription(metadata map[string]interface{}) string {
+
+	m := generic.NewMap(metadata)
+	if m.Has("request") {
+		m = generic.NewMap(m.Get("request"))
+	}
+	return formatDescription(m, knownMetadataKeys)
+}
+
 // Event description formatting
 
 var knownMetadataKeys = []string{
@@ -180,6 +246,8 @@ func formatDescriptionPart(val interface{}) string {
 		return val
 	case float64:
 		return strconv.FormatFloat(val, byte('f'), -1, 64)
+	case int:
+		return strconv.Itoa(val)
 	case bool:
 		if val {
 			return "true"
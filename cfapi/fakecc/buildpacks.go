@@ -0,0 +1,124 @@
+package fakecc
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// AddStack -
+func (f *FakeCC) AddStack(name, description string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.create("stacks", map[string]interface{}{
+		"name":        name,
+		"description": description,
+	}).guid
+}
+
+// BuildpackBits -
+func (f *FakeCC) BuildpackBits(buildpackGUID string) ([]byte, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	content, ok := f.buildpackBits[buildpackGUID]
+	return content, ok
+}
+
+// buildpackNameAvailable - Writes a name taken error and returns false if
+// a buildpack other than the given one has the name for the same stack
+func (f *FakeCC) buildpackNameAvailable(w http.ResponseWriter, name, stack, exceptGUID string) bool {
+	for _, bp := range f.list("buildpacks") {
+		if bp.guid != exceptGUID && strings.EqualFold(str(bp.entity, "name"), name) && str(bp.entity, "stack") == stack {
+			writeError(w, http.StatusBadRequest, 290001, "CF-BuildpackNameTaken",
+				fmt.Sprintf("The buildpack name is already in use: %s", name))
+			return false
+		}
+	}
+	return true
+}
+
+// sortedBuildpacks - Returns the buildpacks ordered by their position
+func (f *FakeCC) sortedBuildpacks() []*resource {
+	buildpacks := f.list("buildpacks")
+	sort.SliceStable(buildpacks, func(i, j int) bool {
+		return toInt64(buildpacks[i].entity["position"]) < toInt64(buildpacks[j].entity["position"])
+	})
+	return buildpacks
+}
+
+// moveBuildpack - Moves a buildpack to the given position and shifts
+// the others so that positions stay contiguous. The position is
+// clamped to the positions taken and nil moves it to the end.
+func (f *FakeCC) moveBuildpack(buildpack *resource, position interface{}) {
+
+	others := []*resource{}
+	for _, bp := range f.sortedBuildpacks() {
+		if bp != buildpack {
+			others = append(others, bp)
+		}
+	}
+	to := len(others) + 1
+	if position != nil && int(toInt64(position)) < to {
+		to = int(toInt64(position))
+	}
+	if to < 1 {
+		to = 1
+	}
+
+	buildpack.entity["position"] = to
+	for i, bp := range others {
+		if i+1 < to {
+			bp.entity["position"] = i + 1
+		} else {
+			bp.entity["position"] = i + 2
+		}
+	}
+}
+
+// uploadBuildpack - Handles buildpack bits uploads. Only
+// zip files may be uploaded to buildpacks which are unlocked.
+func (f *FakeCC) uploadBuildpack(w http.ResponseWriter, r *http.Request, buildpackGUID string) {
+
+	buildpack := f.find("buildpacks", buildpackGUID)
+	if buildpack == nil {
+		writeNotFound(w, "buildpacks", buildpackGUID)
+		return
+	}
+	if locked, _ := buildpack.entity["locked"].(bool); locked {
+		writeError(w, http.StatusBadRequest, 290003, "CF-BuildpackLocked", "The buildpack is locked")
+		return
+	}
+	if err := r.ParseMultipartForm(maxUploadMemory); err != nil {
+		writeError(w, http.StatusBadRequest, 1001, "CF-MessageParseError",
+			fmt.Sprintf("Request invalid due to parse error: %s", err.Error()))
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("buildpack")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 290002, "CF-BuildpackBitsInvalid",
+			"The buildpack upload is invalid: a file must be provided")
+		return
+	}
+	defer file.Close()
+	if !strings.HasSuffix(strings.ToLower(header.Filename), ".zip") {
+		writeError(w, http.StatusBadRequest, 290002, "CF-BuildpackBitsInvalid",
+			"The buildpack upload is invalid: only zip files allowed")
+		return
+	}
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 1001, "CF-MessageParseError",
+			fmt.Sprintf("Request invalid due to parse error: %s", err.Error()))
+		return
+	}
+
+	f.buildpackBits[buildpackGUID] = content
+	buildpack.entity["filename"] = header.Filename
+	buildpack.entity["key"] = fmt.Sprintf("%s_%x", buildpackGUID, sha1.Sum(content))
+	writeJSON(w, http.StatusCreated, f.render(buildpack, 0))
+}
@@ -0,0 +1,129 @@
+package fakecc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+)
+
+// defaultFeatureFlags - The feature flags of a new foundation
+func defaultFeatureFlags() map[string]bool {
+	return map[string]bool{
+		"user_org_creation":                    false,
+		"private_domain_creation":              true,
+		"app_bits_upload":                      true,
+		"app_scaling":                          true,
+		"route_creation":                       true,
+		"service_instance_creation":            true,
+		"diego_docker":                         false,
+		"set_roles_by_username":                true,
+		"unset_roles_by_username":              true,
+		"task_creation":                        true,
+		"env_var_visibility":                   true,
+		"space_scoped_private_broker_creation": true,
+		"space_developer_env_var_visibility":   true,
+		"service_instance_sharing":             false,
+	}
+}
+
+// SetFeatureFlag -
+func (f *FakeCC) SetFeatureFlag(name string, enabled bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.featureFlags[name] = enabled
+}
+
+// SetEnvironmentVariableGroup - Sets the variables of the "running"
+// or the "staging" environment variable group
+func (f *FakeCC) SetEnvironmentVariableGroup(group string, variables map[string]interface{}) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.envGroups[group] = copyEntity(variables)
+}
+
+// config - Handles the feature flags and the
+// environment variable groups under /v2/config
+func (f *FakeCC) config(w http.ResponseWriter, r *http.Request, segments []string) {
+
+	switch {
+	case len(segments) == 1 && segments[0] == "feature_flags" && r.Method == "GET":
+		names := []string{}
+		for name := range f.featureFlags {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		flags := []map[string]interface{}{}
+		for _, name := range names {
+			flags = append(flags, f.renderFeatureFlag(name))
+		}
+		writeJSON(w, http.StatusOK, flags)
+
+	case len(segments) == 2 && segments[0] == "feature_flags":
+		name := segments[1]
+		if _, ok := f.featureFlags[name]; !ok {
+			writeError(w, http.StatusNotFound, 330000, "CF-FeatureFlagNotFound",
+				fmt.Sprintf("The feature flag could not be found: %s", name))
+			return
+		}
+		switch r.Method {
+		case "GET":
+			writeJSON(w, http.StatusOK, f.renderFeatureFlag(name))
+		case "PUT":
+			body, err := readBody(r)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, 1001, "CF-MessageParseError",
+					fmt.Sprintf("Request invalid due to parse error: %s", err.Error()))
+				return
+			}
+			enabled, ok := body["enabled"].(bool)
+			if !ok {
+				writeError(w, http.StatusBadRequest, 1001, "CF-MessageParseError",
+					"Request invalid due to parse error: enabled must be a boolean")
+				return
+			}
+			f.featureFlags[name] = enabled
+			writeJSON(w, http.StatusOK, f.renderFeatureFlag(name))
+		default:
+			writeError(w, http.StatusNotFound, 10000, "CF-NotFound", "Unknown request")
+		}
+
+	case len(segments) == 2 && segments[0] == "environment_variable_groups" &&
+		(segments[1] == "running" || segments[1] == "staging"):
+
+		group := segments[1]
+		switch r.Method {
+		case "GET":
+			writeJSON(w, http.StatusOK, f.envGroups[group])
+		case "PUT":
+			data, err := ioutil.ReadAll(r.Body)
+			variables := map[string]interface{}{}
+			if err == nil {
+				err = json.Unmarshal(data, &variables)
+			}
+			if err != nil {
+				writeError(w, http.StatusBadRequest, 1001, "CF-MessageParseError",
+					fmt.Sprintf("Request invalid due to parse error: %s", err.Error()))
+				return
+			}
+			f.envGroups[group] = variables
+			writeJSON(w, http.StatusOK, variables)
+		default:
+			writeError(w, http.StatusNotFound, 10000, "CF-NotFound", "Unknown request")
+		}
+
+	default:
+		writeError(w, http.StatusNotFound, 10000, "CF-NotFound", "Unknown request")
+	}
+}
+
+// renderFeatureFlag -
+func (f *FakeCC) renderFeatureFlag(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":          name,
+		"enabled":       f.featureFlags[name],
+		"error_message": nil,
+		"url":           "/v2/config/feature_flags/" + name,
+	}
+}
@@ -0,0 +1,286 @@
+package fakecc
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxUploadMemory - Multipart uploads larger than this are buffered on disk
+const maxUploadMemory = 32 << 20
+
+// readUpload - Reads the content of a file field of a multipart upload
+func readUpload(r *http.Request, field string) ([]byte, bool, error) {
+
+	if err := r.ParseMultipartForm(maxUploadMemory); err != nil {
+		return nil, false, err
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, err := r.FormFile(field)
+	if err == http.ErrMissingFile {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+
+	content, err := ioutil.ReadAll(file)
+	return content, true, err
+}
+
+// uploadBits - Handles application bits uploads. The uploaded zip
+// file becomes the package of the app and invalidates its droplet.
+func (f *FakeCC) uploadBits(w http.ResponseWriter, r *http.Request, appGUID string) {
+
+	app := f.find("apps", appGUID)
+	if app == nil {
+		writeNotFound(w, "apps", appGUID)
+		return
+	}
+	content, ok, err := readUpload(r, "application")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 1001, "CF-MessageParseError",
+			fmt.Sprintf("Request invalid due to parse error: %s", err.Error()))
+		return
+	}
+	if !ok {
+		// Only cached resources were matched
+		content = []byte{}
+	}
+
+	f.packages[appGUID] = content
+	delete(f.droplets, appGUID)
+	app.entity["package_state"] = "PENDING"
+	app.entity["package_updated_at"] = time.Now().UTC().Format(time.RFC3339)
+
+	if queryValues(r.URL).Get("async") == "true" {
+		writeJSON(w, http.StatusCreated, f.render(f.createJob(), 0))
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{})
+}
+
+// uploadDroplet - Handles droplet uploads
+func (f *FakeCC) uploadDroplet(w http.ResponseWriter, r *http.Request, appGUID string) {
+
+	app := f.find("apps", appGUID)
+	if app == nil {
+		writeNotFound(w, "apps", appGUID)
+		return
+	}
+	content, ok, err := readUpload(r, "droplet")
+	if err == nil && !ok {
+		err = fmt.Errorf("Missing droplet file")
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 1001, "CF-MessageParseError",
+			fmt.Sprintf("Request invalid due to parse error: %s", err.Error()))
+		return
+	}
+
+	f.droplets[appGUID] = content
+	app.entity["package_state"] = "STAGED"
+
+	writeJSON(w, http.StatusCreated, f.render(f.createJob(), 0))
+}
+
+// download - Redirects content downloads to the fake blobstore
+// as the CC does. The blobstore does not require authorization.
+func (f *FakeCC) download(w http.ResponseWriter, r *http.Request, kind, appGUID string) {
+
+	if !f.exists(w, "apps", appGUID) {
+		return
+	}
+	content := f.packages
+	if kind == "droplets" {
+		content = f.droplets
+	}
+	if _, ok := content[appGUID]; !ok {
+		writeError(w, http.StatusNotFound, 10000, "CF-NotFound",
+			fmt.Sprintf("The %s of the app could not be found: %s", kind[:len(kind)-1], appGUID))
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("%s/blobstore/%s/%s", f.server.URL, kind, appGUID), http.StatusFound)
+}
+
+// blobstore - Serves package and droplet content. Range
+// requests are supported so downloads can be resumed.
+func (f *FakeCC) blobstore(w http.ResponseWriter, r *http.Request, segments []string) {
+
+	if len(segments) == 2 && r.Method == "GET" {
+		var (
+			content []byte
+			ok      bool
+		)
+		switch segments[0] {
+		case "packages":
+			content, ok = f.packages[segments[1]]
+		case "droplets":
+			content, ok = f.droplets[segments[1]]
+		}
+		if ok {
+			if f.CorruptDownloads && len(content) > 0 {
+				content = append([]byte{content[0] ^ 0xff}, content[1:]...)
+			}
+			w.Header().Set("Content-Type", "application/octet-stream")
+			if f.MisalignRanges > 0 && len(r.Header.Get("Range")) > 0 {
+				f.MisalignRanges--
+				r.Header.Set("Range", "bytes=0-")
+			}
+			if f.InterruptDownloads > 0 {
+				f.InterruptDownloads--
+				if f.ExpireTokensOnInterrupt {
+					f.accessTokens = make(map[string]string)
+				}
+				w = &interruptedWriter{ResponseWriter: w}
+			}
+			http.ServeContent(w, r, segments[1], time.Time{}, bytes.NewReader(content))
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+// interruptedWriter - Writes only half of the declared content length
+// of a response so the server drops the connection once it is sent
+type interruptedWriter struct {
+	http.ResponseWriter
+	remaining int
+}
+
+func (w *interruptedWriter) WriteHeader(status int) {
+	length, _ := strconv.Atoi(w.Header().Get("Content-Length"))
+	w.remaining = length / 2
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *interruptedWriter) Write(p []byte) (int, error) {
+	if len(p) > w.remaining {
+		n, _ := w.ResponseWriter.Write(p[:w.remaining])
+		w.remaining -= n
+		return n, fmt.Errorf("Download interrupted")
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.remaining -= n
+	return n, err
+}
+
+// restage - Stages the package of an app again
+func (f *FakeCC) restage(w http.ResponseWriter, appGUID string) {
+
+	app := f.find("apps", appGUID)
+	if app == nil {
+		writeNotFound(w, "apps", appGUID)
+		return
+	}
+	delete(f.droplets, appGUID)
+	app.entity["package_state"] = "PENDING"
+	if !f.stage(w, app) {
+		return
+	}
+	app.entity["state"] = "STARTED"
+	f.startApp(app)
+	f.addEvent(Event{
+		Type:      "audit.app.restage",
+		Actee:     app.guid,
+		ActeeType: "app",
+		ActeeName: str(app.entity, "name"),
+		SpaceGUID: str(app.entity, "space_guid"),
+	})
+	writeJSON(w, http.StatusCreated, f.render(app, 0))
+}
+
+// createJob - Creates an async job which completes after AsyncPolls polls
+func (f *FakeCC) createJob() *resource {
+
+	job := f.create("jobs", map[string]interface{}{
+		"status": "queued",
+	})
+	job.entity["guid"] = job.guid
+	job.failure, f.jobFailure = f.jobFailure, ""
+	f.advanceJob(job)
+	return job
+}
+
+// advanceJob -
+func (f *FakeCC) advanceJob(job *resource) {
+
+	if job.polls < f.AsyncPolls {
+		if job.polls > 0 {
+			job.entity["status"] = "running"
+		}
+		job.polls++
+		return
+	}
+	if len(job.failure) > 0 {
+		job.entity["status"] = "failed"
+		job.entity["error"] = "Use of entity>error is deprecated in favor of entity>error_details."
+		job.entity["error_details"] = map[string]interface{}{
+			"code":        10001,
+			"description": job.failure,
+			"error_code":  "CF-JobFailed",
+		}
+		return
+	}
+	job.entity["status"] = "finished"
+}
+
+// getJob - Returns the state of a job advancing it by one poll
+func (f *FakeCC) getJob(w http.ResponseWriter, guid string) {
+
+	job := f.find("jobs", guid)
+	if job == nil {
+		writeNotFound(w, "jobs", guid)
+		return
+	}
+	f.advanceJob(job)
+	writeJSON(w, http.StatusOK, f.render(job, 0))
+}
+
+// advanceServiceOperations - Advances the in progress last operations
+// of all service instances by one poll
+func (f *FakeCC) advanceServiceOperations() {
+
+	for _, si := range f.list("service_instances") {
+		op, ok := si.entity["last_operation"].(map[string]interface{})
+		if !ok || op["state"] != "in progress" {
+			continue
+		}
+		si.polls++
+		if si.polls < f.AsyncPolls {
+			continue
+		}
+		si.polls = 0
+		f.completeServiceOperation(si, op)
+	}
+}
+
+// startServiceOperation - Assigns the failure set for the next service
+// instance operation to the operation just started on the given instance
+func (f *FakeCC) startServiceOperation(si *resource) {
+
+	si.failure, f.serviceFailure = f.serviceFailure, ""
+
+	op, ok := si.entity["last_operation"].(map[string]interface{})
+	if ok && op["state"] != "in progress" {
+		f.completeServiceOperation(si, op)
+	}
+}
+
+// completeServiceOperation - Completes the last operation of a service
+// instance failing it if a failure was set when it started
+func (f *FakeCC) completeServiceOperation(si *resource, op map[string]interface{}) {
+
+	op["updated_at"] = time.Now().UTC().Format(time.RFC3339)
+	if len(si.failure) > 0 {
+		op["state"] = "failed"
+		op["description"] = si.failure
+		si.failure = ""
+		return
+	}
+	op["state"] = "succeeded"
+}
@@ -0,0 +1,58 @@
+package fakecc
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// AddRouterGroup - Adds a router group of the given type i.e. "tcp" or "http"
+func (f *FakeCC) AddRouterGroup(name, groupType string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.create("router_groups", map[string]interface{}{
+		"name": name,
+		"type": groupType,
+	}).guid
+}
+
+// routing - Handles the routing API requests. Only
+// the listing of the router groups is supported.
+func (f *FakeCC) routing(w http.ResponseWriter, r *http.Request, path string) {
+
+	if r.Method != "GET" || path != "v1/router_groups" {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{
+			"name":    "ResourceNotFoundError",
+			"message": "Unknown request",
+		})
+		return
+	}
+	groups := []map[string]interface{}{}
+	for _, g := range f.list("router_groups") {
+		groups = append(groups, map[string]interface{}{
+			"guid":             g.guid,
+			"name":             g.entity["name"],
+			"type":             g.entity["type"],
+			"reservable_ports": "1024-65535",
+		})
+	}
+	writeJSON(w, http.StatusOK, groups)
+}
+
+// routerGroupAvailable - Writes an error and returns false if a router
+// group is given which does not exist. Otherwise sets the router group
+// type of the shared domain to be created.
+func (f *FakeCC) routerGroupAvailable(w http.ResponseWriter, domain map[string]interface{}) bool {
+
+	guid := str(domain, "router_group_guid")
+	if len(guid) == 0 {
+		return true
+	}
+	group := f.find("router_groups", guid)
+	if group == nil {
+		writeError(w, http.StatusBadRequest, 130006, "CF-RouterGroupNotFound",
+			fmt.Sprintf("The router group could not be found: %s", guid))
+		return false
+	}
+	domain["router_group_type"] = group.entity["type"]
+	return true
+}
@@ -0,0 +1,674 @@
+// Package fakecc provides an in-process fake of the Cloud Controller v2
+// and v3 APIs and the UAA token endpoint. It is backed by in-memory
+// state so that sessions and the copy managers can be tested end to
+// end without a Cloud Foundry foundation.
+package fakecc
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FakeCC -
+type FakeCC struct {
+
+	// PageSize - Number of results per page returned by list
+	// endpoints when the request does not specify one
+	PageSize int
+
+	// AsyncPolls - Number of polls after which async jobs and
+	// service instance operations complete
+	AsyncPolls int
+
+	// InterruptDownloads - Number of subsequent blobstore downloads
+	// whose connection is dropped after half the content was sent
+	InterruptDownloads int
+
+	// ExpireTokensOnInterrupt - Whether access tokens expire when a
+	// blobstore download is interrupted so that resuming the download
+	// requires the token to be refreshed
+	ExpireTokensOnInterrupt bool
+
+	// MisalignRanges - Number of subsequent blobstore range requests
+	// which are served from the start of the content instead of from
+	// the requested offset
+	MisalignRanges int
+
+	// DropRequests - Number of subsequent requests whose connection
+	// is closed without a response being sent
+	DropRequests int
+
+	// CorruptDownloads - Whether the blobstore serves content which
+	// does not match the checksums the fake reports for it
+	CorruptDownloads bool
+
+	// V2Only - Whether the fake behaves like a foundation which serves
+	// only the v2 API. Its root does not advertise the v3 API then.
+	V2Only bool
+
+	// NoV3Downloads - Whether the fake behaves like an older foundation
+	// which advertises the v3 API but does not serve package and droplet
+	// downloads via the v3 API
+	NoV3Downloads bool
+
+	server *httptest.Server
+	mutex  sync.Mutex
+
+	users         map[string]string
+	accessTokens  map[string]string
+	refreshTokens map[string]string
+
+	resources map[string]map[string]*resource
+	sequence  int
+	nextPort  int
+
+	packages map[string][]byte
+	droplets map[string][]byte
+
+	// reasons of crashed app instances by app GUID and index
+	crashes map[string]map[int]string
+	// stopped apps whose instances are still running by app GUID
+	stopping map[string]bool
+	// logs of apps by app GUID in the order they were logged
+	logs map[string][]appLog
+	// spaces security groups are bound to by relation
+	// i.e. "spaces" or "staging_spaces" and group GUID
+	boundSpaces map[string]map[string]map[string]bool
+	// users of identity providers other than the UAA by origin
+	externalUsers map[string]map[string]bool
+	// GUIDs of the users having a role in an org or
+	// space by org or space GUID and role relation
+	roles map[string]map[string]map[string]bool
+	// orgs entitled to use an isolation segment by segment GUID
+	entitledOrgs map[string]map[string]bool
+	// uploaded bits of buildpacks by buildpack GUID
+	buildpackBits map[string][]byte
+	// feature flags by name and the variables of
+	// the environment variable groups by group
+	featureFlags map[string]bool
+	envGroups    map[string]map[string]interface{}
+	// catalogs served by service brokers by broker URL
+	brokerCatalogs map[string][]catalogOffering
+
+	jobFailure     string
+	serviceFailure string
+
+	// reason and description of the next staging which is to fail
+	stagingFailure            string
+	stagingFailureDescription string
+
+	requests []string
+}
+
+// resource - A CC v2 resource stored by the fake
+type resource struct {
+	guid       string
+	collection string
+	sequence   int
+	createdAt  time.Time
+	updatedAt  time.Time
+	entity     map[string]interface{}
+
+	// polls of an async operation in progress
+	polls int
+
+	// failure description of a job or of a service
+	// instance operation which is to fail
+	failure string
+}
+
+// New - Starts a fake Cloud Controller listening on a local port
+func New() *FakeCC {
+
+	f := &FakeCC{
+		PageSize:   50,
+		AsyncPolls: 1,
+
+		users:         make(map[string]string),
+		accessTokens:  make(map[string]string),
+		refreshTokens: make(map[string]string),
+		resources:     make(map[string]map[string]*resource),
+		nextPort:      1024,
+		packages:      make(map[string][]byte),
+		droplets:      make(map[string][]byte),
+		crashes:       make(map[string]map[int]string),
+		stopping:      make(map[string]bool),
+		logs:          make(map[string][]appLog),
+		boundSpaces:   make(map[string]map[string]map[string]bool),
+		externalUsers: make(map[string]map[string]bool),
+		roles:         make(map[string]map[string]map[string]bool),
+		entitledOrgs:  make(map[string]map[string]bool),
+		buildpackBits: make(map[string][]byte),
+		featureFlags:  defaultFeatureFlags(),
+		envGroups:     map[string]map[string]interface{}{"running": {}, "staging": {}},
+
+		brokerCatalogs: make(map[string][]catalogOffering),
+	}
+	f.addDefaultQuota()
+	f.server = httptest.NewServer(f)
+	return f
+}
+
+// URL - The API endpoint of the fake
+func (f *FakeCC) URL() string {
+	return f.server.URL
+}
+
+// Close -
+func (f *FakeCC) Close() {
+	f.server.Close()
+}
+
+// Requests - Returns the method and request URI of all
+// requests received by the fake in the order received
+func (f *FakeCC) Requests() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]string{}, f.requests...)
+}
+
+// AddUser -
+func (f *FakeCC) AddUser(username, password string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.users[username] = password
+}
+
+// ExpireTokens - Invalidates all access tokens issued so far so
+// that clients need to refresh them before the next request
+func (f *FakeCC) ExpireTokens() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.accessTokens = make(map[string]string)
+}
+
+// AddOrg -
+func (f *FakeCC) AddOrg(name string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.create("organizations", map[string]interface{}{
+		"name":                  name,
+		"status":                "active",
+		"quota_definition_guid": DefaultQuotaGUID,
+	}).guid
+}
+
+// AddSpace -
+func (f *FakeCC) AddSpace(orgGUID, name string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.create("spaces", map[string]interface{}{
+		"name":              name,
+		"organization_guid": orgGUID,
+		"allow_ssh":         true,
+	}).guid
+}
+
+// AddSharedDomain -
+func (f *FakeCC) AddSharedDomain(name string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.create("shared_domains", map[string]interface{}{
+		"name": name,
+	}).guid
+}
+
+// AddPrivateDomain -
+func (f *FakeCC) AddPrivateDomain(orgGUID, name string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.create("private_domains", map[string]interface{}{
+		"name":                     name,
+		"owning_organization_guid": orgGUID,
+	}).guid
+}
+
+// AddApp - Adds an app to a space. The given attributes
+// override the defaults of a newly created app.
+func (f *FakeCC) AddApp(spaceGUID, name string, attributes map[string]interface{}) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	entity := appDefaults()
+	for k, v := range attributes {
+		entity[k] = v
+	}
+	entity["name"] = name
+	entity["space_guid"] = spaceGUID
+	return f.create("apps", entity).guid
+}
+
+// SetAppPackage - Sets the bits of an app as if they were uploaded
+func (f *FakeCC) SetAppPackage(appGUID string, content []byte) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.packages[appGUID] = content
+}
+
+// SetAppDroplet - Sets the droplet of an app as if it was staged
+func (f *FakeCC) SetAppDroplet(appGUID string, content []byte) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.droplets[appGUID] = content
+	if app := f.find("apps", appGUID); app != nil {
+		app.entity["package_state"] = "STAGED"
+	}
+}
+
+// CrashAppInstance - Makes the instance of an app with the given index
+// report that it crashed for the given reason until the app is started
+func (f *FakeCC) CrashAppInstance(appGUID string, index int, reason string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if _, ok := f.crashes[appGUID]; !ok {
+		f.crashes[appGUID] = make(map[int]string)
+	}
+	f.crashes[appGUID][index] = reason
+}
+
+// AppPackage -
+func (f *FakeCC) AppPackage(appGUID string) ([]byte, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	content, ok := f.packages[appGUID]
+	return content, ok
+}
+
+// AppDroplet -
+func (f *FakeCC) AppDroplet(appGUID string) ([]byte, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	content, ok := f.droplets[appGUID]
+	return content, ok
+}
+
+// AddRoute - Adds a route. A port of 0 creates an HTTP route.
+func (f *FakeCC) AddRoute(spaceGUID, domainGUID, host, path string, port int) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	entity := map[string]interface{}{
+		"host":        host,
+		"path":        path,
+		"domain_guid": domainGUID,
+		"space_guid":  spaceGUID,
+	}
+	if port > 0 {
+		entity["port"] = port
+	} else {
+		entity["port"] = nil
+	}
+	return f.create("routes", entity).guid
+}
+
+// MapRoute -
+func (f *FakeCC) MapRoute(routeGUID, appGUID string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.mapRoute(routeGUID, appGUID, 8080)
+}
+
+// AddServiceOffering - Adds a service with the given plans. Returns
+// the GUID of the service and the GUIDs of its plans in order.
+func (f *FakeCC) AddServiceOffering(label string, plans ...string) (string, []string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	service := f.create("services", map[string]interface{}{
+		"label":       label,
+		"provider":    nil,
+		"version":     nil,
+		"description": fmt.Sprintf("%s service", label),
+		"active":      true,
+		"bindable":    true,
+		"tags":        []interface{}{},
+	})
+	planGUIDs := []string{}
+	for _, p := range plans {
+		plan := f.create("service_plans", map[string]interface{}{
+			"name":         p,
+			"description":  fmt.Sprintf("%s plan", p),
+			"free":         true,
+			"public":       true,
+			"active":       true,
+			"service_guid": service.guid,
+		})
+		planGUIDs = append(planGUIDs, plan.guid)
+	}
+	return service.guid, planGUIDs
+}
+
+// AddServiceInstance - Adds a managed service instance. The given
+// credentials are returned by bindings and keys of the instance.
+func (f *FakeCC) AddServiceInstance(spaceGUID, name, planGUID string, credentials map[string]interface{}) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.create("service_instances", map[string]interface{}{
+		"name":              name,
+		"space_guid":        spaceGUID,
+		"service_plan_guid": planGUID,
+		"credentials":       credentials,
+		"tags":              []interface{}{},
+		"type":              "managed_service_instance",
+		"last_operation":    lastOperation("create", "succeeded"),
+	}).guid
+}
+
+// AddUserProvidedService -
+func (f *FakeCC) AddUserProvidedService(spaceGUID, name string, credentials map[string]interface{}) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.create("user_provided_service_instances", map[string]interface{}{
+		"name":              name,
+		"space_guid":        spaceGUID,
+		"credentials":       credentials,
+		"syslog_drain_url":  "",
+		"route_service_url": "",
+		"type":              "user_provided_service_instance",
+	}).guid
+}
+
+// BindService -
+func (f *FakeCC) BindService(serviceInstanceGUID, appGUID string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.create("service_bindings", map[string]interface{}{
+		"app_guid":              appGUID,
+		"service_instance_guid": serviceInstanceGUID,
+		"credentials":           f.instanceCredentials(serviceInstanceGUID),
+	}).guid
+}
+
+// AddServiceKey -
+func (f *FakeCC) AddServiceKey(serviceInstanceGUID, name string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.create("service_keys", map[string]interface{}{
+		"name":                  name,
+		"service_instance_guid": serviceInstanceGUID,
+		"credentials":           f.instanceCredentials(serviceInstanceGUID),
+	}).guid
+}
+
+// Event - An audit event to add to the fake
+type Event struct {
+	Type      string
+	Actee     string
+	ActeeType string
+	ActeeName string
+	SpaceGUID string
+	Timestamp time.Time
+	Metadata  map[string]interface{}
+}
+
+// AddEvent -
+func (f *FakeCC) AddEvent(event Event) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.addEvent(event).guid
+}
+
+// FailNextJob - Causes the next async job created by
+// the fake to fail with the given error description
+func (f *FakeCC) FailNextJob(description string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.jobFailure = description
+}
+
+// FailNextServiceOperation - Causes the next operation on a managed
+// service instance to fail with the given description
+func (f *FakeCC) FailNextServiceOperation(description string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.serviceFailure = description
+}
+
+// FailNextStaging - Causes the next staging of an app to fail with the
+// given reason i.e. "BuildpackCompileFailed" and description
+func (f *FakeCC) FailNextStaging(reason, description string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.stagingFailure = reason
+	f.stagingFailureDescription = description
+}
+
+// Entity - Returns a copy of the entity of a resource
+func (f *FakeCC) Entity(collection, guid string) (map[string]interface{}, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if r := f.find(collection, guid); r != nil {
+		return copyEntity(r.entity), true
+	}
+	return nil, false
+}
+
+// FindByName - Returns the GUID of the first resource
+// in the collection having the given name
+func (f *FakeCC) FindByName(collection, name string) (string, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for _, r := range f.list(collection) {
+		if r.entity["name"] == name {
+			return r.guid, true
+		}
+	}
+	return "", false
+}
+
+// Count - Returns the number of resources in a collection
+func (f *FakeCC) Count(collection string) int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.resources[collection])
+}
+
+// State helpers. These expect the caller to hold the mutex.
+
+func (f *FakeCC) newGUID() string {
+	f.sequence++
+	return fmt.Sprintf("%08x-0000-4000-8000-%012x", f.sequence, f.sequence)
+}
+
+func (f *FakeCC) create(collection string, entity map[string]interface{}) *resource {
+
+	now := time.Now().UTC()
+	r := &resource{
+		guid:       f.newGUID(),
+		collection: collection,
+		sequence:   f.sequence,
+		createdAt:  now,
+		updatedAt:  now,
+		entity:     entity,
+	}
+	if _, ok := f.resources[collection]; !ok {
+		f.resources[collection] = make(map[string]*resource)
+	}
+	f.resources[collection][r.guid] = r
+	return r
+}
+
+func (f *FakeCC) find(collection, guid string) *resource {
+	if c, ok := f.resources[collection]; ok {
+		return c[guid]
+	}
+	return nil
+}
+
+func (f *FakeCC) remove(collection, guid string) {
+	if c, ok := f.resources[collection]; ok {
+		delete(c, guid)
+	}
+}
+
+// list - Returns the resources of a collection in the order created
+func (f *FakeCC) list(collection string) []*resource {
+	resources := []*resource{}
+	for _, r := range f.resources[collection] {
+		resources = append(resources, r)
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].sequence < resources[j].sequence
+	})
+	return resources
+}
+
+// filter - Returns the resources in the collection for
+// which the given attribute has the given value
+func (f *FakeCC) filter(collection, attribute, value string) []*resource {
+	resources := []*resource{}
+	for _, r := range f.list(collection) {
+		if v, ok := r.entity[attribute]; ok && fmt.Sprintf("%v", v) == value {
+			resources = append(resources, r)
+		}
+	}
+	return resources
+}
+
+// findServiceInstance - Returns a managed or user-provided service instance
+func (f *FakeCC) findServiceInstance(guid string) *resource {
+	if r := f.find("service_instances", guid); r != nil {
+		return r
+	}
+	return f.find("user_provided_service_instances", guid)
+}
+
+// findDomain - Returns a shared or private domain
+func (f *FakeCC) findDomain(guid string) *resource {
+	if r := f.find("shared_domains", guid); r != nil {
+		return r
+	}
+	return f.find("private_domains", guid)
+}
+
+func (f *FakeCC) instanceCredentials(serviceInstanceGUID string) map[string]interface{} {
+	if si := f.findServiceInstance(serviceInstanceGUID); si != nil {
+		if c, ok := si.entity["credentials"].(map[string]interface{}); ok && c != nil {
+			return copyEntity(c)
+		}
+		return map[string]interface{}{
+			"uri": fmt.Sprintf("fake://%s", si.entity["name"]),
+		}
+	}
+	return map[string]interface{}{}
+}
+
+func (f *FakeCC) mapRoute(routeGUID, appGUID string, appPort int) string {
+	return f.create("route_mappings", map[string]interface{}{
+		"app_guid":   appGUID,
+		"route_guid": routeGUID,
+		"app_port":   appPort,
+	}).guid
+}
+
+func (f *FakeCC) addEvent(event Event) *resource {
+
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	if event.Metadata == nil {
+		event.Metadata = map[string]interface{}{}
+	}
+	entity := map[string]interface{}{
+		"type":       event.Type,
+		"actor":      "user-guid",
+		"actor_type": "user",
+		"actor_name": "admin",
+		"actee":      event.Actee,
+		"actee_type": event.ActeeType,
+		"actee_name": event.ActeeName,
+		"timestamp":  event.Timestamp.Format(time.RFC3339),
+		"metadata":   event.Metadata,
+		"space_guid": event.SpaceGUID,
+	}
+	if space := f.find("spaces", event.SpaceGUID); space != nil {
+		entity["organization_guid"] = space.entity["organization_guid"]
+	}
+	return f.create("events", entity)
+}
+
+// issueToken - Issues an access and refresh token for a user
+func (f *FakeCC) issueToken(username string) (string, string) {
+
+	f.sequence++
+	claims, _ := json.Marshal(map[string]interface{}{
+		"jti":       fmt.Sprintf("token-%d", f.sequence),
+		"user_id":   fmt.Sprintf("user-%s", username),
+		"user_name": username,
+		"email":     username,
+		"origin":    "uaa",
+		"client_id": "cf",
+		"scope":     []string{"cloud_controller.admin", "cloud_controller.read", "cloud_controller.write"},
+		"iat":       time.Now().Unix(),
+		"exp":       time.Now().Add(time.Hour).Unix(),
+	})
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	accessToken := fmt.Sprintf("%s.%s.%s", header,
+		base64.RawURLEncoding.EncodeToString(claims),
+		base64.RawURLEncoding.EncodeToString([]byte("fake-signature")))
+	refreshToken := fmt.Sprintf("refresh-token-%d", f.sequence)
+
+	f.accessTokens[accessToken] = username
+	f.refreshTokens[refreshToken] = username
+	return accessToken, refreshToken
+}
+
+func appDefaults() map[string]interface{} {
+	return map[string]interface{}{
+		"production":                 false,
+		"buildpack":                  nil,
+		"detected_buildpack":         "",
+		"environment_json":           map[string]interface{}{},
+		"memory":                     1024,
+		"instances":                  1,
+		"disk_quota":                 1024,
+		"state":                      "STOPPED",
+		"version":                    "00000000-0000-4000-8000-000000000000",
+		"command":                    nil,
+		"console":                    false,
+		"debug":                      nil,
+		"staging_task_id":            nil,
+		"package_state":              "PENDING",
+		"health_check_type":          "port",
+		"health_check_timeout":       nil,
+		"health_check_http_endpoint": nil,
+		"staging_failed_reason":      nil,
+		"staging_failed_description": nil,
+		"diego":                      true,
+		"docker_image":               nil,
+		"package_updated_at":         nil,
+		"detected_start_command":     "",
+		"enable_ssh":                 true,
+		"ports":                      []interface{}{8080},
+	}
+}
+
+func lastOperation(opType, state string) map[string]interface{} {
+	now := time.Now().UTC().Format(time.RFC3339)
+	return map[string]interface{}{
+		"type":        opType,
+		"state":       state,
+		"description": "",
+		"created_at":  now,
+		"updated_at":  now,
+	}
+}
+
+// copyEntity - Returns a deep copy of a JSON entity
+func copyEntity(entity map[string]interface{}) map[string]interface{} {
+	data, _ := json.Marshal(entity)
+	c := make(map[string]interface{})
+	_ = json.Unmarshal(data, &c)
+	return c
+}
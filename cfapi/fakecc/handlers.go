@@ -0,0 +1,432 @@
+package fakecc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// notFoundErrors - CC error codes returned when a resource is not found
+var notFoundErrors = map[string]struct {
+	code      int
+	errorCode string
+	name      string
+}{
+	"organizations":                   {30003, "CF-OrganizationNotFound", "organization"},
+	"spaces":                          {40004, "CF-SpaceNotFound", "app space"},
+	"apps":                            {100004, "CF-AppNotFound", "app"},
+	"routes":                          {210002, "CF-RouteNotFound", "route"},
+	"route_mappings":                  {210007, "CF-RouteMappingNotFound", "route mapping"},
+	"shared_domains":                  {130002, "CF-DomainNotFound", "domain"},
+	"private_domains":                 {130002, "CF-DomainNotFound", "domain"},
+	"services":                        {120003, "CF-ServiceNotFound", "service"},
+	"service_plans":                   {110003, "CF-ServicePlanNotFound", "service plan"},
+	"service_instances":               {60004, "CF-ServiceInstanceNotFound", "service instance"},
+	"user_provided_service_instances": {60004, "CF-ServiceInstanceNotFound", "service instance"},
+	"service_bindings":                {90004, "CF-ServiceBindingNotFound", "service binding"},
+	"service_keys":                    {360003, "CF-ServiceKeyNotFound", "service key"},
+	"events":                          {10000, "CF-NotFound", "event"},
+	"jobs":                            {10000, "CF-NotFound", "job"},
+	"quota_definitions":               {240001, "CF-QuotaDefinitionNotFound", "quota definition"},
+	"space_quota_definitions":         {310007, "CF-SpaceQuotaDefinitionNotFound", "space quota definition"},
+	"security_groups":                 {300002, "CF-SecurityGroupNotFound", "security group"},
+	"users":                           {20003, "CF-UserNotFound", "user"},
+	"buildpacks":                      {10000, "CF-NotFound", "buildpack"},
+	"stacks":                          {250003, "CF-StackNotFound", "stack"},
+	"service_brokers":                 {270004, "CF-ServiceBrokerNotFound", "service broker"},
+	"service_plan_visibilities":       {260003, "CF-ServicePlanVisibilityNotFound", "service plan visibility"},
+}
+
+// ServeHTTP -
+func (f *FakeCC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.requests = append(f.requests, r.Method+" "+r.URL.RequestURI())
+
+	if f.DropRequests > 0 {
+		f.DropRequests--
+		if conn, _, err := w.(http.Hijacker).Hijack(); err == nil {
+			conn.Close()
+			return
+		}
+	}
+
+	path := strings.TrimRight(r.URL.Path, "/")
+	switch {
+	case path == "":
+		f.root(w, r)
+	case path == "/v2/info":
+		f.info(w, r)
+	case path == "/oauth/token":
+		f.token(w, r)
+	case strings.HasPrefix(path, "/blobstore/"):
+		f.blobstore(w, r, strings.Split(strings.TrimPrefix(path, "/blobstore/"), "/"))
+	case strings.HasPrefix(path, "/v2/"):
+		if !f.authorized(r) {
+			writeError(w, http.StatusUnauthorized, 1000, "CF-InvalidAuthToken", "Invalid Auth Token")
+			return
+		}
+		f.v2(w, r, strings.Split(strings.TrimPrefix(path, "/v2/"), "/"))
+	case strings.HasPrefix(path, "/routing/"):
+		if !f.authorized(r) {
+			writeJSON(w, http.StatusUnauthorized, map[string]interface{}{
+				"name":    "UnauthorizedError",
+				"message": "Token is expired",
+			})
+			return
+		}
+		f.routing(w, r, strings.TrimPrefix(path, "/routing/"))
+	case strings.HasPrefix(path, "/api/v1/read/"):
+		if !f.authorized(r) {
+			writeJSON(w, http.StatusUnauthorized, map[string]interface{}{"error": "Unauthorized"})
+			return
+		}
+		f.logCacheRead(w, r, strings.TrimPrefix(path, "/api/v1/read/"))
+	case strings.HasPrefix(path, "/v3/"):
+		if !f.authorized(r) {
+			writeError(w, http.StatusUnauthorized, 1000, "CF-InvalidAuthToken", "Invalid Auth Token")
+			return
+		}
+		f.v3(w, r, strings.Split(strings.TrimPrefix(path, "/v3/"), "/"))
+	default:
+		writeError(w, http.StatusNotFound, 10000, "CF-NotFound", "Unknown request")
+	}
+}
+
+// v2 - Routes requests to the v2 API
+func (f *FakeCC) v2(w http.ResponseWriter, r *http.Request, segments []string) {
+
+	collection := segments[0]
+	if collection == "config" {
+		f.config(w, r, segments[1:])
+		return
+	}
+	if r.Method == "GET" && collection == "routes" && len(segments) > 1 && segments[1] == "reserved" {
+		f.routeReserved(w, r, segments[2:])
+		return
+	}
+	if r.Method == "GET" && (collection == "service_instances" ||
+		(len(segments) > 2 && segments[2] == "service_instances")) {
+
+		f.advanceServiceOperations()
+	}
+
+	switch len(segments) {
+	case 1:
+		switch r.Method {
+		case "GET":
+			f.listCollection(w, r, collection)
+			return
+		case "POST":
+			f.createResource(w, r, collection)
+			return
+		}
+
+	case 2:
+		guid := segments[1]
+		if collection == "jobs" && r.Method == "GET" {
+			f.getJob(w, guid)
+			return
+		}
+		switch r.Method {
+		case "GET":
+			f.getResource(w, r, collection, guid)
+			return
+		case "PUT":
+			f.updateResource(w, r, collection, guid)
+			return
+		case "DELETE":
+			f.deleteResource(w, r, collection, guid)
+			return
+		}
+
+	case 3:
+		guid, sub := segments[1], segments[2]
+		switch r.Method + " " + collection + "/" + sub {
+		case "GET spaces/summary":
+			f.spaceSummary(w, guid)
+			return
+		case "GET apps/summary":
+			f.appSummary(w, guid)
+			return
+		case "GET apps/env":
+			f.appEnv(w, guid)
+			return
+		case "GET apps/instances":
+			f.instances(w, guid)
+			return
+		case "GET apps/stats":
+			f.stats(w, guid)
+			return
+		case "PUT apps/bits":
+			f.uploadBits(w, r, guid)
+			return
+		case "GET apps/download":
+			f.download(w, r, "packages", guid)
+			return
+		case "POST apps/restage":
+			f.restage(w, guid)
+			return
+		case "PUT buildpacks/bits":
+			f.uploadBuildpack(w, r, guid)
+			return
+		}
+		if r.Method == "GET" {
+			f.listRelated(w, r, collection, guid, sub)
+			return
+		}
+		if r.Method == "PUT" && isRoleRelation(collection, sub) {
+			f.setUserRole(w, r, collection, guid, sub, true)
+			return
+		}
+
+	case 4:
+		guid, sub, target := segments[1], segments[2], segments[3]
+		switch r.Method + " " + collection + "/" + sub + "/" + target {
+		case "GET apps/droplet/download":
+			f.download(w, r, "droplets", guid)
+			return
+		case "PUT apps/droplet/upload":
+			f.uploadDroplet(w, r, guid)
+			return
+		}
+		if r.Method == "POST" && target == "remove" && isRoleRelation(collection, sub) {
+			f.setUserRole(w, r, collection, guid, sub, false)
+			return
+		}
+		switch r.Method + " " + collection + "/" + sub {
+		case "PUT routes/apps":
+			f.bindRoute(w, guid, target)
+			return
+		case "PUT apps/routes":
+			f.bindRoute(w, target, guid)
+			return
+		case "DELETE routes/apps":
+			f.unbindRoute(w, guid, target)
+			return
+		case "DELETE apps/routes":
+			f.unbindRoute(w, target, guid)
+			return
+		case "PUT organizations/private_domains":
+			f.sharePrivateDomain(w, guid, target, true)
+			return
+		case "DELETE organizations/private_domains":
+			f.sharePrivateDomain(w, guid, target, false)
+			return
+		case "PUT space_quota_definitions/spaces":
+			f.assignSpaceQuota(w, guid, target, true)
+			return
+		case "DELETE space_quota_definitions/spaces":
+			f.assignSpaceQuota(w, guid, target, false)
+			return
+		case "PUT security_groups/spaces", "PUT security_groups/staging_spaces":
+			f.bindSecurityGroup(w, guid, sub, target, true)
+			return
+		case "DELETE security_groups/spaces", "DELETE security_groups/staging_spaces":
+			f.bindSecurityGroup(w, guid, sub, target, false)
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, 10000, "CF-NotFound", "Unknown request")
+}
+
+// info -
+func (f *FakeCC) info(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"name":                         "fakecc",
+		"build":                        "",
+		"support":                      "",
+		"version":                      0,
+		"description":                  "Fake Cloud Controller",
+		"authorization_endpoint":       f.server.URL,
+		"token_endpoint":               f.server.URL,
+		"min_cli_version":              nil,
+		"min_recommended_cli_version":  nil,
+		"api_version":                  "2.75.0",
+		"app_ssh_endpoint":             "",
+		"app_ssh_host_key_fingerprint": "",
+		"app_ssh_oauth_client":         "ssh-proxy",
+		"doppler_logging_endpoint":     strings.Replace(f.server.URL, "http", "ws", 1),
+		"routing_endpoint":             f.server.URL + "/routing",
+	})
+}
+
+// token - UAA token endpoint supporting the password and refresh token grants
+func (f *FakeCC) token(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, 10000, "CF-NotFound", "Unknown request")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeUAAError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	var username string
+	switch r.PostForm.Get("grant_type") {
+	case "password":
+		username = r.PostForm.Get("username")
+		if password, ok := f.users[username]; !ok || password != r.PostForm.Get("password") {
+			writeUAAError(w, http.StatusUnauthorized, "unauthorized", "Bad credentials")
+			return
+		}
+	case "refresh_token":
+		var ok bool
+		if username, ok = f.refreshTokens[r.PostForm.Get("refresh_token")]; !ok {
+			writeUAAError(w, http.StatusUnauthorized, "invalid_token", "Invalid refresh token")
+			return
+		}
+	default:
+		writeUAAError(w, http.StatusBadRequest, "unsupported_grant_type", "Unsupported grant type")
+		return
+	}
+
+	accessToken, refreshToken := f.issueToken(username)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token":  accessToken,
+		"token_type":    "bearer",
+		"refresh_token": refreshToken,
+		"expires_in":    3599,
+		"scope":         "cloud_controller.admin cloud_controller.read cloud_controller.write",
+		"jti":           "fake",
+	})
+}
+
+// authorized -
+func (f *FakeCC) authorized(r *http.Request) bool {
+	parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "bearer") {
+		return false
+	}
+	_, ok := f.accessTokens[strings.TrimSpace(parts[1])]
+	return ok
+}
+
+// listCollection -
+func (f *FakeCC) listCollection(w http.ResponseWriter, r *http.Request, collection string) {
+
+	var resources []*resource
+	switch collection {
+	case "domains":
+		resources = append(f.list("private_domains"), f.list("shared_domains")...)
+	case "buildpacks":
+		resources = f.sortedBuildpacks()
+	default:
+		if _, ok := notFoundErrors[collection]; !ok {
+			writeError(w, http.StatusNotFound, 10000, "CF-NotFound", "Unknown request")
+			return
+		}
+		resources = f.list(collection)
+	}
+	f.writePage(w, r, resources)
+}
+
+// listRelated - Lists the resources related to a parent resource
+func (f *FakeCC) listRelated(w http.ResponseWriter, r *http.Request, collection, guid, relation string) {
+
+	parent := f.find(collection, guid)
+	if parent == nil {
+		writeNotFound(w, collection, guid)
+		return
+	}
+	resources, ok := f.children(parent, relation)
+	if !ok {
+		writeError(w, http.StatusNotFound, 10000, "CF-NotFound", "Unknown request")
+		return
+	}
+	if collection == "spaces" && relation == "service_instances" &&
+		queryValues(r.URL).Get("return_user_provided_service_instances") == "true" {
+
+		ups, _ := f.children(parent, "user_provided_service_instances")
+		resources = append(resources, ups...)
+	}
+	f.writePage(w, r, resources)
+}
+
+// writePage -
+func (f *FakeCC) writePage(w http.ResponseWriter, r *http.Request, resources []*resource) {
+	page, err := f.page(r.URL, resources, inlineDepth(r.URL))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 10005, "CF-BadQueryParameter", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// getResource -
+func (f *FakeCC) getResource(w http.ResponseWriter, r *http.Request, collection, guid string) {
+
+	var res *resource
+	switch collection {
+	case "domains":
+		res = f.findDomain(guid)
+	default:
+		res = f.find(collection, guid)
+	}
+	if res == nil {
+		writeNotFound(w, collection, guid)
+		return
+	}
+	writeJSON(w, http.StatusOK, f.render(res, inlineDepth(r.URL)))
+}
+
+// readBody - Reads a JSON request body. An empty body is an empty entity.
+func readBody(r *http.Request) (map[string]interface{}, error) {
+
+	body := make(map[string]interface{})
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(strings.TrimSpace(string(data))) > 0 {
+		if err = json.Unmarshal(data, &body); err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
+
+// writeJSON -
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+// writeError - Writes a CC v2 error response
+func writeError(w http.ResponseWriter, status int, code int, errorCode, description string) {
+	writeJSON(w, status, map[string]interface{}{
+		"code":        code,
+		"description": description,
+		"error_code":  errorCode,
+	})
+}
+
+// writeNotFound -
+func writeNotFound(w http.ResponseWriter, collection, guid string) {
+	if e, ok := notFoundErrors[collection]; ok {
+		writeError(w, http.StatusNotFound, e.code, e.errorCode,
+			fmt.Sprintf("The %s could not be found: %s", e.name, guid))
+		return
+	}
+	writeError(w, http.StatusNotFound, 10000, "CF-NotFound", "Unknown request")
+}
+
+// writeUAAError -
+func writeUAAError(w http.ResponseWriter, status int, errorType, description string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error":             errorType,
+		"error_description": description,
+	})
+}
@@ -0,0 +1,364 @@
+package fakecc_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mevansam/cf-cli-api/cfapi/fakecc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fake Cloud Controller Tests", func() {
+
+	var (
+		cc    *fakecc.FakeCC
+		token string
+
+		orgGUID, spaceGUID, domainGUID string
+	)
+
+	login := func(form url.Values) (int, map[string]interface{}) {
+		resp, err := http.PostForm(cc.URL()+"/oauth/token", form)
+		Expect(err).ShouldNot(HaveOccurred())
+		defer resp.Body.Close()
+
+		body := make(map[string]interface{})
+		Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+		return resp.StatusCode, body
+	}
+
+	request := func(method, path string, body interface{}) (int, map[string]interface{}) {
+		var reader *bytes.Reader
+		if body != nil {
+			data, err := json.Marshal(body)
+			Expect(err).ShouldNot(HaveOccurred())
+			reader = bytes.NewReader(data)
+		} else {
+			reader = bytes.NewReader([]byte{})
+		}
+		req, err := http.NewRequest(method, cc.URL()+path, reader)
+		Expect(err).ShouldNot(HaveOccurred())
+		req.Header.Set("Authorization", "bearer "+token)
+
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ShouldNot(HaveOccurred())
+		defer resp.Body.Close()
+
+		result := make(map[string]interface{})
+		if data, _ := ioutil.ReadAll(resp.Body); len(data) > 0 {
+			Expect(json.Unmarshal(data, &result)).To(Succeed())
+		}
+		return resp.StatusCode, result
+	}
+
+	entity := func(resource map[string]interface{}) map[string]interface{} {
+		return resource["entity"].(map[string]interface{})
+	}
+	guid := func(resource map[string]interface{}) string {
+		return resource["metadata"].(map[string]interface{})["guid"].(string)
+	}
+
+	BeforeEach(func() {
+		cc = fakecc.New()
+		cc.AddUser("admin", "admin-password")
+
+		status, body := login(url.Values{
+			"grant_type": {"password"},
+			"username":   {"admin"},
+			"password":   {"admin-password"},
+		})
+		Expect(status).To(Equal(http.StatusOK))
+		token = body["access_token"].(string)
+
+		orgGUID = cc.AddOrg("org1")
+		spaceGUID = cc.AddSpace(orgGUID, "space1")
+		domainGUID = cc.AddSharedDomain("apps.example.com")
+	})
+	AfterEach(func() {
+		cc.Close()
+	})
+
+	Context("authorization", func() {
+
+		It("rejects bad credentials and unauthorized requests", func() {
+			status, body := login(url.Values{
+				"grant_type": {"password"},
+				"username":   {"admin"},
+				"password":   {"wrong"},
+			})
+			Expect(status).To(Equal(http.StatusUnauthorized))
+			Expect(body["error"]).To(Equal("unauthorized"))
+
+			token = "invalid"
+			status, body = request("GET", "/v2/organizations", nil)
+			Expect(status).To(Equal(http.StatusUnauthorized))
+			Expect(body["error_code"]).To(Equal("CF-InvalidAuthToken"))
+		})
+
+		It("refreshes expired tokens", func() {
+			_, body := login(url.Values{
+				"grant_type": {"password"},
+				"username":   {"admin"},
+				"password":   {"admin-password"},
+			})
+			cc.ExpireTokens()
+
+			token = body["access_token"].(string)
+			status, _ := request("GET", "/v2/organizations", nil)
+			Expect(status).To(Equal(http.StatusUnauthorized))
+
+			status, body = login(url.Values{
+				"grant_type":    {"refresh_token"},
+				"refresh_token": {body["refresh_token"].(string)},
+			})
+			Expect(status).To(Equal(http.StatusOK))
+
+			token = body["access_token"].(string)
+			status, _ = request("GET", "/v2/organizations", nil)
+			Expect(status).To(Equal(http.StatusOK))
+		})
+	})
+
+	Context("resources", func() {
+
+		It("paginates and filters lists", func() {
+			for i := 0; i < 5; i++ {
+				cc.AddApp(spaceGUID, fmt.Sprintf("app%d", i), nil)
+			}
+
+			status, page := request("GET", "/v2/apps?results-per-page=2", nil)
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(page["total_results"]).To(BeEquivalentTo(5))
+			Expect(page["total_pages"]).To(BeEquivalentTo(3))
+			Expect(page["resources"]).To(HaveLen(2))
+			Expect(page["next_url"]).To(ContainSubstring("page=2"))
+
+			_, page = request("GET", page["next_url"].(string), nil)
+			resources := page["resources"].([]interface{})
+			Expect(entity(resources[0].(map[string]interface{}))["name"]).To(Equal("app2"))
+
+			_, page = request("GET", fmt.Sprintf("/v2/spaces/%s/apps?q=name:APP4", spaceGUID), nil)
+			Expect(page["total_results"]).To(BeEquivalentTo(1))
+		})
+
+		It("returns typed errors for missing and conflicting resources", func() {
+			status, body := request("GET", "/v2/apps/unknown", nil)
+			Expect(status).To(Equal(http.StatusNotFound))
+			Expect(body["error_code"]).To(Equal("CF-AppNotFound"))
+
+			status, _ = request("POST", "/v2/apps", map[string]interface{}{"name": "app", "space_guid": spaceGUID})
+			Expect(status).To(Equal(http.StatusCreated))
+			status, body = request("POST", "/v2/apps", map[string]interface{}{"name": "app", "space_guid": spaceGUID})
+			Expect(status).To(Equal(http.StatusBadRequest))
+			Expect(body["error_code"]).To(Equal("CF-AppNameTaken"))
+		})
+
+		It("maps routes to apps and inlines their domains", func() {
+			appGUID := cc.AddApp(spaceGUID, "app", nil)
+
+			status, route := request("POST", "/v2/routes", map[string]interface{}{
+				"host": "app", "domain_guid": domainGUID, "space_guid": spaceGUID,
+			})
+			Expect(status).To(Equal(http.StatusCreated))
+			status, _ = request("PUT", fmt.Sprintf("/v2/routes/%s/apps/%s", guid(route), appGUID), nil)
+			Expect(status).To(Equal(http.StatusCreated))
+
+			_, page := request("GET", fmt.Sprintf("/v2/apps/%s/routes", appGUID), nil)
+			Expect(page["total_results"]).To(BeEquivalentTo(1))
+			r := entity(page["resources"].([]interface{})[0].(map[string]interface{}))
+			Expect(entity(r["domain"].(map[string]interface{}))["name"]).To(Equal("apps.example.com"))
+
+			_, summary := request("GET", fmt.Sprintf("/v2/spaces/%s/summary", spaceGUID), nil)
+			app := summary["apps"].([]interface{})[0].(map[string]interface{})
+			Expect(app["urls"]).To(ConsistOf("app.apps.example.com"))
+		})
+
+		It("deletes service instances with bindings only when recursive", func() {
+			_, plans := cc.AddServiceOffering("mysql", "small")
+			instanceGUID := cc.AddServiceInstance(spaceGUID, "db", plans[0], map[string]interface{}{"password": "secret"})
+			appGUID := cc.AddApp(spaceGUID, "app", nil)
+			bindingGUID := cc.BindService(instanceGUID, appGUID)
+
+			_, binding := request("GET", "/v2/service_bindings/"+bindingGUID, nil)
+			Expect(entity(binding)["credentials"]).To(HaveKeyWithValue("password", "secret"))
+
+			_, env := request("GET", fmt.Sprintf("/v2/apps/%s/env", appGUID), nil)
+			Expect(env["system_env_json"].(map[string]interface{})["VCAP_SERVICES"]).To(HaveKey("mysql"))
+
+			status, body := request("DELETE", "/v2/service_instances/"+instanceGUID, nil)
+			Expect(status).To(Equal(http.StatusBadRequest))
+			Expect(body["error_code"]).To(Equal("CF-AssociationNotEmpty"))
+
+			status, _ = request("DELETE", "/v2/service_instances/"+instanceGUID+"?recursive=true", nil)
+			Expect(status).To(Equal(http.StatusNoContent))
+			Expect(cc.Count("service_bindings")).To(Equal(0))
+		})
+		It("assigns space quotas only to spaces of the quota's org", func() {
+			_, org := request("GET", "/v2/organizations/"+orgGUID, nil)
+			Expect(entity(org)["quota_definition_guid"]).To(Equal(fakecc.DefaultQuotaGUID))
+
+			quotaGUID := cc.AddSpaceQuota(orgGUID, "small", map[string]interface{}{"memory_limit": 512})
+			status, _ := request("PUT", fmt.Sprintf("/v2/space_quota_definitions/%s/spaces/%s", quotaGUID, spaceGUID), nil)
+			Expect(status).To(Equal(http.StatusCreated))
+
+			_, space := request("GET", "/v2/spaces/"+spaceGUID, nil)
+			Expect(entity(space)["space_quota_definition_guid"]).To(Equal(quotaGUID))
+
+			otherSpaceGUID := cc.AddSpace(cc.AddOrg("org2"), "space1")
+			status, body := request("PUT", fmt.Sprintf("/v2/space_quota_definitions/%s/spaces/%s", quotaGUID, otherSpaceGUID), nil)
+			Expect(status).To(Equal(http.StatusBadRequest))
+			Expect(body["error_code"]).To(Equal("CF-InvalidSpaceQuotaDefinition"))
+
+			status, _ = request("DELETE", "/v2/space_quota_definitions/"+quotaGUID, nil)
+			Expect(status).To(Equal(http.StatusNoContent))
+			_, space = request("GET", "/v2/spaces/"+spaceGUID, nil)
+			Expect(entity(space)["space_quota_definition_guid"]).To(BeNil())
+		})
+	})
+
+	Context("async operations", func() {
+
+		It("completes service instance operations after polling", func() {
+			cc.AsyncPolls = 2
+			_, plans := cc.AddServiceOffering("mysql", "small")
+
+			status, instance := request("POST", "/v2/service_instances?accepts_incomplete=true", map[string]interface{}{
+				"name": "db", "space_guid": spaceGUID, "service_plan_guid": plans[0],
+			})
+			Expect(status).To(Equal(http.StatusAccepted))
+
+			state := func() interface{} {
+				_, body := request("GET", "/v2/service_instances/"+guid(instance), nil)
+				return entity(body)["last_operation"].(map[string]interface{})["state"]
+			}
+			Expect(state()).To(Equal("in progress"))
+			Expect(state()).To(Equal("succeeded"))
+		})
+
+		It("uploads and downloads app bits via jobs and the blobstore", func() {
+			appGUID := cc.AddApp(spaceGUID, "app", nil)
+
+			var upload bytes.Buffer
+			writer := multipart.NewWriter(&upload)
+			part, err := writer.CreateFormFile("application", "app.zip")
+			Expect(err).ShouldNot(HaveOccurred())
+			part.Write([]byte("0123456789"))
+			writer.Close()
+
+			req, _ := http.NewRequest("PUT", fmt.Sprintf("%s/v2/apps/%s/bits?async=true", cc.URL(), appGUID), &upload)
+			req.Header.Set("Authorization", "bearer "+token)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ShouldNot(HaveOccurred())
+			job := make(map[string]interface{})
+			Expect(json.NewDecoder(resp.Body).Decode(&job)).To(Succeed())
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+			Expect(entity(job)["status"]).To(Equal("queued"))
+
+			_, job = request("GET", "/v2/jobs/"+guid(job), nil)
+			Expect(entity(job)["status"]).To(Equal("finished"))
+
+			req, _ = http.NewRequest("GET", fmt.Sprintf("%s/v2/apps/%s/download", cc.URL(), appGUID), nil)
+			req.Header.Set("Authorization", "bearer "+token)
+			req.Header.Set("Range", "bytes=4-")
+			resp, err = http.DefaultClient.Do(req)
+			Expect(err).ShouldNot(HaveOccurred())
+			content, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusPartialContent))
+			Expect(string(content)).To(Equal("456789"))
+
+			status, _ := request("PUT", "/v2/apps/"+appGUID, map[string]interface{}{"state": "STARTED"})
+			Expect(status).To(Equal(http.StatusCreated))
+			droplet, ok := cc.AppDroplet(appGUID)
+			Expect(ok).To(BeTrue())
+			Expect(string(droplet)).To(Equal("0123456789"))
+		})
+
+		It("fails jobs on request", func() {
+			appGUID := cc.AddApp(spaceGUID, "app", nil)
+			cc.FailNextJob("staging failed")
+
+			var upload bytes.Buffer
+			writer := multipart.NewWriter(&upload)
+			part, _ := writer.CreateFormFile("droplet", "droplet.tgz")
+			part.Write([]byte("droplet"))
+			writer.Close()
+
+			req, _ := http.NewRequest("PUT", fmt.Sprintf("%s/v2/apps/%s/droplet/upload", cc.URL(), appGUID), &upload)
+			req.Header.Set("Authorization", "bearer "+token)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ShouldNot(HaveOccurred())
+			job := make(map[string]interface{})
+			Expect(json.NewDecoder(resp.Body).Decode(&job)).To(Succeed())
+			resp.Body.Close()
+
+			_, job = request("GET", "/v2/jobs/"+guid(job), nil)
+			Expect(entity(job)["status"]).To(Equal("failed"))
+			Expect(entity(job)["error_details"]).To(HaveKeyWithValue("description", "staging failed"))
+		})
+	})
+
+	It("records audit events for app changes", func() {
+		_, app := request("POST", "/v2/apps", map[string]interface{}{"name": "app", "space_guid": spaceGUID})
+		request("PUT", "/v2/apps/"+guid(app), map[string]interface{}{"memory": 512})
+
+		_, page := request("GET", "/v2/events?q=actee:"+guid(app), nil)
+		types := []string{}
+		for _, e := range page["resources"].([]interface{}) {
+			types = append(types, entity(e.(map[string]interface{}))["type"].(string))
+		}
+		Expect(strings.Join(types, ",")).To(Equal("audit.app.create,audit.app.update"))
+	})
+
+	Context("v3 API", func() {
+
+		It("advertises the v3 API at its root unless serving only v2", func() {
+			_, root := request("GET", "/", nil)
+			Expect(root["links"]).To(HaveKey("cloud_controller_v3"))
+
+			cc.V2Only = true
+			_, root = request("GET", "/", nil)
+			Expect(root["links"]).To(HaveKey("cloud_controller_v2"))
+			Expect(root["links"]).ToNot(HaveKey("cloud_controller_v3"))
+
+			status, _ := request("GET", "/v3/apps", nil)
+			Expect(status).To(Equal(http.StatusNotFound))
+		})
+
+		It("stages packages into droplets and paginates audit events", func() {
+			appGUID := cc.AddApp(spaceGUID, "app", nil)
+			cc.SetAppPackage(appGUID, []byte("bits"))
+			for i := 0; i < 3; i++ {
+				cc.AddEvent(fakecc.Event{Type: "audit.app.update", Actee: appGUID, SpaceGUID: spaceGUID})
+			}
+
+			status, build := request("POST", "/v3/builds", map[string]interface{}{
+				"package": map[string]interface{}{"guid": "package-" + appGUID},
+			})
+			Expect(status).To(Equal(http.StatusCreated))
+			Expect(build["state"]).To(Equal("STAGED"))
+			content, ok := cc.AppDroplet(appGUID)
+			Expect(ok).To(BeTrue())
+			Expect(string(content)).To(Equal("bits"))
+
+			_, page := request("GET", "/v3/audit_events?target_guids="+appGUID+"&per_page=2", nil)
+			Expect(page["resources"]).To(HaveLen(2))
+			next := page["pagination"].(map[string]interface{})["next"].(map[string]interface{})
+			Expect(next["href"]).To(ContainSubstring("page=2"))
+
+			_, page = request("GET", "/v3/audit_events?target_guids="+appGUID+"&per_page=2&page=2", nil)
+			Expect(page["resources"]).To(HaveLen(1))
+			Expect(page["pagination"].(map[string]interface{})["next"]).To(BeNil())
+		})
+	})
+})
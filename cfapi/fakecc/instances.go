@@ -0,0 +1,158 @@
+package fakecc
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// appInstances - Returns the state of each instance of a started app
+// keyed by its index. Instances are starting until they have been
+// polled AsyncPolls times after the app was started and are still
+// running until they have been polled as often after it was stopped.
+// Writes an error and returns nil if the app is not started or staged.
+func (f *FakeCC) appInstances(w http.ResponseWriter, app *resource, poll bool) map[string]map[string]interface{} {
+
+	if f.stopping[app.guid] {
+		if app.polls < f.AsyncPolls {
+			if poll {
+				app.polls++
+			}
+			instances := map[string]map[string]interface{}{}
+			for i := 0; i < int(toInt64(app.entity["instances"])); i++ {
+				instances[strconv.Itoa(i)] = map[string]interface{}{
+					"state":  "RUNNING",
+					"since":  float64(app.updatedAt.UnixNano()) / 1e9,
+					"uptime": int64(time.Since(app.updatedAt) / time.Second),
+				}
+			}
+			return instances
+		}
+		delete(f.stopping, app.guid)
+	}
+	if str(app.entity, "state") != "STARTED" {
+		writeError(w, http.StatusBadRequest, 220001, "CF-InstancesError", fmt.Sprintf(
+			"Instances error: Request failed for app: %s as the app is in stopped state.", str(app.entity, "name")))
+		return nil
+	}
+	if str(app.entity, "package_state") == "FAILED" {
+		writeError(w, http.StatusBadRequest, 170001, "CF-StagingError",
+			fmt.Sprintf("Staging error: %s", str(app.entity, "staging_failed_reason")))
+		return nil
+	}
+	if str(app.entity, "package_state") != "STAGED" {
+		writeError(w, http.StatusBadRequest, 170002, "CF-NotStaged", "App has not finished staging")
+		return nil
+	}
+
+	state := "RUNNING"
+	if app.polls < f.AsyncPolls {
+		state = "STARTING"
+		if poll {
+			app.polls++
+		}
+	}
+	instances := map[string]map[string]interface{}{}
+	for i := 0; i < int(toInt64(app.entity["instances"])); i++ {
+		instance := map[string]interface{}{
+			"state":  state,
+			"since":  float64(app.updatedAt.UnixNano()) / 1e9,
+			"uptime": int64(time.Since(app.updatedAt) / time.Second),
+		}
+		if reason, ok := f.crashes[app.guid][i]; ok {
+			instance["state"] = "CRASHED"
+			instance["details"] = reason
+			instance["uptime"] = 0
+		}
+		instances[strconv.Itoa(i)] = instance
+	}
+	return instances
+}
+
+// instances - Serves the state of the instances of an app
+func (f *FakeCC) instances(w http.ResponseWriter, appGUID string) {
+
+	app := f.find("apps", appGUID)
+	if app == nil {
+		writeNotFound(w, "apps", appGUID)
+		return
+	}
+	if instances := f.appInstances(w, app, true); instances != nil {
+		writeJSON(w, http.StatusOK, instances)
+	}
+}
+
+// stats - Serves the resource usage of the running instances of an app
+func (f *FakeCC) stats(w http.ResponseWriter, appGUID string) {
+
+	app := f.find("apps", appGUID)
+	if app == nil {
+		writeNotFound(w, "apps", appGUID)
+		return
+	}
+	instances := f.appInstances(w, app, false)
+	if instances == nil {
+		return
+	}
+
+	host := "10.0.32.15"
+	memQuota := toInt64(app.entity["memory"]) * 1024 * 1024
+	diskQuota := toInt64(app.entity["disk_quota"]) * 1024 * 1024
+
+	stats := map[string]interface{}{}
+	for index, instance := range instances {
+		if instance["state"] != "RUNNING" {
+			stats[index] = map[string]interface{}{"state": instance["state"]}
+			continue
+		}
+		i, _ := strconv.Atoi(index)
+		stats[index] = map[string]interface{}{
+			"state": "RUNNING",
+			"stats": map[string]interface{}{
+				"name":       app.entity["name"],
+				"uris":       f.appSummaryEntity(app)["urls"],
+				"host":       host,
+				"port":       61000 + i,
+				"uptime":     instance["uptime"],
+				"mem_quota":  memQuota,
+				"disk_quota": diskQuota,
+				"fds_quota":  16384,
+				"usage": map[string]interface{}{
+					"time": time.Now().UTC().Format(time.RFC3339),
+					"cpu":  0.01,
+					"mem":  memQuota / 4,
+					"disk": diskQuota / 8,
+				},
+			},
+		}
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// startApp - Restarts the instances of an app
+// clearing the crashes of earlier instances
+func (f *FakeCC) startApp(app *resource) {
+	app.polls = 0
+	delete(f.crashes, app.guid)
+	delete(f.stopping, app.guid)
+}
+
+// stopApp - Stops an app whose instances keep
+// running until they have been polled
+func (f *FakeCC) stopApp(app *resource) {
+	app.polls = 0
+	f.stopping[app.guid] = true
+}
+
+func toInt64(v interface{}) int64 {
+	switch n := v.(type) {
+	case int:
+		return int64(n)
+	case int64:
+		return n
+	case float64:
+		return int64(n)
+	}
+	return 0
+}
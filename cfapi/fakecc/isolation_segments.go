@@ -0,0 +1,121 @@
+package fakecc
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// AddIsolationSegment -
+func (f *FakeCC) AddIsolationSegment(name string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.create("isolation_segments", map[string]interface{}{"name": name}).guid
+}
+
+// v3IsolationSegments - Lists the isolation segments
+// with the names given by the names filter
+func (f *FakeCC) v3IsolationSegments(w http.ResponseWriter, r *http.Request) {
+
+	names := v3Filter(r.URL, "names")
+
+	resources := []interface{}{}
+	for _, segment := range f.list("isolation_segments") {
+		if names == nil || names[str(segment.entity, "name")] {
+			resources = append(resources, renderV3IsolationSegment(segment))
+		}
+	}
+	writeJSON(w, http.StatusOK, f.v3Page(r.URL, resources))
+}
+
+// v3EntitleOrgs - Entitles the orgs in the body to use an isolation segment
+func (f *FakeCC) v3EntitleOrgs(w http.ResponseWriter, r *http.Request, segmentGUID string) {
+
+	if f.find("isolation_segments", segmentGUID) == nil {
+		writeV3NotFound(w, "Isolation segment not found")
+		return
+	}
+	body, err := readBody(r)
+	if err != nil {
+		writeV3Error(w, http.StatusUnprocessableEntity, 10008, "CF-UnprocessableEntity", err.Error())
+		return
+	}
+	data, _ := body["data"].([]interface{})
+	orgGUIDs := []string{}
+	for _, d := range data {
+		orgGUID := str(d.(map[string]interface{}), "guid")
+		if f.find("organizations", orgGUID) == nil {
+			writeV3Error(w, http.StatusUnprocessableEntity, 10008, "CF-UnprocessableEntity",
+				fmt.Sprintf("Organization guids do not exist: %s", orgGUID))
+			return
+		}
+		orgGUIDs = append(orgGUIDs, orgGUID)
+	}
+
+	if _, ok := f.entitledOrgs[segmentGUID]; !ok {
+		f.entitledOrgs[segmentGUID] = make(map[string]bool)
+	}
+	for _, orgGUID := range orgGUIDs {
+		f.entitledOrgs[segmentGUID][orgGUID] = true
+	}
+	entitled := []interface{}{}
+	for _, org := range f.list("organizations") {
+		if f.entitledOrgs[segmentGUID][org.guid] {
+			entitled = append(entitled, map[string]interface{}{"guid": org.guid})
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": entitled})
+}
+
+// v3AssignIsolationSegment - Sets the default isolation segment of an org
+// or the isolation segment of a space. The org of the space or the org
+// itself must be entitled to use the segment. A null segment GUID
+// removes the assignment.
+func (f *FakeCC) v3AssignIsolationSegment(w http.ResponseWriter, r *http.Request, collection, guid string) {
+
+	res := f.find(collection, guid)
+	if res == nil {
+		kind := "Organization"
+		if collection == "spaces" {
+			kind = "Space"
+		}
+		writeV3NotFound(w, kind+" not found")
+		return
+	}
+	body, err := readBody(r)
+	if err != nil {
+		writeV3Error(w, http.StatusUnprocessableEntity, 10008, "CF-UnprocessableEntity", err.Error())
+		return
+	}
+	data, _ := body["data"].(map[string]interface{})
+	segmentGUID := str(data, "guid")
+
+	attribute, orgGUID := "default_isolation_segment_guid", guid
+	if collection == "spaces" {
+		attribute, orgGUID = "isolation_segment_guid", str(res.entity, "organization_guid")
+	}
+	if len(segmentGUID) == 0 {
+		res.entity[attribute] = nil
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": nil})
+		return
+	}
+	if f.find("isolation_segments", segmentGUID) == nil || !f.entitledOrgs[segmentGUID][orgGUID] {
+		writeV3Error(w, http.StatusUnprocessableEntity, 10008, "CF-UnprocessableEntity",
+			fmt.Sprintf("Unable to assign isolation segment with guid '%s'. Ensure it has been "+
+				"entitled to the organization that this space belongs to.", segmentGUID))
+		return
+	}
+	res.entity[attribute] = segmentGUID
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{"guid": segmentGUID},
+	})
+}
+
+func renderV3IsolationSegment(segment *resource) map[string]interface{} {
+	return map[string]interface{}{
+		"guid":       segment.guid,
+		"name":       str(segment.entity, "name"),
+		"created_at": segment.createdAt.Format(time.RFC3339),
+		"updated_at": segment.updatedAt.Format(time.RFC3339),
+	}
+}
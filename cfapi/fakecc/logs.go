@@ -0,0 +1,121 @@
+package fakecc
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// appLog - A log line of an app kept by the fake log cache
+type appLog struct {
+	timestamp  int64
+	sourceType string
+	instanceID string
+	message    string
+	isError    bool
+}
+
+// AddAppLog - Adds a line to the logs of an app as if it had been
+// logged now by the given source i.e. "APP/PROC/WEB" or "STG"
+func (f *FakeCC) AddAppLog(appGUID, sourceType, instanceID, message string, isError bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.addLog(appGUID, sourceType, instanceID, message, isError)
+}
+
+// AddSimultaneousAppLogs - Adds lines to the logs of an app as if
+// they had all been logged now at the same nanosecond
+func (f *FakeCC) AddSimultaneousAppLogs(appGUID, sourceType, instanceID string, messages ...string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	timestamp := f.nextLogTimestamp(appGUID)
+	for _, message := range messages {
+		f.logs[appGUID] = append(f.logs[appGUID], appLog{
+			timestamp:  timestamp,
+			sourceType: sourceType,
+			instanceID: instanceID,
+			message:    message,
+		})
+	}
+}
+
+// addLog - Logs a line for an app. Timestamps are increasing
+// so the order of the logs is well defined.
+func (f *FakeCC) addLog(appGUID, sourceType, instanceID, message string, isError bool) {
+	f.logs[appGUID] = append(f.logs[appGUID], appLog{
+		timestamp:  f.nextLogTimestamp(appGUID),
+		sourceType: sourceType,
+		instanceID: instanceID,
+		message:    message,
+		isError:    isError,
+	})
+}
+
+// nextLogTimestamp - Returns the current time or the time after
+// the last log of an app if that was logged at the same time
+func (f *FakeCC) nextLogTimestamp(appGUID string) int64 {
+	timestamp := time.Now().UnixNano()
+	if logs := f.logs[appGUID]; len(logs) > 0 && timestamp <= logs[len(logs)-1].timestamp {
+		timestamp = logs[len(logs)-1].timestamp + 1
+	}
+	return timestamp
+}
+
+// logCacheRead - Serves the log envelopes of an app like the read
+// endpoint of the log cache. Supports the start_time, end_time,
+// limit and descending parameters.
+func (f *FakeCC) logCacheRead(w http.ResponseWriter, r *http.Request, sourceID string) {
+
+	query := queryValues(r.URL)
+
+	start, _ := strconv.ParseInt(query.Get("start_time"), 10, 64)
+	end := time.Now().UnixNano() + 1
+	if value := query.Get("end_time"); len(value) > 0 {
+		end, _ = strconv.ParseInt(value, 10, 64)
+	}
+	limit := 100
+	if value, err := strconv.Atoi(query.Get("limit")); err == nil && value > 0 {
+		limit = value
+	}
+	if limit > 1000 {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "limit must be less than or equal to 1000"})
+		return
+	}
+
+	logs := []appLog{}
+	for _, l := range f.logs[sourceID] {
+		if l.timestamp >= start && l.timestamp < end {
+			logs = append(logs, l)
+		}
+	}
+	if query.Get("descending") == "true" {
+		sort.SliceStable(logs, func(i, j int) bool { return logs[i].timestamp > logs[j].timestamp })
+	}
+	if len(logs) > limit {
+		logs = logs[:limit]
+	}
+
+	batch := []interface{}{}
+	for _, l := range logs {
+		logType := "OUT"
+		if l.isError {
+			logType = "ERR"
+		}
+		batch = append(batch, map[string]interface{}{
+			"timestamp":   strconv.FormatInt(l.timestamp, 10),
+			"source_id":   sourceID,
+			"instance_id": l.instanceID,
+			"tags":        map[string]interface{}{"source_type": l.sourceType},
+			"log": map[string]interface{}{
+				// encoded as base64 by the JSON encoder
+				"payload": []byte(l.message),
+				"type":    logType,
+			},
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"envelopes": map[string]interface{}{"batch": batch},
+	})
+}
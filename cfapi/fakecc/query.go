@@ -0,0 +1,227 @@
+package fakecc
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// condition - A single 'q' filter condition of a list request
+type condition struct {
+	attribute string
+	operator  string
+	value     string
+}
+
+var conditionPattern = regexp.MustCompile(`^([a-z_]+)(>=|<=|>|<|:| IN )(.*)$`)
+
+// timestamp formats accepted by CC filters
+var timestampFormats = []string{
+	"2006-01-02 15:04:05-07:00",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02 15:04:05Z07:00",
+}
+
+// queryValues - Parses the query of a request. Older CLI versions
+// escape the whole 'q=name:value' pair so these are unwrapped.
+func queryValues(u *url.URL) url.Values {
+
+	values := u.Query()
+	for k, v := range values {
+		if i := strings.Index(k, "="); i > 0 && len(v) == 1 && len(v[0]) == 0 {
+			delete(values, k)
+			values.Add(k[:i], k[i+1:])
+		}
+	}
+	return values
+}
+
+// parseConditions -
+func parseConditions(values url.Values) ([]condition, error) {
+
+	conditions := []condition{}
+	for _, q := range values["q"] {
+		for _, c := range strings.Split(q, ";") {
+			m := conditionPattern.FindStringSubmatch(c)
+			if m == nil {
+				return nil, fmt.Errorf("Invalid filter '%s'", c)
+			}
+			conditions = append(conditions, condition{attribute: m[1], operator: strings.TrimSpace(m[2]), value: m[3]})
+		}
+	}
+	return conditions, nil
+}
+
+// matches - Returns whether the resource satisfies all the conditions
+func (r *resource) matches(conditions []condition) bool {
+
+	for _, c := range conditions {
+
+		var actual interface{}
+		if c.attribute == "guid" {
+			actual = r.guid
+		} else {
+			actual = r.entity[c.attribute]
+		}
+		if !compare(c, actual) {
+			return false
+		}
+	}
+	return true
+}
+
+// compare -
+func compare(c condition, actual interface{}) bool {
+
+	if actual == nil {
+		return false
+	}
+	a := fmt.Sprintf("%v", actual)
+
+	switch c.operator {
+	case ":":
+		if c.attribute == "name" || c.attribute == "label" {
+			return strings.EqualFold(a, c.value)
+		}
+		return a == c.value
+	case "IN":
+		for _, v := range strings.Split(c.value, ",") {
+			if a == v {
+				return true
+			}
+		}
+		return false
+	}
+
+	var cmp int
+	if at, ae := parseTimestamp(a); ae == nil {
+		vt, ve := parseTimestamp(c.value)
+		if ve != nil {
+			return false
+		}
+		switch {
+		case at.Before(vt):
+			cmp = -1
+		case at.After(vt):
+			cmp = 1
+		}
+	} else if an, ae := strconv.ParseFloat(a, 64); ae == nil {
+		vn, ve := strconv.ParseFloat(c.value, 64)
+		if ve != nil {
+			return false
+		}
+		switch {
+		case an < vn:
+			cmp = -1
+		case an > vn:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(a, c.value)
+	}
+
+	switch c.operator {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+func parseTimestamp(s string) (t time.Time, err error) {
+	for _, layout := range timestampFormats {
+		if t, err = time.Parse(layout, s); err == nil {
+			return
+		}
+	}
+	return
+}
+
+// page - Returns a page of the given resources as a CC paginated response
+func (f *FakeCC) page(u *url.URL, resources []*resource, depth int) (map[string]interface{}, error) {
+
+	values := queryValues(u)
+
+	conditions, err := parseConditions(values)
+	if err != nil {
+		return nil, err
+	}
+	matched := []*resource{}
+	for _, r := range resources {
+		if r.matches(conditions) {
+			matched = append(matched, r)
+		}
+	}
+
+	if orderBy := values.Get("order-by"); len(orderBy) > 0 && orderBy != "id" {
+		sort.SliceStable(matched, func(i, j int) bool {
+			a, b := fmt.Sprintf("%v", matched[i].entity[orderBy]), fmt.Sprintf("%v", matched[j].entity[orderBy])
+			if at, err := parseTimestamp(a); err == nil {
+				if bt, err := parseTimestamp(b); err == nil {
+					return at.Before(bt)
+				}
+			}
+			return a < b
+		})
+	}
+	if values.Get("order-direction") == "desc" {
+		for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+			matched[i], matched[j] = matched[j], matched[i]
+		}
+	}
+
+	perPage := f.PageSize
+	if n, err := strconv.Atoi(values.Get("results-per-page")); err == nil && n > 0 {
+		perPage = n
+	}
+	pageNum := 1
+	if n, err := strconv.Atoi(values.Get("page")); err == nil && n > 0 {
+		pageNum = n
+	}
+	totalPages := (len(matched) + perPage - 1) / perPage
+
+	pageURL := func(n int) interface{} {
+		if n < 1 || n > totalPages {
+			return nil
+		}
+		v := url.Values{}
+		for k, vv := range values {
+			v[k] = vv
+		}
+		v.Set("page", strconv.Itoa(n))
+		v.Set("results-per-page", strconv.Itoa(perPage))
+		return u.Path + "?" + v.Encode()
+	}
+
+	rendered := []interface{}{}
+	start := (pageNum - 1) * perPage
+	for i := start; i < len(matched) && i < start+perPage; i++ {
+		rendered = append(rendered, f.render(matched[i], depth))
+	}
+
+	return map[string]interface{}{
+		"total_results": len(matched),
+		"total_pages":   totalPages,
+		"prev_url":      pageURL(pageNum - 1),
+		"next_url":      pageURL(pageNum + 1),
+		"resources":     rendered,
+	}, nil
+}
+
+// inlineDepth -
+func inlineDepth(u *url.URL) int {
+	depth, _ := strconv.Atoi(queryValues(u).Get("inline-relations-depth"))
+	if depth > 2 {
+		depth = 2
+	}
+	return depth
+}
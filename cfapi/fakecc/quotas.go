@@ -0,0 +1,96 @@
+package fakecc
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DefaultQuotaGUID - GUID of the quota named "default" orgs are assigned
+// when created. It is outside the sequence of GUIDs the fake generates.
+const DefaultQuotaGUID = "00000000-0000-4000-8000-000000000000"
+
+// quotaDefaults - Returns the attributes of a newly created quota definition
+func quotaDefaults() map[string]interface{} {
+	return map[string]interface{}{
+		"non_basic_services_allowed": true,
+		"total_services":             100,
+		"total_routes":               1000,
+		"memory_limit":               10240,
+		"instance_memory_limit":      -1,
+		"app_instance_limit":         -1,
+		"total_reserved_route_ports": 0,
+	}
+}
+
+// addDefaultQuota - Adds the quota named "default"
+func (f *FakeCC) addDefaultQuota() {
+
+	entity := quotaDefaults()
+	entity["name"] = "default"
+
+	now := time.Now().UTC()
+	f.resources["quota_definitions"] = map[string]*resource{
+		DefaultQuotaGUID: {
+			guid:       DefaultQuotaGUID,
+			collection: "quota_definitions",
+			createdAt:  now,
+			updatedAt:  now,
+			entity:     entity,
+		},
+	}
+}
+
+// AddQuota - Adds an org quota definition. The given
+// attributes override the defaults of a new quota.
+func (f *FakeCC) AddQuota(name string, attributes map[string]interface{}) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	entity := quotaDefaults()
+	for k, v := range attributes {
+		entity[k] = v
+	}
+	entity["name"] = name
+	return f.create("quota_definitions", entity).guid
+}
+
+// AddSpaceQuota - Adds a space quota definition to an org. The
+// given attributes override the defaults of a new quota.
+func (f *FakeCC) AddSpaceQuota(orgGUID, name string, attributes map[string]interface{}) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	entity := quotaDefaults()
+	for k, v := range attributes {
+		entity[k] = v
+	}
+	entity["name"] = name
+	entity["organization_guid"] = orgGUID
+	return f.create("space_quota_definitions", entity).guid
+}
+
+// assignSpaceQuota - Assigns a space quota definition
+// to a space or removes the assignment
+func (f *FakeCC) assignSpaceQuota(w http.ResponseWriter, quotaGUID, spaceGUID string, assign bool) {
+
+	if !f.exists(w, "space_quota_definitions", quotaGUID) || !f.exists(w, "spaces", spaceGUID) {
+		return
+	}
+	quota, space := f.find("space_quota_definitions", quotaGUID), f.find("spaces", spaceGUID)
+	if str(quota.entity, "organization_guid") != str(space.entity, "organization_guid") {
+		writeError(w, http.StatusBadRequest, 310002, "CF-InvalidSpaceQuotaDefinition",
+			fmt.Sprintf("The space quota definition is invalid: %s", quotaGUID))
+		return
+	}
+
+	if assign {
+		space.entity["space_quota_definition_guid"] = quotaGUID
+		writeJSON(w, http.StatusCreated, f.render(quota, 0))
+		return
+	}
+	if str(space.entity, "space_quota_definition_guid") == quotaGUID {
+		space.entity["space_quota_definition_guid"] = nil
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,214 @@
+package fakecc
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// toOne - Collections referred to by the '<name>_guid' attributes of entities
+var toOne = map[string][]string{
+	"organization":        {"organizations"},
+	"owning_organization": {"organizations"},
+	"space":               {"spaces"},
+	"domain":              {"shared_domains", "private_domains"},
+	"service":             {"services"},
+	"service_plan":        {"service_plans"},
+	"service_instance":    {"service_instances", "user_provided_service_instances"},
+	"app":                 {"apps"},
+	"route":               {"routes"},
+	"service_broker":      {"service_brokers"},
+
+	"quota_definition":       {"quota_definitions"},
+	"space_quota_definition": {"space_quota_definitions"},
+}
+
+// toMany - Related resources listed by the resources of a collection
+var toMany = map[string][]string{
+	"organizations":                   {"spaces", "private_domains", "space_quota_definitions"},
+	"spaces":                          {"apps", "routes", "service_instances"},
+	"space_quota_definitions":         {"spaces"},
+	"security_groups":                 {"spaces", "staging_spaces"},
+	"apps":                            {"routes", "service_bindings", "route_mappings"},
+	"routes":                          {"apps", "route_mappings"},
+	"services":                        {"service_plans"},
+	"service_plans":                   {"service_instances"},
+	"service_instances":               {"service_bindings", "service_keys"},
+	"user_provided_service_instances": {"service_bindings"},
+}
+
+// children - Returns the resources related to the given resource by
+// the named to-many relationship. Returns false if there is no such
+// relationship.
+func (f *FakeCC) children(r *resource, relation string) ([]*resource, bool) {
+
+	switch r.collection + "/" + relation {
+	case "organizations/spaces":
+		return f.filter("spaces", "organization_guid", r.guid), true
+	case "organizations/private_domains":
+		return f.orgPrivateDomains(r.guid), true
+	case "organizations/space_quota_definitions":
+		return f.filter("space_quota_definitions", "organization_guid", r.guid), true
+	case "private_domains/shared_organizations":
+		orgs := []*resource{}
+		if guids, ok := r.entity["shared_organization_guids"].([]interface{}); ok {
+			for _, g := range guids {
+				if org := f.find("organizations", fmt.Sprintf("%v", g)); org != nil {
+					orgs = append(orgs, org)
+				}
+			}
+		}
+		return orgs, true
+	case "organizations/domains":
+		return append(f.orgPrivateDomains(r.guid), f.list("shared_domains")...), true
+	case "spaces/apps":
+		return f.filter("apps", "space_guid", r.guid), true
+	case "spaces/routes":
+		return f.filter("routes", "space_guid", r.guid), true
+	case "spaces/service_instances":
+		return f.filter("service_instances", "space_guid", r.guid), true
+	case "spaces/user_provided_service_instances":
+		return f.filter("user_provided_service_instances", "space_guid", r.guid), true
+	case "spaces/services":
+		return f.list("services"), true
+	case "apps/routes":
+		routes := []*resource{}
+		for _, m := range f.filter("route_mappings", "app_guid", r.guid) {
+			if route := f.find("routes", fmt.Sprintf("%v", m.entity["route_guid"])); route != nil {
+				routes = append(routes, route)
+			}
+		}
+		return routes, true
+	case "apps/service_bindings":
+		return f.filter("service_bindings", "app_guid", r.guid), true
+	case "apps/route_mappings":
+		return f.filter("route_mappings", "app_guid", r.guid), true
+	case "routes/apps":
+		apps := []*resource{}
+		for _, m := range f.filter("route_mappings", "route_guid", r.guid) {
+			if app := f.find("apps", fmt.Sprintf("%v", m.entity["app_guid"])); app != nil {
+				apps = append(apps, app)
+			}
+		}
+		return apps, true
+	case "routes/route_mappings":
+		return f.filter("route_mappings", "route_guid", r.guid), true
+	case "space_quota_definitions/spaces":
+		return f.filter("spaces", "space_quota_definition_guid", r.guid), true
+	case "security_groups/spaces", "security_groups/staging_spaces":
+		return f.securityGroupSpaces(r.guid, relation), true
+	case "spaces/security_groups":
+		return f.spaceSecurityGroups(r.guid, "spaces"), true
+	case "spaces/staging_security_groups":
+		return f.spaceSecurityGroups(r.guid, "staging_spaces"), true
+	case "organizations/users", "organizations/managers", "organizations/billing_managers",
+		"organizations/auditors", "spaces/developers", "spaces/managers", "spaces/auditors":
+		return f.roleUsers(r.guid, relation), true
+	case "services/service_plans":
+		return f.filter("service_plans", "service_guid", r.guid), true
+	case "service_plans/service_instances":
+		return f.filter("service_instances", "service_plan_guid", r.guid), true
+	case "service_instances/service_bindings", "user_provided_service_instances/service_bindings":
+		return f.filter("service_bindings", "service_instance_guid", r.guid), true
+	case "service_instances/service_keys":
+		return f.filter("service_keys", "service_instance_guid", r.guid), true
+	}
+	return nil, false
+}
+
+// orgPrivateDomains - Returns the private domains owned by or shared with an org
+func (f *FakeCC) orgPrivateDomains(orgGUID string) []*resource {
+	domains := []*resource{}
+	for _, d := range f.list("private_domains") {
+		shared := d.entity["owning_organization_guid"] == orgGUID
+		if orgs, ok := d.entity["shared_organization_guids"].([]interface{}); ok {
+			for _, o := range orgs {
+				shared = shared || o == orgGUID
+			}
+		}
+		if shared {
+			domains = append(domains, d)
+		}
+	}
+	return domains
+}
+
+// render - Renders a resource as returned by the CC with
+// related resources inlined up to the given depth
+func (f *FakeCC) render(r *resource, depth int) map[string]interface{} {
+
+	entity := make(map[string]interface{})
+	for k, v := range r.entity {
+		entity[k] = v
+	}
+	if r.collection == "service_instances" {
+		// managed service credentials are only
+		// available via bindings and keys
+		entity["credentials"] = map[string]interface{}{}
+	}
+	if r.collection == "apps" {
+		// the v2 API reports the sha1 checksums of the
+		// app's content which downloads are verified with
+		if content, ok := f.packages[r.guid]; ok {
+			entity["package_hash"] = fmt.Sprintf("%x", sha1.Sum(content))
+		}
+		if content, ok := f.droplets[r.guid]; ok {
+			entity["droplet_hash"] = fmt.Sprintf("%x", sha1.Sum(content))
+		}
+	}
+
+	for k := range r.entity {
+		if !strings.HasSuffix(k, "_guid") {
+			continue
+		}
+		name := strings.TrimSuffix(k, "_guid")
+		collections, ok := toOne[name]
+		if !ok {
+			continue
+		}
+		guid := fmt.Sprintf("%v", r.entity[k])
+		for _, c := range collections {
+			related := f.find(c, guid)
+			if related == nil {
+				continue
+			}
+			entity[name+"_url"] = fmt.Sprintf("/v2/%s/%s", c, guid)
+
+			// Domains are always inlined as they are
+			// required to render the URL of a route
+			if depth > 0 {
+				entity[name] = f.render(related, depth-1)
+			} else if name == "domain" {
+				entity[name] = f.render(related, 0)
+			}
+			break
+		}
+	}
+
+	for _, relation := range toMany[r.collection] {
+		entity[relation+"_url"] = fmt.Sprintf("/v2/%s/%s/%s", r.collection, r.guid, relation)
+		if depth > 0 {
+			children, _ := f.children(r, relation)
+			rendered := []interface{}{}
+			for _, c := range children {
+				rendered = append(rendered, f.render(c, depth-1))
+			}
+			entity[relation] = rendered
+		}
+	}
+	if r.collection == "organizations" && depth > 0 {
+		// The CLI reads the private domains of an org as 'domains'
+		entity["domains"] = entity["private_domains"]
+	}
+
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"guid":       r.guid,
+			"url":        fmt.Sprintf("/v2/%s/%s", r.collection, r.guid),
+			"created_at": r.createdAt.Format(time.RFC3339),
+			"updated_at": r.updatedAt.Format(time.RFC3339),
+		},
+		"entity": entity,
+	}
+}
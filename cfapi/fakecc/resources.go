@@ -0,0 +1,734 @@
+package fakecc
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// required - Writes a parse error and returns false if
+// any of the given attributes are missing from the body
+func required(w http.ResponseWriter, body map[string]interface{}, attributes ...string) bool {
+	for _, a := range attributes {
+		if v, ok := body[a]; !ok || v == nil || v == "" {
+			writeError(w, http.StatusBadRequest, 1001, "CF-MessageParseError",
+				fmt.Sprintf("Request invalid due to parse error: Field: %s, Error: Missing field %s", a, a))
+			return false
+		}
+	}
+	return true
+}
+
+// str - Returns the string value of an attribute
+func str(entity map[string]interface{}, attribute string) string {
+	if v, ok := entity[attribute]; ok && v != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return ""
+}
+
+// nameTaken - Returns whether a resource with the given
+// name exists in the collections for the given scope
+func (f *FakeCC) nameTaken(name, scopeAttribute, scope string, collections ...string) bool {
+	for _, c := range collections {
+		for _, r := range f.list(c) {
+			if strings.EqualFold(str(r.entity, "name"), name) &&
+				(len(scopeAttribute) == 0 || str(r.entity, scopeAttribute) == scope) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// createResource - Handles POST requests to a collection
+func (f *FakeCC) createResource(w http.ResponseWriter, r *http.Request, collection string) {
+
+	body, err := readBody(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 1001, "CF-MessageParseError",
+			fmt.Sprintf("Request invalid due to parse error: %s", err.Error()))
+		return
+	}
+
+	status := http.StatusCreated
+	var created *resource
+
+	switch collection {
+	case "organizations":
+		if !required(w, body, "name") {
+			return
+		}
+		if f.nameTaken(str(body, "name"), "", "", "organizations") {
+			writeError(w, http.StatusBadRequest, 30002, "CF-OrganizationNameTaken",
+				fmt.Sprintf("The organization name is taken: %s", str(body, "name")))
+			return
+		}
+		if _, ok := body["status"]; !ok {
+			body["status"] = "active"
+		}
+		if _, ok := body["quota_definition_guid"]; !ok {
+			body["quota_definition_guid"] = DefaultQuotaGUID
+		} else if !f.exists(w, "quota_definitions", str(body, "quota_definition_guid")) {
+			return
+		}
+		created = f.create(collection, body)
+
+	case "quota_definitions":
+		if !required(w, body, "name") {
+			return
+		}
+		if f.nameTaken(str(body, "name"), "", "", "quota_definitions") {
+			writeError(w, http.StatusBadRequest, 240002, "CF-QuotaDefinitionNameTaken",
+				fmt.Sprintf("Quota Definition is taken: %s", str(body, "name")))
+			return
+		}
+		entity := quotaDefaults()
+		for k, v := range body {
+			entity[k] = v
+		}
+		created = f.create(collection, entity)
+
+	case "space_quota_definitions":
+		if !required(w, body, "name", "organization_guid") || !f.exists(w, "organizations", str(body, "organization_guid")) {
+			return
+		}
+		if f.nameTaken(str(body, "name"), "organization_guid", str(body, "organization_guid"), "space_quota_definitions") {
+			writeError(w, http.StatusBadRequest, 310001, "CF-SpaceQuotaDefinitionNameTaken",
+				fmt.Sprintf("Space Quota Definition is taken: %s", str(body, "name")))
+			return
+		}
+		entity := quotaDefaults()
+		for k, v := range body {
+			entity[k] = v
+		}
+		created = f.create(collection, entity)
+
+	case "security_groups":
+		if !required(w, body, "name") || !validSecurityGroupRules(w, body) {
+			return
+		}
+		if f.nameTaken(str(body, "name"), "", "", "security_groups") {
+			writeError(w, http.StatusBadRequest, 300005, "CF-SecurityGroupNameTaken",
+				fmt.Sprintf("The security group name is taken: %s", str(body, "name")))
+			return
+		}
+		created = f.create(collection, body)
+
+	case "buildpacks":
+		if !required(w, body, "name") || !f.buildpackNameAvailable(w, str(body, "name"), "", "") {
+			return
+		}
+		created = f.create(collection, map[string]interface{}{
+			"name":     body["name"],
+			"stack":    nil,
+			"enabled":  true,
+			"locked":   false,
+			"filename": nil,
+		})
+		for _, a := range []string{"enabled", "locked"} {
+			if v, ok := body[a].(bool); ok {
+				created.entity[a] = v
+			}
+		}
+		f.moveBuildpack(created, body["position"])
+
+	case "spaces":
+		if !required(w, body, "name", "organization_guid") || !f.exists(w, "organizations", str(body, "organization_guid")) {
+			return
+		}
+		if f.nameTaken(str(body, "name"), "organization_guid", str(body, "organization_guid"), "spaces") {
+			writeError(w, http.StatusBadRequest, 40002, "CF-SpaceNameTaken",
+				fmt.Sprintf("The app space name is taken: %s", str(body, "name")))
+			return
+		}
+		if _, ok := body["allow_ssh"]; !ok {
+			body["allow_ssh"] = true
+		}
+		created = f.create(collection, body)
+
+	case "shared_domains", "private_domains":
+		if !required(w, body, "name") {
+			return
+		}
+		if collection == "private_domains" &&
+			(!required(w, body, "owning_organization_guid") || !f.exists(w, "organizations", str(body, "owning_organization_guid"))) {
+			return
+		}
+		if f.nameTaken(str(body, "name"), "", "", "shared_domains", "private_domains") {
+			writeError(w, http.StatusBadRequest, 130003, "CF-DomainNameTaken",
+				fmt.Sprintf("The domain name is taken: %s", str(body, "name")))
+			return
+		}
+		if collection == "shared_domains" && !f.routerGroupAvailable(w, body) {
+			return
+		}
+		created = f.create(collection, body)
+
+	case "apps":
+		if !required(w, body, "name", "space_guid") || !f.exists(w, "spaces", str(body, "space_guid")) {
+			return
+		}
+		if f.nameTaken(str(body, "name"), "space_guid", str(body, "space_guid"), "apps") {
+			writeError(w, http.StatusBadRequest, 100002, "CF-AppNameTaken",
+				fmt.Sprintf("The app name is taken: %s", str(body, "name")))
+			return
+		}
+		entity := appDefaults()
+		for k, v := range body {
+			entity[k] = v
+		}
+		created = f.create(collection, entity)
+		f.addEvent(Event{
+			Type:      "audit.app.create",
+			Actee:     created.guid,
+			ActeeType: "app",
+			ActeeName: str(entity, "name"),
+			SpaceGUID: str(entity, "space_guid"),
+			Metadata:  map[string]interface{}{"request": copyEntity(body)},
+		})
+
+	case "routes":
+		if !required(w, body, "domain_guid", "space_guid") || !f.exists(w, "spaces", str(body, "space_guid")) {
+			return
+		}
+		if f.findDomain(str(body, "domain_guid")) == nil {
+			writeNotFound(w, "shared_domains", str(body, "domain_guid"))
+			return
+		}
+		for _, a := range []string{"host", "path"} {
+			if _, ok := body[a]; !ok {
+				body[a] = ""
+			}
+		}
+		if queryValues(r.URL).Get("generate_port") == "true" {
+			body["port"] = f.nextPort
+			f.nextPort++
+		} else if _, ok := body["port"]; !ok {
+			body["port"] = nil
+		}
+		if f.routeTaken(body) {
+			writeError(w, http.StatusBadRequest, 210003, "CF-RouteHostTaken",
+				fmt.Sprintf("The host is taken: %s", str(body, "host")))
+			return
+		}
+		created = f.create(collection, body)
+
+	case "route_mappings":
+		if !required(w, body, "app_guid", "route_guid") ||
+			!f.exists(w, "apps", str(body, "app_guid")) || !f.exists(w, "routes", str(body, "route_guid")) {
+			return
+		}
+		for _, m := range f.filter("route_mappings", "route_guid", str(body, "route_guid")) {
+			if str(m.entity, "app_guid") == str(body, "app_guid") {
+				writeError(w, http.StatusBadRequest, 210006, "CF-RouteMappingTaken",
+					fmt.Sprintf("The route mapping is taken: %s", str(body, "route_guid")))
+				return
+			}
+		}
+		appPort := 8080
+		if p, ok := body["app_port"].(float64); ok {
+			appPort = int(p)
+		}
+		created = f.find(collection, f.mapRoute(str(body, "route_guid"), str(body, "app_guid"), appPort))
+		f.mapRouteEvent(str(body, "app_guid"), str(body, "route_guid"))
+
+	case "service_instances":
+		if !required(w, body, "name", "space_guid", "service_plan_guid") || !f.exists(w, "spaces", str(body, "space_guid")) {
+			return
+		}
+		if f.find("service_plans", str(body, "service_plan_guid")) == nil {
+			writeError(w, http.StatusBadRequest, 60003, "CF-InvalidServicePlan",
+				fmt.Sprintf("The service plan is invalid: %s", str(body, "service_plan_guid")))
+			return
+		}
+		if !f.serviceNameAvailable(w, body) {
+			return
+		}
+		state := "succeeded"
+		if queryValues(r.URL).Get("accepts_incomplete") == "true" && f.AsyncPolls > 0 {
+			state = "in progress"
+			status = http.StatusAccepted
+		}
+		if _, ok := body["tags"]; !ok {
+			body["tags"] = []interface{}{}
+		}
+		body["type"] = "managed_service_instance"
+		body["last_operation"] = lastOperation("create", state)
+		created = f.create(collection, body)
+		f.startServiceOperation(created)
+
+	case "user_provided_service_instances":
+		if !required(w, body, "name", "space_guid") || !f.exists(w, "spaces", str(body, "space_guid")) {
+			return
+		}
+		if !f.serviceNameAvailable(w, body) {
+			return
+		}
+		for _, a := range []string{"syslog_drain_url", "route_service_url"} {
+			if _, ok := body[a]; !ok {
+				body[a] = ""
+			}
+		}
+		if _, ok := body["credentials"]; !ok {
+			body["credentials"] = map[string]interface{}{}
+		}
+		body["type"] = "user_provided_service_instance"
+		created = f.create(collection, body)
+
+	case "service_bindings":
+		if !required(w, body, "app_guid", "service_instance_guid") || !f.exists(w, "apps", str(body, "app_guid")) {
+			return
+		}
+		si := f.findServiceInstance(str(body, "service_instance_guid"))
+		if si == nil {
+			writeNotFound(w, "service_instances", str(body, "service_instance_guid"))
+			return
+		}
+		if op, ok := si.entity["last_operation"].(map[string]interface{}); ok && op["state"] == "in progress" {
+			writeError(w, http.StatusConflict, 60016, "CF-AsyncServiceInstanceOperationInProgress",
+				fmt.Sprintf("An operation for service instance %s is in progress.", str(si.entity, "name")))
+			return
+		}
+		for _, b := range f.filter("service_bindings", "app_guid", str(body, "app_guid")) {
+			if str(b.entity, "service_instance_guid") == str(body, "service_instance_guid") {
+				writeError(w, http.StatusBadRequest, 90003, "CF-ServiceBindingAppServiceTaken",
+					fmt.Sprintf("The app is already bound to the service instance: %s", str(body, "service_instance_guid")))
+				return
+			}
+		}
+		body["credentials"] = f.instanceCredentials(str(body, "service_instance_guid"))
+		created = f.create(collection, body)
+
+	case "service_keys":
+		if !required(w, body, "name", "service_instance_guid") || !f.exists(w, "service_instances", str(body, "service_instance_guid")) {
+			return
+		}
+		if f.nameTaken(str(body, "name"), "service_instance_guid", str(body, "service_instance_guid"), "service_keys") {
+			writeError(w, http.StatusBadRequest, 360001, "CF-ServiceKeyNameTaken",
+				fmt.Sprintf("The service key name is taken: %s", str(body, "name")))
+			return
+		}
+		body["credentials"] = f.instanceCredentials(str(body, "service_instance_guid"))
+		created = f.create(collection, body)
+
+	case "service_brokers":
+		if !required(w, body, "name", "broker_url", "auth_username", "auth_password") ||
+			!f.serviceBrokerAvailable(w, str(body, "name"), str(body, "broker_url"), "") {
+			return
+		}
+		if spaceGUID, ok := body["space_guid"]; ok && !f.exists(w, "spaces", fmt.Sprintf("%v", spaceGUID)) {
+			return
+		}
+		// the password is never returned
+		delete(body, "auth_password")
+		created = f.create(collection, body)
+		f.addBrokerCatalog(created)
+
+	case "service_plan_visibilities":
+		if !required(w, body, "service_plan_guid", "organization_guid") ||
+			!f.exists(w, "service_plans", str(body, "service_plan_guid")) ||
+			!f.exists(w, "organizations", str(body, "organization_guid")) {
+			return
+		}
+		for _, v := range f.filter(collection, "service_plan_guid", str(body, "service_plan_guid")) {
+			if str(v.entity, "organization_guid") == str(body, "organization_guid") {
+				writeError(w, http.StatusBadRequest, 260002, "CF-ServicePlanVisibilityAlreadyExists",
+					"This combination of ServicePlan and Organization is already taken: organization_id and service_plan_id unique")
+				return
+			}
+		}
+		created = f.create(collection, body)
+
+	case "services", "service_plans", "events":
+		created = f.create(collection, body)
+
+	default:
+		writeError(w, http.StatusNotFound, 10000, "CF-NotFound", "Unknown request")
+		return
+	}
+
+	writeJSON(w, status, f.render(created, 0))
+}
+
+// exists - Writes a not found error and returns false
+// if the referenced resource does not exist
+func (f *FakeCC) exists(w http.ResponseWriter, collection, guid string) bool {
+	if f.find(collection, guid) == nil {
+		writeNotFound(w, collection, guid)
+		return false
+	}
+	return true
+}
+
+// routeTaken - Returns whether a route with the same
+// host, domain, path and port as the given one exists
+func (f *FakeCC) routeTaken(route map[string]interface{}) bool {
+	for _, r := range f.filter("routes", "domain_guid", str(route, "domain_guid")) {
+		if strings.EqualFold(str(r.entity, "host"), str(route, "host")) &&
+			str(r.entity, "path") == str(route, "path") &&
+			str(r.entity, "port") == str(route, "port") {
+			return true
+		}
+	}
+	return false
+}
+
+// serviceNameAvailable - Writes a name taken error and returns false if a
+// managed or user-provided service instance with the name exists in the space
+func (f *FakeCC) serviceNameAvailable(w http.ResponseWriter, body map[string]interface{}) bool {
+	if f.nameTaken(str(body, "name"), "space_guid", str(body, "space_guid"),
+		"service_instances", "user_provided_service_instances") {
+
+		writeError(w, http.StatusBadRequest, 60002, "CF-ServiceInstanceNameTaken",
+			fmt.Sprintf("The service instance name is taken: %s", str(body, "name")))
+		return false
+	}
+	return true
+}
+
+// updateResource - Handles PUT requests to a resource
+func (f *FakeCC) updateResource(w http.ResponseWriter, r *http.Request, collection, guid string) {
+
+	res := f.find(collection, guid)
+	if res == nil {
+		writeNotFound(w, collection, guid)
+		return
+	}
+	body, err := readBody(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 1001, "CF-MessageParseError",
+			fmt.Sprintf("Request invalid due to parse error: %s", err.Error()))
+		return
+	}
+
+	status := http.StatusCreated
+	switch collection {
+	case "organizations":
+		if guid, ok := body["quota_definition_guid"]; ok && !f.exists(w, "quota_definitions", fmt.Sprintf("%v", guid)) {
+			return
+		}
+
+	case "quota_definitions":
+		if name, ok := body["name"]; ok && !strings.EqualFold(fmt.Sprintf("%v", name), str(res.entity, "name")) &&
+			f.nameTaken(fmt.Sprintf("%v", name), "", "", "quota_definitions") {
+
+			writeError(w, http.StatusBadRequest, 240002, "CF-QuotaDefinitionNameTaken",
+				fmt.Sprintf("Quota Definition is taken: %s", name))
+			return
+		}
+
+	case "space_quota_definitions":
+		// the org of a space quota cannot be changed
+		delete(body, "organization_guid")
+
+	case "security_groups":
+		if _, ok := body["rules"]; ok && !validSecurityGroupRules(w, body) {
+			return
+		}
+
+	case "buildpacks":
+		if name, ok := body["name"]; ok && !strings.EqualFold(fmt.Sprintf("%v", name), str(res.entity, "name")) &&
+			!f.buildpackNameAvailable(w, fmt.Sprintf("%v", name), str(res.entity, "stack"), guid) {
+			return
+		}
+		if position, ok := body["position"]; ok {
+			f.moveBuildpack(res, position)
+			delete(body, "position")
+		}
+		// the bits of a buildpack can only be changed by an upload
+		delete(body, "filename")
+		delete(body, "key")
+
+	case "service_brokers":
+		if !f.serviceBrokerAvailable(w, str(body, "name"), str(body, "broker_url"), guid) {
+			return
+		}
+		delete(body, "auth_password")
+
+	case "apps":
+		if name, ok := body["name"]; ok && !strings.EqualFold(fmt.Sprintf("%v", name), str(res.entity, "name")) &&
+			f.nameTaken(fmt.Sprintf("%v", name), "space_guid", str(res.entity, "space_guid"), "apps") {
+
+			writeError(w, http.StatusBadRequest, 100002, "CF-AppNameTaken",
+				fmt.Sprintf("The app name is taken: %s", name))
+			return
+		}
+		if body["state"] == "STARTED" {
+			if !f.stage(w, res) {
+				return
+			}
+			if str(res.entity, "state") != "STARTED" {
+				f.startApp(res)
+			}
+		}
+		if body["state"] == "STOPPED" && str(res.entity, "state") == "STARTED" {
+			f.stopApp(res)
+		}
+		f.addEvent(Event{
+			Type:      "audit.app.update",
+			Actee:     res.guid,
+			ActeeType: "app",
+			ActeeName: str(res.entity, "name"),
+			SpaceGUID: str(res.entity, "space_guid"),
+			Metadata:  map[string]interface{}{"request": copyEntity(body)},
+		})
+
+	case "service_instances":
+		state := "succeeded"
+		if queryValues(r.URL).Get("accepts_incomplete") == "true" && f.AsyncPolls > 0 {
+			state = "in progress"
+			status = http.StatusAccepted
+			res.polls = 0
+		}
+		body["last_operation"] = lastOperation("update", state)
+	}
+
+	for k, v := range body {
+		res.entity[k] = v
+	}
+	switch collection {
+	case "service_instances":
+		f.startServiceOperation(res)
+	case "service_brokers":
+		f.addBrokerCatalog(res)
+	}
+	res.updatedAt = time.Now().UTC()
+	writeJSON(w, status, f.render(res, 0))
+}
+
+// stage - Stages the package of an app into its droplet unless staging
+// was made to fail by FailNextStaging. Writes an error and returns
+// false if no bits have been uploaded.
+func (f *FakeCC) stage(w http.ResponseWriter, app *resource) bool {
+
+	if _, ok := f.droplets[app.guid]; ok && str(app.entity, "package_state") == "STAGED" {
+		return true
+	}
+	content, ok := f.packages[app.guid]
+	if !ok && str(app.entity, "docker_image") == "" {
+		writeError(w, http.StatusBadRequest, 170004, "CF-AppPackageInvalid",
+			"The app package is invalid: bits have not been uploaded")
+		return false
+	}
+	f.addLog(app.guid, "STG", "0", "Staging app and tracing logs...", false)
+	if len(f.stagingFailure) > 0 {
+		f.addLog(app.guid, "STG", "0", f.stagingFailureDescription, true)
+		app.entity["package_state"] = "FAILED"
+		app.entity["staging_failed_reason"] = f.stagingFailure
+		app.entity["staging_failed_description"] = f.stagingFailureDescription
+		f.stagingFailure, f.stagingFailureDescription = "", ""
+		return true
+	}
+	if ok {
+		f.droplets[app.guid] = content
+	}
+	f.addLog(app.guid, "STG", "0", "Uploading droplet...", false)
+	app.entity["package_state"] = "STAGED"
+	app.entity["staging_failed_reason"] = nil
+	app.entity["staging_failed_description"] = nil
+	return true
+}
+
+// deleteResource - Handles DELETE requests to a resource
+func (f *FakeCC) deleteResource(w http.ResponseWriter, r *http.Request, collection, guid string) {
+
+	res := f.find(collection, guid)
+	if res == nil {
+		writeNotFound(w, collection, guid)
+		return
+	}
+	values := queryValues(r.URL)
+	recursive := values.Get("recursive") == "true"
+
+	if collection == "service_brokers" && !f.serviceBrokerRemovable(w, res) {
+		return
+	}
+	if !recursive {
+		for _, relation := range dependents[collection] {
+			if children, _ := f.children(res, relation); len(children) > 0 {
+				writeError(w, http.StatusBadRequest, 10006, "CF-AssociationNotEmpty",
+					fmt.Sprintf("Please delete the %s associations for your %s.",
+						strings.Join(dependents[collection], ", "), collection))
+				return
+			}
+		}
+	}
+	f.cascade(res)
+
+	if values.Get("async") == "true" {
+		writeJSON(w, http.StatusAccepted, f.render(f.createJob(), 0))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// dependents - Relations that must be empty before a
+// resource can be deleted without the recursive flag
+var dependents = map[string][]string{
+	"organizations":                   {"spaces", "private_domains"},
+	"spaces":                          {"apps", "routes", "service_instances", "user_provided_service_instances"},
+	"service_instances":               {"service_bindings", "service_keys"},
+	"user_provided_service_instances": {"service_bindings"},
+}
+
+// cascade - Deletes a resource and all resources depending on it
+func (f *FakeCC) cascade(res *resource) {
+
+	switch res.collection {
+	case "organizations":
+		for _, c := range []string{"spaces", "space_quota_definitions"} {
+			children, _ := f.children(res, c)
+			for _, child := range children {
+				f.cascade(child)
+			}
+		}
+		for _, v := range f.filter("service_plan_visibilities", "organization_guid", res.guid) {
+			f.remove("service_plan_visibilities", v.guid)
+		}
+		// private domains shared with the org are not deleted
+		for _, d := range f.filter("private_domains", "owning_organization_guid", res.guid) {
+			f.cascade(d)
+		}
+		for _, orgs := range f.entitledOrgs {
+			delete(orgs, res.guid)
+		}
+		delete(f.roles, res.guid)
+	case "spaces":
+		for _, c := range []string{"apps", "routes", "service_instances", "user_provided_service_instances"} {
+			children, _ := f.children(res, c)
+			for _, child := range children {
+				f.cascade(child)
+			}
+		}
+		f.unbindSecurityGroups(res.guid)
+		delete(f.roles, res.guid)
+	case "apps":
+		for _, c := range []string{"route_mappings", "service_bindings"} {
+			for _, child := range f.filter(c, "app_guid", res.guid) {
+				f.remove(c, child.guid)
+			}
+		}
+		delete(f.packages, res.guid)
+		delete(f.droplets, res.guid)
+		f.addEvent(Event{
+			Type:      "audit.app.delete-request",
+			Actee:     res.guid,
+			ActeeType: "app",
+			ActeeName: str(res.entity, "name"),
+			SpaceGUID: str(res.entity, "space_guid"),
+		})
+	case "routes":
+		for _, m := range f.filter("route_mappings", "route_guid", res.guid) {
+			f.remove("route_mappings", m.guid)
+		}
+	case "service_instances", "user_provided_service_instances":
+		for _, c := range []string{"service_bindings", "service_keys"} {
+			for _, child := range f.filter(c, "service_instance_guid", res.guid) {
+				f.remove(c, child.guid)
+			}
+		}
+	case "services":
+		for _, p := range f.filter("service_plans", "service_guid", res.guid) {
+			for _, v := range f.filter("service_plan_visibilities", "service_plan_guid", p.guid) {
+				f.remove("service_plan_visibilities", v.guid)
+			}
+			f.remove("service_plans", p.guid)
+		}
+	case "service_brokers":
+		for _, sv := range f.filter("services", "service_broker_guid", res.guid) {
+			f.cascade(sv)
+		}
+	case "private_domains", "shared_domains":
+		for _, r := range f.filter("routes", "domain_guid", res.guid) {
+			f.cascade(r)
+		}
+	case "space_quota_definitions":
+		for _, sp := range f.filter("spaces", "space_quota_definition_guid", res.guid) {
+			sp.entity["space_quota_definition_guid"] = nil
+		}
+	case "security_groups":
+		f.unbindSecurityGroups(res.guid)
+	case "buildpacks":
+		for _, bp := range f.list("buildpacks") {
+			if p := toInt64(bp.entity["position"]); p > toInt64(res.entity["position"]) {
+				bp.entity["position"] = int(p - 1)
+			}
+		}
+		delete(f.buildpackBits, res.guid)
+	}
+	f.remove(res.collection, res.guid)
+}
+
+// bindRoute - Maps a route to an app
+func (f *FakeCC) bindRoute(w http.ResponseWriter, routeGUID, appGUID string) {
+
+	if !f.exists(w, "routes", routeGUID) || !f.exists(w, "apps", appGUID) {
+		return
+	}
+	mapped := false
+	for _, m := range f.filter("route_mappings", "route_guid", routeGUID) {
+		mapped = mapped || str(m.entity, "app_guid") == appGUID
+	}
+	if !mapped {
+		f.mapRoute(routeGUID, appGUID, 8080)
+		f.mapRouteEvent(appGUID, routeGUID)
+	}
+	writeJSON(w, http.StatusCreated, f.render(f.find("routes", routeGUID), 0))
+}
+
+// unbindRoute - Unmaps a route from an app
+func (f *FakeCC) unbindRoute(w http.ResponseWriter, routeGUID, appGUID string) {
+
+	if !f.exists(w, "routes", routeGUID) || !f.exists(w, "apps", appGUID) {
+		return
+	}
+	for _, m := range f.filter("route_mappings", "route_guid", routeGUID) {
+		if str(m.entity, "app_guid") == appGUID {
+			f.remove("route_mappings", m.guid)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// mapRouteEvent -
+func (f *FakeCC) mapRouteEvent(appGUID, routeGUID string) {
+	if app := f.find("apps", appGUID); app != nil {
+		f.addEvent(Event{
+			Type:      "audit.app.map-route",
+			Actee:     appGUID,
+			ActeeType: "app",
+			ActeeName: str(app.entity, "name"),
+			SpaceGUID: str(app.entity, "space_guid"),
+			Metadata:  map[string]interface{}{"route_guid": routeGUID},
+		})
+	}
+}
+
+// sharePrivateDomain - Shares or unshares a private domain with an org
+func (f *FakeCC) sharePrivateDomain(w http.ResponseWriter, orgGUID, domainGUID string, share bool) {
+
+	if !f.exists(w, "organizations", orgGUID) || !f.exists(w, "private_domains", domainGUID) {
+		return
+	}
+	domain := f.find("private_domains", domainGUID)
+	shared := []interface{}{}
+	if orgs, ok := domain.entity["shared_organization_guids"].([]interface{}); ok {
+		for _, o := range orgs {
+			if o != orgGUID {
+				shared = append(shared, o)
+			}
+		}
+	}
+	if share {
+		shared = append(shared, orgGUID)
+	}
+	domain.entity["shared_organization_guids"] = shared
+
+	if share {
+		writeJSON(w, http.StatusCreated, f.render(f.find("organizations", orgGUID), 0))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,139 @@
+package fakecc
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// roleRelations - Relations of orgs and spaces listing the users having a role
+var roleRelations = map[string][]string{
+	"organizations": {"users", "managers", "billing_managers", "auditors"},
+	"spaces":        {"developers", "managers", "auditors"},
+}
+
+// AddExternalUser - Adds a user to the identity provider of the given
+// origin i.e. "ldap". Users added by AddUser are users of the "uaa" origin.
+func (f *FakeCC) AddExternalUser(username, origin string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if _, ok := f.externalUsers[origin]; !ok {
+		f.externalUsers[origin] = make(map[string]bool)
+	}
+	f.externalUsers[origin][username] = true
+}
+
+// isRoleRelation -
+func isRoleRelation(collection, relation string) bool {
+	for _, r := range roleRelations[collection] {
+		if r == relation {
+			return true
+		}
+	}
+	return false
+}
+
+// setUserRole - Grants the role of the given relation of an org or space
+// to the user named in the body or revokes it. Users have to be users of
+// the org to be granted a role in one of its spaces and cannot be removed
+// from an org while they have other roles in the org or its spaces.
+func (f *FakeCC) setUserRole(w http.ResponseWriter, r *http.Request, collection, guid, relation string, grant bool) {
+
+	if !f.exists(w, collection, guid) {
+		return
+	}
+	body, err := readBody(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 1001, "CF-MessageParseError", "Request invalid due to parse error")
+		return
+	}
+	if !required(w, body, "username") {
+		return
+	}
+	user := f.roleUser(str(body, "username"), str(body, "origin"))
+	if user == nil {
+		writeError(w, http.StatusNotFound, 20003, "CF-UserNotFound",
+			fmt.Sprintf("The user could not be found: %s", str(body, "username")))
+		return
+	}
+
+	if grant {
+		if collection == "spaces" {
+			orgGUID := str(f.find("spaces", guid).entity, "organization_guid")
+			if !f.roles[orgGUID]["users"][user.guid] {
+				writeError(w, http.StatusBadRequest, 1002, "CF-InvalidRelation",
+					fmt.Sprintf("User %s is not a member of the space's organization", user.guid))
+				return
+			}
+		}
+		if _, ok := f.roles[guid]; !ok {
+			f.roles[guid] = make(map[string]map[string]bool)
+		}
+		if _, ok := f.roles[guid][relation]; !ok {
+			f.roles[guid][relation] = make(map[string]bool)
+		}
+		f.roles[guid][relation][user.guid] = true
+		writeJSON(w, http.StatusCreated, f.render(f.find(collection, guid), 0))
+		return
+	}
+
+	if collection == "organizations" && relation == "users" && f.hasOtherOrgRoles(guid, user.guid) {
+		writeError(w, http.StatusBadRequest, 10006, "CF-AssociationNotEmpty",
+			"Please delete the user associations for your spaces in the organization.")
+		return
+	}
+	delete(f.roles[guid][relation], user.guid)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// roleUser - Returns the CC user of the user of an identity provider
+// creating it on first use. An empty origin is the "uaa" origin.
+func (f *FakeCC) roleUser(username, origin string) *resource {
+
+	if len(origin) == 0 {
+		origin = "uaa"
+	}
+	for _, u := range f.filter("users", "username", username) {
+		if str(u.entity, "origin") == origin {
+			return u
+		}
+	}
+	if _, ok := f.users[username]; !(ok && origin == "uaa") && !f.externalUsers[origin][username] {
+		return nil
+	}
+	return f.create("users", map[string]interface{}{
+		"username": username,
+		"origin":   origin,
+		"admin":    false,
+		"active":   true,
+	})
+}
+
+// roleUsers - Returns the users having the role of the given relation
+func (f *FakeCC) roleUsers(guid, relation string) []*resource {
+	users := []*resource{}
+	for _, u := range f.list("users") {
+		if f.roles[guid][relation][u.guid] {
+			users = append(users, u)
+		}
+	}
+	return users
+}
+
+// hasOtherOrgRoles - Returns whether a user has roles in an
+// org other than the user role or any roles in its spaces
+func (f *FakeCC) hasOtherOrgRoles(orgGUID, userGUID string) bool {
+	for relation, users := range f.roles[orgGUID] {
+		if relation != "users" && users[userGUID] {
+			return true
+		}
+	}
+	for _, sp := range f.filter("spaces", "organization_guid", orgGUID) {
+		for _, users := range f.roles[sp.guid] {
+			if users[userGUID] {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package fakecc
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// routeReserved - Handles /v2/routes/reserved/domain/:guid[/host/:host]
+// responding with no content if a route with the host and the path and
+// port given by the query exists on the domain and not found otherwise
+func (f *FakeCC) routeReserved(w http.ResponseWriter, r *http.Request, segments []string) {
+
+	valid := len(segments) == 2 || (len(segments) == 4 && segments[2] == "host")
+	if !valid || segments[0] != "domain" {
+		writeError(w, http.StatusNotFound, 10000, "CF-NotFound", "Unknown request")
+		return
+	}
+	if f.findDomain(segments[1]) == nil {
+		writeNotFound(w, "shared_domains", segments[1])
+		return
+	}
+
+	query := queryValues(r.URL)
+	route := map[string]interface{}{
+		"domain_guid": segments[1],
+		"host":        "",
+		"path":        query.Get("path"),
+	}
+	if len(segments) == 4 {
+		route["host"] = segments[3]
+	}
+	if port, err := strconv.Atoi(query.Get("port")); err == nil {
+		route["port"] = port
+	}
+	if !f.routeTaken(route) {
+		writeError(w, http.StatusNotFound, 210002, "CF-RouteNotFound", "The route could not be found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
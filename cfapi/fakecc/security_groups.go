@@ -0,0 +1,90 @@
+package fakecc
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// validSecurityGroupRules - Writes an error and returns false if the
+// rules in the body are not a list of rules naming a protocol and a
+// destination
+func validSecurityGroupRules(w http.ResponseWriter, body map[string]interface{}) bool {
+
+	rules, ok := body["rules"].([]interface{})
+	if !ok {
+		if body["rules"] == nil {
+			body["rules"] = []interface{}{}
+			return true
+		}
+		writeError(w, http.StatusBadRequest, 300001, "CF-SecurityGroupInvalid",
+			"The security group is invalid: rules must be an array")
+		return false
+	}
+	for i, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok || str(rule, "protocol") == "" || str(rule, "destination") == "" {
+			writeError(w, http.StatusBadRequest, 300001, "CF-SecurityGroupInvalid",
+				fmt.Sprintf("The security group is invalid: rule number %d is missing a protocol or destination", i+1))
+			return false
+		}
+	}
+	return true
+}
+
+// bindSecurityGroup - Binds a security group to a space or unbinds
+// it. The relation is "spaces" for running and "staging_spaces" for
+// staging apps.
+func (f *FakeCC) bindSecurityGroup(w http.ResponseWriter, groupGUID, relation, spaceGUID string, bind bool) {
+
+	if !f.exists(w, "security_groups", groupGUID) || !f.exists(w, "spaces", spaceGUID) {
+		return
+	}
+	if _, ok := f.boundSpaces[relation]; !ok {
+		f.boundSpaces[relation] = make(map[string]map[string]bool)
+	}
+	spaces, ok := f.boundSpaces[relation][groupGUID]
+	if !ok {
+		spaces = make(map[string]bool)
+		f.boundSpaces[relation][groupGUID] = spaces
+	}
+
+	if bind {
+		spaces[spaceGUID] = true
+		writeJSON(w, http.StatusCreated, f.render(f.find("security_groups", groupGUID), 0))
+		return
+	}
+	delete(spaces, spaceGUID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// securityGroupSpaces - Returns the spaces a security group is bound to
+func (f *FakeCC) securityGroupSpaces(groupGUID, relation string) []*resource {
+	spaces := []*resource{}
+	for _, sp := range f.list("spaces") {
+		if f.boundSpaces[relation][groupGUID][sp.guid] {
+			spaces = append(spaces, sp)
+		}
+	}
+	return spaces
+}
+
+// spaceSecurityGroups - Returns the security groups bound to a space
+func (f *FakeCC) spaceSecurityGroups(spaceGUID, relation string) []*resource {
+	groups := []*resource{}
+	for _, sg := range f.list("security_groups") {
+		if f.boundSpaces[relation][sg.guid][spaceGUID] {
+			groups = append(groups, sg)
+		}
+	}
+	return groups
+}
+
+// unbindSecurityGroups - Removes all bindings of a security group or a space
+func (f *FakeCC) unbindSecurityGroups(guid string) {
+	for _, groups := range f.boundSpaces {
+		delete(groups, guid)
+		for _, spaces := range groups {
+			delete(spaces, guid)
+		}
+	}
+}
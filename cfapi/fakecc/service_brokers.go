@@ -0,0 +1,104 @@
+package fakecc
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// catalogOffering - A service of the catalog served by a broker
+type catalogOffering struct {
+	label string
+	plans []string
+}
+
+// AddServiceBrokerCatalog - Adds a service with the given plans to the
+// catalog served by the broker at the given URL. The services of the
+// catalog are added when a broker is registered or updated with the URL.
+func (f *FakeCC) AddServiceBrokerCatalog(brokerURL, label string, plans ...string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.brokerCatalogs[brokerURL] = append(f.brokerCatalogs[brokerURL], catalogOffering{label: label, plans: plans})
+}
+
+// serviceBrokerAvailable - Writes a taken error and returns false if a
+// broker other than the given one has the name or the URL. Empty values
+// are ignored.
+func (f *FakeCC) serviceBrokerAvailable(w http.ResponseWriter, name, url, exceptGUID string) bool {
+	for _, b := range f.list("service_brokers") {
+		if b.guid == exceptGUID {
+			continue
+		}
+		if len(name) > 0 && strings.EqualFold(str(b.entity, "name"), name) {
+			writeError(w, http.StatusBadRequest, 270002, "CF-ServiceBrokerNameTaken",
+				fmt.Sprintf("The service broker name is taken: %s", name))
+			return false
+		}
+		if len(url) > 0 && str(b.entity, "broker_url") == url {
+			writeError(w, http.StatusBadRequest, 270003, "CF-ServiceBrokerUrlTaken",
+				fmt.Sprintf("The service broker url is taken: %s", url))
+			return false
+		}
+	}
+	return true
+}
+
+// serviceBrokerRemovable - Writes an error and returns false if
+// instances of the services offered by the broker exist
+func (f *FakeCC) serviceBrokerRemovable(w http.ResponseWriter, broker *resource) bool {
+	for _, sv := range f.filter("services", "service_broker_guid", broker.guid) {
+		for _, p := range f.filter("service_plans", "service_guid", sv.guid) {
+			if len(f.filter("service_instances", "service_plan_guid", p.guid)) > 0 {
+				writeError(w, http.StatusBadRequest, 270010, "CF-ServiceBrokerNotRemovable",
+					fmt.Sprintf("Can not remove brokers that have associated service instances: %s", str(broker.entity, "name")))
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// addBrokerCatalog - Adds the services and plans of the catalog served at
+// the URL of a broker which the broker does not already offer. Plans are
+// added as not public as they are by the Cloud Controller.
+func (f *FakeCC) addBrokerCatalog(broker *resource) {
+
+	for _, offering := range f.brokerCatalogs[str(broker.entity, "broker_url")] {
+		var service *resource
+		for _, sv := range f.filter("services", "service_broker_guid", broker.guid) {
+			if str(sv.entity, "label") == offering.label {
+				service = sv
+			}
+		}
+		if service == nil {
+			service = f.create("services", map[string]interface{}{
+				"label":               offering.label,
+				"provider":            nil,
+				"version":             nil,
+				"description":         fmt.Sprintf("%s service", offering.label),
+				"active":              true,
+				"bindable":            true,
+				"tags":                []interface{}{},
+				"service_broker_guid": broker.guid,
+			})
+		}
+
+		plans := make(map[string]bool)
+		for _, p := range f.filter("service_plans", "service_guid", service.guid) {
+			plans[str(p.entity, "name")] = true
+		}
+		for _, name := range offering.plans {
+			if plans[name] {
+				continue
+			}
+			f.create("service_plans", map[string]interface{}{
+				"name":         name,
+				"description":  fmt.Sprintf("%s plan", name),
+				"free":         true,
+				"public":       false,
+				"active":       true,
+				"service_guid": service.guid,
+			})
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package fakecc
+
+import (
+	"net/http"
+)
+
+// routeSummary -
+func (f *FakeCC) routeSummary(route *resource) map[string]interface{} {
+
+	summary := map[string]interface{}{
+		"guid": route.guid,
+		"host": route.entity["host"],
+		"path": route.entity["path"],
+		"port": route.entity["port"],
+	}
+	if domain := f.findDomain(str(route.entity, "domain_guid")); domain != nil {
+		summary["domain"] = map[string]interface{}{
+			"guid": domain.guid,
+			"name": domain.entity["name"],
+		}
+	}
+	return summary
+}
+
+// routeURL - Returns the URL of a route as listed by app summaries
+func (f *FakeCC) routeURL(route *resource) string {
+
+	var url string
+	if domain := f.findDomain(str(route.entity, "domain_guid")); domain != nil {
+		url = str(domain.entity, "name")
+	}
+	if host := str(route.entity, "host"); len(host) > 0 {
+		url = host + "." + url
+	}
+	if port := str(route.entity, "port"); len(port) > 0 {
+		url = url + ":" + port
+	}
+	return url + str(route.entity, "path")
+}
+
+// serviceInstanceSummary -
+func (f *FakeCC) serviceInstanceSummary(si *resource) map[string]interface{} {
+
+	bindings, _ := f.children(si, "service_bindings")
+	summary := map[string]interface{}{
+		"guid":            si.guid,
+		"name":            si.entity["name"],
+		"bound_app_count": len(bindings),
+		"last_operation":  si.entity["last_operation"],
+		"dashboard_url":   si.entity["dashboard_url"],
+	}
+	if plan := f.find("service_plans", str(si.entity, "service_plan_guid")); plan != nil {
+		planSummary := map[string]interface{}{
+			"guid": plan.guid,
+			"name": plan.entity["name"],
+		}
+		if service := f.find("services", str(plan.entity, "service_guid")); service != nil {
+			planSummary["service"] = map[string]interface{}{
+				"guid":     service.guid,
+				"label":    service.entity["label"],
+				"provider": service.entity["provider"],
+				"version":  service.entity["version"],
+			}
+		}
+		summary["service_plan"] = planSummary
+	}
+	return summary
+}
+
+// appSummaryEntity - Returns the summary of an app as
+// included in the app and space summary responses
+func (f *FakeCC) appSummaryEntity(app *resource) map[string]interface{} {
+
+	summary := copyEntity(app.entity)
+	summary["guid"] = app.guid
+
+	routes, _ := f.children(app, "routes")
+	routeSummaries, urls := []interface{}{}, []interface{}{}
+	for _, r := range routes {
+		routeSummaries = append(routeSummaries, f.routeSummary(r))
+		urls = append(urls, f.routeURL(r))
+	}
+	summary["routes"] = routeSummaries
+	summary["urls"] = urls
+
+	bindings, _ := f.children(app, "service_bindings")
+	services, serviceNames := []interface{}{}, []interface{}{}
+	for _, b := range bindings {
+		if si := f.findServiceInstance(str(b.entity, "service_instance_guid")); si != nil {
+			services = append(services, f.serviceInstanceSummary(si))
+			serviceNames = append(serviceNames, si.entity["name"])
+		}
+	}
+	summary["services"] = services
+	summary["service_names"] = serviceNames
+	summary["service_count"] = len(services)
+
+	running := 0
+	if app.entity["state"] == "STARTED" {
+		running = instancesOf(app)
+	}
+	summary["running_instances"] = running
+	return summary
+}
+
+// instancesOf - Returns the number of instances of an app
+func instancesOf(app *resource) int {
+	switch n := app.entity["instances"].(type) {
+	case int:
+		return n
+	case float64:
+		return int(n)
+	}
+	return 0
+}
+
+// appSummary - Handles /v2/apps/:guid/summary
+func (f *FakeCC) appSummary(w http.ResponseWriter, appGUID string) {
+
+	app := f.find("apps", appGUID)
+	if app == nil {
+		writeNotFound(w, "apps", appGUID)
+		return
+	}
+	summary := f.appSummaryEntity(app)
+
+	domains := []interface{}{}
+	if space := f.find("spaces", str(app.entity, "space_guid")); space != nil {
+		orgDomains := append(f.orgPrivateDomains(str(space.entity, "organization_guid")), f.list("shared_domains")...)
+		for _, d := range orgDomains {
+			domains = append(domains, map[string]interface{}{
+				"guid":                     d.guid,
+				"name":                     d.entity["name"],
+				"owning_organization_guid": d.entity["owning_organization_guid"],
+			})
+		}
+	}
+	summary["available_domains"] = domains
+
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// spaceSummary - Handles /v2/spaces/:guid/summary
+func (f *FakeCC) spaceSummary(w http.ResponseWriter, spaceGUID string) {
+
+	space := f.find("spaces", spaceGUID)
+	if space == nil {
+		writeNotFound(w, "spaces", spaceGUID)
+		return
+	}
+
+	apps := []interface{}{}
+	for _, app := range f.filter("apps", "space_guid", spaceGUID) {
+		apps = append(apps, f.appSummaryEntity(app))
+	}
+	services := []interface{}{}
+	for _, c := range []string{"service_instances", "user_provided_service_instances"} {
+		for _, si := range f.filter(c, "space_guid", spaceGUID) {
+			services = append(services, f.serviceInstanceSummary(si))
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"guid":     space.guid,
+		"name":     space.entity["name"],
+		"apps":     apps,
+		"services": services,
+	})
+}
+
+// appEnv - Handles /v2/apps/:guid/env
+func (f *FakeCC) appEnv(w http.ResponseWriter, appGUID string) {
+
+	app := f.find("apps", appGUID)
+	if app == nil {
+		writeNotFound(w, "apps", appGUID)
+		return
+	}
+
+	vcapServices := map[string]interface{}{}
+	for _, b := range f.filter("service_bindings", "app_guid", appGUID) {
+		si := f.findServiceInstance(str(b.entity, "service_instance_guid"))
+		if si == nil {
+			continue
+		}
+		label, plan := "user-provided", ""
+		if p := f.find("service_plans", str(si.entity, "service_plan_guid")); p != nil {
+			plan = str(p.entity, "name")
+			if s := f.find("services", str(p.entity, "service_guid")); s != nil {
+				label = str(s.entity, "label")
+			}
+		}
+		instances, _ := vcapServices[label].([]interface{})
+		vcapServices[label] = append(instances, map[string]interface{}{
+			"name":          si.entity["name"],
+			"instance_name": si.entity["name"],
+			"binding_name":  nil,
+			"label":         label,
+			"plan":          plan,
+			"tags":          si.entity["tags"],
+			"credentials":   b.entity["credentials"],
+		})
+	}
+
+	summary := f.appSummaryEntity(app)
+	spaceName, orgGUID, orgName := "", "", ""
+	if space := f.find("spaces", str(app.entity, "space_guid")); space != nil {
+		spaceName, orgGUID = str(space.entity, "name"), str(space.entity, "organization_guid")
+		if org := f.find("organizations", str(space.entity, "organization_guid")); org != nil {
+			orgName = str(org.entity, "name")
+		}
+	}
+	vcapApplication := map[string]interface{}{
+		"application_id":      app.guid,
+		"application_name":    app.entity["name"],
+		"application_uris":    summary["urls"],
+		"application_version": app.entity["version"],
+		"name":                app.entity["name"],
+		"uris":                summary["urls"],
+		"version":             app.entity["version"],
+		"space_id":            app.entity["space_guid"],
+		"space_name":          spaceName,
+		"organization_id":     orgGUID,
+		"organization_name":   orgName,
+		"cf_api":              f.server.URL,
+		"limits": map[string]interface{}{
+			"disk": app.entity["disk_quota"],
+			"fds":  16384,
+			"mem":  app.entity["memory"],
+		},
+	}
+
+	environment := app.entity["environment_json"]
+	if environment == nil {
+		environment = map[string]interface{}{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"staging_env_json":     copyEntity(f.envGroups["staging"]),
+		"running_env_json":     copyEntity(f.envGroups["running"]),
+		"environment_json":     environment,
+		"system_env_json":      map[string]interface{}{"VCAP_SERVICES": vcapServices},
+		"application_env_json": map[string]interface{}{"VCAP_APPLICATION": vcapApplication},
+	})
+}
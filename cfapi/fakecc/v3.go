@@ -0,0 +1,572 @@
+package fakecc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// root - Serves the root of the API which links to the APIs
+// and advertises their versions. The v3 API is omitted when
+// the fake is to behave like a foundation serving only v2.
+func (f *FakeCC) root(w http.ResponseWriter, r *http.Request) {
+
+	link := func(href, version string) map[string]interface{} {
+		l := map[string]interface{}{"href": href}
+		if len(version) > 0 {
+			l["meta"] = map[string]interface{}{"version": version}
+		}
+		return l
+	}
+	links := map[string]interface{}{
+		"self":                link(f.server.URL, ""),
+		"cloud_controller_v2": link(f.server.URL+"/v2", "2.75.0"),
+		"uaa":                 link(f.server.URL, ""),
+		"login":               link(f.server.URL, ""),
+	}
+	if !f.V2Only {
+		links["cloud_controller_v3"] = link(f.server.URL+"/v3", "3.35.0")
+		links["logging"] = link(strings.Replace(f.server.URL, "http", "ws", 1), "")
+		links["log_cache"] = link(f.server.URL, "")
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"links": links})
+}
+
+// v3 - Routes requests to the v3 API. Each app has at most one package
+// holding its bits and one droplet whose GUIDs are derived from the
+// app's GUID. Processes share the GUID of their app.
+func (f *FakeCC) v3(w http.ResponseWriter, r *http.Request, segments []string) {
+
+	if f.V2Only {
+		writeV3NotFound(w, "Unknown request")
+		return
+	}
+
+	switch len(segments) {
+	case 1:
+		switch r.Method + " " + segments[0] {
+		case "GET apps":
+			f.v3Apps(w, r)
+			return
+		case "POST builds":
+			f.v3CreateBuild(w, r)
+			return
+		case "POST deployments":
+			f.v3CreateDeployment(w, r)
+			return
+		case "GET audit_events":
+			f.v3AuditEvents(w, r)
+			return
+		case "GET isolation_segments":
+			f.v3IsolationSegments(w, r)
+			return
+		}
+
+	case 2:
+		guid := segments[1]
+		switch r.Method + " " + segments[0] {
+		case "GET apps":
+			if app := f.v3App(w, guid); app != nil {
+				writeJSON(w, http.StatusOK, f.renderV3App(app))
+			}
+			return
+		case "GET packages":
+			if appGUID, ok := f.v3Content(w, f.packages, "package", guid); ok {
+				writeJSON(w, http.StatusOK, f.renderV3Package(appGUID))
+			}
+			return
+		case "GET droplets":
+			if appGUID, ok := f.v3Content(w, f.droplets, "droplet", guid); ok {
+				writeJSON(w, http.StatusOK, f.renderV3Droplet(appGUID))
+			}
+			return
+		case "GET builds":
+			if build := f.find("builds", guid); build != nil {
+				writeJSON(w, http.StatusOK, renderV3Build(build))
+			} else {
+				writeV3NotFound(w, "Build not found")
+			}
+			return
+		case "GET deployments":
+			if deployment := f.find("deployments", guid); deployment != nil {
+				writeJSON(w, http.StatusOK, renderV3Deployment(deployment))
+			} else {
+				writeV3NotFound(w, "Deployment not found")
+			}
+			return
+		}
+
+	case 3:
+		guid := segments[1]
+		if f.NoV3Downloads && segments[2] == "download" {
+			writeError(w, http.StatusNotFound, 10000, "CF-NotFound", "Unknown request")
+			return
+		}
+		switch r.Method + " " + segments[0] + "/" + segments[2] {
+		case "GET apps/packages":
+			if f.v3App(w, guid) == nil {
+				return
+			}
+			// The fake's packages are always ready
+			resources := []interface{}{}
+			states := r.URL.Query().Get("states")
+			if _, ok := f.packages[guid]; ok && (len(states) == 0 || strings.Contains(states, "READY")) {
+				resources = append(resources, f.renderV3Package(guid))
+			}
+			writeJSON(w, http.StatusOK, f.v3Page(r.URL, resources))
+			return
+		case "GET apps/droplets":
+			if f.v3App(w, guid) == nil {
+				return
+			}
+			resources := []interface{}{}
+			if _, ok := f.droplets[guid]; ok {
+				resources = append(resources, f.renderV3Droplet(guid))
+			}
+			writeJSON(w, http.StatusOK, f.v3Page(r.URL, resources))
+			return
+		case "GET apps/processes":
+			if app := f.v3App(w, guid); app != nil {
+				writeJSON(w, http.StatusOK, f.v3Page(r.URL, []interface{}{renderV3Process(app)}))
+			}
+			return
+		case "GET packages/download":
+			if appGUID, ok := f.v3Content(w, f.packages, "package", guid); ok {
+				http.Redirect(w, r, fmt.Sprintf("%s/blobstore/packages/%s", f.server.URL, appGUID), http.StatusFound)
+			}
+			return
+		case "GET droplets/download":
+			if appGUID, ok := f.v3Content(w, f.droplets, "droplet", guid); ok {
+				http.Redirect(w, r, fmt.Sprintf("%s/blobstore/droplets/%s", f.server.URL, appGUID), http.StatusFound)
+			}
+			return
+		}
+
+	case 4:
+		guid := segments[1]
+		switch r.Method + " " + segments[0] + "/" + strings.Join(segments[2:], "/") {
+		case "GET apps/droplets/current":
+			if f.v3App(w, guid) == nil {
+				return
+			}
+			if _, ok := f.droplets[guid]; !ok {
+				writeV3NotFound(w, "Droplet not found")
+				return
+			}
+			writeJSON(w, http.StatusOK, f.renderV3Droplet(guid))
+			return
+		case "PATCH apps/relationships/current_droplet":
+			f.v3SetCurrentDroplet(w, r, guid)
+			return
+		case "POST isolation_segments/relationships/organizations":
+			f.v3EntitleOrgs(w, r, guid)
+			return
+		case "PATCH organizations/relationships/default_isolation_segment":
+			f.v3AssignIsolationSegment(w, r, "organizations", guid)
+			return
+		case "PATCH spaces/relationships/isolation_segment":
+			f.v3AssignIsolationSegment(w, r, "spaces", guid)
+			return
+		}
+	}
+
+	writeV3NotFound(w, "Unknown request")
+}
+
+// v3Apps - Lists the apps in the spaces given by the space_guids filter
+func (f *FakeCC) v3Apps(w http.ResponseWriter, r *http.Request) {
+
+	spaceGUIDs := v3Filter(r.URL, "space_guids")
+
+	apps := []*resource{}
+	for _, app := range f.list("apps") {
+		if spaceGUIDs == nil || spaceGUIDs[str(app.entity, "space_guid")] {
+			apps = append(apps, app)
+		}
+	}
+	if r.URL.Query().Get("order_by") == "name" {
+		sort.SliceStable(apps, func(i, j int) bool {
+			return str(apps[i].entity, "name") < str(apps[j].entity, "name")
+		})
+	}
+	resources := []interface{}{}
+	for _, app := range apps {
+		resources = append(resources, f.renderV3App(app))
+	}
+	writeJSON(w, http.StatusOK, f.v3Page(r.URL, resources))
+}
+
+// v3SetCurrentDroplet -
+func (f *FakeCC) v3SetCurrentDroplet(w http.ResponseWriter, r *http.Request, appGUID string) {
+
+	if f.v3App(w, appGUID) == nil {
+		return
+	}
+	body, err := readBody(r)
+	if err != nil {
+		writeV3Error(w, http.StatusUnprocessableEntity, 10008, "CF-UnprocessableEntity", err.Error())
+		return
+	}
+	data, _ := body["data"].(map[string]interface{})
+	dropletGUID := str(data, "guid")
+	if _, ok := f.droplets[appGUID]; !ok || dropletGUID != "droplet-"+appGUID {
+		writeV3Error(w, http.StatusUnprocessableEntity, 10008, "CF-UnprocessableEntity",
+			"Unable to assign current droplet. Ensure the droplet exists and belongs to this app.")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{"guid": dropletGUID},
+	})
+}
+
+// v3CreateBuild - Stages a package into the droplet of its app
+func (f *FakeCC) v3CreateBuild(w http.ResponseWriter, r *http.Request) {
+
+	body, err := readBody(r)
+	if err != nil {
+		writeV3Error(w, http.StatusUnprocessableEntity, 10008, "CF-UnprocessableEntity", err.Error())
+		return
+	}
+	pkg, _ := body["package"].(map[string]interface{})
+	packageGUID := str(pkg, "guid")
+
+	app := f.find("apps", strings.TrimPrefix(packageGUID, "package-"))
+	if _, ok := f.packages[strings.TrimPrefix(packageGUID, "package-")]; app == nil || !ok {
+		writeV3Error(w, http.StatusUnprocessableEntity, 10008, "CF-UnprocessableEntity",
+			"Unable to use package. Ensure that the package exists and you have access to it.")
+		return
+	}
+	delete(f.droplets, app.guid)
+	app.entity["package_state"] = "PENDING"
+	if !f.stage(w, app) {
+		return
+	}
+	build := f.create("builds", map[string]interface{}{
+		"state":        "STAGED",
+		"package_guid": packageGUID,
+		"droplet_guid": "droplet-" + app.guid,
+		"app_guid":     app.guid,
+	})
+	writeJSON(w, http.StatusCreated, renderV3Build(build))
+}
+
+// v3CreateDeployment - Deploys a droplet of an app. The deployment
+// completes immediately with the app's instances running the droplet.
+func (f *FakeCC) v3CreateDeployment(w http.ResponseWriter, r *http.Request) {
+
+	body, err := readBody(r)
+	if err != nil {
+		writeV3Error(w, http.StatusUnprocessableEntity, 10008, "CF-UnprocessableEntity", err.Error())
+		return
+	}
+	relationships, _ := body["relationships"].(map[string]interface{})
+	appRelationship, _ := relationships["app"].(map[string]interface{})
+	data, _ := appRelationship["data"].(map[string]interface{})
+	appGUID := str(data, "guid")
+
+	app := f.find("apps", appGUID)
+	if app == nil {
+		writeV3Error(w, http.StatusUnprocessableEntity, 10008, "CF-UnprocessableEntity",
+			"Unable to use app. Ensure that the app exists and you have access to it.")
+		return
+	}
+	dropletGUID := "droplet-" + appGUID
+	if droplet, ok := body["droplet"].(map[string]interface{}); ok {
+		dropletGUID = str(droplet, "guid")
+	}
+	if _, ok := f.droplets[appGUID]; !ok || dropletGUID != "droplet-"+appGUID {
+		writeV3Error(w, http.StatusUnprocessableEntity, 10008, "CF-UnprocessableEntity",
+			"Unable to assign current droplet. Ensure the droplet exists and belongs to this app.")
+		return
+	}
+	app.entity["state"] = "STARTED"
+	f.startApp(app)
+
+	deployment := f.create("deployments", map[string]interface{}{
+		"state":        "DEPLOYED",
+		"app_guid":     appGUID,
+		"droplet_guid": dropletGUID,
+	})
+	writeJSON(w, http.StatusCreated, renderV3Deployment(deployment))
+}
+
+// v3AuditEvents - Lists the events recorded by the fake as v3 audit events
+func (f *FakeCC) v3AuditEvents(w http.ResponseWriter, r *http.Request) {
+
+	values := r.URL.Query()
+	spaceGUIDs := v3Filter(r.URL, "space_guids")
+	targetGUIDs := v3Filter(r.URL, "target_guids")
+	types := v3Filter(r.URL, "types")
+
+	var (
+		from      time.Time
+		inclusive bool
+		err       error
+	)
+	if gte := values.Get("created_ats[gte]"); len(gte) > 0 {
+		inclusive = true
+		if from, err = time.Parse(time.RFC3339, gte); err != nil {
+			writeV3Error(w, http.StatusBadRequest, 10005, "CF-BadQueryParameter", err.Error())
+			return
+		}
+	} else if gt := values.Get("created_ats[gt]"); len(gt) > 0 {
+		if from, err = time.Parse(time.RFC3339, gt); err != nil {
+			writeV3Error(w, http.StatusBadRequest, 10005, "CF-BadQueryParameter", err.Error())
+			return
+		}
+	}
+
+	resources := []interface{}{}
+	for _, e := range f.list("events") {
+		if (spaceGUIDs != nil && !spaceGUIDs[str(e.entity, "space_guid")]) ||
+			(targetGUIDs != nil && !targetGUIDs[str(e.entity, "actee")]) ||
+			(types != nil && !types[str(e.entity, "type")]) {
+			continue
+		}
+		if !from.IsZero() {
+			timestamp, _ := time.Parse(time.RFC3339, str(e.entity, "timestamp"))
+			if timestamp.Before(from) || (!inclusive && timestamp.Equal(from)) {
+				continue
+			}
+		}
+		resources = append(resources, renderV3AuditEvent(e))
+	}
+	writeJSON(w, http.StatusOK, f.v3Page(r.URL, resources))
+}
+
+// v3App - Returns the app with the given GUID or writes a not found error
+func (f *FakeCC) v3App(w http.ResponseWriter, guid string) *resource {
+	app := f.find("apps", guid)
+	if app == nil {
+		writeV3NotFound(w, "App not found")
+	}
+	return app
+}
+
+// v3Content - Returns the GUID of the app owning the package or droplet
+// with the given GUID or writes a not found error if it does not exist
+func (f *FakeCC) v3Content(w http.ResponseWriter, content map[string][]byte, kind, guid string) (string, bool) {
+	appGUID := strings.TrimPrefix(guid, kind+"-")
+	if _, ok := content[appGUID]; !ok || appGUID == guid {
+		writeV3NotFound(w, fmt.Sprintf("%s%s not found", strings.ToUpper(kind[:1]), kind[1:]))
+		return "", false
+	}
+	return appGUID, true
+}
+
+// v3Page - Returns a page of the given rendered resources as a v3 paginated
+// response. The page is selected by the page and per_page parameters.
+func (f *FakeCC) v3Page(u *url.URL, resources []interface{}) map[string]interface{} {
+
+	values := u.Query()
+	perPage := f.PageSize
+	if n, err := strconv.Atoi(values.Get("per_page")); err == nil && n > 0 {
+		perPage = n
+	}
+	pageNum := 1
+	if n, err := strconv.Atoi(values.Get("page")); err == nil && n > 0 {
+		pageNum = n
+	}
+	totalPages := (len(resources) + perPage - 1) / perPage
+
+	pageLink := func(n int) interface{} {
+		if n < 1 || n > totalPages {
+			return nil
+		}
+		v := url.Values{}
+		for k, vv := range values {
+			v[k] = vv
+		}
+		v.Set("page", strconv.Itoa(n))
+		v.Set("per_page", strconv.Itoa(perPage))
+		return map[string]interface{}{"href": f.server.URL + u.Path + "?" + v.Encode()}
+	}
+
+	page := []interface{}{}
+	start := (pageNum - 1) * perPage
+	for i := start; i < len(resources) && i < start+perPage; i++ {
+		page = append(page, resources[i])
+	}
+
+	return map[string]interface{}{
+		"pagination": map[string]interface{}{
+			"total_results": len(resources),
+			"total_pages":   totalPages,
+			"first":         pageLink(1),
+			"last":          pageLink(totalPages),
+			"next":          pageLink(pageNum + 1),
+			"previous":      pageLink(pageNum - 1),
+		},
+		"resources": page,
+	}
+}
+
+// v3Filter - Returns the set of comma separated values of a
+// list filter parameter or nil if the parameter is not given
+func v3Filter(u *url.URL, parameter string) map[string]bool {
+	value := u.Query().Get(parameter)
+	if len(value) == 0 {
+		return nil
+	}
+	set := make(map[string]bool)
+	for _, v := range strings.Split(value, ",") {
+		set[v] = true
+	}
+	return set
+}
+
+func (f *FakeCC) renderV3App(app *resource) map[string]interface{} {
+	lifecycle := map[string]interface{}{
+		"type": "buildpack",
+		"data": map[string]interface{}{"buildpacks": []interface{}{}, "stack": "cflinuxfs3"},
+	}
+	if str(app.entity, "docker_image") != "" {
+		lifecycle = map[string]interface{}{"type": "docker", "data": map[string]interface{}{}}
+	}
+	return map[string]interface{}{
+		"guid":       app.guid,
+		"name":       str(app.entity, "name"),
+		"state":      strings.ToUpper(str(app.entity, "state")),
+		"created_at": app.createdAt.Format(time.RFC3339),
+		"updated_at": app.updatedAt.Format(time.RFC3339),
+		"lifecycle":  lifecycle,
+		"relationships": map[string]interface{}{
+			"space": map[string]interface{}{
+				"data": map[string]interface{}{"guid": str(app.entity, "space_guid")},
+			},
+		},
+	}
+}
+
+func (f *FakeCC) renderV3Package(appGUID string) map[string]interface{} {
+	return map[string]interface{}{
+		"guid":       "package-" + appGUID,
+		"type":       "bits",
+		"state":      "READY",
+		"created_at": f.find("apps", appGUID).updatedAt.Format(time.RFC3339),
+		"data": map[string]interface{}{
+			"checksum": checksum(f.packages[appGUID]),
+		},
+	}
+}
+
+func (f *FakeCC) renderV3Droplet(appGUID string) map[string]interface{} {
+	return map[string]interface{}{
+		"guid":       "droplet-" + appGUID,
+		"state":      "STAGED",
+		"checksum":   checksum(f.droplets[appGUID]),
+		"stack":      "cflinuxfs3",
+		"buildpacks": []interface{}{},
+		"created_at": f.find("apps", appGUID).updatedAt.Format(time.RFC3339),
+	}
+}
+
+func renderV3Build(build *resource) map[string]interface{} {
+	return map[string]interface{}{
+		"guid":       build.guid,
+		"state":      str(build.entity, "state"),
+		"error":      nil,
+		"created_at": build.createdAt.Format(time.RFC3339),
+		"package":    map[string]interface{}{"guid": str(build.entity, "package_guid")},
+		"droplet":    map[string]interface{}{"guid": str(build.entity, "droplet_guid")},
+	}
+}
+
+func renderV3Process(app *resource) map[string]interface{} {
+	var command interface{}
+	if c := str(app.entity, "command"); len(c) > 0 {
+		command = c
+	}
+	healthCheckType := str(app.entity, "health_check_type")
+	if len(healthCheckType) == 0 {
+		healthCheckType = "port"
+	}
+	return map[string]interface{}{
+		"guid":         app.guid,
+		"type":         "web",
+		"command":      command,
+		"instances":    app.entity["instances"],
+		"memory_in_mb": app.entity["memory"],
+		"disk_in_mb":   app.entity["disk_quota"],
+		"health_check": map[string]interface{}{"type": healthCheckType},
+	}
+}
+
+func renderV3Deployment(deployment *resource) map[string]interface{} {
+	return map[string]interface{}{
+		"guid":       deployment.guid,
+		"state":      str(deployment.entity, "state"),
+		"created_at": deployment.createdAt.Format(time.RFC3339),
+		"status": map[string]interface{}{
+			"value":  "FINALIZED",
+			"reason": "DEPLOYED",
+		},
+		"droplet": map[string]interface{}{"guid": str(deployment.entity, "droplet_guid")},
+		"relationships": map[string]interface{}{
+			"app": map[string]interface{}{
+				"data": map[string]interface{}{"guid": str(deployment.entity, "app_guid")},
+			},
+		},
+	}
+}
+
+func renderV3AuditEvent(event *resource) map[string]interface{} {
+	rendered := map[string]interface{}{
+		"guid":       event.guid,
+		"type":       str(event.entity, "type"),
+		"created_at": str(event.entity, "timestamp"),
+		"actor": map[string]interface{}{
+			"guid": str(event.entity, "actor"),
+			"type": str(event.entity, "actor_type"),
+			"name": str(event.entity, "actor_name"),
+		},
+		"target": map[string]interface{}{
+			"guid": str(event.entity, "actee"),
+			"type": str(event.entity, "actee_type"),
+			"name": str(event.entity, "actee_name"),
+		},
+		"data":         event.entity["metadata"],
+		"space":        nil,
+		"organization": nil,
+	}
+	if spaceGUID := str(event.entity, "space_guid"); len(spaceGUID) > 0 {
+		rendered["space"] = map[string]interface{}{"guid": spaceGUID}
+	}
+	if orgGUID := str(event.entity, "organization_guid"); len(orgGUID) > 0 {
+		rendered["organization"] = map[string]interface{}{"guid": orgGUID}
+	}
+	return rendered
+}
+
+// checksum - Returns the v3 checksum object of the given content
+func checksum(content []byte) map[string]interface{} {
+	sum := sha256.Sum256(content)
+	return map[string]interface{}{
+		"type":  "sha256",
+		"value": hex.EncodeToString(sum[:]),
+	}
+}
+
+// writeV3NotFound -
+func writeV3NotFound(w http.ResponseWriter, detail string) {
+	writeV3Error(w, http.StatusNotFound, 10010, "CF-ResourceNotFound", detail)
+}
+
+// writeV3Error - Writes a CC v3 error response
+func writeV3Error(w http.ResponseWriter, status int, code int, title, detail string) {
+	writeJSON(w, status, map[string]interface{}{
+		"errors": []interface{}{
+			map[string]interface{}{
+				"code":   code,
+				"title":  title,
+				"detail": detail,
+			},
+		},
+	})
+}
@@ -0,0 +1,146 @@
+package cfapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"code.cloudfoundry.org/cli/cf/api/environmentvariablegroups"
+	"code.cloudfoundry.org/cli/cf/api/featureflags"
+)
+
+// FoundationSettings - A snapshot of the settings of a foundation which
+// apply to all its orgs. The snapshot can be persisted as JSON to
+// compare foundations or a foundation with an earlier snapshot.
+type FoundationSettings struct {
+	FeatureFlags map[string]bool `json:"feature_flags"`
+
+	RunningEnvGroup map[string]interface{} `json:"running_env_group"`
+	StagingEnvGroup map[string]interface{} `json:"staging_env_group"`
+}
+
+// FoundationSettingChange - A setting whose value differs between two
+// snapshots. A nil value means the setting is missing from a snapshot.
+type FoundationSettingChange struct {
+	Setting  string // i.e. "feature flag <name>" or "running env var <name>"
+	Baseline interface{}
+	Other    interface{}
+}
+
+// FoundationSettingsDiff - The settings which differ between two snapshots
+type FoundationSettingsDiff struct {
+	Changes []FoundationSettingChange
+}
+
+// FeatureFlags -
+func (s *CfCliSession) FeatureFlags() featureflags.FeatureFlagRepository {
+	return featureflags.NewCloudControllerFeatureFlagRepository(s.config, s.ccGateway)
+}
+
+// EnvironmentVariableGroups - Returns the repository of the running and
+// staging environment variable groups. It only supports groups whose values
+// are strings or integers. SnapshotFoundationSettings reads values of any
+// type.
+func (s *CfCliSession) EnvironmentVariableGroups() environmentvariablegroups.Repository {
+	return environmentvariablegroups.NewCloudControllerRepository(s.config, s.ccGateway)
+}
+
+// SnapshotFoundationSettings - Returns the feature flags and the
+// environment variable groups currently set for the foundation
+func (s *CfCliSession) SnapshotFoundationSettings() (FoundationSettings, error) {
+
+	settings := FoundationSettings{FeatureFlags: make(map[string]bool)}
+
+	flags, err := s.FeatureFlags().List()
+	if err != nil {
+		return settings, err
+	}
+	for _, f := range flags {
+		settings.FeatureFlags[f.Name] = f.Enabled
+	}
+
+	endpoint := s.config.APIEndpoint()
+	if err = s.ccGateway.GetResource(
+		fmt.Sprintf("%s/v2/config/environment_variable_groups/running", endpoint), &settings.RunningEnvGroup); err != nil {
+		return settings, err
+	}
+	if err = s.ccGateway.GetResource(
+		fmt.Sprintf("%s/v2/config/environment_variable_groups/staging", endpoint), &settings.StagingEnvGroup); err != nil {
+		return settings, err
+	}
+	settings.RunningEnvGroup = nonNilMap(settings.RunningEnvGroup)
+	settings.StagingEnvGroup = nonNilMap(settings.StagingEnvGroup)
+	return settings, nil
+}
+
+// DiffFoundationSettings - Compares a snapshot with a baseline snapshot.
+// Environment variable values are equal if their JSON encodings are equal
+// so values read from a persisted snapshot compare equal to live values.
+// The changes are ordered by setting.
+func DiffFoundationSettings(baseline, other FoundationSettings) FoundationSettingsDiff {
+
+	diff := FoundationSettingsDiff{Changes: []FoundationSettingChange{}}
+
+	compare := func(kind string, baseline, other map[string]interface{}) {
+		for name, value := range baseline {
+			if otherValue, ok := other[name]; !ok || settingKey(value) != settingKey(otherValue) {
+				diff.Changes = append(diff.Changes, FoundationSettingChange{
+					Setting:  kind + " " + name,
+					Baseline: value,
+					Other:    otherValue,
+				})
+			}
+		}
+		for name, value := range other {
+			if _, ok := baseline[name]; !ok {
+				diff.Changes = append(diff.Changes, FoundationSettingChange{
+					Setting: kind + " " + name,
+					Other:   value,
+				})
+			}
+		}
+	}
+
+	flags := func(flags map[string]bool) map[string]interface{} {
+		values := make(map[string]interface{})
+		for name, enabled := range flags {
+			values[name] = enabled
+		}
+		return values
+	}
+	compare("feature flag", flags(baseline.FeatureFlags), flags(other.FeatureFlags))
+	compare("running env var", baseline.RunningEnvGroup, other.RunningEnvGroup)
+	compare("staging env var", baseline.StagingEnvGroup, other.StagingEnvGroup)
+
+	sort.Slice(diff.Changes, func(i, j int) bool { return diff.Changes[i].Setting < diff.Changes[j].Setting })
+	return diff
+}
+
+// HasChanges -
+func (d FoundationSettingsDiff) HasChanges() bool {
+	return len(d.Changes) > 0
+}
+
+// String - Renders each change on a line as '<setting>: <baseline> -> <other>'
+// where a setting missing from a snapshot is rendered as '<missing>'
+func (d FoundationSettingsDiff) String() string {
+
+	render := func(value interface{}) string {
+		if value == nil {
+			return "<missing>"
+		}
+		return settingKey(value)
+	}
+	lines := []string{}
+	for _, c := range d.Changes {
+		lines = append(lines, fmt.Sprintf("%s: %s -> %s", c.Setting, render(c.Baseline), render(c.Other)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// settingKey - Renders a setting's value as JSON
+func settingKey(value interface{}) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
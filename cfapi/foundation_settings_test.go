@@ -0,0 +1,44 @@
+package cfapi_test
+
+import (
+	"encoding/json"
+
+	"github.com/mevansam/cf-cli-api/cfapi"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Foundation Settings Tests", func() {
+
+	baseline := cfapi.FoundationSettings{
+		FeatureFlags:    map[string]bool{"app_scaling": true, "diego_docker": false},
+		RunningEnvGroup: map[string]interface{}{"JAVA_OPTS": "-Xss256k", "RETRIES": 3},
+		StagingEnvGroup: map[string]interface{}{},
+	}
+
+	It("Should report no changes between equal snapshots", func() {
+		data, err := json.Marshal(baseline)
+		Expect(err).ShouldNot(HaveOccurred())
+		persisted := cfapi.FoundationSettings{}
+		Expect(json.Unmarshal(data, &persisted)).To(Succeed())
+
+		diff := cfapi.DiffFoundationSettings(baseline, persisted)
+		Expect(diff.HasChanges()).To(BeFalse())
+		Expect(diff.String()).To(BeEmpty())
+	})
+	It("Should report the settings changed, added and removed ordered by setting", func() {
+		other := cfapi.FoundationSettings{
+			FeatureFlags:    map[string]bool{"app_scaling": true, "diego_docker": true},
+			RunningEnvGroup: map[string]interface{}{"RETRIES": 5},
+			StagingEnvGroup: map[string]interface{}{"BP_DEBUG": "true"},
+		}
+
+		diff := cfapi.DiffFoundationSettings(baseline, other)
+		Expect(diff.HasChanges()).To(BeTrue())
+		Expect(diff.String()).To(Equal(
+			"feature flag diego_docker: false -> true\n" +
+				"running env var JAVA_OPTS: \"-Xss256k\" -> <missing>\n" +
+				"running env var RETRIES: 3 -> 5\n" +
+				"staging env var BP_DEBUG: <missing> -> \"true\""))
+	})
+})
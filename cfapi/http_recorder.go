@@ -0,0 +1,382 @@
+package cfapi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxRecordedBodySize - Bodies larger than this are truncated in recordings
+var MaxRecordedBodySize int64 = 64 * 1024
+
+// HTTPExchange - A recorded request/response performed by a session
+type HTTPExchange struct {
+	Source    string        `json:"source"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+
+	Request  HTTPRecordedRequest  `json:"request"`
+	Response HTTPRecordedResponse `json:"response"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// HTTPRecordedRequest -
+type HTTPRecordedRequest struct {
+	Method        string      `json:"method"`
+	URL           string      `json:"url"`
+	Proto         string      `json:"proto,omitempty"`
+	Headers       http.Header `json:"headers,omitempty"`
+	Body          string      `json:"body,omitempty"`
+	BodySize      int64       `json:"body_size"`
+	BodyTruncated bool        `json:"body_truncated,omitempty"`
+}
+
+// HTTPRecordedResponse -
+type HTTPRecordedResponse struct {
+	StatusCode    int         `json:"status_code"`
+	Status        string      `json:"status,omitempty"`
+	Proto         string      `json:"proto,omitempty"`
+	Headers       http.Header `json:"headers,omitempty"`
+	Body          string      `json:"body,omitempty"`
+	BodySize      int64       `json:"body_size"`
+	BodyTruncated bool        `json:"body_truncated,omitempty"`
+}
+
+const (
+	// HTTPSourceGateway - exchanges performed via the CC and UAA gateways
+	HTTPSourceGateway = "gateway"
+	// HTTPSourceRaw - raw downloads and uploads of application content
+	HTTPSourceRaw = "raw"
+)
+
+// HTTPRecorder - Destination for the HTTP exchanges of a session
+type HTTPRecorder interface {
+	Record(exchange HTTPExchange) error
+	Close() error
+}
+
+// jsonLinesRecorder -
+type jsonLinesRecorder struct {
+	mutex   sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// NewJSONLinesRecorder - Appends each exchange to the given file as a
+// single line JSON object as soon as it has been recorded
+func NewJSONLinesRecorder(path string) (HTTPRecorder, error) {
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonLinesRecorder{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+// Record -
+func (r *jsonLinesRecorder) Record(exchange HTTPExchange) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.encoder.Encode(exchange)
+}
+
+// Close -
+func (r *jsonLinesRecorder) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.file.Close()
+}
+
+// harRecorder -
+type harRecorder struct {
+	mutex     sync.Mutex
+	path      string
+	exchanges []HTTPExchange
+}
+
+// NewHARRecorder - Collects exchanges and writes them to the given
+// file in HTTP Archive (HAR 1.2) format when the recorder is closed
+func NewHARRecorder(path string) (HTTPRecorder, error) {
+
+	// Fail early if the archive cannot be written
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	file.Close()
+
+	return &harRecorder{path: path}, nil
+}
+
+// Record -
+func (r *harRecorder) Record(exchange HTTPExchange) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.exchanges = append(r.exchanges, exchange)
+	return nil
+}
+
+// Close -
+func (r *harRecorder) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	file, err := os.OpenFile(r.path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(toHAR(r.exchanges))
+}
+
+// ReadHTTPExchanges - Reads the exchanges from a file
+// written by a HAR or a JSON lines recorder
+func ReadHTTPExchanges(path string) (exchanges []HTTPExchange, err error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	archive := harArchive{}
+	if err = json.Unmarshal(data, &archive); err == nil && len(archive.Log.Version) > 0 {
+		return fromHAR(archive), nil
+	}
+
+	exchanges = []HTTPExchange{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), int(4*MaxRecordedBodySize+1024*1024))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		exchange := HTTPExchange{}
+		if err = json.Unmarshal([]byte(line), &exchange); err != nil {
+			return nil, fmt.Errorf("Unable to parse HTTP exchange in '%s': %s", path, err.Error())
+		}
+		exchanges = append(exchanges, exchange)
+	}
+	err = scanner.Err()
+	return
+}
+
+// HAR 1.2 document model
+
+type harArchive struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+	Comment     string         `json:"comment,omitempty"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+	Comment     string         `json:"comment,omitempty"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+const harTruncatedComment = "truncated"
+
+// toHAR -
+func toHAR(exchanges []HTTPExchange) harArchive {
+
+	archive := harArchive{
+		Log: harLog{
+			Version: "1.2",
+			Creator: harCreator{Name: "cf-cli-api", Version: "1.0"},
+			Entries: []harEntry{},
+		},
+	}
+
+	for _, e := range exchanges {
+
+		ms := float64(e.Duration) / float64(time.Millisecond)
+		entry := harEntry{
+			StartedDateTime: e.StartedAt.Format(time.RFC3339Nano),
+			Time:            ms,
+			Timings:         harTimings{Wait: ms},
+			Comment:         e.Source,
+		}
+
+		entry.Request = harRequest{
+			Method:      e.Request.Method,
+			URL:         e.Request.URL,
+			HTTPVersion: e.Request.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     toHARHeaders(e.Request.Headers),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    e.Request.BodySize,
+		}
+		if u, err := url.Parse(e.Request.URL); err == nil {
+			for name, values := range u.Query() {
+				for _, v := range values {
+					entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: name, Value: v})
+				}
+			}
+		}
+		if len(e.Request.Body) > 0 {
+			entry.Request.PostData = &harPostData{
+				MimeType: e.Request.Headers.Get("Content-Type"),
+				Text:     e.Request.Body,
+			}
+		}
+		if e.Request.BodyTruncated {
+			entry.Request.Comment = harTruncatedComment
+		}
+
+		entry.Response = harResponse{
+			Status:      e.Response.StatusCode,
+			StatusText:  strings.TrimSpace(strings.TrimPrefix(e.Response.Status, strconv.Itoa(e.Response.StatusCode))),
+			HTTPVersion: e.Response.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     toHARHeaders(e.Response.Headers),
+			Content: harContent{
+				Size:     e.Response.BodySize,
+				MimeType: e.Response.Headers.Get("Content-Type"),
+				Text:     e.Response.Body,
+			},
+			RedirectURL: e.Response.Headers.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    e.Response.BodySize,
+			Comment:     e.Error,
+		}
+		if e.Response.BodyTruncated {
+			entry.Response.Content.Comment = harTruncatedComment
+		}
+
+		archive.Log.Entries = append(archive.Log.Entries, entry)
+	}
+	return archive
+}
+
+// fromHAR -
+func fromHAR(archive harArchive) []HTTPExchange {
+
+	exchanges := []HTTPExchange{}
+	for _, entry := range archive.Log.Entries {
+
+		startedAt, _ := time.Parse(time.RFC3339Nano, entry.StartedDateTime)
+		e := HTTPExchange{
+			Source:    entry.Comment,
+			StartedAt: startedAt,
+			Duration:  time.Duration(entry.Time * float64(time.Millisecond)),
+			Request: HTTPRecordedRequest{
+				Method:        entry.Request.Method,
+				URL:           entry.Request.URL,
+				Proto:         entry.Request.HTTPVersion,
+				Headers:       fromHARHeaders(entry.Request.Headers),
+				BodySize:      entry.Request.BodySize,
+				BodyTruncated: entry.Request.Comment == harTruncatedComment,
+			},
+			Response: HTTPRecordedResponse{
+				StatusCode:    entry.Response.Status,
+				Status:        strings.TrimSpace(fmt.Sprintf("%d %s", entry.Response.Status, entry.Response.StatusText)),
+				Proto:         entry.Response.HTTPVersion,
+				Headers:       fromHARHeaders(entry.Response.Headers),
+				Body:          entry.Response.Content.Text,
+				BodySize:      entry.Response.BodySize,
+				BodyTruncated: entry.Response.Content.Comment == harTruncatedComment,
+			},
+			Error: entry.Response.Comment,
+		}
+		if entry.Request.PostData != nil {
+			e.Request.Body = entry.Request.PostData.Text
+		}
+		exchanges = append(exchanges, e)
+	}
+	return exchanges
+}
+
+func toHARHeaders(headers http.Header) []harNameValue {
+	nvs := []harNameValue{}
+	for name, values := range headers {
+		for _, v := range values {
+			nvs = append(nvs, harNameValue{Name: name, Value: v})
+		}
+	}
+	return nvs
+}
+
+func fromHARHeaders(nvs []harNameValue) http.Header {
+	headers := http.Header{}
+	for _, nv := range nvs {
+		headers.Add(nv.Name, nv.Value)
+	}
+	return headers
+}
@@ -0,0 +1,195 @@
+package cfapi_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/mevansam/cf-cli-api/cfapi"
+	"github.com/mevansam/cf-cli-api/cfapi/fakecc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HTTP Recorder Tests", func() {
+
+	var (
+		err     error
+		tempDir string
+		server  *httptest.Server
+	)
+
+	BeforeEach(func() {
+		tempDir, err = ioutil.TempDir("", "recorder")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/v2/service_keys/key-1000":
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"entity":{"name":"key1","credentials":{"password":"secret"}}}`))
+			default:
+				w.Header().Set("Content-Type", "application/octet-stream")
+				w.Write(make([]byte, 1024))
+			}
+		}))
+	})
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(tempDir)
+	})
+
+	record := func(recorder cfapi.HTTPRecorder) {
+		client := &http.Client{
+			Transport: cfapi.NewRecordingTransport(nil, recorder, cfapi.NewRedactor(cfapi.DefaultSensitiveKeys...)),
+		}
+		for _, path := range []string{
+			"/v2/service_keys/key-1000",
+			"/blobstore/droplets/app-1000?X-Amz-Expires=300&X-Amz-Signature=0123456789abcdef",
+		} {
+			req, err := http.NewRequest("GET", server.URL+path, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			req.Header.Set("Authorization", "bearer some-token")
+
+			resp, err := client.Do(req)
+			Expect(err).ShouldNot(HaveOccurred())
+			_, err = ioutil.ReadAll(resp.Body)
+			Expect(err).ShouldNot(HaveOccurred())
+			resp.Body.Close()
+		}
+		Expect(recorder.Close()).To(Succeed())
+	}
+
+	verify := func(exchanges []cfapi.HTTPExchange) {
+		Expect(len(exchanges)).To(Equal(2))
+
+		Expect(exchanges[0].Request.Method).To(Equal("GET"))
+		Expect(exchanges[0].Request.URL).To(Equal(server.URL + "/v2/service_keys/key-1000"))
+		Expect(exchanges[0].Request.Headers.Get("Authorization")).To(Equal(cfapi.RedactedValue))
+		Expect(exchanges[0].Response.StatusCode).To(Equal(200))
+		Expect(exchanges[0].Response.Body).To(Equal(`{"entity":{"name":"key1","credentials":"` + cfapi.RedactedValue + `"}}`))
+		Expect(exchanges[0].Response.BodyTruncated).To(BeFalse())
+
+		Expect(exchanges[1].Request.URL).To(Equal(server.URL +
+			"/blobstore/droplets/app-1000?X-Amz-Expires=300&X-Amz-Signature=" + url.QueryEscape(cfapi.RedactedValue)))
+		Expect(exchanges[1].Response.Body).To(BeEmpty())
+		Expect(exchanges[1].Response.BodySize).To(Equal(int64(1024)))
+		Expect(exchanges[1].Response.BodyTruncated).To(BeTrue())
+	}
+
+	Context("Recording HTTP exchanges", func() {
+
+		It("Should record exchanges to a HAR file", func() {
+			path := filepath.Join(tempDir, "trace.har")
+			recorder, err := cfapi.NewHARRecorder(path)
+			Expect(err).ShouldNot(HaveOccurred())
+			record(recorder)
+
+			exchanges, err := cfapi.ReadHTTPExchanges(path)
+			Expect(err).ShouldNot(HaveOccurred())
+			verify(exchanges)
+		})
+		It("Should record exchanges to a JSON lines file", func() {
+			path := filepath.Join(tempDir, "trace.jsonl")
+			recorder, err := cfapi.NewJSONLinesRecorder(path)
+			Expect(err).ShouldNot(HaveOccurred())
+			record(recorder)
+
+			exchanges, err := cfapi.ReadHTTPExchanges(path)
+			Expect(err).ShouldNot(HaveOccurred())
+			verify(exchanges)
+		})
+	})
+	Context("Recording gateway exchanges", func() {
+
+		var fake *fakecc.FakeCC
+
+		BeforeEach(func() {
+			fake = fakecc.New()
+			fake.AddUser("admin", "admin-password")
+			fake.AddSpace(fake.AddOrg("org1"), "space1")
+		})
+		AfterEach(func() {
+			fake.Close()
+		})
+
+		It("Should pair the responses of a session with their requests when a request fails", func() {
+			recorder := &memoryRecorder{}
+			logger := cfapi.NewLogger(false, "false")
+			logger.SetHTTPRecorder(recorder)
+
+			session, err := cfapi.NewCfCliSessionProvider().NewCfSession(fake.URL(),
+				"admin", "admin-password", "org1", "space1", true, logger)
+			Expect(err).ShouldNot(HaveOccurred())
+			defer session.Close()
+
+			// The gateway attempts a request three
+			// times before it fails at the transport level
+			fake.DropRequests = 3
+			_, err = session.Organizations().FindByName("org1")
+			Expect(err).Should(HaveOccurred())
+
+			_, err = session.Spaces().FindByName("space1")
+			Expect(err).ShouldNot(HaveOccurred())
+			_, err = session.Organizations().FindByName("org1")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			exchanges := recorder.gatewayExchanges()
+			Expect(len(exchanges)).To(BeNumerically(">", 3))
+			for _, e := range exchanges {
+				Expect(e.Request.URL).To(HavePrefix(fake.URL() + "/"))
+			}
+
+			failed, spaces, orgs := exchanges[len(exchanges)-3], exchanges[len(exchanges)-2], exchanges[len(exchanges)-1]
+			Expect(failed.Request.URL).To(ContainSubstring("/v2/organizations?"))
+			Expect(failed.Error).ToNot(BeEmpty())
+			Expect(failed.Response.StatusCode).To(BeZero())
+
+			Expect(spaces.Request.URL).To(ContainSubstring("/spaces?q=name%3Aspace1"))
+			Expect(spaces.Error).To(BeEmpty())
+			Expect(spaces.Response.StatusCode).To(Equal(200))
+			Expect(spaces.Response.Body).To(ContainSubstring(`"name":"space1"`))
+
+			Expect(orgs.Request.URL).To(ContainSubstring("/v2/organizations?"))
+			Expect(orgs.Response.StatusCode).To(Equal(200))
+			Expect(orgs.Response.Body).To(ContainSubstring(`"name":"org1"`))
+		})
+	})
+})
+
+// memoryRecorder - Collects recorded exchanges in memory
+type memoryRecorder struct {
+	mutex     sync.Mutex
+	exchanges []cfapi.HTTPExchange
+}
+
+// Record -
+func (r *memoryRecorder) Record(exchange cfapi.HTTPExchange) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.exchanges = append(r.exchanges, exchange)
+	return nil
+}
+
+// Close -
+func (r *memoryRecorder) Close() error {
+	return nil
+}
+
+// gatewayExchanges - Returns the exchanges recorded from gateway dumps
+func (r *memoryRecorder) gatewayExchanges() []cfapi.HTTPExchange {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	exchanges := []cfapi.HTTPExchange{}
+	for _, e := range r.exchanges {
+		if e.Source == cfapi.HTTPSourceGateway {
+			exchanges = append(exchanges, e)
+		}
+	}
+	return exchanges
+}
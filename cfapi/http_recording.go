@@ -0,0 +1,372 @@
+package cfapi
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/cli/cf/configuration/coreconfig"
+	"code.cloudfoundry.org/cli/cf/trace"
+)
+
+// recordingTransport - Records the exchanges of the raw
+// HTTP client used for application content transfers
+type recordingTransport struct {
+	transport http.RoundTripper
+	recorder  HTTPRecorder
+	redactor  *Redactor
+}
+
+// NewRecordingTransport -
+func NewRecordingTransport(transport http.RoundTripper, recorder HTTPRecorder, redactor *Redactor) http.RoundTripper {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &recordingTransport{transport: transport, recorder: recorder, redactor: redactor}
+}
+
+// RoundTrip -
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	exchange := HTTPExchange{
+		Source:    HTTPSourceRaw,
+		StartedAt: time.Now(),
+		Request: HTTPRecordedRequest{
+			Method:   req.Method,
+			URL:      t.redactor.RedactURL(req.URL.String()),
+			Proto:    req.Proto,
+			Headers:  redactHeaders(req.Header, t.redactor),
+			BodySize: req.ContentLength,
+		},
+	}
+
+	// Raw request bodies are application content
+	// and are recorded only by their size
+	if req.ContentLength > 0 {
+		exchange.Request.BodyTruncated = true
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		exchange.Duration = time.Since(exchange.StartedAt)
+		exchange.Error = err.Error()
+		_ = t.recorder.Record(exchange)
+		return nil, err
+	}
+
+	exchange.Response = HTTPRecordedResponse{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Proto:      resp.Proto,
+		Headers:    redactHeaders(resp.Header, t.redactor),
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	resp.Body = &recordingBody{
+		body:     resp.Body,
+		capture:  len(contentType) > 0 && isTextContent(contentType),
+		exchange: exchange,
+		recorder: t.recorder,
+		redactor: t.redactor,
+	}
+	return resp, nil
+}
+
+// redactHeaders -
+func redactHeaders(headers http.Header, redactor *Redactor) http.Header {
+	redacted := http.Header{}
+	for name, values := range headers {
+		for _, v := range values {
+			if redactor.IsSensitive(name) || strings.EqualFold(name, "Authorization") {
+				v = RedactedValue
+			}
+			redacted.Add(name, v)
+		}
+	}
+	return redacted
+}
+
+// recordingBody - Counts the bytes of a response body and captures it
+// up to MaxRecordedBodySize. The exchange is recorded when the body
+// has been read to the end or is closed.
+type recordingBody struct {
+	body     io.ReadCloser
+	capture  bool
+	buffer   bytes.Buffer
+	size     int64
+	once     sync.Once
+	exchange HTTPExchange
+	recorder HTTPRecorder
+	redactor *Redactor
+}
+
+// Read -
+func (b *recordingBody) Read(p []byte) (n int, err error) {
+	n, err = b.body.Read(p)
+	if n > 0 {
+		b.size += int64(n)
+		if b.capture && int64(b.buffer.Len()) < MaxRecordedBodySize {
+			remaining := MaxRecordedBodySize - int64(b.buffer.Len())
+			if int64(n) > remaining {
+				b.buffer.Write(p[:remaining])
+			} else {
+				b.buffer.Write(p[:n])
+			}
+		}
+	}
+	if err == io.EOF {
+		b.record()
+	}
+	return
+}
+
+// Close -
+func (b *recordingBody) Close() error {
+	err := b.body.Close()
+	b.record()
+	return err
+}
+
+// record -
+func (b *recordingBody) record() {
+	b.once.Do(func() {
+		b.exchange.Duration = time.Since(b.exchange.StartedAt)
+		b.exchange.Response.BodySize = b.size
+		b.exchange.Response.BodyTruncated = !b.capture || b.size > int64(b.buffer.Len())
+		if b.capture {
+			b.exchange.Response.Body = b.redactor.RedactText(b.buffer.String())
+		}
+		_ = b.recorder.Record(b.exchange)
+	})
+}
+
+// recordingPrinter - Records the exchanges of the CC and UAA gateways.
+// The gateways do not expose their transport so exchanges are
+// reconstructed from the request and response dumps they write
+// to their trace printer. These dumps are written whether or not
+// tracing has been enabled.
+//
+// A gateway dumps the request and then the response of an exchange
+// from the goroutine performing it so responses are paired with the
+// pending request of their goroutine. A request which failed at the
+// transport level has no response dump. It is recorded with an error
+// once the next request of its goroutine is dumped. If the goroutine
+// cannot be determined requests are recorded without their responses
+// as concurrent exchanges could otherwise be paired with each other.
+type recordingPrinter struct {
+	printer  trace.Printer
+	recorder HTTPRecorder
+	logger   *Logger
+	redactor *Redactor
+	config   coreconfig.Reader
+
+	mutex sync.Mutex
+	// requests awaiting their response by goroutine ID
+	pending map[uint64]HTTPExchange
+	// warns once that dumps cannot be paired
+	unpairedOnce sync.Once
+}
+
+var (
+	ansiPattern      = regexp.MustCompile("\x1b\\[[0-9;]*m")
+	dumpPattern      = regexp.MustCompile(`^\s*(REQUEST|RESPONSE):[^\n]*\n`)
+	goroutinePattern = regexp.MustCompile(`^goroutine (\d+) `)
+)
+
+// NewRecordingPrinter - Returns a printer recording the exchanges whose
+// dumps it prints. Request URLs are recorded with the scheme of the API
+// endpoint of the given configuration.
+func NewRecordingPrinter(printer trace.Printer, recorder HTTPRecorder, logger *Logger, config coreconfig.Reader) trace.Printer {
+	return &recordingPrinter{
+		printer:  printer,
+		recorder: recorder,
+		logger:   logger,
+		redactor: logger.Redactor(),
+		config:   config,
+		pending:  make(map[uint64]HTTPExchange),
+	}
+}
+
+// Print -
+func (p *recordingPrinter) Print(v ...interface{}) {
+	p.capture(fmt.Sprint(v...))
+	p.printer.Print(v...)
+}
+
+// Printf -
+func (p *recordingPrinter) Printf(format string, v ...interface{}) {
+	p.capture(fmt.Sprintf(format, v...))
+	p.printer.Printf(format, v...)
+}
+
+// Println -
+func (p *recordingPrinter) Println(v ...interface{}) {
+	p.capture(fmt.Sprintln(v...))
+	p.printer.Println(v...)
+}
+
+// WritesToConsole -
+func (p *recordingPrinter) WritesToConsole() bool {
+	return p.printer.WritesToConsole()
+}
+
+// capture -
+func (p *recordingPrinter) capture(text string) {
+
+	text = ansiPattern.ReplaceAllString(text, "")
+	m := dumpPattern.FindStringSubmatchIndex(text)
+	if m == nil {
+		return
+	}
+	dump := strings.TrimLeft(text[m[1]:], "\r\n")
+	if !strings.HasSuffix(dump, "\n") {
+		dump += "\n"
+	}
+
+	id, ok := goroutineID()
+	if !ok {
+		p.unpairedOnce.Do(func() {
+			p.logger.Warn("Unable to determine the goroutine of an HTTP dump. Recording requests without their responses.")
+		})
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !ok {
+		if text[m[2]:m[3]] == "REQUEST" {
+			if exchange, ok := p.parseRequest(dump); ok {
+				_ = p.recorder.Record(exchange)
+			}
+		}
+		return
+	}
+
+	exchange, pending := p.pending[id]
+	if text[m[2]:m[3]] == "REQUEST" {
+		if pending {
+			exchange.Duration = time.Since(exchange.StartedAt)
+			exchange.Error = "No response was received for the request."
+			_ = p.recorder.Record(exchange)
+			delete(p.pending, id)
+		}
+		if exchange, ok := p.parseRequest(dump); ok {
+			p.pending[id] = exchange
+		}
+		return
+	}
+	if !pending {
+		return
+	}
+
+	delete(p.pending, id)
+	exchange.Duration = time.Since(exchange.StartedAt)
+	p.parseResponse(dump, &exchange)
+	_ = p.recorder.Record(exchange)
+}
+
+// goroutineID - Returns the ID of the calling goroutine parsed from
+// the header of its stack trace and whether it could be parsed
+func goroutineID() (uint64, bool) {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	if m := goroutinePattern.FindSubmatch(buf); m != nil {
+		if id, err := strconv.ParseUint(string(m[1]), 10, 64); err == nil {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// parseRequest -
+func (p *recordingPrinter) parseRequest(dump string) (exchange HTTPExchange, ok bool) {
+
+	req, err := http.ReadRequest(bufio.NewReader(strings.NewReader(dump)))
+	if err != nil {
+		return
+	}
+	defer req.Body.Close()
+
+	scheme := "https"
+	if endpoint, err := url.Parse(p.config.APIEndpoint()); err == nil && len(endpoint.Scheme) > 0 {
+		scheme = endpoint.Scheme
+	}
+	exchange = HTTPExchange{
+		Source:    HTTPSourceGateway,
+		StartedAt: time.Now(),
+		Request: HTTPRecordedRequest{
+			Method:  req.Method,
+			URL:     p.redactor.RedactURL(scheme + "://" + req.Host + req.RequestURI),
+			Proto:   req.Proto,
+			Headers: redactHeaders(req.Header, p.redactor),
+		},
+	}
+	body, _ := ioutil.ReadAll(req.Body)
+	exchange.Request.BodySize = int64(len(body))
+	exchange.Request.Body, exchange.Request.BodyTruncated =
+		p.recordedBody(body, req.Header.Get("Content-Type"))
+
+	return exchange, true
+}
+
+// parseResponse -
+func (p *recordingPrinter) parseResponse(dump string, exchange *HTTPExchange) {
+
+	resp, err := http.ReadResponse(bufio.NewReader(strings.NewReader(dump)), nil)
+	if err != nil {
+		exchange.Error = fmt.Sprintf("Unable to parse response dump: %s", err.Error())
+		return
+	}
+	defer resp.Body.Close()
+
+	exchange.Response = HTTPRecordedResponse{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Proto:      resp.Proto,
+		Headers:    redactHeaders(resp.Header, p.redactor),
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	exchange.Response.BodySize = int64(len(body))
+	exchange.Response.Body, exchange.Response.BodyTruncated =
+		p.recordedBody(body, resp.Header.Get("Content-Type"))
+}
+
+// recordedBody -
+func (p *recordingPrinter) recordedBody(body []byte, contentType string) (string, bool) {
+	if len(body) == 0 {
+		return "", false
+	}
+	if !isTextContent(contentType) {
+		return "", true
+	}
+	if int64(len(body)) > MaxRecordedBodySize {
+		return p.redactor.RedactText(string(body[:MaxRecordedBodySize])), true
+	}
+	return p.redactor.RedactText(string(body)), false
+}
+
+// isTextContent - Returns whether a body of the given
+// content type can be recorded as text
+func isTextContent(contentType string) bool {
+
+	contentType = strings.ToLower(contentType)
+	if len(contentType) == 0 {
+		// CC and UAA responses without a content type are JSON
+		return true
+	}
+	return strings.HasPrefix(contentType, "text/") ||
+		strings.HasPrefix(contentType, "application/json") ||
+		strings.HasPrefix(contentType, "application/x-www-form-urlencoded") ||
+		strings.HasPrefix(contentType, "application/xml") ||
+		strings.Contains(contentType, "+json")
+}
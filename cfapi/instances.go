@@ -0,0 +1,169 @@
+package cfapi
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/cf/errors"
+)
+
+// States of app instances
+const (
+	AppInstanceStarting = "STARTING"
+	AppInstanceRunning  = "RUNNING"
+	AppInstanceCrashed  = "CRASHED"
+	AppInstanceDown     = "DOWN"
+)
+
+// stagingErrorCode - CC error code returned for the instances
+// of an app whose staging failed
+const stagingErrorCode = "170001"
+
+type appInstanceResource struct {
+	State   string  `json:"state"`
+	Since   float64 `json:"since"`
+	Uptime  int64   `json:"uptime"`
+	Details string  `json:"details"`
+}
+
+type appInstanceStatsResource struct {
+	State string `json:"state"`
+	Stats *struct {
+		Host      string `json:"host"`
+		Port      int    `json:"port"`
+		Uptime    int64  `json:"uptime"`
+		MemQuota  int64  `json:"mem_quota"`
+		DiskQuota int64  `json:"disk_quota"`
+		Usage     struct {
+			CPU  float64 `json:"cpu"`
+			Mem  int64   `json:"mem"`
+			Disk int64   `json:"disk"`
+		} `json:"usage"`
+	} `json:"stats"`
+}
+
+// AppInstancesCrashedError - Returned when instances of
+// an app crashed while waiting for them to be running
+type AppInstancesCrashedError struct {
+	AppGUID string
+	Crashed []AppInstance
+}
+
+// Error -
+func (e *AppInstancesCrashedError) Error() string {
+	reasons := []string{}
+	for _, i := range e.Crashed {
+		if len(i.Details) > 0 {
+			reasons = append(reasons, fmt.Sprintf("#%d (%s)", i.Index, i.Details))
+		} else {
+			reasons = append(reasons, fmt.Sprintf("#%d", i.Index))
+		}
+	}
+	return fmt.Sprintf("Instances of app with GUID '%s' crashed: %s", e.AppGUID, strings.Join(reasons, ", "))
+}
+
+// GetAppInstances - Returns the state and the resource
+// usage of the instances of an app ordered by their index
+func (s *CfCliSession) GetAppInstances(appGUID string) ([]AppInstance, error) {
+
+	instancesResponse := map[string]appInstanceResource{}
+	if err := s.ccGateway.GetResource(
+		fmt.Sprintf("%s/v2/apps/%s/instances", s.config.APIEndpoint(), appGUID), &instancesResponse); err != nil {
+		return nil, err
+	}
+	statsResponse := map[string]appInstanceStatsResource{}
+	if err := s.ccGateway.GetResource(
+		fmt.Sprintf("%s/v2/apps/%s/stats", s.config.APIEndpoint(), appGUID), &statsResponse); err != nil {
+		return nil, err
+	}
+
+	instances := []AppInstance{}
+	for k, v := range instancesResponse {
+		index, err := strconv.Atoi(k)
+		if err != nil {
+			continue
+		}
+		sec, dec := math.Modf(v.Since)
+		instance := AppInstance{
+			Index:   index,
+			State:   strings.ToUpper(v.State),
+			Since:   time.Unix(int64(sec), int64(dec*1e9)).UTC(),
+			Uptime:  time.Duration(v.Uptime) * time.Second,
+			Details: v.Details,
+		}
+		if stats, ok := statsResponse[k]; ok && stats.Stats != nil {
+			instance.Host = stats.Stats.Host
+			instance.Port = stats.Stats.Port
+			instance.CPUUsage = stats.Stats.Usage.CPU
+			instance.MemUsage = stats.Stats.Usage.Mem
+			instance.MemQuota = stats.Stats.MemQuota
+			instance.DiskUsage = stats.Stats.Usage.Disk
+			instance.DiskQuota = stats.Stats.DiskQuota
+			if stats.Stats.Uptime > 0 {
+				instance.Uptime = time.Duration(stats.Stats.Uptime) * time.Second
+			}
+		}
+		instances = append(instances, instance)
+	}
+	sort.Slice(instances, func(i, j int) bool { return instances[i].Index < instances[j].Index })
+	return instances, nil
+}
+
+// WaitForAppInstances - Polls the instances of an app until the given number
+// of them is running and returns them. Waiting ends with an error as soon as
+// an instance crashed or the app failed to stage. A timeout of 0 waits until
+// the instances are running or crashed.
+func (s *CfCliSession) WaitForAppInstances(appGUID string, running int,
+	timeout time.Duration) (instances []AppInstance, err error) {
+
+	operation := fmt.Sprintf("%d instance(s) of app with GUID '%s' to be running", running, appGUID)
+
+	err = s.poll(operation, timeout, func() (done bool, err error) {
+		instances, done, err = s.appInstancesRunning(appGUID, running)
+		return
+	})
+	return
+}
+
+// appInstancesRunning - Returns the instances of an app and whether the
+// given number of them is running. Returns an error if an instance
+// crashed or the app failed to stage.
+func (s *CfCliSession) appInstancesRunning(appGUID string, running int) ([]AppInstance, bool, error) {
+
+	instances, err := s.GetAppInstances(appGUID)
+	if err != nil {
+		if httpError, ok := err.(errors.HTTPError); ok {
+			switch httpError.ErrorCode() {
+			case errors.NotStaged:
+				return nil, false, nil
+			case stagingErrorCode:
+				return nil, true, &AsyncFailedError{
+					Operation: fmt.Sprintf("staging of app with GUID '%s'", appGUID),
+					Reason:    err.Error(),
+				}
+			}
+		}
+		return nil, false, err
+	}
+
+	count, crashed := 0, []AppInstance{}
+	for _, i := range instances {
+		switch i.State {
+		case AppInstanceRunning:
+			count++
+		case AppInstanceCrashed:
+			crashed = append(crashed, i)
+		}
+	}
+	if count >= running {
+		return instances, true, nil
+	}
+	if len(crashed) > 0 {
+		return instances, true, &AppInstancesCrashedError{AppGUID: appGUID, Crashed: crashed}
+	}
+	return instances, false, nil
+}
@@ -0,0 +1,175 @@
+package cfapi
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/cf/errors"
+	"code.cloudfoundry.org/cli/cf/models"
+)
+
+// States of an app and of its package
+const (
+	AppStarted = "STARTED"
+	AppStopped = "STOPPED"
+
+	PackageStaged = "STAGED"
+	PackageFailed = "FAILED"
+)
+
+type appStateResource struct {
+	Entity struct {
+		Instances                int    `json:"instances"`
+		PackageState             string `json:"package_state"`
+		StagingFailedReason      string `json:"staging_failed_reason"`
+		StagingFailedDescription string `json:"staging_failed_description"`
+	} `json:"entity"`
+}
+
+// AppStagingFailedError - Returned when staging an app failed. The reason
+// is the error code reported by the Cloud Controller i.e.
+// "BuildpackCompileFailed" and the description explains it.
+type AppStagingFailedError struct {
+	AppGUID     string
+	Reason      string
+	Description string
+}
+
+// Error -
+func (e *AppStagingFailedError) Error() string {
+	if len(e.Description) > 0 {
+		return fmt.Sprintf("Staging of app with GUID '%s' failed: %s - %s", e.AppGUID, e.Reason, e.Description)
+	}
+	return fmt.Sprintf("Staging of app with GUID '%s' failed: %s", e.AppGUID, e.Reason)
+}
+
+// StartApp - Starts an app and waits until it staged and all of its
+// instances are running. A timeout of 0 waits until the app is running
+// or its staging or one of its instances failed.
+func (s *CfCliSession) StartApp(appGUID string, timeout time.Duration) ([]AppInstance, error) {
+
+	s.logger.DebugMessage("Starting app with GUID '%s'.", appGUID)
+
+	state := AppStarted
+	if _, err := s.Applications().Update(appGUID, models.AppParams{State: &state}); err != nil {
+		return nil, err
+	}
+	return s.waitForApp(appGUID, timeout)
+}
+
+// StopApp - Stops all instances of an app and waits until none of them
+// is reported as starting or running. A timeout of 0 waits until the
+// app is stopped.
+func (s *CfCliSession) StopApp(appGUID string, timeout time.Duration) error {
+
+	s.logger.DebugMessage("Stopping app with GUID '%s'.", appGUID)
+
+	state := AppStopped
+	if _, err := s.Applications().Update(appGUID, models.AppParams{State: &state}); err != nil {
+		return err
+	}
+	return s.waitForAppStopped(appGUID, timeout)
+}
+
+// RestartApp - Stops and starts an app and waits until all of its
+// instances are running again
+func (s *CfCliSession) RestartApp(appGUID string, timeout time.Duration) ([]AppInstance, error) {
+	if err := s.StopApp(appGUID, timeout); err != nil {
+		return nil, err
+	}
+	return s.StartApp(appGUID, timeout)
+}
+
+// RestageApp - Stages the package of an app again and waits until the
+// app is running with the new droplet. The Cloud Controller starts the
+// app when it is restaged.
+func (s *CfCliSession) RestageApp(appGUID string, timeout time.Duration) ([]AppInstance, error) {
+
+	s.logger.DebugMessage("Restaging app with GUID '%s'.", appGUID)
+
+	if err := s.Applications().CreateRestageRequest(appGUID); err != nil {
+		return nil, err
+	}
+	return s.waitForApp(appGUID, timeout)
+}
+
+// ScaleApp - Changes the number of instances and the quotas of an app. If
+// the app is started it waits until all of its instances are running.
+func (s *CfCliSession) ScaleApp(appGUID string, scale AppScale, timeout time.Duration) ([]AppInstance, error) {
+
+	s.logger.DebugMessage("Scaling app with GUID '%s'.", appGUID)
+
+	app, err := s.Applications().Update(appGUID, models.AppParams{
+		InstanceCount: scale.Instances,
+		Memory:        scale.MemoryInMB,
+		DiskQuota:     scale.DiskInMB,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if app.State != models.ApplicationStateStarted {
+		return []AppInstance{}, nil
+	}
+	return s.waitForApp(appGUID, timeout)
+}
+
+// waitForAppStopped - Polls the instances of an app until none of them
+// is starting or running. The Cloud Controller responds with an
+// instances error once all instances of a stopped app are gone.
+func (s *CfCliSession) waitForAppStopped(appGUID string, timeout time.Duration) error {
+
+	url := fmt.Sprintf("%s/v2/apps/%s/instances", s.config.APIEndpoint(), appGUID)
+
+	return s.poll(fmt.Sprintf("app with GUID '%s' to be stopped", appGUID), timeout, func() (bool, error) {
+
+		instances := map[string]appInstanceResource{}
+		if err := s.ccGateway.GetResource(url, &instances); err != nil {
+			if httpError, ok := err.(errors.HTTPError); ok && httpError.ErrorCode() == errors.InstancesError {
+				return true, nil
+			}
+			return false, err
+		}
+		for _, i := range instances {
+			switch strings.ToUpper(i.State) {
+			case AppInstanceStarting, AppInstanceRunning:
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// waitForApp - Polls an app until it staged and the number of
+// instances it is to run are running and returns them
+func (s *CfCliSession) waitForApp(appGUID string, timeout time.Duration) (instances []AppInstance, err error) {
+
+	url := fmt.Sprintf("%s/v2/apps/%s", s.config.APIEndpoint(), appGUID)
+
+	err = s.poll(fmt.Sprintf("app with GUID '%s' to be running", appGUID), timeout, func() (bool, error) {
+
+		app := appStateResource{}
+		if err := s.ccGateway.GetResource(url, &app); err != nil {
+			return false, err
+		}
+		switch app.Entity.PackageState {
+		case PackageStaged:
+		case PackageFailed:
+			return true, &AppStagingFailedError{
+				AppGUID:     appGUID,
+				Reason:      app.Entity.StagingFailedReason,
+				Description: app.Entity.StagingFailedDescription,
+			}
+		default:
+			return false, nil
+		}
+
+		var (
+			done bool
+			err  error
+		)
+		instances, done, err = s.appInstancesRunning(appGUID, app.Entity.Instances)
+		return done, err
+	})
+	return
+}
@@ -0,0 +1,152 @@
+package cfapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/cli/cf/terminal"
+)
+
+// LogField -
+type LogField struct {
+	Key   string
+	Value interface{}
+}
+
+// LogRecord -
+type LogRecord struct {
+	Time    time.Time
+	Level   LogLevel
+	Message string
+	Fields  []LogField
+}
+
+// LogSink - Destination for structured log records
+type LogSink interface {
+	Write(record LogRecord) error
+}
+
+// LogSinkFunc - Adapts a function to the LogSink interface
+type LogSinkFunc func(record LogRecord) error
+
+// Write -
+func (f LogSinkFunc) Write(record LogRecord) error {
+	return f(record)
+}
+
+// textLogSink -
+type textLogSink struct {
+	mutex sync.Mutex
+	out   io.Writer
+}
+
+// NewTextLogSink - Writes records as human readable lines
+// with the fields appended as key=value pairs
+func NewTextLogSink(out io.Writer) LogSink {
+	return &textLogSink{out: out}
+}
+
+// Write -
+func (s *textLogSink) Write(record LogRecord) (err error) {
+
+	hdr := terminal.HeaderColor(fmt.Sprintf("[%s] %s:",
+		record.Time.Format(time.RFC3339), strings.ToUpper(record.Level.String())))
+
+	line := []string{hdr, record.Message}
+	for _, f := range record.Fields {
+		line = append(line, fmt.Sprintf("%s=%s", f.Key, formatTextValue(f.Value)))
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, err = fmt.Fprintln(s.out, strings.Join(line, " "))
+	return
+}
+
+// jsonLogSink -
+type jsonLogSink struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewJSONLogSink - Writes each record as a single line JSON object
+func NewJSONLogSink(out io.Writer) LogSink {
+	return &jsonLogSink{encoder: json.NewEncoder(out)}
+}
+
+// Write -
+func (s *jsonLogSink) Write(record LogRecord) error {
+
+	entry := make(map[string]interface{})
+	for _, f := range record.Fields {
+		entry[f.Key] = jsonValue(f.Value)
+	}
+	entry["time"] = record.Time.Format(time.RFC3339Nano)
+	entry["level"] = record.Level.String()
+	entry["msg"] = record.Message
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.encoder.Encode(entry)
+}
+
+// toLogFields - Converts alternating key-value arguments to log
+// fields. A trailing key without a value is logged as '!MISSING'.
+func toLogFields(keysAndValues []interface{}) []LogField {
+
+	fields := make([]LogField, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+
+		var key string
+		if k, ok := keysAndValues[i].(string); ok {
+			key = k
+		} else {
+			key = fmt.Sprintf("%v", keysAndValues[i])
+		}
+		if i+1 < len(keysAndValues) {
+			fields = append(fields, LogField{Key: key, Value: keysAndValues[i+1]})
+		} else {
+			fields = append(fields, LogField{Key: key, Value: "!MISSING"})
+		}
+	}
+	return fields
+}
+
+// formatTextValue -
+func formatTextValue(v interface{}) string {
+
+	var s string
+	switch vv := v.(type) {
+	case error:
+		s = vv.Error()
+	case fmt.Stringer:
+		s = vv.String()
+	case string:
+		s = vv
+	default:
+		s = fmt.Sprintf("%+v", vv)
+	}
+	if strings.ContainsAny(s, " \t\n\"=") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
+
+// jsonValue -
+func jsonValue(v interface{}) interface{} {
+
+	switch vv := v.(type) {
+	case error:
+		return vv.Error()
+	case time.Duration:
+		return vv.String()
+	}
+	if _, err := json.Marshal(v); err != nil {
+		return fmt.Sprintf("%+v", v)
+	}
+	return v
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/kr/pretty"
@@ -13,11 +14,68 @@ import (
 	"code.cloudfoundry.org/cli/cf/trace"
 )
 
+// LogLevel -
+type LogLevel int
+
+const (
+	// LogLevelDebug -
+	LogLevelDebug LogLevel = iota
+	// LogLevelInfo -
+	LogLevelInfo
+	// LogLevelWarn -
+	LogLevelWarn
+	// LogLevelError -
+	LogLevelError
+)
+
+// String -
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "debug"
+	case LogLevelInfo:
+		return "info"
+	case LogLevelWarn:
+		return "warn"
+	case LogLevelError:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+}
+
+// ParseLogLevel -
+func ParseLogLevel(level string) (LogLevel, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return LogLevelDebug, nil
+	case "info":
+		return LogLevelInfo, nil
+	case "warn", "warning":
+		return LogLevelWarn, nil
+	case "error":
+		return LogLevelError, nil
+	}
+	return LogLevelInfo, fmt.Errorf("Unknown log level '%s'.", level)
+}
+
 // Logger -
 type Logger struct {
 	TracePrinter trace.Printer
 	UI           terminal.UI
-	isDebug      bool
+
+	config *loggerConfig
+	fields []LogField
+}
+
+// loggerConfig - state shared by a logger and the
+// loggers derived from it via With()
+type loggerConfig struct {
+	mutex    sync.RWMutex
+	level    LogLevel
+	sink     LogSink
+	redactor *Redactor
+	recorder HTTPRecorder
 }
 
 // NewLogger -
@@ -25,14 +83,17 @@ func NewLogger(debug bool, tracePath string) *Logger {
 
 	l := &Logger{}
 
+	l.config = newLoggerConfig(debug, NewTextLogSink(os.Stdout))
+
+	var tracePrinter trace.Printer
 	if _, err := os.Stat(tracePath); os.IsExist(err) {
-		l.TracePrinter = trace.NewLogger(os.Stdout, true, tracePath, "")
+		tracePrinter = trace.NewLogger(os.Stdout, true, tracePath, "")
 	} else {
-		l.TracePrinter = trace.NewLogger(os.Stdout, strings.ToLower(tracePath) == "true", "", "")
+		tracePrinter = trace.NewLogger(os.Stdout, strings.ToLower(tracePath) == "true", "", "")
 	}
+	l.TracePrinter = NewRedactingPrinter(tracePrinter, l.config.redactor)
 
 	l.UI = terminal.NewUI(os.Stdin, os.Stdout, terminal.NewTeePrinter(os.Stdout), l.TracePrinter)
-	l.isDebug = debug
 
 	return l
 }
@@ -42,28 +103,149 @@ func NewFileLogger(debug bool, tracePath string, inFile *os.File, outFile *os.Fi
 
 	l := &Logger{}
 
+	l.config = newLoggerConfig(debug, NewTextLogSink(outFile))
+
+	var tracePrinter trace.Printer
 	if _, err := os.Stat(tracePath); os.IsExist(err) {
-		l.TracePrinter = trace.NewLogger(outFile, true, tracePath, "")
+		tracePrinter = trace.NewLogger(outFile, true, tracePath, "")
 	} else {
-		l.TracePrinter = trace.NewLogger(outFile, strings.ToLower(tracePath) == "true", "", "")
+		tracePrinter = trace.NewLogger(outFile, strings.ToLower(tracePath) == "true", "", "")
 	}
+	l.TracePrinter = NewRedactingPrinter(tracePrinter, l.config.redactor)
 
 	l.UI = terminal.NewUI(inFile, outFile, terminal.NewTeePrinter(outFile), l.TracePrinter)
-	l.isDebug = debug
 
 	return l
 }
 
-// LogMessage -
+// newLoggerConfig -
+func newLoggerConfig(debug bool, sink LogSink) *loggerConfig {
+
+	c := &loggerConfig{
+		sink:     sink,
+		level:    LogLevelInfo,
+		redactor: NewRedactor(DefaultSensitiveKeys...),
+	}
+	if debug {
+		c.level = LogLevelDebug
+	}
+	return c
+}
+
+// SetLevel -
+func (l *Logger) SetLevel(level LogLevel) {
+	l.config.mutex.Lock()
+	defer l.config.mutex.Unlock()
+	l.config.level = level
+}
+
+// Level -
+func (l *Logger) Level() LogLevel {
+	l.config.mutex.RLock()
+	defer l.config.mutex.RUnlock()
+	return l.config.level
+}
+
+// SetSink - Replaces the sink log records are written to. This
+// also applies to all loggers derived from this logger.
+func (l *Logger) SetSink(sink LogSink) {
+	l.config.mutex.Lock()
+	defer l.config.mutex.Unlock()
+	l.config.sink = sink
+}
+
+// SetSensitiveKeys - Replaces the keys whose values are masked in
+// log records and HTTP traces. The defaults are DefaultSensitiveKeys.
+func (l *Logger) SetSensitiveKeys(keys ...string) {
+	l.config.redactor.SetSensitiveKeys(keys...)
+}
+
+// AddSensitiveKeys -
+func (l *Logger) AddSensitiveKeys(keys ...string) {
+	l.config.redactor.AddSensitiveKeys(keys...)
+}
+
+// Redactor -
+func (l *Logger) Redactor() *Redactor {
+	return l.config.redactor
+}
+
+// SetHTTPRecorder - Records every HTTP request and response performed
+// by sessions created with this logger. Sessions pick up the recorder
+// when they are created. The recorder is owned by the caller which
+// must close it once the sessions are no longer in use.
+func (l *Logger) SetHTTPRecorder(recorder HTTPRecorder) {
+	l.config.mutex.Lock()
+	defer l.config.mutex.Unlock()
+	l.config.recorder = recorder
+}
+
+// HTTPRecorder -
+func (l *Logger) HTTPRecorder() HTTPRecorder {
+	l.config.mutex.RLock()
+	defer l.config.mutex.RUnlock()
+	return l.config.recorder
+}
+
+// IsDebug -
+func (l *Logger) IsDebug() bool {
+	return l.Enabled(LogLevelDebug)
+}
+
+// Enabled -
+func (l *Logger) Enabled(level LogLevel) bool {
+	return level >= l.Level()
+}
+
+// With - Returns a logger that adds the given key-value
+// pairs to every record it emits
+func (l *Logger) With(keysAndValues ...interface{}) *Logger {
+
+	fields := make([]LogField, 0, len(l.fields)+len(keysAndValues)/2)
+	fields = append(fields, l.fields...)
+	fields = append(fields, toLogFields(keysAndValues)...)
+
+	return &Logger{
+		TracePrinter: l.TracePrinter,
+		UI:           l.UI,
+		config:       l.config,
+		fields:       fields,
+	}
+}
+
+// Debug -
+func (l *Logger) Debug(msg string, keysAndValues ...interface{}) {
+	l.log(LogLevelDebug, msg, keysAndValues)
+}
+
+// Info -
+func (l *Logger) Info(msg string, keysAndValues ...interface{}) {
+	l.log(LogLevelInfo, msg, keysAndValues)
+}
+
+// Warn -
+func (l *Logger) Warn(msg string, keysAndValues ...interface{}) {
+	l.log(LogLevelWarn, msg, keysAndValues)
+}
+
+// Error -
+func (l *Logger) Error(msg string, keysAndValues ...interface{}) {
+	l.log(LogLevelError, msg, keysAndValues)
+}
+
+// LogMessage - Logs a formatted message at debug level so that it is
+// only visible when debugging as it was when written to the trace
 func (l *Logger) LogMessage(format string, v ...interface{}) {
-	l.TracePrinter.Printf(format, v)
+	if l.IsDebug() {
+		l.write(LogLevelDebug, fmt.Sprintf(format, l.redactArgs(v)...), nil)
+	}
 }
 
 // DebugMessage -
 func (l *Logger) DebugMessage(format string, v ...interface{}) {
-	if l.isDebug {
+	if l.IsDebug() {
 		vv := []interface{}{}
-		for _, o := range v {
+		for _, o := range l.redactArgs(v) {
 			k := reflect.ValueOf(o).Kind()
 			if k == reflect.Struct ||
 				k == reflect.Interface ||
@@ -75,7 +257,50 @@ func (l *Logger) DebugMessage(format string, v ...interface{}) {
 				vv = append(vv, o)
 			}
 		}
-		hdr := terminal.HeaderColor(fmt.Sprintf("[%s] DEBUG:", time.Now().Format(time.RFC3339)))
-		l.UI.Say(fmt.Sprintf("%s %s", hdr, format), vv...)
+		l.write(LogLevelDebug, fmt.Sprintf(format, vv...), nil)
+	}
+}
+
+// log -
+func (l *Logger) log(level LogLevel, msg string, keysAndValues []interface{}) {
+	if l.Enabled(level) {
+		l.write(level, msg, toLogFields(keysAndValues))
+	}
+}
+
+// redactArgs -
+func (l *Logger) redactArgs(v []interface{}) []interface{} {
+	vv := make([]interface{}, len(v))
+	for i, o := range v {
+		vv[i] = l.config.redactor.RedactValue(o)
+	}
+	return vv
+}
+
+// write -
+func (l *Logger) write(level LogLevel, msg string, fields []LogField) {
+
+	record := LogRecord{
+		Time:    time.Now(),
+		Level:   level,
+		Message: msg,
+	}
+	for _, f := range append(append([]LogField{}, l.fields...), fields...) {
+		if l.config.redactor.IsSensitive(f.Key) {
+			f.Value = RedactedValue
+		} else {
+			f.Value = l.config.redactor.RedactValue(f.Value)
+		}
+		record.Fields = append(record.Fields, f)
+	}
+
+	l.config.mutex.RLock()
+	sink := l.config.sink
+	l.config.mutex.RUnlock()
+
+	if sink != nil {
+		if err := sink.Write(record); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to write log record: %s\n", err.Error())
+		}
 	}
 }
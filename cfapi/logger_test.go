@@ -0,0 +1,96 @@
+package cfapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mevansam/cf-cli-api/cfapi"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Logger Tests", func() {
+
+	var (
+		logger  *cfapi.Logger
+		records []cfapi.LogRecord
+	)
+
+	BeforeEach(func() {
+		records = []cfapi.LogRecord{}
+
+		logger = cfapi.NewLogger(false, "false")
+		logger.SetSink(cfapi.LogSinkFunc(func(record cfapi.LogRecord) error {
+			records = append(records, record)
+			return nil
+		}))
+	})
+
+	Context("Leveled logging", func() {
+
+		It("Should only emit records at or above the logger's level", func() {
+
+			logger.Debug("debug message")
+			logger.DebugMessage("debug message %d", 1)
+			logger.Info("info message")
+			logger.Warn("warn message")
+			logger.Error("error message")
+			Expect(len(records)).To(Equal(3))
+			Expect(records[0].Level).To(Equal(cfapi.LogLevelInfo))
+			Expect(records[2].Level).To(Equal(cfapi.LogLevelError))
+
+			logger.SetLevel(cfapi.LogLevelDebug)
+			Expect(logger.IsDebug()).To(BeTrue())
+			logger.DebugMessage("debug message %d of %d", 2, 3)
+			Expect(len(records)).To(Equal(4))
+			Expect(records[3].Message).To(Equal("debug message 2 of 3"))
+		})
+		It("Should format log message arguments and log them only when debugging", func() {
+
+			logger.LogMessage("copied %d apps to space '%s'", 1, "dev")
+			Expect(records).To(BeEmpty())
+
+			logger.SetLevel(cfapi.LogLevelDebug)
+			logger.LogMessage("copied %d apps to space '%s'", 2, "dev")
+			Expect(len(records)).To(Equal(1))
+			Expect(records[0].Level).To(Equal(cfapi.LogLevelDebug))
+			Expect(records[0].Message).To(Equal("copied 2 apps to space 'dev'"))
+		})
+		It("Should add key-value fields to records", func() {
+
+			appLogger := logger.With("app", "app1")
+			appLogger.Info("starting", "instances", 2, fmt.Errorf("dangling"))
+			Expect(len(records)).To(Equal(1))
+			Expect(records[0].Fields).To(Equal([]cfapi.LogField{
+				{Key: "app", Value: "app1"},
+				{Key: "instances", Value: 2},
+				{Key: "dangling", Value: "!MISSING"},
+			}))
+		})
+	})
+
+	Context("Log sinks", func() {
+
+		It("Should write records as JSON", func() {
+
+			out := bytes.Buffer{}
+			logger.SetSink(cfapi.NewJSONLogSink(&out))
+			logger.Warn("route not found", "host", "app1", "error", fmt.Errorf("not found"))
+
+			entry := make(map[string]interface{})
+			Expect(json.Unmarshal(out.Bytes(), &entry)).To(Succeed())
+			Expect(entry["level"]).To(Equal("warn"))
+			Expect(entry["msg"]).To(Equal("route not found"))
+			Expect(entry["host"]).To(Equal("app1"))
+			Expect(entry["error"]).To(Equal("not found"))
+		})
+		It("Should write records as text", func() {
+
+			out := bytes.Buffer{}
+			logger.SetSink(cfapi.NewTextLogSink(&out))
+			logger.Info("bound route", "route", "app1.example.com", "path", "/a b")
+			Expect(out.String()).To(ContainSubstring("INFO: bound route route=app1.example.com path=\"/a b\""))
+		})
+	})
+})
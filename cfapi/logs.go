@@ -0,0 +1,210 @@
+package cfapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogPollingInterval - Time to wait between reads of the
+// log cache while streaming the logs of an app
+var LogPollingInterval = time.Second
+
+// LogCacheReadLimit - Maximum number of envelopes
+// read from the log cache at once
+var LogCacheReadLimit = 1000
+
+type logCacheReadResource struct {
+	Envelopes struct {
+		Batch []logEnvelopeResource `json:"batch"`
+	} `json:"envelopes"`
+}
+
+type logEnvelopeResource struct {
+	Timestamp  string            `json:"timestamp"`
+	SourceID   string            `json:"source_id"`
+	InstanceID string            `json:"instance_id"`
+	Tags       map[string]string `json:"tags"`
+	Log        *struct {
+		Payload []byte `json:"payload"`
+		Type    string `json:"type"`
+	} `json:"log"`
+}
+
+// ToModel -
+func (r logEnvelopeResource) ToModel() AppLog {
+	ns, _ := strconv.ParseInt(r.Timestamp, 10, 64)
+	return AppLog{
+		Timestamp:  time.Unix(0, ns).UTC(),
+		SourceType: r.Tags["source_type"],
+		InstanceID: r.InstanceID,
+		Message:    strings.TrimRight(string(r.Log.Payload), "\r\n"),
+		IsError:    r.Log.Type == "ERR",
+	}
+}
+
+// GetRecentLogs - Returns up to the given number of the most recent logs
+// of an app in the order they were logged. The logs are read from the log
+// cache of the foundation.
+func (s *CfCliSession) GetRecentLogs(appGUID string, limit int) ([]AppLog, error) {
+
+	if limit <= 0 || limit > LogCacheReadLimit {
+		limit = LogCacheReadLimit
+	}
+	query := url.Values{}
+	query.Set("descending", "true")
+	query.Set("limit", strconv.Itoa(limit))
+
+	logs, err := s.readLogCache(appGUID, query)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(logs)-1; i < j; i, j = i+1, j-1 {
+		logs[i], logs[j] = logs[j], logs[i]
+	}
+	return logs, nil
+}
+
+// StreamLogs - Streams the logs of an app logged from now on until the
+// given stop channel is closed. The log cache is polled for new logs
+// every polling interval. Both returned channels are closed once
+// streaming stopped. An error ends streaming.
+func (s *CfCliSession) StreamLogs(appGUID string, stop <-chan struct{}) (<-chan AppLog, <-chan error) {
+
+	logs := make(chan AppLog, 100)
+	errs := make(chan error, 1)
+
+	// Logs are read from the timestamp of the last log streamed as a
+	// read may have returned only some of the logs logged at the same
+	// nanosecond. The logs at that timestamp which have already been
+	// streamed are counted so that they are skipped when read again.
+	start := time.Now().UnixNano()
+	streamed := make(map[string]int)
+	limit := LogCacheReadLimit
+
+	go func() {
+		defer close(errs)
+		defer close(logs)
+
+		for {
+			query := url.Values{}
+			query.Set("start_time", strconv.FormatInt(start, 10))
+			query.Set("limit", strconv.Itoa(limit))
+
+			batch, err := s.readLogCache(appGUID, query)
+			if err != nil {
+				errs <- err
+				return
+			}
+			skip := make(map[string]int)
+			for k, n := range streamed {
+				skip[k] = n
+			}
+			sent := 0
+			for _, l := range batch {
+				timestamp, key := l.Timestamp.UnixNano(), logKey(l)
+				if timestamp == start && skip[key] > 0 {
+					skip[key]--
+					continue
+				}
+				select {
+				case logs <- l:
+				case <-stop:
+					return
+				}
+				if timestamp != start {
+					start, streamed = timestamp, make(map[string]int)
+				}
+				streamed[key]++
+				sent++
+			}
+			if sent == 0 && len(batch) == limit {
+				// More logs were logged at the same nanosecond than a
+				// single read returns so the remaining ones are skipped
+				start, streamed = start+1, make(map[string]int)
+			}
+			if len(batch) < limit {
+				select {
+				case <-stop:
+					return
+				case <-time.After(LogPollingInterval):
+				}
+			}
+		}
+	}()
+	return logs, errs
+}
+
+// logKey - Identifies a log among the logs logged at the same time
+func logKey(l AppLog) string {
+	return fmt.Sprintf("%s/%s/%t/%s", l.SourceType, l.InstanceID, l.IsError, l.Message)
+}
+
+// readLogCache - Reads the log envelopes of an app from the log cache
+// selected by the given query. The access token is refreshed once if
+// the log cache rejects it.
+func (s *CfCliSession) readLogCache(appGUID string, query url.Values) ([]AppLog, error) {
+
+	info, err := s.GetAPIInfo()
+	if err != nil {
+		return nil, err
+	}
+	if len(info.LogCacheURL) == 0 {
+		return nil, fmt.Errorf("Unable to read the logs of app with GUID '%s' as the foundation does not advertise a log cache endpoint.", appGUID)
+	}
+	query.Set("envelope_types", "LOG")
+	endpoint := fmt.Sprintf("%s/api/v1/read/%s?%s", strings.TrimRight(info.LogCacheURL, "/"), appGUID, query.Encode())
+
+	request, err := s.ccGateway.NewRequest("GET", endpoint, s.config.AccessToken(), nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := s.httpClient.Do(request.HTTPReq)
+	if err == nil && response.StatusCode == http.StatusUnauthorized {
+		response.Body.Close()
+
+		var token string
+		if token, err = s.uaa.RefreshAuthToken(); err != nil {
+			return nil, err
+		}
+		request.HTTPReq.Header.Set("Authorization", token)
+		response, err = s.httpClient.Do(request.HTTPReq)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return nil, fmt.Errorf("Unable to read the logs of app with GUID '%s' as the log cache responded with status '%s'.",
+			appGUID, response.Status)
+	}
+	resource := logCacheReadResource{}
+	if err = json.NewDecoder(response.Body).Decode(&resource); err != nil {
+		return nil, fmt.Errorf("Unable to parse the logs of app with GUID '%s': %s", appGUID, err.Error())
+	}
+
+	logs := []AppLog{}
+	for _, e := range resource.Envelopes.Batch {
+		if e.Log != nil {
+			logs = append(logs, e.ToModel())
+		}
+	}
+	return logs, nil
+}
+
+// logCacheURLFromDoppler - Derives the log cache endpoint from the doppler
+// endpoint of a foundation whose API root does not advertise it. Both are
+// served from the system domain of the foundation.
+func logCacheURLFromDoppler(dopplerURL string) string {
+
+	u, err := url.Parse(dopplerURL)
+	if err != nil || !strings.HasPrefix(u.Hostname(), "doppler.") {
+		return ""
+	}
+	return "https://log-cache." + strings.TrimPrefix(u.Hostname(), "doppler.")
+}
@@ -0,0 +1,53 @@
+package mock_test
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/cli/cf/api"
+	"code.cloudfoundry.org/cli/cf/models"
+)
+
+type FakeRoutingAPIRepository struct {
+	ListRouterGroupsStub        func(cb func(models.RouterGroup) bool) (apiErr error)
+	listRouterGroupsMutex       sync.RWMutex
+	listRouterGroupsArgsForCall []struct {
+		cb func(models.RouterGroup) bool
+	}
+	listRouterGroupsReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeRoutingAPIRepository) ListRouterGroups(cb func(models.RouterGroup) bool) (apiErr error) {
+	fake.listRouterGroupsMutex.Lock()
+	fake.listRouterGroupsArgsForCall = append(fake.listRouterGroupsArgsForCall, struct {
+		cb func(models.RouterGroup) bool
+	}{cb})
+	fake.listRouterGroupsMutex.Unlock()
+	if fake.ListRouterGroupsStub != nil {
+		return fake.ListRouterGroupsStub(cb)
+	} else {
+		return fake.listRouterGroupsReturns.result1
+	}
+}
+
+func (fake *FakeRoutingAPIRepository) ListRouterGroupsCallCount() int {
+	fake.listRouterGroupsMutex.RLock()
+	defer fake.listRouterGroupsMutex.RUnlock()
+	return len(fake.listRouterGroupsArgsForCall)
+}
+
+func (fake *FakeRoutingAPIRepository) ListRouterGroupsArgsForCall(i int) func(models.RouterGroup) bool {
+	fake.listRouterGroupsMutex.RLock()
+	defer fake.listRouterGroupsMutex.RUnlock()
+	return fake.listRouterGroupsArgsForCall[i].cb
+}
+
+func (fake *FakeRoutingAPIRepository) ListRouterGroupsReturns(result1 error) {
+	fake.ListRouterGroupsStub = nil
+	fake.listRouterGroupsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ api.RoutingAPIRepository = new(FakeRoutingAPIRepository)
@@ -0,0 +1,256 @@
+package mock_test
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/cli/cf/api/securitygroups"
+	"code.cloudfoundry.org/cli/cf/models"
+)
+
+type FakeSecurityGroupRepository struct {
+	CreateStub        func(name string, rules []map[string]interface{}) error
+	createMutex       sync.RWMutex
+	createArgsForCall []struct {
+		name  string
+		rules []map[string]interface{}
+	}
+	createReturns struct {
+		result1 error
+	}
+	UpdateStub        func(guid string, rules []map[string]interface{}) error
+	updateMutex       sync.RWMutex
+	updateArgsForCall []struct {
+		guid  string
+		rules []map[string]interface{}
+	}
+	updateReturns struct {
+		result1 error
+	}
+	ReadStub        func(string) (models.SecurityGroup, error)
+	readMutex       sync.RWMutex
+	readArgsForCall []struct {
+		arg1 string
+	}
+	readReturns struct {
+		result1 models.SecurityGroup
+		result2 error
+	}
+	DeleteStub        func(string) error
+	deleteMutex       sync.RWMutex
+	deleteArgsForCall []struct {
+		arg1 string
+	}
+	deleteReturns struct {
+		result1 error
+	}
+	FindAllStub        func() ([]models.SecurityGroup, error)
+	findAllMutex       sync.RWMutex
+	findAllArgsForCall []struct{}
+	findAllReturns     struct {
+		result1 []models.SecurityGroup
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeSecurityGroupRepository) Create(name string, rules []map[string]interface{}) error {
+	var rulesCopy []map[string]interface{}
+	if rules != nil {
+		rulesCopy = make([]map[string]interface{}, len(rules))
+		copy(rulesCopy, rules)
+	}
+	fake.createMutex.Lock()
+	fake.createArgsForCall = append(fake.createArgsForCall, struct {
+		name  string
+		rules []map[string]interface{}
+	}{name, rulesCopy})
+	fake.recordInvocation("Create", []interface{}{name, rulesCopy})
+	fake.createMutex.Unlock()
+	if fake.CreateStub != nil {
+		return fake.CreateStub(name, rules)
+	} else {
+		return fake.createReturns.result1
+	}
+}
+
+func (fake *FakeSecurityGroupRepository) CreateCallCount() int {
+	fake.createMutex.RLock()
+	defer fake.createMutex.RUnlock()
+	return len(fake.createArgsForCall)
+}
+
+func (fake *FakeSecurityGroupRepository) CreateArgsForCall(i int) (string, []map[string]interface{}) {
+	fake.createMutex.RLock()
+	defer fake.createMutex.RUnlock()
+	return fake.createArgsForCall[i].name, fake.createArgsForCall[i].rules
+}
+
+func (fake *FakeSecurityGroupRepository) CreateReturns(result1 error) {
+	fake.CreateStub = nil
+	fake.createReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSecurityGroupRepository) Update(guid string, rules []map[string]interface{}) error {
+	var rulesCopy []map[string]interface{}
+	if rules != nil {
+		rulesCopy = make([]map[string]interface{}, len(rules))
+		copy(rulesCopy, rules)
+	}
+	fake.updateMutex.Lock()
+	fake.updateArgsForCall = append(fake.updateArgsForCall, struct {
+		guid  string
+		rules []map[string]interface{}
+	}{guid, rulesCopy})
+	fake.recordInvocation("Update", []interface{}{guid, rulesCopy})
+	fake.updateMutex.Unlock()
+	if fake.UpdateStub != nil {
+		return fake.UpdateStub(guid, rules)
+	} else {
+		return fake.updateReturns.result1
+	}
+}
+
+func (fake *FakeSecurityGroupRepository) UpdateCallCount() int {
+	fake.updateMutex.RLock()
+	defer fake.updateMutex.RUnlock()
+	return len(fake.updateArgsForCall)
+}
+
+func (fake *FakeSecurityGroupRepository) UpdateArgsForCall(i int) (string, []map[string]interface{}) {
+	fake.updateMutex.RLock()
+	defer fake.updateMutex.RUnlock()
+	return fake.updateArgsForCall[i].guid, fake.updateArgsForCall[i].rules
+}
+
+func (fake *FakeSecurityGroupRepository) UpdateReturns(result1 error) {
+	fake.UpdateStub = nil
+	fake.updateReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSecurityGroupRepository) Read(arg1 string) (models.SecurityGroup, error) {
+	fake.readMutex.Lock()
+	fake.readArgsForCall = append(fake.readArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("Read", []interface{}{arg1})
+	fake.readMutex.Unlock()
+	if fake.ReadStub != nil {
+		return fake.ReadStub(arg1)
+	} else {
+		return fake.readReturns.result1, fake.readReturns.result2
+	}
+}
+
+func (fake *FakeSecurityGroupRepository) ReadCallCount() int {
+	fake.readMutex.RLock()
+	defer fake.readMutex.RUnlock()
+	return len(fake.readArgsForCall)
+}
+
+func (fake *FakeSecurityGroupRepository) ReadArgsForCall(i int) string {
+	fake.readMutex.RLock()
+	defer fake.readMutex.RUnlock()
+	return fake.readArgsForCall[i].arg1
+}
+
+func (fake *FakeSecurityGroupRepository) ReadReturns(result1 models.SecurityGroup, result2 error) {
+	fake.ReadStub = nil
+	fake.readReturns = struct {
+		result1 models.SecurityGroup
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSecurityGroupRepository) Delete(arg1 string) error {
+	fake.deleteMutex.Lock()
+	fake.deleteArgsForCall = append(fake.deleteArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("Delete", []interface{}{arg1})
+	fake.deleteMutex.Unlock()
+	if fake.DeleteStub != nil {
+		return fake.DeleteStub(arg1)
+	} else {
+		return fake.deleteReturns.result1
+	}
+}
+
+func (fake *FakeSecurityGroupRepository) DeleteCallCount() int {
+	fake.deleteMutex.RLock()
+	defer fake.deleteMutex.RUnlock()
+	return len(fake.deleteArgsForCall)
+}
+
+func (fake *FakeSecurityGroupRepository) DeleteArgsForCall(i int) string {
+	fake.deleteMutex.RLock()
+	defer fake.deleteMutex.RUnlock()
+	return fake.deleteArgsForCall[i].arg1
+}
+
+func (fake *FakeSecurityGroupRepository) DeleteReturns(result1 error) {
+	fake.DeleteStub = nil
+	fake.deleteReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSecurityGroupRepository) FindAll() ([]models.SecurityGroup, error) {
+	fake.findAllMutex.Lock()
+	fake.findAllArgsForCall = append(fake.findAllArgsForCall, struct{}{})
+	fake.recordInvocation("FindAll", []interface{}{})
+	fake.findAllMutex.Unlock()
+	if fake.FindAllStub != nil {
+		return fake.FindAllStub()
+	} else {
+		return fake.findAllReturns.result1, fake.findAllReturns.result2
+	}
+}
+
+func (fake *FakeSecurityGroupRepository) FindAllCallCount() int {
+	fake.findAllMutex.RLock()
+	defer fake.findAllMutex.RUnlock()
+	return len(fake.findAllArgsForCall)
+}
+
+func (fake *FakeSecurityGroupRepository) FindAllReturns(result1 []models.SecurityGroup, result2 error) {
+	fake.FindAllStub = nil
+	fake.findAllReturns = struct {
+		result1 []models.SecurityGroup
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSecurityGroupRepository) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.createMutex.RLock()
+	defer fake.createMutex.RUnlock()
+	fake.updateMutex.RLock()
+	defer fake.updateMutex.RUnlock()
+	fake.readMutex.RLock()
+	defer fake.readMutex.RUnlock()
+	fake.deleteMutex.RLock()
+	defer fake.deleteMutex.RUnlock()
+	fake.findAllMutex.RLock()
+	defer fake.findAllMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeSecurityGroupRepository) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ securitygroups.SecurityGroupRepo = new(FakeSecurityGroupRepository)
@@ -0,0 +1,325 @@
+package mock_test
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"code.cloudfoundry.org/cli/cf/errors"
+	"code.cloudfoundry.org/cli/cf/models"
+	"github.com/mevansam/cf-cli-api/cfapi"
+)
+
+// AddStack -
+func (s *MemoryState) AddStack(name, description string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stack := &memoryStack{
+		seq: s.nextSeq(),
+		fields: models.Stack{
+			GUID:        s.newGUID("stack"),
+			Name:        name,
+			Description: description,
+		},
+	}
+	s.stacks[stack.fields.GUID] = stack
+	return stack.fields.GUID
+}
+
+// BuildpackBits -
+func (s *MemoryState) BuildpackBits(buildpackGUID string) ([]byte, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if bp, ok := s.buildpacks[buildpackGUID]; ok && bp.bits != nil {
+		return bp.bits, true
+	}
+	return nil, false
+}
+
+// buildpackRepository - Keeps the positions of the buildpacks
+// contiguous as the Cloud Controller does. Buildpacks created
+// or moved to a position shift the buildpacks after it.
+func (s *MemoryState) buildpackRepository() *FakeBuildpackRepository {
+
+	find := func(match func(models.Buildpack) bool) []models.Buildpack {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		result := []models.Buildpack{}
+		for _, bp := range s.sortedBuildpacks() {
+			if match(bp.fields) {
+				result = append(result, s.buildpackFields(bp))
+			}
+		}
+		return result
+	}
+
+	return &FakeBuildpackRepository{
+		FindByNameStub: func(name string) (models.Buildpack, error) {
+			found := find(func(bp models.Buildpack) bool { return bp.Name == name })
+			switch len(found) {
+			case 0:
+				return models.Buildpack{}, errors.NewModelNotFoundError("Buildpack", name)
+			case 1:
+				return found[0], nil
+			}
+			return models.Buildpack{}, errors.NewAmbiguousModelError("Buildpack", name)
+		},
+		FindByNameAndStackStub: func(name, stack string) (models.Buildpack, error) {
+			found := find(func(bp models.Buildpack) bool { return bp.Name == name && bp.Stack == stack })
+			if len(found) == 0 {
+				return models.Buildpack{}, errors.NewModelNotFoundError("Buildpack", name)
+			}
+			return found[0], nil
+		},
+		FindByNameWithNilStackStub: func(name string) (models.Buildpack, error) {
+			found := find(func(bp models.Buildpack) bool { return bp.Name == name && len(bp.Stack) == 0 })
+			if len(found) == 0 {
+				return models.Buildpack{}, errors.NewModelNotFoundError("Buildpack", name)
+			}
+			return found[0], nil
+		},
+		ListBuildpacksStub: func(cb func(models.Buildpack) bool) error {
+			for _, bp := range find(func(models.Buildpack) bool { return true }) {
+				if !cb(bp) {
+					break
+				}
+			}
+			return nil
+		},
+		CreateStub: func(name string, position *int, enabled *bool, locked *bool) (models.Buildpack, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if s.buildpackNameTaken(name, "", "") {
+				return models.Buildpack{}, errors.NewHTTPError(400, "290001",
+					fmt.Sprintf("The buildpack name is already in use: %s", name))
+			}
+			bp := &memoryBuildpack{
+				seq: s.nextSeq(),
+				fields: models.Buildpack{
+					GUID:     s.newGUID("buildpack"),
+					Name:     name,
+					Position: new(int),
+					Enabled:  boolValue(enabled, true),
+					Locked:   boolValue(locked, false),
+				},
+			}
+			s.buildpacks[bp.fields.GUID] = bp
+			s.moveBuildpack(bp, position)
+			return s.buildpackFields(bp), nil
+		},
+		UpdateStub: func(buildpack models.Buildpack) (models.Buildpack, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			bp, err := s.buildpack(buildpack.GUID)
+			if err != nil {
+				return models.Buildpack{}, err
+			}
+			if len(buildpack.Name) > 0 && buildpack.Name != bp.fields.Name {
+				if s.buildpackNameTaken(buildpack.Name, bp.fields.Stack, bp.fields.GUID) {
+					return models.Buildpack{}, errors.NewHTTPError(400, "290001",
+						fmt.Sprintf("The buildpack name is already in use: %s", buildpack.Name))
+				}
+				bp.fields.Name = buildpack.Name
+			}
+			if buildpack.Enabled != nil {
+				bp.fields.Enabled = boolValue(buildpack.Enabled, true)
+			}
+			if buildpack.Locked != nil {
+				bp.fields.Locked = boolValue(buildpack.Locked, false)
+			}
+			if buildpack.Position != nil {
+				s.moveBuildpack(bp, buildpack.Position)
+			}
+			return s.buildpackFields(bp), nil
+		},
+		DeleteStub: func(buildpackGUID string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			bp, err := s.buildpack(buildpackGUID)
+			if err != nil {
+				return err
+			}
+			delete(s.buildpacks, buildpackGUID)
+			for _, other := range s.buildpacks {
+				if *other.fields.Position > *bp.fields.Position {
+					*other.fields.Position--
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// stackRepository -
+func (s *MemoryState) stackRepository() *FakeStackRepository {
+
+	return &FakeStackRepository{
+		FindByNameStub: func(name string) (models.Stack, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			for _, st := range s.sortedStacks() {
+				if st.fields.Name == name {
+					return st.fields, nil
+				}
+			}
+			return models.Stack{}, errors.NewModelNotFoundError("Stack", name)
+		},
+		FindByGUIDStub: func(guid string) (models.Stack, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			st, ok := s.stacks[guid]
+			if !ok {
+				return models.Stack{}, errors.NewHTTPError(404, "250003",
+					fmt.Sprintf("The stack could not be found: %s", guid))
+			}
+			return st.fields, nil
+		},
+		FindAllStub: func() ([]models.Stack, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			result := []models.Stack{}
+			for _, st := range s.sortedStacks() {
+				result = append(result, st.fields)
+			}
+			return result, nil
+		},
+	}
+}
+
+// buildpacksSession - Backs the buildpack upload of the given session by the state
+func (s *MemoryState) buildpacksSession(session *MockSession) {
+
+	session.MockUploadBuildpack = func(buildpackGUID string, buildpack *os.File) error {
+		if _, err := buildpack.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		content, err := ioutil.ReadAll(buildpack)
+		if err != nil {
+			return err
+		}
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		bp, err := s.buildpack(buildpackGUID)
+		if err != nil {
+			return err
+		}
+		if *bp.fields.Locked {
+			return errors.NewHTTPError(400, "290003", "The buildpack is locked")
+		}
+		filename := filepath.Base(buildpack.Name())
+		if !strings.HasSuffix(strings.ToLower(filename), ".zip") {
+			filename += ".zip"
+		}
+		bp.bits = content
+		bp.fields.Filename = filename
+		bp.fields.Key = fmt.Sprintf("%s_%x", bp.fields.GUID, sha1.Sum(content))
+
+		reportProgress(session, cfapi.ProgressUploadBuildpack, buildpackGUID, len(content))
+		return nil
+	}
+}
+
+// boolValue - Returns a pointer to the given value or to the default if it is nil
+func boolValue(value *bool, def bool) *bool {
+	if value != nil {
+		def = *value
+	}
+	return &def
+}
+
+// Helpers. The mutex must be held when calling the following.
+
+func (s *MemoryState) buildpack(guid string) (*memoryBuildpack, error) {
+	bp, ok := s.buildpacks[guid]
+	if !ok {
+		return nil, errors.NewHTTPError(404, "10000", fmt.Sprintf("The buildpack could not be found: %s", guid))
+	}
+	return bp, nil
+}
+
+func (s *MemoryState) buildpackNameTaken(name, stack, exceptGUID string) bool {
+	for _, bp := range s.buildpacks {
+		if bp.fields.GUID != exceptGUID && strings.EqualFold(bp.fields.Name, name) && bp.fields.Stack == stack {
+			return true
+		}
+	}
+	return false
+}
+
+// moveBuildpack - Moves a buildpack to the given position which is
+// clamped to the positions taken. A nil position moves it to the end.
+func (s *MemoryState) moveBuildpack(bp *memoryBuildpack, position *int) {
+
+	others := []*memoryBuildpack{}
+	for _, other := range s.sortedBuildpacks() {
+		if other != bp {
+			others = append(others, other)
+		}
+	}
+	to := len(others) + 1
+	if position != nil && *position < to {
+		to = *position
+	}
+	if to < 1 {
+		to = 1
+	}
+
+	*bp.fields.Position = to
+	for i, other := range others {
+		if i+1 < to {
+			*other.fields.Position = i + 1
+		} else {
+			*other.fields.Position = i + 2
+		}
+	}
+}
+
+// sortedBuildpacks - Returns the buildpacks ordered by their position
+func (s *MemoryState) sortedBuildpacks() []*memoryBuildpack {
+	buildpacks := []*memoryBuildpack{}
+	for _, bp := range s.buildpacks {
+		buildpacks = append(buildpacks, bp)
+	}
+	sort.Slice(buildpacks, func(i, j int) bool {
+		if *buildpacks[i].fields.Position != *buildpacks[j].fields.Position {
+			return *buildpacks[i].fields.Position < *buildpacks[j].fields.Position
+		}
+		return buildpacks[i].seq < buildpacks[j].seq
+	})
+	return buildpacks
+}
+
+func (s *MemoryState) sortedStacks() []*memoryStack {
+	stacks := []*memoryStack{}
+	for _, st := range s.stacks {
+		stacks = append(stacks, st)
+	}
+	sort.Slice(stacks, func(i, j int) bool { return stacks[i].seq < stacks[j].seq })
+	return stacks
+}
+
+// buildpackFields - Returns a copy of the fields of a buildpack
+// which does not share the pointers held by the state
+func (s *MemoryState) buildpackFields(bp *memoryBuildpack) models.Buildpack {
+	fields := bp.fields
+	position := *bp.fields.Position
+	fields.Position = &position
+	fields.Enabled = boolValue(bp.fields.Enabled, true)
+	fields.Locked = boolValue(bp.fields.Locked, false)
+	return fields
+}
@@ -0,0 +1,161 @@
+package mock_test
+
+import (
+	"fmt"
+	"sort"
+
+	"code.cloudfoundry.org/cli/cf/errors"
+	"code.cloudfoundry.org/cli/cf/models"
+)
+
+// AddRouterGroup - Adds a router group of the given type i.e. "tcp" or "http"
+func (s *MemoryState) AddRouterGroup(name, groupType string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	group := &memoryRouterGroup{
+		seq: s.nextSeq(),
+		fields: models.RouterGroup{
+			GUID: s.newGUID("router-group"),
+			Name: name,
+			Type: groupType,
+		},
+	}
+	s.routerGroups[group.fields.GUID] = group
+	return group.fields.GUID
+}
+
+// routerGroupRepository -
+func (s *MemoryState) routerGroupRepository() *FakeRoutingAPIRepository {
+
+	return &FakeRoutingAPIRepository{
+		ListRouterGroupsStub: func(cb func(models.RouterGroup) bool) error {
+			s.mutex.Lock()
+			groups := []models.RouterGroup{}
+			for _, g := range s.sortedRouterGroups() {
+				groups = append(groups, g.fields)
+			}
+			s.mutex.Unlock()
+
+			for _, g := range groups {
+				if !cb(g) {
+					break
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// domainsSession - Backs the domain management functions of the given session by the state
+func (s *MemoryState) domainsSession(session *MockSession) {
+
+	session.MockCreatePrivateDomain = func(orgGUID, name string) (models.DomainFields, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if _, ok := s.orgs[orgGUID]; !ok {
+			return models.DomainFields{}, errors.NewHTTPError(404, "30003",
+				fmt.Sprintf("The organization could not be found: %s", orgGUID))
+		}
+		if s.findDomain(name) != nil {
+			return models.DomainFields{}, errors.NewHTTPError(400, "130003",
+				fmt.Sprintf("The domain name is taken: %s", name))
+		}
+		return s.addDomain(name, orgGUID).fields, nil
+	}
+
+	session.MockCreateSharedDomain = func(name, routerGroupName string) (models.DomainFields, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		var group *memoryRouterGroup
+		if len(routerGroupName) > 0 {
+			for _, g := range s.routerGroups {
+				if g.fields.Name == routerGroupName {
+					group = g
+				}
+			}
+			if group == nil {
+				return models.DomainFields{}, fmt.Errorf("Unable to create shared domain '%s' as router group '%s' does not exist.",
+					name, routerGroupName)
+			}
+		}
+		if s.findDomain(name) != nil {
+			return models.DomainFields{}, errors.NewHTTPError(400, "130003",
+				fmt.Sprintf("The domain name is taken: %s", name))
+		}
+		domain := s.addDomain(name, "")
+		if group != nil {
+			domain.fields.RouterGroupGUID = group.fields.GUID
+			domain.fields.RouterGroupType = group.fields.Type
+		}
+		return domain.fields, nil
+	}
+
+	session.MockDeleteDomain = func(domain models.DomainFields) error {
+		return s.deleteDomain(domain.GUID)
+	}
+
+	session.MockGetPrivateDomainSharedOrgs = func(domainGUID string) ([]models.OrganizationFields, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		d, err := s.privateDomain(domainGUID)
+		if err != nil {
+			return nil, err
+		}
+		orgs := []*memoryOrg{}
+		for orgGUID := range d.sharedWith {
+			if o, ok := s.orgs[orgGUID]; ok {
+				orgs = append(orgs, o)
+			}
+		}
+		sort.Slice(orgs, func(i, j int) bool { return orgs[i].seq < orgs[j].seq })
+
+		fields := []models.OrganizationFields{}
+		for _, o := range orgs {
+			fields = append(fields, o.fields)
+		}
+		return fields, nil
+	}
+
+	session.MockSetPrivateDomainSharedOrgs = func(domainGUID string, orgGUIDs []string) error {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		d, err := s.privateDomain(domainGUID)
+		if err != nil {
+			return err
+		}
+		sharedWith := make(map[string]bool)
+		for _, orgGUID := range orgGUIDs {
+			if _, ok := s.orgs[orgGUID]; !ok {
+				return errors.NewHTTPError(404, "30003",
+					fmt.Sprintf("The organization could not be found: %s", orgGUID))
+			}
+			sharedWith[orgGUID] = true
+		}
+		d.sharedWith = sharedWith
+		return nil
+	}
+}
+
+// Helpers. The mutex must be held when calling the following.
+
+func (s *MemoryState) privateDomain(guid string) (*memoryDomain, error) {
+	d, ok := s.domains[guid]
+	if !ok || d.fields.Shared {
+		return nil, errors.NewHTTPError(404, "130002", fmt.Sprintf("The domain could not be found: %s", guid))
+	}
+	return d, nil
+}
+
+func (s *MemoryState) sortedRouterGroups() []*memoryRouterGroup {
+	groups := []*memoryRouterGroup{}
+	for _, g := range s.routerGroups {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].seq < groups[j].seq })
+	return groups
+}
@@ -0,0 +1,167 @@
+package mock_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"code.cloudfoundry.org/cli/cf/errors"
+	"code.cloudfoundry.org/cli/cf/models"
+	"github.com/mevansam/cf-cli-api/cfapi"
+)
+
+// defaultFeatureFlags - The feature flags of a new foundation
+func defaultFeatureFlags() map[string]bool {
+	return map[string]bool{
+		"user_org_creation":                    false,
+		"private_domain_creation":              true,
+		"app_bits_upload":                      true,
+		"app_scaling":                          true,
+		"route_creation":                       true,
+		"service_instance_creation":            true,
+		"diego_docker":                         false,
+		"set_roles_by_username":                true,
+		"unset_roles_by_username":              true,
+		"task_creation":                        true,
+		"env_var_visibility":                   true,
+		"space_scoped_private_broker_creation": true,
+		"space_developer_env_var_visibility":   true,
+		"service_instance_sharing":             false,
+	}
+}
+
+// SetFeatureFlag -
+func (s *MemoryState) SetFeatureFlag(name string, enabled bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.featureFlags[name] = enabled
+}
+
+// SetEnvironmentVariableGroup - Sets the variables of the "running"
+// or the "staging" environment variable group
+func (s *MemoryState) SetEnvironmentVariableGroup(group string, variables map[string]interface{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.envGroups[group] = copyEnvGroup(variables)
+}
+
+// featureFlagRepository -
+func (s *MemoryState) featureFlagRepository() *FakeFeatureFlagRepository {
+	return &FakeFeatureFlagRepository{
+		ListStub: func() ([]models.FeatureFlag, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			flags := []models.FeatureFlag{}
+			for name, enabled := range s.featureFlags {
+				flags = append(flags, models.FeatureFlag{Name: name, Enabled: enabled})
+			}
+			sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+			return flags, nil
+		},
+		FindByNameStub: func(name string) (models.FeatureFlag, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			enabled, ok := s.featureFlags[name]
+			if !ok {
+				return models.FeatureFlag{}, featureFlagNotFound(name)
+			}
+			return models.FeatureFlag{Name: name, Enabled: enabled}, nil
+		},
+		UpdateStub: func(name string, enabled bool) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if _, ok := s.featureFlags[name]; !ok {
+				return featureFlagNotFound(name)
+			}
+			s.featureFlags[name] = enabled
+			return nil
+		},
+	}
+}
+
+// environmentVariableGroupsRepository - Lists the variables of the
+// groups as strings the way the CLI backed repository does
+func (s *MemoryState) environmentVariableGroupsRepository() *FakeEnvironmentVariableGroupsRepository {
+
+	list := func(group string) ([]models.EnvironmentVariable, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		var variables []models.EnvironmentVariable
+		for name, value := range s.envGroups[group] {
+			var v string
+			switch value := value.(type) {
+			case string:
+				v = value
+			case float64:
+				v = fmt.Sprintf("%d", int(value))
+			default:
+				return nil, fmt.Errorf("Attempted to read environment variable value of unknown type: %#v", value)
+			}
+			variables = append(variables, models.EnvironmentVariable{Name: name, Value: v})
+		}
+		return variables, nil
+	}
+	set := func(group, data string) error {
+		variables := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(data), &variables); err != nil {
+			return errors.NewHTTPError(400, "1001", fmt.Sprintf("Request invalid due to parse error: %s", err.Error()))
+		}
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		s.envGroups[group] = variables
+		return nil
+	}
+
+	return &FakeEnvironmentVariableGroupsRepository{
+		ListRunningStub: func() ([]models.EnvironmentVariable, error) {
+			return list("running")
+		},
+		ListStagingStub: func() ([]models.EnvironmentVariable, error) {
+			return list("staging")
+		},
+		SetRunningStub: func(data string) error {
+			return set("running", data)
+		},
+		SetStagingStub: func(data string) error {
+			return set("staging", data)
+		},
+	}
+}
+
+// foundationSettingsSession - Backs the foundation settings snapshot of the given session by the state
+func (s *MemoryState) foundationSettingsSession(session *MockSession) {
+
+	session.MockSnapshotFoundationSettings = func() (cfapi.FoundationSettings, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		settings := cfapi.FoundationSettings{
+			FeatureFlags:    make(map[string]bool),
+			RunningEnvGroup: copyEnvGroup(s.envGroups["running"]),
+			StagingEnvGroup: copyEnvGroup(s.envGroups["staging"]),
+		}
+		for name, enabled := range s.featureFlags {
+			settings.FeatureFlags[name] = enabled
+		}
+		return settings, nil
+	}
+}
+
+// featureFlagNotFound -
+func featureFlagNotFound(name string) error {
+	return errors.NewHTTPError(404, "330000", fmt.Sprintf("The feature flag could not be found: %s", name))
+}
+
+// copyEnvGroup - Returns a deep copy of the variables of a group whose
+// values are of the types they have when decoded from JSON
+func copyEnvGroup(variables map[string]interface{}) map[string]interface{} {
+	copied := map[string]interface{}{}
+	data, _ := json.Marshal(variables)
+	json.Unmarshal(data, &copied)
+	return copied
+}
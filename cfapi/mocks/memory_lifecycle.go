@@ -0,0 +1,78 @@
+package mock_test
+
+import (
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/cf/errors"
+	"code.cloudfoundry.org/cli/cf/models"
+	"github.com/mevansam/cf-cli-api/cfapi"
+)
+
+// lifecycleSession - Backs the app lifecycle functions of the given
+// session by its repositories. Staging completes synchronously so
+// waiting only reports the outcome of staging and of the instances.
+func (s *MemoryState) lifecycleSession(session *MockSession) {
+
+	session.MockStartApp = func(appGUID string, timeout time.Duration) ([]cfapi.AppInstance, error) {
+		state := cfapi.AppStarted
+		if _, err := session.Applications().Update(appGUID, models.AppParams{State: &state}); err != nil {
+			return nil, err
+		}
+		return s.waitForApp(session, appGUID, timeout)
+	}
+	session.MockStopApp = func(appGUID string, timeout time.Duration) error {
+		state := cfapi.AppStopped
+		_, err := session.Applications().Update(appGUID, models.AppParams{State: &state})
+		return err
+	}
+	session.MockRestartApp = func(appGUID string, timeout time.Duration) ([]cfapi.AppInstance, error) {
+		if err := session.StopApp(appGUID, timeout); err != nil {
+			return nil, err
+		}
+		return session.StartApp(appGUID, timeout)
+	}
+	session.MockRestageApp = func(appGUID string, timeout time.Duration) ([]cfapi.AppInstance, error) {
+		if err := session.Applications().CreateRestageRequest(appGUID); err != nil {
+			return nil, err
+		}
+		return s.waitForApp(session, appGUID, timeout)
+	}
+	session.MockScaleApp = func(appGUID string, scale cfapi.AppScale, timeout time.Duration) ([]cfapi.AppInstance, error) {
+		app, err := session.Applications().Update(appGUID, models.AppParams{
+			InstanceCount: scale.Instances,
+			Memory:        scale.MemoryInMB,
+			DiskQuota:     scale.DiskInMB,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(app.State, models.ApplicationStateStarted) {
+			return []cfapi.AppInstance{}, nil
+		}
+		return s.waitForApp(session, appGUID, timeout)
+	}
+}
+
+// waitForApp - Returns the instances of a started app once
+// they are running or the details of its failed staging
+func (s *MemoryState) waitForApp(session *MockSession, appGUID string, timeout time.Duration) ([]cfapi.AppInstance, error) {
+
+	s.mutex.Lock()
+	a, ok := s.apps[appGUID]
+	if !ok {
+		s.mutex.Unlock()
+		return nil, errors.NewModelNotFoundError("App", appGUID)
+	}
+	fields, description := a.fields, a.stagingFailedDescription
+	s.mutex.Unlock()
+
+	if fields.PackageState == cfapi.PackageFailed {
+		return nil, &cfapi.AppStagingFailedError{
+			AppGUID:     appGUID,
+			Reason:      fields.StagingFailedReason,
+			Description: description,
+		}
+	}
+	return session.WaitForAppInstances(appGUID, fields.InstanceCount, timeout)
+}
@@ -0,0 +1,83 @@
+package mock_test
+
+import (
+	"time"
+
+	"github.com/mevansam/cf-cli-api/cfapi"
+)
+
+// AddAppLog - Adds a line to the logs of an app. A zero
+// timestamp of the line is replaced by the current time.
+func (s *MemoryState) AddAppLog(appGUID string, log cfapi.AppLog) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.addLog(appGUID, log)
+}
+
+// addLog - Logs a line for an app keeping the logs of
+// each app ordered by unique increasing timestamps
+func (s *MemoryState) addLog(appGUID string, log cfapi.AppLog) {
+
+	if log.Timestamp.IsZero() {
+		log.Timestamp = time.Now().UTC()
+	}
+	if logs := s.logs[appGUID]; len(logs) > 0 && !log.Timestamp.After(logs[len(logs)-1].Timestamp) {
+		log.Timestamp = logs[len(logs)-1].Timestamp.Add(time.Nanosecond)
+	}
+	s.logs[appGUID] = append(s.logs[appGUID], log)
+}
+
+// logsAfter - Returns the logs of an app logged at or after the given time
+func (s *MemoryState) logsAfter(appGUID string, from time.Time) []cfapi.AppLog {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	logs := []cfapi.AppLog{}
+	for _, l := range s.logs[appGUID] {
+		if !l.Timestamp.Before(from) {
+			logs = append(logs, l)
+		}
+	}
+	return logs
+}
+
+// logsSession - Backs the log functions of the given session by
+// the logs of the state. Streaming polls the state for new logs.
+func (s *MemoryState) logsSession(session *MockSession) {
+
+	session.MockGetRecentLogs = func(appGUID string, limit int) ([]cfapi.AppLog, error) {
+		logs := s.logsAfter(appGUID, time.Time{})
+		if limit > 0 && len(logs) > limit {
+			logs = logs[len(logs)-limit:]
+		}
+		return logs, nil
+	}
+	session.MockStreamLogs = func(appGUID string, stop <-chan struct{}) (<-chan cfapi.AppLog, <-chan error) {
+
+		logs := make(chan cfapi.AppLog, 100)
+		errs := make(chan error, 1)
+
+		from := time.Now().UTC()
+		go func() {
+			defer close(errs)
+			defer close(logs)
+
+			for {
+				for _, l := range s.logsAfter(appGUID, from) {
+					select {
+					case logs <- l:
+					case <-stop:
+						return
+					}
+					from = l.Timestamp.Add(time.Nanosecond)
+				}
+				select {
+				case <-stop:
+					return
+				case <-time.After(cfapi.LogPollingInterval):
+				}
+			}
+		}()
+		return logs, errs
+	}
+}
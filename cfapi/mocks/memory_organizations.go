@@ -0,0 +1,222 @@
+package mock_test
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/cli/cf/errors"
+	"code.cloudfoundry.org/cli/cf/models"
+	"github.com/mevansam/cf-cli-api/cfapi"
+)
+
+// AddIsolationSegment -
+func (s *MemoryState) AddIsolationSegment(name string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	segment := &memoryIsolationSegment{
+		seq:  s.nextSeq(),
+		guid: s.newGUID("isolation-segment"),
+		name: name,
+		orgs: make(map[string]bool),
+	}
+	s.isolationSegments[segment.guid] = segment
+	return segment.guid
+}
+
+// orgsSession - Backs the org and space lifecycle functions of the given session by the state
+func (s *MemoryState) orgsSession(session *MockSession) {
+
+	session.MockEnsureOrg = func(name string, options cfapi.OrgOptions) (models.OrganizationFields, bool, error) {
+
+		org, created, err := func() (models.OrganizationFields, bool, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			var quota *memoryQuota
+			if len(options.QuotaName) > 0 {
+				if quota = s.findQuota(options.QuotaName); quota == nil {
+					return models.OrganizationFields{}, false, errors.NewModelNotFoundError("Quota", options.QuotaName)
+				}
+			}
+			var segment *memoryIsolationSegment
+			if len(options.IsolationSegmentName) > 0 {
+				if segment = s.findIsolationSegment(options.IsolationSegmentName); segment == nil {
+					return models.OrganizationFields{}, false,
+						errors.NewModelNotFoundError("Isolation Segment", options.IsolationSegmentName)
+				}
+			}
+
+			o, created := s.findOrg(name), false
+			if o == nil {
+				o, created = s.addOrg(name), true
+			}
+			if quota != nil {
+				o.quotaGUID = quota.fields.GUID
+			}
+			if segment != nil {
+				segment.orgs[o.fields.GUID] = true
+				o.defaultIsolationSegmentGUID = segment.guid
+			}
+			return o.fields, created, nil
+		}()
+		if err != nil {
+			return org, created, err
+		}
+		for _, r := range options.Roles {
+			if err = session.SetOrgRole(org.GUID, r.Username, r.Origin, r.Role); err != nil {
+				return org, created, err
+			}
+		}
+		return org, created, nil
+	}
+
+	session.MockEnsureSpace = func(orgGUID, name string, options cfapi.SpaceOptions) (models.SpaceFields, bool, error) {
+
+		space, created, err := func() (models.SpaceFields, bool, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if _, ok := s.orgs[orgGUID]; !ok {
+				return models.SpaceFields{}, false, errors.NewModelNotFoundError("Organization", orgGUID)
+			}
+			var quota *memorySpaceQuota
+			if len(options.SpaceQuotaName) > 0 {
+				for _, q := range s.spaceQuotas {
+					if q.fields.OrgGUID == orgGUID && q.fields.Name == options.SpaceQuotaName {
+						quota = q
+					}
+				}
+				if quota == nil {
+					return models.SpaceFields{}, false, errors.NewModelNotFoundError("Space Quota", options.SpaceQuotaName)
+				}
+			}
+
+			var segment *memoryIsolationSegment
+			if len(options.IsolationSegmentName) > 0 {
+				if segment = s.findIsolationSegment(options.IsolationSegmentName); segment == nil {
+					return models.SpaceFields{}, false,
+						errors.NewModelNotFoundError("Isolation Segment", options.IsolationSegmentName)
+				}
+			}
+
+			sp, created := s.findSpace(orgGUID, name), false
+			if sp == nil {
+				sp, created = s.addSpace(orgGUID, name), true
+			}
+			if quota != nil {
+				sp.spaceQuotaGUID = quota.fields.GUID
+			}
+			if segment != nil {
+				if !segment.orgs[orgGUID] {
+					return sp.fields, created, errors.NewHTTPError(422, "10008",
+						fmt.Sprintf("Unable to assign isolation segment with guid '%s'. Ensure it has been "+
+							"entitled to the organization that this space belongs to.", segment.guid))
+				}
+				sp.isolationSegmentGUID = segment.guid
+			}
+			return sp.fields, created, nil
+		}()
+		if err != nil {
+			return space, created, err
+		}
+		for _, r := range options.Roles {
+			if err = session.SetSpaceRole(space.GUID, r.Username, r.Origin, r.Role); err != nil {
+				return space, created, err
+			}
+		}
+		return space, created, nil
+	}
+
+	session.MockPreviewOrgDelete = func(orgGUID string) (cfapi.DeletePreview, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		preview := newDeletePreview()
+		if _, ok := s.orgs[orgGUID]; !ok {
+			return preview, errors.NewModelNotFoundError("Organization", orgGUID)
+		}
+		for _, sp := range s.orgSpaces(orgGUID) {
+			preview.Spaces = append(preview.Spaces, sp.Name)
+			s.addSpaceDeletePreview(sp.GUID, sp.Name+"/", &preview)
+		}
+		for _, d := range s.orgDomains(orgGUID) {
+			if d.OwningOrganizationGUID == orgGUID {
+				preview.PrivateDomains = append(preview.PrivateDomains, d.Name)
+			}
+		}
+		for _, q := range s.orgSpaceQuotas(orgGUID) {
+			preview.SpaceQuotas = append(preview.SpaceQuotas, q.Name)
+		}
+		return preview, nil
+	}
+
+	session.MockPreviewSpaceDelete = func(spaceGUID string) (cfapi.DeletePreview, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		preview := newDeletePreview()
+		if _, ok := s.spaces[spaceGUID]; !ok {
+			return preview, errors.NewModelNotFoundError("Space", spaceGUID)
+		}
+		s.addSpaceDeletePreview(spaceGUID, "", &preview)
+		return preview, nil
+	}
+
+	session.MockDeleteOrg = func(orgGUID string) error {
+		if err := session.Organizations().Delete(orgGUID); err != nil {
+			return err
+		}
+		if session.GetSessionOrg().GUID == orgGUID {
+			session.SetSessionOrg(models.OrganizationFields{})
+			session.SetSessionSpace(models.SpaceFields{})
+		}
+		return nil
+	}
+
+	session.MockDeleteSpace = func(spaceGUID string) error {
+		if err := session.Spaces().Delete(spaceGUID); err != nil {
+			return err
+		}
+		if session.GetSessionSpace().GUID == spaceGUID {
+			session.SetSessionSpace(models.SpaceFields{})
+		}
+		return nil
+	}
+}
+
+// Helpers. The mutex must be held when calling the following.
+
+func (s *MemoryState) findIsolationSegment(name string) *memoryIsolationSegment {
+	for _, segment := range s.isolationSegments {
+		if segment.name == name {
+			return segment
+		}
+	}
+	return nil
+}
+
+// addSpaceDeletePreview - Adds the apps, service instances and routes of a
+// space to a preview. Apps and service instances are named with the prefix.
+func (s *MemoryState) addSpaceDeletePreview(spaceGUID, prefix string, preview *cfapi.DeletePreview) {
+	for _, a := range s.spaceApps(spaceGUID) {
+		preview.Apps = append(preview.Apps, prefix+a.fields.Name)
+	}
+	for _, si := range s.spaceServiceInstances(spaceGUID) {
+		preview.ServiceInstances = append(preview.ServiceInstances, prefix+si.fields.Name)
+	}
+	for _, r := range s.spaceRoutes(spaceGUID) {
+		preview.Routes = append(preview.Routes, s.routeFields(r).URL())
+	}
+}
+
+// newDeletePreview - Returns a preview with empty lists
+func newDeletePreview() cfapi.DeletePreview {
+	return cfapi.DeletePreview{
+		Spaces:           []string{},
+		Apps:             []string{},
+		ServiceInstances: []string{},
+		Routes:           []string{},
+		PrivateDomains:   []string{},
+		SpaceQuotas:      []string{},
+	}
+}
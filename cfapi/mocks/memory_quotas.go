@@ -0,0 +1,369 @@
+package mock_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"code.cloudfoundry.org/cli/cf/errors"
+	"code.cloudfoundry.org/cli/cf/models"
+	"github.com/mevansam/cf-cli-api/cfapi"
+)
+
+// defaultQuotaGUID - GUID of the quota orgs are assigned when created
+const defaultQuotaGUID = "quota-default"
+
+// defaultQuota - The quota named "default" every foundation starts with
+func defaultQuota() models.QuotaFields {
+	return models.QuotaFields{
+		GUID:                    defaultQuotaGUID,
+		Name:                    "default",
+		MemoryLimit:             10240,
+		InstanceMemoryLimit:     cfapi.Unlimited,
+		RoutesLimit:             1000,
+		ServicesLimit:           100,
+		NonBasicServicesAllowed: true,
+		AppInstanceLimit:        cfapi.Unlimited,
+		ReservedRoutePorts:      json.Number("0"),
+	}
+}
+
+// quotaRepository -
+func (s *MemoryState) quotaRepository() *FakeQuotaRepository {
+	return &FakeQuotaRepository{
+		FindAllStub: func() ([]models.QuotaFields, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			quotas := []*memoryQuota{}
+			for _, q := range s.quotas {
+				quotas = append(quotas, q)
+			}
+			sort.Slice(quotas, func(i, j int) bool { return quotas[i].seq < quotas[j].seq })
+
+			result := []models.QuotaFields{}
+			for _, q := range quotas {
+				result = append(result, q.fields)
+			}
+			return result, nil
+		},
+		FindByNameStub: func(name string) (models.QuotaFields, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if q := s.findQuota(name); q != nil {
+				return q.fields, nil
+			}
+			return models.QuotaFields{}, errors.NewModelNotFoundError("Quota", name)
+		},
+		AssignQuotaToOrgStub: func(orgGUID, quotaGUID string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			o, ok := s.orgs[orgGUID]
+			if !ok {
+				return errors.NewModelNotFoundError("Organization", orgGUID)
+			}
+			if _, ok := s.quotas[quotaGUID]; !ok {
+				return errors.NewModelNotFoundError("Quota", quotaGUID)
+			}
+			o.quotaGUID = quotaGUID
+			return nil
+		},
+		CreateStub: func(quota models.QuotaFields) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if s.findQuota(quota.Name) != nil {
+				return errors.NewHTTPError(400, "240002", fmt.Sprintf("Quota Definition is taken: %s", quota.Name))
+			}
+			if len(quota.ReservedRoutePorts) == 0 {
+				quota.ReservedRoutePorts = "0"
+			}
+			quota.GUID = s.newGUID("quota")
+			s.quotas[quota.GUID] = &memoryQuota{seq: s.nextSeq(), fields: quota}
+			return nil
+		},
+		UpdateStub: func(quota models.QuotaFields) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			q, ok := s.quotas[quota.GUID]
+			if !ok {
+				return errors.NewModelNotFoundError("Quota", quota.GUID)
+			}
+			if other := s.findQuota(quota.Name); other != nil && other != q {
+				return errors.NewHTTPError(400, "240002", fmt.Sprintf("Quota Definition is taken: %s", quota.Name))
+			}
+			q.fields = quota
+			return nil
+		},
+		DeleteStub: func(quotaGUID string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if _, ok := s.quotas[quotaGUID]; !ok {
+				return errors.NewModelNotFoundError("Quota", quotaGUID)
+			}
+			delete(s.quotas, quotaGUID)
+			return nil
+		},
+	}
+}
+
+// spaceQuotaRepository - Returns the space quota repository of
+// the session which targets the org returned by the given function
+func (s *MemoryState) spaceQuotaRepository(orgGUID func() string) *FakeSpaceQuotaRepository {
+
+	findByOrg := func(orgGUID string) ([]models.SpaceQuota, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if _, ok := s.orgs[orgGUID]; !ok {
+			return nil, errors.NewModelNotFoundError("Organization", orgGUID)
+		}
+		return s.orgSpaceQuotas(orgGUID), nil
+	}
+	findByNameAndOrgGUID := func(name, orgGUID string) (models.SpaceQuota, error) {
+		quotas, err := findByOrg(orgGUID)
+		if err != nil {
+			return models.SpaceQuota{}, err
+		}
+		for _, q := range quotas {
+			if q.Name == name {
+				return q, nil
+			}
+		}
+		return models.SpaceQuota{}, errors.NewModelNotFoundError("Space Quota", name)
+	}
+	// associated - Returns the space and the space quota of an
+	// association after checking they belong to the same org
+	associated := func(spaceGUID, quotaGUID string) (*memorySpace, error) {
+		sp, ok := s.spaces[spaceGUID]
+		if !ok {
+			return nil, errors.NewModelNotFoundError("Space", spaceGUID)
+		}
+		q, ok := s.spaceQuotas[quotaGUID]
+		if !ok {
+			return nil, errors.NewModelNotFoundError("Space Quota", quotaGUID)
+		}
+		if q.fields.OrgGUID != sp.orgGUID {
+			return nil, errors.NewHTTPError(400, "310002",
+				"The space quota definition does not belong to the organization of the space")
+		}
+		return sp, nil
+	}
+
+	return &FakeSpaceQuotaRepository{
+		FindByNameStub: func(name string) (models.SpaceQuota, error) {
+			return findByNameAndOrgGUID(name, orgGUID())
+		},
+		FindByOrgStub: findByOrg,
+		FindByGUIDStub: func(guid string) (models.SpaceQuota, error) {
+			quotas, err := findByOrg(orgGUID())
+			if err != nil {
+				return models.SpaceQuota{}, err
+			}
+			for _, q := range quotas {
+				if q.GUID == guid {
+					return q, nil
+				}
+			}
+			return models.SpaceQuota{}, errors.NewModelNotFoundError("Space Quota", guid)
+		},
+		FindByNameAndOrgGUIDStub: findByNameAndOrgGUID,
+		AssociateSpaceWithQuotaStub: func(spaceGUID, quotaGUID string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			sp, err := associated(spaceGUID, quotaGUID)
+			if err != nil {
+				return err
+			}
+			sp.spaceQuotaGUID = quotaGUID
+			return nil
+		},
+		UnassignQuotaFromSpaceStub: func(spaceGUID, quotaGUID string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			sp, err := associated(spaceGUID, quotaGUID)
+			if err != nil {
+				return err
+			}
+			if sp.spaceQuotaGUID == quotaGUID {
+				sp.spaceQuotaGUID = ""
+			}
+			return nil
+		},
+		CreateStub: func(quota models.SpaceQuota) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if _, ok := s.orgs[quota.OrgGUID]; !ok {
+				return errors.NewModelNotFoundError("Organization", quota.OrgGUID)
+			}
+			for _, q := range s.orgSpaceQuotas(quota.OrgGUID) {
+				if q.Name == quota.Name {
+					return errors.NewHTTPError(400, "310001", fmt.Sprintf("Space Quota Definition is taken: %s", quota.Name))
+				}
+			}
+			if len(quota.ReservedRoutePortsLimit) == 0 {
+				quota.ReservedRoutePortsLimit = "0"
+			}
+			quota.GUID = s.newGUID("space-quota")
+			s.spaceQuotas[quota.GUID] = &memorySpaceQuota{seq: s.nextSeq(), fields: quota}
+			return nil
+		},
+		UpdateStub: func(quota models.SpaceQuota) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			q, ok := s.spaceQuotas[quota.GUID]
+			if !ok {
+				return errors.NewModelNotFoundError("Space Quota", quota.GUID)
+			}
+			// The org of a space quota cannot be changed
+			quota.OrgGUID = q.fields.OrgGUID
+			q.fields = quota
+			return nil
+		},
+		DeleteStub: func(quotaGUID string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if _, ok := s.spaceQuotas[quotaGUID]; !ok {
+				return errors.NewModelNotFoundError("Space Quota", quotaGUID)
+			}
+			for _, sp := range s.spaces {
+				if sp.spaceQuotaGUID == quotaGUID {
+					sp.spaceQuotaGUID = ""
+				}
+			}
+			delete(s.spaceQuotas, quotaGUID)
+			return nil
+		},
+	}
+}
+
+// quotaUsageSession - Backs the quota usage functions of
+// the given session by the apps, routes and service
+// instances of the state
+func (s *MemoryState) quotaUsageSession(session *MockSession) {
+
+	session.MockGetOrgQuotaUsage = func(orgGUID string) (cfapi.QuotaUsage, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		o, ok := s.orgs[orgGUID]
+		if !ok {
+			return cfapi.QuotaUsage{}, errors.NewModelNotFoundError("Organization", orgGUID)
+		}
+		q, ok := s.quotas[o.quotaGUID]
+		if !ok {
+			return cfapi.QuotaUsage{}, errors.NewModelNotFoundError("Quota", o.quotaGUID)
+		}
+		usage := cfapi.QuotaUsage{
+			QuotaGUID:               q.fields.GUID,
+			QuotaName:               q.fields.Name,
+			MemoryLimit:             q.fields.MemoryLimit,
+			InstanceMemoryLimit:     q.fields.InstanceMemoryLimit,
+			AppInstanceLimit:        q.fields.AppInstanceLimit,
+			RoutesLimit:             q.fields.RoutesLimit,
+			ServicesLimit:           q.fields.ServicesLimit,
+			ReservedRoutePortsLimit: numberLimit(q.fields.ReservedRoutePorts),
+		}
+		for _, sp := range s.orgSpaces(orgGUID) {
+			s.addSpaceUsage(sp.GUID, &usage)
+		}
+		return usage, nil
+	}
+	session.MockGetSpaceQuotaUsage = func(spaceGUID string) (cfapi.QuotaUsage, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		sp, ok := s.spaces[spaceGUID]
+		if !ok {
+			return cfapi.QuotaUsage{}, errors.NewModelNotFoundError("Space", spaceGUID)
+		}
+		usage := cfapi.QuotaUsage{
+			MemoryLimit:             cfapi.Unlimited,
+			InstanceMemoryLimit:     cfapi.Unlimited,
+			AppInstanceLimit:        cfapi.Unlimited,
+			RoutesLimit:             cfapi.Unlimited,
+			ServicesLimit:           cfapi.Unlimited,
+			ReservedRoutePortsLimit: cfapi.Unlimited,
+		}
+		if q, ok := s.spaceQuotas[sp.spaceQuotaGUID]; ok {
+			usage = cfapi.QuotaUsage{
+				QuotaGUID:               q.fields.GUID,
+				QuotaName:               q.fields.Name,
+				MemoryLimit:             q.fields.MemoryLimit,
+				InstanceMemoryLimit:     q.fields.InstanceMemoryLimit,
+				AppInstanceLimit:        q.fields.AppInstanceLimit,
+				RoutesLimit:             q.fields.RoutesLimit,
+				ServicesLimit:           q.fields.ServicesLimit,
+				ReservedRoutePortsLimit: numberLimit(q.fields.ReservedRoutePortsLimit),
+			}
+		}
+		s.addSpaceUsage(spaceGUID, &usage)
+		return usage, nil
+	}
+}
+
+// Helpers. The mutex must be held when calling the following.
+
+func (s *MemoryState) findQuota(name string) *memoryQuota {
+	for _, q := range s.quotas {
+		if q.fields.Name == name {
+			return q
+		}
+	}
+	return nil
+}
+
+func (s *MemoryState) orgSpaceQuotas(orgGUID string) []models.SpaceQuota {
+	quotas := []*memorySpaceQuota{}
+	for _, q := range s.spaceQuotas {
+		if q.fields.OrgGUID == orgGUID {
+			quotas = append(quotas, q)
+		}
+	}
+	sort.Slice(quotas, func(i, j int) bool { return quotas[i].seq < quotas[j].seq })
+
+	result := []models.SpaceQuota{}
+	for _, q := range quotas {
+		result = append(result, q.fields)
+	}
+	return result
+}
+
+// addSpaceUsage - Adds the memory and the instances of the started apps
+// and the routes and managed service instances of a space to the usage
+func (s *MemoryState) addSpaceUsage(spaceGUID string, usage *cfapi.QuotaUsage) {
+	for _, a := range s.spaceApps(spaceGUID) {
+		if strings.EqualFold(a.fields.State, cfapi.AppStarted) {
+			usage.MemoryUsed += a.fields.Memory * int64(a.fields.InstanceCount)
+			usage.AppInstancesUsed += a.fields.InstanceCount
+		}
+	}
+	for _, r := range s.spaceRoutes(spaceGUID) {
+		usage.RoutesUsed++
+		if r.port > 0 {
+			usage.ReservedRoutePortsUsed++
+		}
+	}
+	for _, si := range s.spaceServiceInstances(spaceGUID) {
+		if !si.userProvided {
+			usage.ServicesUsed++
+		}
+	}
+}
+
+func numberLimit(limit json.Number) int {
+	if n, err := limit.Int64(); err == nil {
+		return int(n)
+	}
+	return cfapi.Unlimited
+}
@@ -0,0 +1,1063 @@
+package mock_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"code.cloudfoundry.org/cli/cf/api/resources"
+	"code.cloudfoundry.org/cli/cf/errors"
+	"code.cloudfoundry.org/cli/cf/models"
+)
+
+// The repositories of in-memory sessions are the counterfeiter fakes with
+// stubs operating on the shared state. Errors the Cloud Controller would
+// return are surfaced as the same CLI error types.
+
+// organizations -
+func (s *MemoryState) organizations() *FakeOrganizationRepository {
+	return &FakeOrganizationRepository{
+		ListOrgsStub: func(limit int) ([]models.Organization, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			orgs := []*memoryOrg{}
+			for _, o := range s.orgs {
+				orgs = append(orgs, o)
+			}
+			sort.Slice(orgs, func(i, j int) bool { return orgs[i].seq < orgs[j].seq })
+
+			result := []models.Organization{}
+			for _, o := range orgs {
+				if limit > 0 && len(result) == limit {
+					break
+				}
+				result = append(result, s.organization(o))
+			}
+			return result, nil
+		},
+		FindByNameStub: func(name string) (models.Organization, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if o := s.findOrg(name); o != nil {
+				return s.organization(o), nil
+			}
+			return models.Organization{}, errors.NewModelNotFoundError("Organization", name)
+		},
+		CreateStub: func(org models.Organization) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if s.findOrg(org.Name) != nil {
+				return errors.NewHTTPError(400, "30002", fmt.Sprintf("The organization name is taken: %s", org.Name))
+			}
+			s.addOrg(org.Name)
+			return nil
+		},
+		RenameStub: func(orgGUID string, name string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			o, ok := s.orgs[orgGUID]
+			if !ok {
+				return errors.NewModelNotFoundError("Organization", orgGUID)
+			}
+			o.fields.Name = name
+			return nil
+		},
+		DeleteStub: func(orgGUID string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if _, ok := s.orgs[orgGUID]; !ok {
+				return errors.NewModelNotFoundError("Organization", orgGUID)
+			}
+			for _, sp := range s.orgSpaces(orgGUID) {
+				s.deleteSpace(sp.GUID)
+			}
+			for guid, q := range s.spaceQuotas {
+				if q.fields.OrgGUID == orgGUID {
+					delete(s.spaceQuotas, guid)
+				}
+			}
+			for guid, d := range s.domains {
+				if d.fields.OwningOrganizationGUID != orgGUID {
+					delete(d.sharedWith, orgGUID)
+					continue
+				}
+				for _, r := range s.routes {
+					if r.domainGUID == guid {
+						s.deleteRoute(r.guid)
+					}
+				}
+				delete(s.domains, guid)
+			}
+			for _, segment := range s.isolationSegments {
+				delete(segment.orgs, orgGUID)
+			}
+			for guid, v := range s.planVisibilities {
+				if v.fields.OrganizationGUID == orgGUID {
+					delete(s.planVisibilities, guid)
+				}
+			}
+			delete(s.roles, orgGUID)
+			delete(s.orgs, orgGUID)
+			return nil
+		},
+		SharePrivateDomainStub: func(orgGUID string, domainGUID string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			d, ok := s.domains[domainGUID]
+			if !ok || d.fields.Shared {
+				return errors.NewModelNotFoundError("Domain", domainGUID)
+			}
+			d.sharedWith[orgGUID] = true
+			return nil
+		},
+		UnsharePrivateDomainStub: func(orgGUID string, domainGUID string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if d, ok := s.domains[domainGUID]; ok {
+				delete(d.sharedWith, orgGUID)
+			}
+			return nil
+		},
+	}
+}
+
+// spaceRepository - Returns the space repository of the session
+// which targets the org returned by the given function
+func (s *MemoryState) spaceRepository(orgGUID func() string) *FakeSpaceRepository {
+
+	list := func(orgGUID string, cb func(models.Space) bool) error {
+		s.mutex.Lock()
+		spaces := []models.Space{}
+		for _, sp := range s.orgSpaces(orgGUID) {
+			spaces = append(spaces, s.space(s.spaces[sp.GUID]))
+		}
+		s.mutex.Unlock()
+
+		for _, sp := range spaces {
+			if !cb(sp) {
+				break
+			}
+		}
+		return nil
+	}
+	find := func(name, orgGUID string) (models.Space, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if sp := s.findSpace(orgGUID, name); sp != nil {
+			return s.space(sp), nil
+		}
+		return models.Space{}, errors.NewModelNotFoundError("Space", name)
+	}
+
+	return &FakeSpaceRepository{
+		ListSpacesStub: func(cb func(models.Space) bool) error {
+			return list(orgGUID(), cb)
+		},
+		ListSpacesFromOrgStub: list,
+		FindByNameStub: func(name string) (models.Space, error) {
+			return find(name, orgGUID())
+		},
+		FindByNameInOrgStub: find,
+		CreateStub: func(name string, orgGUID string, spaceQuotaGUID string) (models.Space, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if _, ok := s.orgs[orgGUID]; !ok {
+				return models.Space{}, errors.NewModelNotFoundError("Organization", orgGUID)
+			}
+			if s.findSpace(orgGUID, name) != nil {
+				return models.Space{}, errors.NewHTTPError(400, "40002",
+					fmt.Sprintf("The app space name is taken: %s", name))
+			}
+			sp := s.addSpace(orgGUID, name)
+			return s.space(sp), nil
+		},
+		RenameStub: func(spaceGUID, newName string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			sp, ok := s.spaces[spaceGUID]
+			if !ok {
+				return errors.NewModelNotFoundError("Space", spaceGUID)
+			}
+			sp.fields.Name = newName
+			return nil
+		},
+		SetAllowSSHStub: func(spaceGUID string, allow bool) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			sp, ok := s.spaces[spaceGUID]
+			if !ok {
+				return errors.NewModelNotFoundError("Space", spaceGUID)
+			}
+			sp.fields.AllowSSH = allow
+			return nil
+		},
+		DeleteStub: func(spaceGUID string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if _, ok := s.spaces[spaceGUID]; !ok {
+				return errors.NewModelNotFoundError("Space", spaceGUID)
+			}
+			s.deleteSpace(spaceGUID)
+			return nil
+		},
+	}
+}
+
+// domainRepository -
+func (s *MemoryState) domainRepository() *FakeDomainRepository {
+
+	list := func(orgGUID string, cb func(models.DomainFields) bool) error {
+		s.mutex.Lock()
+		domains := s.orgDomains(orgGUID)
+		s.mutex.Unlock()
+
+		for _, d := range domains {
+			if !cb(d) {
+				break
+			}
+		}
+		return nil
+	}
+	find := func(name string, filter func(d *memoryDomain) bool) (models.DomainFields, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if d := s.findDomain(name); d != nil && filter(d) {
+			return d.fields, nil
+		}
+		return models.DomainFields{}, errors.NewModelNotFoundError("Domain", name)
+	}
+	findInOrg := func(name string, orgGUID string) (models.DomainFields, error) {
+		return find(name, func(d *memoryDomain) bool {
+			return d.fields.Shared || d.fields.OwningOrganizationGUID == orgGUID || d.sharedWith[orgGUID]
+		})
+	}
+
+	return &FakeDomainRepository{
+		ListDomainsForOrgStub: list,
+		FindSharedByNameStub: func(name string) (models.DomainFields, error) {
+			return find(name, func(d *memoryDomain) bool { return d.fields.Shared })
+		},
+		FindPrivateByNameStub: func(name string) (models.DomainFields, error) {
+			return find(name, func(d *memoryDomain) bool { return !d.fields.Shared })
+		},
+		FindByNameInOrgStub: findInOrg,
+		CreateStub: func(domainName string, owningOrgGUID string) (models.DomainFields, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if s.findDomain(domainName) != nil {
+				return models.DomainFields{}, errors.NewHTTPError(400, "130003",
+					fmt.Sprintf("The domain name is taken: %s", domainName))
+			}
+			return s.addDomain(domainName, owningOrgGUID).fields, nil
+		},
+		CreateSharedDomainStub: func(domainName string, routerGroupGUID string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if s.findDomain(domainName) != nil {
+				return errors.NewHTTPError(400, "130003",
+					fmt.Sprintf("The domain name is taken: %s", domainName))
+			}
+			var group *memoryRouterGroup
+			if len(routerGroupGUID) > 0 {
+				if group = s.routerGroups[routerGroupGUID]; group == nil {
+					return errors.NewHTTPError(400, "130006",
+						fmt.Sprintf("The router group could not be found: %s", routerGroupGUID))
+				}
+			}
+			domain := s.addDomain(domainName, "")
+			if group != nil {
+				domain.fields.RouterGroupGUID = group.fields.GUID
+				domain.fields.RouterGroupType = group.fields.Type
+			}
+			return nil
+		},
+		DeleteStub: func(domainGUID string) error {
+			return s.deleteDomain(domainGUID)
+		},
+		DeleteSharedDomainStub: func(domainGUID string) error {
+			return s.deleteDomain(domainGUID)
+		},
+		FirstOrDefaultStub: func(orgGUID string, name *string) (models.DomainFields, error) {
+			if name != nil {
+				return findInOrg(*name, orgGUID)
+			}
+
+			// The default domain is the first shared domain
+			// as determined by the CLI's domain repository
+			var domain *models.DomainFields
+			list(orgGUID, func(d models.DomainFields) bool {
+				domain = &d
+				return !d.Shared
+			})
+			if domain == nil {
+				return models.DomainFields{}, errors.New("Could not find a default domain")
+			}
+			return *domain, nil
+		},
+	}
+}
+
+// routeRepository - Returns the route repository of the session
+// which targets the space returned by the given function
+func (s *MemoryState) routeRepository(spaceGUID func() string) *FakeRouteRepository {
+
+	list := func(spaceGUID string, cb func(models.Route) bool) error {
+		s.mutex.Lock()
+		routes := []models.Route{}
+		for _, r := range s.spaceRoutes(spaceGUID) {
+			routes = append(routes, s.routeFields(r))
+		}
+		s.mutex.Unlock()
+
+		for _, r := range routes {
+			if !cb(r) {
+				break
+			}
+		}
+		return nil
+	}
+	create := func(host, path, domainGUID, spaceGUID string, port int, randomPort bool) (models.Route, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		return s.createRoute(host, path, domainGUID, spaceGUID, port, randomPort)
+	}
+
+	return &FakeRouteRepository{
+		ListRoutesStub: func(cb func(models.Route) bool) error {
+			return list(spaceGUID(), cb)
+		},
+		ListAllRoutesStub: func(cb func(models.Route) bool) error {
+			return list("", cb)
+		},
+		FindStub: func(host string, domain models.DomainFields, path string, port int) (models.Route, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if r := s.findRoute(host, s.domainGUID(domain), normalizedRoutePath(path), port); r != nil {
+				return s.routeFields(r), nil
+			}
+			return models.Route{}, errors.NewModelNotFoundError("Route", domain.URLForHostAndPath(host, path, port))
+		},
+		CreateStub: func(host string, domain models.DomainFields, path string, port int, useRandomPort bool) (models.Route, error) {
+			s.mutex.Lock()
+			domainGUID := s.domainGUID(domain)
+			s.mutex.Unlock()
+			return create(host, path, domainGUID, spaceGUID(), port, useRandomPort)
+		},
+		CheckIfExistsStub: func(host string, domain models.DomainFields, path string) (bool, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+			return s.findRoute(host, s.domainGUID(domain), normalizedRoutePath(path), 0) != nil, nil
+		},
+		CreateInSpaceStub: create,
+		BindStub: func(routeGUID, appGUID string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			r, ok := s.routes[routeGUID]
+			if !ok {
+				return errors.NewModelNotFoundError("Route", routeGUID)
+			}
+			if _, ok := s.apps[appGUID]; !ok {
+				return errors.NewModelNotFoundError("App", appGUID)
+			}
+			if !containsString(r.appGUIDs, appGUID) {
+				r.appGUIDs = append(r.appGUIDs, appGUID)
+			}
+			return nil
+		},
+		UnbindStub: func(routeGUID, appGUID string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			r, ok := s.routes[routeGUID]
+			if !ok {
+				return errors.NewModelNotFoundError("Route", routeGUID)
+			}
+			r.appGUIDs = removeString(r.appGUIDs, appGUID)
+			delete(r.appPorts, appGUID)
+			return nil
+		},
+		DeleteStub: func(routeGUID string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if _, ok := s.routes[routeGUID]; !ok {
+				return errors.NewModelNotFoundError("Route", routeGUID)
+			}
+			s.deleteRoute(routeGUID)
+			return nil
+		},
+	}
+}
+
+// applicationRepository - Returns the application repository of the
+// session which targets the space returned by the given function
+func (s *MemoryState) applicationRepository(spaceGUID func() string) *FakeApplicationsRepository {
+
+	read := func(name, spaceGUID string) (models.Application, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if a := s.findApp(spaceGUID, name); a != nil {
+			return s.application(a), nil
+		}
+		return models.Application{}, errors.NewModelNotFoundError("App", name)
+	}
+
+	return &FakeApplicationsRepository{
+		CreateStub: func(params models.AppParams) (models.Application, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if params.Name == nil {
+				return models.Application{}, errors.NewHTTPError(400, "1001", "The request is invalid")
+			}
+			fields := models.ApplicationFields{SpaceGUID: spaceGUID()}
+			applyAppParams(&fields, params)
+
+			if s.findApp(fields.SpaceGUID, fields.Name) != nil {
+				return models.Application{}, errors.NewHTTPError(400, "100002",
+					fmt.Sprintf("The app name is taken: %s", fields.Name))
+			}
+			return s.application(s.addApp(fields)), nil
+		},
+		GetAppStub: func(appGUID string) (models.Application, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if a, ok := s.apps[appGUID]; ok {
+				return s.application(a), nil
+			}
+			return models.Application{}, errors.NewModelNotFoundError("App", appGUID)
+		},
+		ReadStub: func(name string) (models.Application, error) {
+			return read(name, spaceGUID())
+		},
+		ReadFromSpaceStub: read,
+		UpdateStub: func(appGUID string, params models.AppParams) (models.Application, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			a, ok := s.apps[appGUID]
+			if !ok {
+				return models.Application{}, errors.NewModelNotFoundError("App", appGUID)
+			}
+			started := a.fields.State == "STARTED"
+			applyAppParams(&a.fields, params)
+			if a.fields.State == "STARTED" {
+				if a.droplet == nil && a.bits == nil {
+					return models.Application{}, errors.NewHTTPError(400, "170004", "App package is invalid: bits have not been uploaded")
+				}
+				if a.fields.PackageState != "STAGED" {
+					s.stageApp(a)
+				}
+				if !started {
+					a.crashed = nil
+				}
+			}
+			return s.application(a), nil
+		},
+		DeleteStub: func(appGUID string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if _, ok := s.apps[appGUID]; !ok {
+				return errors.NewModelNotFoundError("App", appGUID)
+			}
+			s.deleteApp(appGUID)
+			return nil
+		},
+		CreateRestageRequestStub: func(appGUID string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			a, ok := s.apps[appGUID]
+			if !ok {
+				return errors.NewModelNotFoundError("App", appGUID)
+			}
+			if a.bits == nil && a.droplet == nil {
+				return errors.NewHTTPError(400, "170004", "App package is invalid: bits have not been uploaded")
+			}
+			s.stageApp(a)
+			a.fields.State = "STARTED"
+			a.crashed = nil
+			return nil
+		},
+	}
+}
+
+// applicationBitsRepository -
+func (s *MemoryState) applicationBitsRepository() *FakeApplicationBitsRepository {
+	return &FakeApplicationBitsRepository{
+		GetApplicationFilesStub: func(appFilesRequest []resources.AppFileResource) ([]resources.AppFileResource, error) {
+			return []resources.AppFileResource{}, nil
+		},
+		UploadBitsStub: func(appGUID string, zipFile *os.File, presentFiles []resources.AppFileResource) error {
+			if _, err := zipFile.Seek(0, 0); err != nil {
+				return err
+			}
+			content, err := ioutil.ReadAll(zipFile)
+			if err != nil {
+				return err
+			}
+
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			a, ok := s.apps[appGUID]
+			if !ok {
+				return errors.NewModelNotFoundError("App", appGUID)
+			}
+			a.bits = content
+			a.fields.PackageState = "PENDING"
+			return nil
+		},
+	}
+}
+
+// appSummaryRepository - Returns the app summary repository of the
+// session which targets the space returned by the given function
+func (s *MemoryState) appSummaryRepository(spaceGUID func() string) *FakeAppSummaryRepository {
+	return &FakeAppSummaryRepository{
+		GetSummariesInCurrentSpaceStub: func() ([]models.Application, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			apps := []models.Application{}
+			for _, a := range s.spaceApps(spaceGUID()) {
+				apps = append(apps, s.application(a))
+			}
+			return apps, nil
+		},
+		GetSummaryStub: func(appGUID string) (models.Application, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if a, ok := s.apps[appGUID]; ok {
+				return s.application(a), nil
+			}
+			return models.Application{}, errors.NewModelNotFoundError("App", appGUID)
+		},
+	}
+}
+
+// appEventsRepository -
+func (s *MemoryState) appEventsRepository() *FakeAppEventsRepository {
+	return &FakeAppEventsRepository{
+		RecentEventsStub: func(appGUID string, limit int64) ([]models.EventFields, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			events := []models.EventFields{}
+			for i := len(s.events) - 1; i >= 0; i-- {
+				if limit > 0 && int64(len(events)) == limit {
+					break
+				}
+				if s.events[i].Actee == appGUID {
+					events = append(events, eventFields(s.events[i]))
+				}
+			}
+			return events, nil
+		},
+	}
+}
+
+// serviceRepository - Returns the service repository of the session
+// which targets the space returned by the given function
+func (s *MemoryState) serviceRepository(spaceGUID func() string) *FakeServiceRepository {
+
+	offerings := func(label string) (models.ServiceOfferings, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		offerings := s.offerings(label)
+		if len(label) > 0 && len(offerings) == 0 {
+			return nil, errors.NewModelNotFoundError("Service offering", label)
+		}
+		return offerings, nil
+	}
+	deleteInstance := func(instance models.ServiceInstance, purge bool) error {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if _, ok := s.serviceInstances[instance.GUID]; !ok {
+			return errors.NewModelNotFoundError("Service instance", instance.Name)
+		}
+		if !purge && (len(s.instanceBindings(instance.GUID)) > 0 || len(s.serviceKeyFields(instance.GUID)) > 0) {
+			return errors.NewHTTPError(400, "10006",
+				"Please delete the service_bindings, service_keys, and routes associations for your service_instances.")
+		}
+		s.deleteServiceInstance(instance.GUID)
+		return nil
+	}
+
+	return &FakeServiceRepository{
+		GetServiceOfferingByGUIDStub: func(serviceGUID string) (models.ServiceOffering, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if sv, ok := s.services[serviceGUID]; ok {
+				return models.ServiceOffering{
+					ServiceOfferingFields: sv.fields,
+					Plans:                 s.servicePlans(serviceGUID),
+				}, nil
+			}
+			return models.ServiceOffering{}, errors.NewModelNotFoundError("Service offering", serviceGUID)
+		},
+		FindServiceOfferingsByLabelStub: offerings,
+		FindServiceOfferingsForSpaceByLabelStub: func(spaceGUID, name string) (models.ServiceOfferings, error) {
+			return offerings(name)
+		},
+		ListServicesFromBrokerStub: func(brokerGUID string) ([]models.ServiceOffering, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			offerings := []models.ServiceOffering{}
+			for _, o := range s.offerings("") {
+				if o.BrokerGUID == brokerGUID {
+					offerings = append(offerings, models.ServiceOffering{ServiceOfferingFields: o.ServiceOfferingFields})
+				}
+			}
+			return offerings, nil
+		},
+		GetAllServiceOfferingsStub: func() (models.ServiceOfferings, error) {
+			return offerings("")
+		},
+		GetServiceOfferingsForSpaceStub: func(spaceGUID string) (models.ServiceOfferings, error) {
+			return offerings("")
+		},
+		FindInstanceByNameStub: func(name string) (models.ServiceInstance, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if si := s.findServiceInstance(spaceGUID(), name); si != nil {
+				return s.serviceInstance(si), nil
+			}
+			return models.ServiceInstance{}, errors.NewModelNotFoundError("Service instance", name)
+		},
+		CreateServiceInstanceStub: func(name, planGUID string, params map[string]interface{}, tags []string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if _, ok := s.plans[planGUID]; !ok {
+				return errors.NewModelNotFoundError("Service plan", planGUID)
+			}
+			if s.findServiceInstance(spaceGUID(), name) != nil {
+				return errors.NewModelAlreadyExistsError("Service", name)
+			}
+			si := s.addServiceInstance(spaceGUID(), name, planGUID, nil, false)
+			si.fields.Params = params
+			si.fields.Tags = tags
+			s.startServiceOperation(si, "create")
+			return nil
+		},
+		UpdateServiceInstanceStub: func(instanceGUID, planGUID string, params map[string]interface{}, tags []string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			si, ok := s.serviceInstances[instanceGUID]
+			if !ok {
+				return errors.NewModelNotFoundError("Service instance", instanceGUID)
+			}
+			if len(planGUID) > 0 {
+				if _, ok := s.plans[planGUID]; !ok {
+					return errors.NewModelNotFoundError("Service plan", planGUID)
+				}
+				si.planGUID = planGUID
+			}
+			if params != nil {
+				si.fields.Params = params
+			}
+			if tags != nil {
+				si.fields.Tags = tags
+			}
+			if !si.userProvided {
+				s.startServiceOperation(si, "update")
+			}
+			return nil
+		},
+		RenameServiceStub: func(instance models.ServiceInstance, newName string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			si, ok := s.serviceInstances[instance.GUID]
+			if !ok {
+				return errors.NewModelNotFoundError("Service instance", instance.Name)
+			}
+			si.fields.Name = newName
+			return nil
+		},
+		DeleteServiceStub: func(instance models.ServiceInstance) error {
+			return deleteInstance(instance, false)
+		},
+		PurgeServiceInstanceStub: func(instance models.ServiceInstance) error {
+			return deleteInstance(instance, true)
+		},
+	}
+}
+
+// servicePlanRepository -
+func (s *MemoryState) servicePlanRepository() *FakeServicePlanRepository {
+	return &FakeServicePlanRepository{
+		SearchStub: func(searchParameters map[string]string) ([]models.ServicePlanFields, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if serviceGUID, ok := searchParameters["service_guid"]; ok {
+				return s.servicePlans(serviceGUID), nil
+			}
+			plans := []models.ServicePlanFields{}
+			for _, o := range s.offerings("") {
+				plans = append(plans, o.Plans...)
+			}
+			return plans, nil
+		},
+		UpdateStub: func(plan models.ServicePlanFields, serviceGUID string, public bool) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			p, err := s.servicePlan(plan.GUID)
+			if err != nil {
+				return err
+			}
+			p.fields.Public = public
+			return nil
+		},
+		ListPlansFromManyServicesStub: func(serviceGUIDs []string) ([]models.ServicePlanFields, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			plans := []models.ServicePlanFields{}
+			for _, g := range serviceGUIDs {
+				plans = append(plans, s.servicePlans(g)...)
+			}
+			return plans, nil
+		},
+	}
+}
+
+// serviceSummaryRepository - Returns the service summary repository of
+// the session which targets the space returned by the given function
+func (s *MemoryState) serviceSummaryRepository(spaceGUID func() string) *FakeServiceSummaryRepository {
+	return &FakeServiceSummaryRepository{
+		GetSummariesInCurrentSpaceStub: func() ([]models.ServiceInstance, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			instances := []models.ServiceInstance{}
+			for _, si := range s.spaceServiceInstances(spaceGUID()) {
+				instances = append(instances, s.serviceInstance(si))
+			}
+			return instances, nil
+		},
+	}
+}
+
+// userProvidedServiceRepository - Returns the user provided service repository
+// of the session which targets the space returned by the given function
+func (s *MemoryState) userProvidedServiceRepository(spaceGUID func() string) *FakeUserProvidedServiceInstanceRepository {
+	return &FakeUserProvidedServiceInstanceRepository{
+		CreateStub: func(name, drainURL string, routeServiceURL string, params map[string]interface{}) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if s.findServiceInstance(spaceGUID(), name) != nil {
+				return errors.NewHTTPError(400, "60002",
+					fmt.Sprintf("The service instance name is taken: %s", name))
+			}
+			si := s.addServiceInstance(spaceGUID(), name, "", params, true)
+			si.fields.SysLogDrainURL = drainURL
+			si.fields.RouteServiceURL = routeServiceURL
+			return nil
+		},
+		UpdateStub: func(fields models.ServiceInstanceFields) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			si, ok := s.serviceInstances[fields.GUID]
+			if !ok || !si.userProvided {
+				return errors.NewModelNotFoundError("Service instance", fields.Name)
+			}
+			if fields.Params != nil {
+				si.credentials = fields.Params
+			}
+			si.fields.SysLogDrainURL = fields.SysLogDrainURL
+			si.fields.RouteServiceURL = fields.RouteServiceURL
+			return nil
+		},
+		GetSummariesStub: func() (models.UserProvidedServiceSummary, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			summary := models.UserProvidedServiceSummary{Resources: []models.UserProvidedServiceEntity{}}
+			for _, si := range s.spaceServiceInstances("") {
+				if si.userProvided {
+					summary.Resources = append(summary.Resources,
+						models.UserProvidedServiceEntity{UserProvidedService: s.userProvidedService(si)})
+				}
+			}
+			summary.Total = len(summary.Resources)
+			return summary, nil
+		},
+	}
+}
+
+// serviceKeyRepository -
+func (s *MemoryState) serviceKeyRepository() *FakeServiceKeyRepository {
+	return &FakeServiceKeyRepository{
+		CreateServiceKeyStub: func(instanceGUID string, keyName string, params map[string]interface{}) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if _, ok := s.serviceInstances[instanceGUID]; !ok {
+				return errors.NewModelNotFoundError("Service instance", instanceGUID)
+			}
+			if s.findServiceKey(instanceGUID, keyName) != nil {
+				return errors.NewModelAlreadyExistsError("Service key", keyName)
+			}
+			s.addServiceKey(instanceGUID, keyName)
+			return nil
+		},
+		ListServiceKeysStub: func(instanceGUID string) ([]models.ServiceKey, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			keys := []models.ServiceKey{}
+			for _, k := range s.serviceKeyFields(instanceGUID) {
+				keys = append(keys, s.serviceKey(s.serviceKeys[k.GUID]))
+			}
+			return keys, nil
+		},
+		GetServiceKeyStub: func(instanceGUID string, keyName string) (models.ServiceKey, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			// As the CLI's repository an empty key is
+			// returned if no key with the name exists
+			if k := s.findServiceKey(instanceGUID, keyName); k != nil {
+				return s.serviceKey(k), nil
+			}
+			return models.ServiceKey{}, nil
+		},
+		DeleteServiceKeyStub: func(keyGUID string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if _, ok := s.serviceKeys[keyGUID]; !ok {
+				return errors.NewModelNotFoundError("Service key", keyGUID)
+			}
+			delete(s.serviceKeys, keyGUID)
+			return nil
+		},
+	}
+}
+
+// serviceBindingRepository -
+func (s *MemoryState) serviceBindingRepository() *FakeServiceBindingRepository {
+	return &FakeServiceBindingRepository{
+		CreateStub: func(instanceGUID string, appGUID string, paramsMap map[string]interface{}) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			si, ok := s.serviceInstances[instanceGUID]
+			if !ok {
+				return errors.NewModelNotFoundError("Service instance", instanceGUID)
+			}
+			if si.fields.LastOperation.State == "in progress" {
+				return errors.NewHTTPError(409, "60016",
+					fmt.Sprintf("An operation for service instance %s is in progress.", si.fields.Name))
+			}
+			if _, ok := s.apps[appGUID]; !ok {
+				return errors.NewModelNotFoundError("App", appGUID)
+			}
+			for _, b := range s.instanceBindings(instanceGUID) {
+				if b.appGUID == appGUID {
+					return errors.NewHTTPError(400, "90003",
+						"The app space binding to service is taken")
+				}
+			}
+			s.addServiceBinding(instanceGUID, appGUID)
+			return nil
+		},
+		DeleteStub: func(instance models.ServiceInstance, appGUID string) (bool, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			for _, b := range s.instanceBindings(instance.GUID) {
+				if b.appGUID == appGUID {
+					delete(s.serviceBindings, b.guid)
+					return true, nil
+				}
+			}
+			return false, nil
+		},
+		ListAllForServiceStub: func(instanceGUID string) ([]models.ServiceBindingFields, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			bindings := []models.ServiceBindingFields{}
+			for _, b := range s.instanceBindings(instanceGUID) {
+				bindings = append(bindings, s.serviceBinding(b))
+			}
+			return bindings, nil
+		},
+	}
+}
+
+// Helpers. The mutex must be held when calling the following.
+
+func (s *MemoryState) organization(o *memoryOrg) models.Organization {
+	org := models.Organization{
+		OrganizationFields: o.fields,
+		Spaces:             s.orgSpaces(o.fields.GUID),
+		Domains:            s.orgDomains(o.fields.GUID),
+	}
+	if q, ok := s.quotas[o.quotaGUID]; ok {
+		org.QuotaDefinition = q.fields
+	}
+	return org
+}
+
+func (s *MemoryState) space(sp *memorySpace) models.Space {
+
+	space := models.Space{
+		SpaceFields:      sp.fields,
+		Applications:     []models.ApplicationFields{},
+		ServiceInstances: []models.ServiceInstanceFields{},
+		Domains:          s.orgDomains(sp.orgGUID),
+		SpaceQuotaGUID:   sp.spaceQuotaGUID,
+	}
+	if o, ok := s.orgs[sp.orgGUID]; ok {
+		space.Organization = o.fields
+	}
+	for _, a := range s.spaceApps(sp.fields.GUID) {
+		space.Applications = append(space.Applications, a.fields)
+	}
+	for _, si := range s.spaceServiceInstances(sp.fields.GUID) {
+		space.ServiceInstances = append(space.ServiceInstances, si.fields)
+	}
+	return space
+}
+
+// domainGUID - Returns the GUID of a domain given
+// its fields which may only have the name set
+func (s *MemoryState) domainGUID(domain models.DomainFields) string {
+	if len(domain.GUID) > 0 {
+		return domain.GUID
+	}
+	if d := s.findDomain(domain.Name); d != nil {
+		return d.fields.GUID
+	}
+	return ""
+}
+
+func (s *MemoryState) deleteSpace(spaceGUID string) {
+	for _, a := range s.spaceApps(spaceGUID) {
+		s.deleteApp(a.fields.GUID)
+	}
+	for _, r := range s.spaceRoutes(spaceGUID) {
+		s.deleteRoute(r.guid)
+	}
+	for _, si := range s.spaceServiceInstances(spaceGUID) {
+		s.deleteServiceInstance(si.fields.GUID)
+	}
+	for _, sg := range s.securityGroups {
+		for _, spaces := range sg.spaces {
+			delete(spaces, spaceGUID)
+		}
+	}
+	delete(s.roles, spaceGUID)
+	delete(s.spaces, spaceGUID)
+}
+
+func (s *MemoryState) deleteDomain(domainGUID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.domains[domainGUID]; !ok {
+		return errors.NewModelNotFoundError("Domain", domainGUID)
+	}
+	for _, r := range s.routes {
+		if r.domainGUID == domainGUID {
+			s.deleteRoute(r.guid)
+		}
+	}
+	delete(s.domains, domainGUID)
+	return nil
+}
+
+func (s *MemoryState) deleteServiceInstance(instanceGUID string) {
+	for guid, b := range s.serviceBindings {
+		if b.instanceGUID == instanceGUID {
+			delete(s.serviceBindings, guid)
+		}
+	}
+	for guid, k := range s.serviceKeys {
+		if k.instanceGUID == instanceGUID {
+			delete(s.serviceKeys, guid)
+		}
+	}
+	delete(s.serviceInstances, instanceGUID)
+}
+
+// applyAppParams - Applies the non-nil params to the fields of an app
+func applyAppParams(fields *models.ApplicationFields, params models.AppParams) {
+
+	if params.Name != nil {
+		fields.Name = *params.Name
+	}
+	if params.SpaceGUID != nil {
+		fields.SpaceGUID = *params.SpaceGUID
+	}
+	if params.BuildpackURL != nil {
+		fields.BuildpackURL = *params.BuildpackURL
+	}
+	if params.Command != nil {
+		fields.Command = *params.Command
+	}
+	if params.DiskQuota != nil {
+		fields.DiskQuota = *params.DiskQuota
+	}
+	if params.InstanceCount != nil {
+		fields.InstanceCount = *params.InstanceCount
+	}
+	if params.Memory != nil {
+		fields.Memory = *params.Memory
+	}
+	if params.HealthCheckType != nil {
+		fields.HealthCheckType = *params.HealthCheckType
+	}
+	if params.HealthCheckHTTPEndpoint != nil {
+		fields.HealthCheckHTTPEndpoint = *params.HealthCheckHTTPEndpoint
+	}
+	if params.EnvironmentVars != nil {
+		fields.EnvironmentVars = *params.EnvironmentVars
+	}
+	if params.DockerImage != nil {
+		fields.DockerImage = *params.DockerImage
+	}
+	if params.StackGUID != nil {
+		fields.StackGUID = *params.StackGUID
+	}
+	if params.State != nil {
+		fields.State = strings.ToUpper(*params.State)
+	}
+}
@@ -0,0 +1,217 @@
+package mock_test
+
+import (
+	"fmt"
+	"sort"
+
+	"code.cloudfoundry.org/cli/cf/errors"
+	"code.cloudfoundry.org/cli/cf/models"
+	"github.com/mevansam/cf-cli-api/cfapi"
+)
+
+// defaultOrigin - The origin of users who were given none
+const defaultOrigin = "uaa"
+
+// AddUser - Adds a user with the given username to the identity provider
+// of the given origin so that roles can be granted to the user. An empty
+// origin adds the user to the UAA.
+func (s *MemoryState) AddUser(username, origin string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(origin) == 0 {
+		origin = defaultOrigin
+	}
+	if u := s.findUser(username, origin); u != nil {
+		return u.guid
+	}
+	u := &memoryUser{seq: s.nextSeq(), username: username, origin: origin}
+	u.guid = s.newGUID("user")
+	s.users[u.guid] = u
+	return u.guid
+}
+
+// rolesSession - Backs the user role functions of the given session by the state
+func (s *MemoryState) rolesSession(session *MockSession) {
+
+	session.MockGetOrgUsers = func(orgGUID string) ([]cfapi.UserRoles, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if _, ok := s.orgs[orgGUID]; !ok {
+			return nil, errors.NewModelNotFoundError("Organization", orgGUID)
+		}
+		return s.userRoles(orgGUID), nil
+	}
+	session.MockGetSpaceUsers = func(spaceGUID string) ([]cfapi.UserRoles, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if _, ok := s.spaces[spaceGUID]; !ok {
+			return nil, errors.NewModelNotFoundError("Space", spaceGUID)
+		}
+		return s.userRoles(spaceGUID), nil
+	}
+
+	session.MockSetOrgRole = func(orgGUID, username, origin string, role models.Role) error {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if _, ok := cfapi.OrgRoles[role]; !ok {
+			return fmt.Errorf("Unable to grant role '%s' as it is not an org role.", role.ToString())
+		}
+		if _, ok := s.orgs[orgGUID]; !ok {
+			return errors.NewModelNotFoundError("Organization", orgGUID)
+		}
+		u, err := s.roleUser(username, origin)
+		if err != nil {
+			return err
+		}
+		s.grant(orgGUID, models.RoleOrgUser, u.guid)
+		s.grant(orgGUID, role, u.guid)
+		return nil
+	}
+	session.MockUnsetOrgRole = func(orgGUID, username, origin string, role models.Role) error {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if _, ok := cfapi.OrgRoles[role]; !ok {
+			return fmt.Errorf("Unable to revoke role '%s' as it is not an org role.", role.ToString())
+		}
+		if _, ok := s.orgs[orgGUID]; !ok {
+			return errors.NewModelNotFoundError("Organization", orgGUID)
+		}
+		u, err := s.roleUser(username, origin)
+		if err != nil {
+			return err
+		}
+		if role == models.RoleOrgUser && s.hasOtherOrgRoles(orgGUID, u.guid) {
+			return errors.NewHTTPError(400, "10006",
+				"Please delete the user associations for your spaces in the organization.")
+		}
+		delete(s.roles[orgGUID][role], u.guid)
+		return nil
+	}
+
+	session.MockSetSpaceRole = func(spaceGUID, username, origin string, role models.Role) error {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if _, ok := cfapi.SpaceRoles[role]; !ok {
+			return fmt.Errorf("Unable to grant role '%s' as it is not a space role.", role.ToString())
+		}
+		sp, ok := s.spaces[spaceGUID]
+		if !ok {
+			return errors.NewModelNotFoundError("Space", spaceGUID)
+		}
+		u, err := s.roleUser(username, origin)
+		if err != nil {
+			return err
+		}
+		s.grant(sp.orgGUID, models.RoleOrgUser, u.guid)
+		s.grant(spaceGUID, role, u.guid)
+		return nil
+	}
+	session.MockUnsetSpaceRole = func(spaceGUID, username, origin string, role models.Role) error {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if _, ok := cfapi.SpaceRoles[role]; !ok {
+			return fmt.Errorf("Unable to revoke role '%s' as it is not a space role.", role.ToString())
+		}
+		if _, ok := s.spaces[spaceGUID]; !ok {
+			return errors.NewModelNotFoundError("Space", spaceGUID)
+		}
+		u, err := s.roleUser(username, origin)
+		if err != nil {
+			return err
+		}
+		delete(s.roles[spaceGUID][role], u.guid)
+		return nil
+	}
+}
+
+// Helpers. The mutex must be held when calling the following.
+
+func (s *MemoryState) findUser(username, origin string) *memoryUser {
+	for _, u := range s.users {
+		if u.username == username && u.origin == origin {
+			return u
+		}
+	}
+	return nil
+}
+
+// roleUser - Returns the user roles are granted to or revoked from
+func (s *MemoryState) roleUser(username, origin string) (*memoryUser, error) {
+	if len(origin) == 0 {
+		origin = defaultOrigin
+	}
+	if u := s.findUser(username, origin); u != nil {
+		return u, nil
+	}
+	return nil, errors.NewHTTPError(404, "20003", fmt.Sprintf("The user could not be found: %s", username))
+}
+
+func (s *MemoryState) grant(guid string, role models.Role, userGUID string) {
+	if _, ok := s.roles[guid]; !ok {
+		s.roles[guid] = make(map[models.Role]map[string]bool)
+	}
+	if _, ok := s.roles[guid][role]; !ok {
+		s.roles[guid][role] = make(map[string]bool)
+	}
+	s.roles[guid][role][userGUID] = true
+}
+
+// hasOtherOrgRoles - Returns whether a user has roles in an
+// org other than the user role or any roles in its spaces
+func (s *MemoryState) hasOtherOrgRoles(orgGUID, userGUID string) bool {
+	for role, users := range s.roles[orgGUID] {
+		if role != models.RoleOrgUser && users[userGUID] {
+			return true
+		}
+	}
+	for _, sp := range s.orgSpaces(orgGUID) {
+		for _, users := range s.roles[sp.GUID] {
+			if users[userGUID] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// userRoles - Returns the users having roles in an
+// org or space with their roles ordered by username
+func (s *MemoryState) userRoles(guid string) []cfapi.UserRoles {
+
+	roles := []models.Role{}
+	for role := range s.roles[guid] {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i] < roles[j] })
+
+	users := make(map[string]*cfapi.UserRoles)
+	for _, role := range roles {
+		for userGUID := range s.roles[guid][role] {
+			user, ok := users[userGUID]
+			if !ok {
+				user = &cfapi.UserRoles{GUID: userGUID, Username: s.users[userGUID].username}
+				users[userGUID] = user
+			}
+			user.Roles = append(user.Roles, role)
+		}
+	}
+
+	result := []cfapi.UserRoles{}
+	for _, u := range users {
+		result = append(result, *u)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Username == result[j].Username {
+			return result[i].GUID < result[j].GUID
+		}
+		return result[i].Username < result[j].Username
+	})
+	return result
+}
@@ -0,0 +1,120 @@
+package mock_test
+
+import (
+	"fmt"
+	"strings"
+
+	"code.cloudfoundry.org/cli/cf/errors"
+	"code.cloudfoundry.org/cli/cf/models"
+	"github.com/mevansam/cf-cli-api/cfapi"
+)
+
+// defaultAppPort - The port of an app's instances routes are mapped to by default
+const defaultAppPort = 8080
+
+// routesSession - Backs the route management functions of the given session by the state
+func (s *MemoryState) routesSession(session *MockSession) {
+
+	session.MockCreateRoute = func(spaceGUID string, domain models.DomainFields,
+		host, path string, port int, randomPort bool) (models.Route, error) {
+
+		if domain.RouterGroupType == cfapi.TCPRouterGroupType {
+			if len(host) > 0 || len(path) > 0 {
+				return models.Route{}, fmt.Errorf("Unable to create a route with a host or path on TCP domain '%s'.", domain.Name)
+			}
+			if port == 0 && !randomPort {
+				return models.Route{}, fmt.Errorf("Unable to create a route without a port on TCP domain '%s'.", domain.Name)
+			}
+		} else if port != 0 || randomPort {
+			return models.Route{}, fmt.Errorf("Unable to create a route with a port on HTTP domain '%s'.", domain.Name)
+		}
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if _, ok := s.spaces[spaceGUID]; !ok {
+			return models.Route{}, errors.NewHTTPError(404, "40004",
+				fmt.Sprintf("The app space could not be found: %s", spaceGUID))
+		}
+		return s.createRoute(host, path, s.domainGUID(domain), spaceGUID, port, randomPort)
+	}
+
+	session.MockRouteReserved = func(domain models.DomainFields, host, path string, port int) (bool, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		return s.findRoute(host, s.domainGUID(domain), normalizedRoutePath(path), port) != nil, nil
+	}
+
+	session.MockMapRoute = func(routeGUID, appGUID string, appPort int) error {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		r, ok := s.routes[routeGUID]
+		if !ok {
+			return errors.NewHTTPError(404, "210002", fmt.Sprintf("The route could not be found: %s", routeGUID))
+		}
+		if _, ok := s.apps[appGUID]; !ok {
+			return errors.NewHTTPError(404, "100004", fmt.Sprintf("The app could not be found: %s", appGUID))
+		}
+		if containsString(r.appGUIDs, appGUID) {
+			return errors.NewHTTPError(400, "210006", fmt.Sprintf("The route mapping is taken: %s", routeGUID))
+		}
+		if appPort == 0 {
+			appPort = defaultAppPort
+		}
+		r.appGUIDs = append(r.appGUIDs, appGUID)
+		r.appPorts[appGUID] = appPort
+		return nil
+	}
+
+	session.MockGetRouteMappings = func(routeGUID string) ([]cfapi.RouteMapping, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		r, ok := s.routes[routeGUID]
+		if !ok {
+			return nil, errors.NewHTTPError(404, "210002", fmt.Sprintf("The route could not be found: %s", routeGUID))
+		}
+		mappings := []cfapi.RouteMapping{}
+		for _, appGUID := range r.appGUIDs {
+			appPort, ok := r.appPorts[appGUID]
+			if !ok {
+				appPort = defaultAppPort
+			}
+			mappings = append(mappings, cfapi.RouteMapping{RouteGUID: routeGUID, AppGUID: appGUID, AppPort: appPort})
+		}
+		return mappings, nil
+	}
+}
+
+// Helpers. The mutex must be held when calling the following.
+
+// createRoute - Creates a route picking the first free port from 1024
+// upwards when a random port is requested
+func (s *MemoryState) createRoute(host, path, domainGUID, spaceGUID string, port int, randomPort bool) (models.Route, error) {
+
+	if _, ok := s.domains[domainGUID]; !ok {
+		return models.Route{}, errors.NewModelNotFoundError("Domain", domainGUID)
+	}
+	path = normalizedRoutePath(path)
+	if randomPort {
+		port = 1024
+		for s.findRoute(host, domainGUID, path, port) != nil {
+			port++
+		}
+	}
+	if s.findRoute(host, domainGUID, path, port) != nil {
+		return models.Route{}, errors.NewHTTPError(400, "210003",
+			fmt.Sprintf("The host is taken: %s", host))
+	}
+	return s.routeFields(s.addRoute(spaceGUID, domainGUID, host, path, port)), nil
+}
+
+// normalizedRoutePath - Prefixes a route's context path with a
+// slash the way the CLI does when creating or finding routes
+func normalizedRoutePath(path string) string {
+	if len(path) > 0 && !strings.HasPrefix(path, "/") {
+		return "/" + path
+	}
+	return path
+}
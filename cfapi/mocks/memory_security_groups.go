@@ -0,0 +1,180 @@
+package mock_test
+
+import (
+	"fmt"
+	"sort"
+
+	"code.cloudfoundry.org/cli/cf/errors"
+	"code.cloudfoundry.org/cli/cf/models"
+	"github.com/mevansam/cf-cli-api/cfapi"
+)
+
+// securityGroupRepository -
+func (s *MemoryState) securityGroupRepository() *FakeSecurityGroupRepository {
+	return &FakeSecurityGroupRepository{
+		CreateStub: func(name string, rules []map[string]interface{}) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if s.findSecurityGroup(name) != nil {
+				return errors.NewHTTPError(400, "300005", fmt.Sprintf("The security group name is taken: %s", name))
+			}
+			if rules == nil {
+				rules = []map[string]interface{}{}
+			}
+			guid := s.newGUID("security-group")
+			s.securityGroups[guid] = &memorySecurityGroup{
+				seq:    s.nextSeq(),
+				fields: models.SecurityGroupFields{GUID: guid, Name: name, Rules: rules},
+				spaces: map[cfapi.SecurityGroupLifecycle]map[string]bool{
+					cfapi.SecurityGroupRunning: make(map[string]bool),
+					cfapi.SecurityGroupStaging: make(map[string]bool),
+				},
+			}
+			return nil
+		},
+		UpdateStub: func(guid string, rules []map[string]interface{}) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			sg, ok := s.securityGroups[guid]
+			if !ok {
+				return errors.NewModelNotFoundError("security group", guid)
+			}
+			if rules == nil {
+				rules = []map[string]interface{}{}
+			}
+			sg.fields.Rules = rules
+			return nil
+		},
+		ReadStub: func(name string) (models.SecurityGroup, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			sg := s.findSecurityGroup(name)
+			if sg == nil {
+				return models.SecurityGroup{}, errors.NewModelNotFoundError("security group", name)
+			}
+			return s.securityGroup(sg), nil
+		},
+		DeleteStub: func(guid string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if _, ok := s.securityGroups[guid]; !ok {
+				return errors.NewModelNotFoundError("security group", guid)
+			}
+			delete(s.securityGroups, guid)
+			return nil
+		},
+		FindAllStub: func() ([]models.SecurityGroup, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			groups := []*memorySecurityGroup{}
+			for _, sg := range s.securityGroups {
+				groups = append(groups, sg)
+			}
+			sort.Slice(groups, func(i, j int) bool { return groups[i].seq < groups[j].seq })
+
+			result := []models.SecurityGroup{}
+			for _, sg := range groups {
+				result = append(result, s.securityGroup(sg))
+			}
+			return result, nil
+		},
+	}
+}
+
+// securityGroupsSession - Backs the functions of the given session
+// binding security groups to spaces by the state
+func (s *MemoryState) securityGroupsSession(session *MockSession) {
+
+	bind := func(securityGroupGUID, spaceGUID string, lifecycle cfapi.SecurityGroupLifecycle, bound bool) error {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		sg, ok := s.securityGroups[securityGroupGUID]
+		if !ok {
+			return errors.NewModelNotFoundError("security group", securityGroupGUID)
+		}
+		if _, ok := s.spaces[spaceGUID]; !ok {
+			return errors.NewModelNotFoundError("Space", spaceGUID)
+		}
+		spaces, ok := sg.spaces[lifecycle]
+		if !ok {
+			return fmt.Errorf("Unknown security group lifecycle '%s'.", lifecycle)
+		}
+		if bound {
+			spaces[spaceGUID] = true
+		} else {
+			delete(spaces, spaceGUID)
+		}
+		return nil
+	}
+
+	session.MockBindSecurityGroupToSpace = func(securityGroupGUID, spaceGUID string, lifecycle cfapi.SecurityGroupLifecycle) error {
+		return bind(securityGroupGUID, spaceGUID, lifecycle, true)
+	}
+	session.MockUnbindSecurityGroupFromSpace = func(securityGroupGUID, spaceGUID string, lifecycle cfapi.SecurityGroupLifecycle) error {
+		return bind(securityGroupGUID, spaceGUID, lifecycle, false)
+	}
+	session.MockGetSpaceSecurityGroups = func(spaceGUID string, lifecycle cfapi.SecurityGroupLifecycle) ([]models.SecurityGroupFields, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if lifecycle != cfapi.SecurityGroupRunning && lifecycle != cfapi.SecurityGroupStaging {
+			return nil, fmt.Errorf("Unknown security group lifecycle '%s'.", lifecycle)
+		}
+		if _, ok := s.spaces[spaceGUID]; !ok {
+			return nil, errors.NewModelNotFoundError("Space", spaceGUID)
+		}
+		groups := []*memorySecurityGroup{}
+		for _, sg := range s.securityGroups {
+			if sg.spaces[lifecycle][spaceGUID] {
+				groups = append(groups, sg)
+			}
+		}
+		sort.Slice(groups, func(i, j int) bool { return groups[i].seq < groups[j].seq })
+
+		result := []models.SecurityGroupFields{}
+		for _, sg := range groups {
+			result = append(result, sg.fields)
+		}
+		return result, nil
+	}
+}
+
+// Helpers. The mutex must be held when calling the following.
+
+func (s *MemoryState) findSecurityGroup(name string) *memorySecurityGroup {
+	for _, sg := range s.securityGroups {
+		if sg.fields.Name == name {
+			return sg
+		}
+	}
+	return nil
+}
+
+// securityGroup - Returns a security group with the spaces
+// it is bound to for running apps like the CF CLI reads it
+func (s *MemoryState) securityGroup(sg *memorySecurityGroup) models.SecurityGroup {
+
+	spaces := []*memorySpace{}
+	for guid := range sg.spaces[cfapi.SecurityGroupRunning] {
+		if sp, ok := s.spaces[guid]; ok {
+			spaces = append(spaces, sp)
+		}
+	}
+	sort.Slice(spaces, func(i, j int) bool { return spaces[i].seq < spaces[j].seq })
+
+	group := models.SecurityGroup{SecurityGroupFields: sg.fields, Spaces: []models.Space{}}
+	for _, sp := range spaces {
+		space := models.Space{SpaceFields: sp.fields}
+		if o, ok := s.orgs[sp.orgGUID]; ok {
+			space.Organization = o.fields
+		}
+		group.Spaces = append(group.Spaces, space)
+	}
+	return group
+}
@@ -0,0 +1,408 @@
+package mock_test
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"code.cloudfoundry.org/cli/cf/errors"
+	"code.cloudfoundry.org/cli/cf/models"
+)
+
+// AddServiceBrokerCatalog - Adds a service with the given plans to the
+// catalog served by the broker at the given URL. The services of the
+// catalog are added when a broker is registered or updated with the URL.
+func (s *MemoryState) AddServiceBrokerCatalog(brokerURL, label string, plans ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.brokerCatalogs[brokerURL] = append(s.brokerCatalogs[brokerURL], brokerCatalogOffering{label: label, plans: plans})
+}
+
+// serviceBrokerRepository - Registering or updating a broker adds the
+// services and plans of its catalog which are not public as when they
+// are added by the Cloud Controller.
+func (s *MemoryState) serviceBrokerRepository() *FakeServiceBrokerRepository {
+
+	return &FakeServiceBrokerRepository{
+		ListServiceBrokersStub: func(cb func(models.ServiceBroker) bool) error {
+			s.mutex.Lock()
+			brokers := []models.ServiceBroker{}
+			for _, b := range s.sortedServiceBrokers() {
+				brokers = append(brokers, b.fields)
+			}
+			s.mutex.Unlock()
+
+			for _, b := range brokers {
+				if !cb(b) {
+					break
+				}
+			}
+			return nil
+		},
+		FindByNameStub: func(name string) (models.ServiceBroker, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			for _, b := range s.sortedServiceBrokers() {
+				if b.fields.Name == name {
+					return b.fields, nil
+				}
+			}
+			return models.ServiceBroker{}, errors.NewModelNotFoundError("Service Broker", name)
+		},
+		FindByGUIDStub: func(guid string) (models.ServiceBroker, error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			b, err := s.serviceBroker(guid)
+			if err != nil {
+				return models.ServiceBroker{}, err
+			}
+			return b.fields, nil
+		},
+		CreateStub: func(name, url, username, password, spaceGUID string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if err := s.serviceBrokerTaken(name, url, ""); err != nil {
+				return err
+			}
+			b := &memoryServiceBroker{
+				seq: s.nextSeq(),
+				fields: models.ServiceBroker{
+					GUID:     s.newGUID("service-broker"),
+					Name:     name,
+					URL:      url,
+					Username: username,
+				},
+			}
+			s.serviceBrokers[b.fields.GUID] = b
+			s.addBrokerCatalog(b)
+			return nil
+		},
+		UpdateStub: func(broker models.ServiceBroker) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			b, err := s.serviceBroker(broker.GUID)
+			if err != nil {
+				return err
+			}
+			if err = s.serviceBrokerTaken("", broker.URL, b.fields.GUID); err != nil {
+				return err
+			}
+			b.fields.URL = broker.URL
+			b.fields.Username = broker.Username
+			s.addBrokerCatalog(b)
+			return nil
+		},
+		RenameStub: func(guid, name string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			b, err := s.serviceBroker(guid)
+			if err != nil {
+				return err
+			}
+			if err = s.serviceBrokerTaken(name, "", guid); err != nil {
+				return err
+			}
+			b.fields.Name = name
+			return nil
+		},
+		DeleteStub: func(guid string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			b, err := s.serviceBroker(guid)
+			if err != nil {
+				return err
+			}
+			for _, si := range s.serviceInstances {
+				p, ok := s.plans[si.planGUID]
+				if !ok {
+					continue
+				}
+				if sv, ok := s.services[p.fields.ServiceOfferingGUID]; ok && sv.fields.BrokerGUID == guid {
+					return errors.NewHTTPError(400, "270010",
+						fmt.Sprintf("Can not remove brokers that have associated service instances: %s", b.fields.Name))
+				}
+			}
+			for serviceGUID, sv := range s.services {
+				if sv.fields.BrokerGUID != guid {
+					continue
+				}
+				for planGUID, p := range s.plans {
+					if p.fields.ServiceOfferingGUID == serviceGUID {
+						s.deletePlanVisibilities(planGUID, "")
+						delete(s.plans, planGUID)
+					}
+				}
+				delete(s.services, serviceGUID)
+			}
+			delete(s.serviceBrokers, guid)
+			return nil
+		},
+	}
+}
+
+// servicePlanVisibilityRepository -
+func (s *MemoryState) servicePlanVisibilityRepository() *FakeServicePlanVisibilityRepository {
+
+	search := func(params map[string]string) ([]models.ServicePlanVisibilityFields, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		visibilities := []models.ServicePlanVisibilityFields{}
+		for _, v := range s.sortedPlanVisibilities() {
+			if planGUID, ok := params["service_plan_guid"]; ok && v.fields.ServicePlanGUID != planGUID {
+				continue
+			}
+			if orgGUID, ok := params["organization_guid"]; ok && v.fields.OrganizationGUID != orgGUID {
+				continue
+			}
+			visibilities = append(visibilities, v.fields)
+		}
+		return visibilities, nil
+	}
+
+	return &FakeServicePlanVisibilityRepository{
+		CreateStub: func(planGUID, orgGUID string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if _, err := s.servicePlan(planGUID); err != nil {
+				return err
+			}
+			if _, ok := s.orgs[orgGUID]; !ok {
+				return errors.NewHTTPError(404, "30003", fmt.Sprintf("The organization could not be found: %s", orgGUID))
+			}
+			if len(s.planVisibilityGUIDs(planGUID, orgGUID)) > 0 {
+				return errors.NewHTTPError(400, "260002",
+					"This combination of ServicePlan and Organization is already taken: organization_id and service_plan_id unique")
+			}
+			s.addPlanVisibility(planGUID, orgGUID)
+			return nil
+		},
+		ListStub: func() ([]models.ServicePlanVisibilityFields, error) {
+			return search(map[string]string{})
+		},
+		DeleteStub: func(guid string) error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			if _, ok := s.planVisibilities[guid]; !ok {
+				return errors.NewHTTPError(404, "260003",
+					fmt.Sprintf("The service plan visibility could not be found: %s", guid))
+			}
+			delete(s.planVisibilities, guid)
+			return nil
+		},
+		SearchStub: search,
+	}
+}
+
+// serviceBrokersSession - Backs the catalog and service plan
+// access functions of the given session by the state
+func (s *MemoryState) serviceBrokersSession(session *MockSession) {
+
+	session.MockGetServiceBrokerCatalog = func(brokerGUID string) (models.ServiceBroker, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		b, err := s.serviceBroker(brokerGUID)
+		if err != nil {
+			return models.ServiceBroker{}, err
+		}
+		broker := b.fields
+		broker.Services = []models.ServiceOffering{}
+		for _, o := range s.offerings("") {
+			if o.BrokerGUID != brokerGUID {
+				continue
+			}
+			for i, p := range o.Plans {
+				if p.Public {
+					continue
+				}
+				o.Plans[i].OrgNames = []string{}
+				for _, v := range s.sortedPlanVisibilities() {
+					if v.fields.ServicePlanGUID == p.GUID {
+						o.Plans[i].OrgNames = append(o.Plans[i].OrgNames, s.orgs[v.fields.OrganizationGUID].fields.Name)
+					}
+				}
+				sort.Strings(o.Plans[i].OrgNames)
+			}
+			broker.Services = append(broker.Services, o)
+		}
+		return broker, nil
+	}
+
+	session.MockEnableServicePlanAccess = func(planGUID, orgGUID string) error {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		p, err := s.servicePlan(planGUID)
+		if err != nil || p.fields.Public {
+			return err
+		}
+		if len(orgGUID) == 0 {
+			p.fields.Public = true
+			return nil
+		}
+		if _, ok := s.orgs[orgGUID]; !ok {
+			return errors.NewHTTPError(404, "30003", fmt.Sprintf("The organization could not be found: %s", orgGUID))
+		}
+		if len(s.planVisibilityGUIDs(planGUID, orgGUID)) == 0 {
+			s.addPlanVisibility(planGUID, orgGUID)
+		}
+		return nil
+	}
+
+	session.MockDisableServicePlanAccess = func(planGUID, orgGUID string) error {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		p, err := s.servicePlan(planGUID)
+		if err != nil {
+			return err
+		}
+		if len(orgGUID) > 0 && p.fields.Public {
+			return fmt.Errorf("Unable to revoke access of org '%s' to service plan '%s' as the plan is public.",
+				orgGUID, p.fields.Name)
+		}
+		p.fields.Public = false
+		s.deletePlanVisibilities(planGUID, orgGUID)
+		return nil
+	}
+}
+
+// Helpers. The mutex must be held when calling the following.
+
+func (s *MemoryState) serviceBroker(guid string) (*memoryServiceBroker, error) {
+	b, ok := s.serviceBrokers[guid]
+	if !ok {
+		return nil, errors.NewHTTPError(404, "270004", fmt.Sprintf("The service broker could not be found: %s", guid))
+	}
+	return b, nil
+}
+
+// serviceBrokerTaken - Returns an error if a broker other than
+// the given one has the name or the URL. Empty values are ignored.
+func (s *MemoryState) serviceBrokerTaken(name, url, exceptGUID string) error {
+	for _, b := range s.serviceBrokers {
+		if b.fields.GUID == exceptGUID {
+			continue
+		}
+		if len(name) > 0 && strings.EqualFold(b.fields.Name, name) {
+			return errors.NewHTTPError(400, "270002", fmt.Sprintf("The service broker name is taken: %s", name))
+		}
+		if len(url) > 0 && b.fields.URL == url {
+			return errors.NewHTTPError(400, "270003", fmt.Sprintf("The service broker url is taken: %s", url))
+		}
+	}
+	return nil
+}
+
+// addBrokerCatalog - Adds the services and plans of the catalog served
+// at the URL of a broker which the broker does not already offer
+func (s *MemoryState) addBrokerCatalog(b *memoryServiceBroker) {
+
+	for _, offering := range s.brokerCatalogs[b.fields.URL] {
+		var service *memoryService
+		for _, sv := range s.services {
+			if sv.fields.BrokerGUID == b.fields.GUID && sv.fields.Label == offering.label {
+				service = sv
+			}
+		}
+		if service == nil {
+			service = &memoryService{
+				seq: s.nextSeq(),
+				fields: models.ServiceOfferingFields{
+					GUID:        s.newGUID("service"),
+					BrokerGUID:  b.fields.GUID,
+					Label:       offering.label,
+					Description: fmt.Sprintf("%s service", offering.label),
+				},
+			}
+			s.services[service.fields.GUID] = service
+		}
+
+		plans := make(map[string]bool)
+		for _, p := range s.servicePlans(service.fields.GUID) {
+			plans[p.Name] = true
+		}
+		for _, name := range offering.plans {
+			if plans[name] {
+				continue
+			}
+			plan := &memoryPlan{
+				seq: s.nextSeq(),
+				fields: models.ServicePlanFields{
+					GUID:                s.newGUID("service-plan"),
+					Name:                name,
+					Description:         fmt.Sprintf("%s plan", name),
+					Free:                true,
+					Active:              true,
+					ServiceOfferingGUID: service.fields.GUID,
+				},
+			}
+			s.plans[plan.fields.GUID] = plan
+		}
+	}
+}
+
+func (s *MemoryState) servicePlan(guid string) (*memoryPlan, error) {
+	p, ok := s.plans[guid]
+	if !ok {
+		return nil, errors.NewHTTPError(404, "110003", fmt.Sprintf("The service plan could not be found: %s", guid))
+	}
+	return p, nil
+}
+
+func (s *MemoryState) addPlanVisibility(planGUID, orgGUID string) {
+	v := &memoryPlanVisibility{
+		seq: s.nextSeq(),
+		fields: models.ServicePlanVisibilityFields{
+			GUID:             s.newGUID("service-plan-visibility"),
+			ServicePlanGUID:  planGUID,
+			OrganizationGUID: orgGUID,
+		},
+	}
+	s.planVisibilities[v.fields.GUID] = v
+}
+
+// planVisibilityGUIDs - Returns the visibilities of a plan
+// for the given org or for all orgs if no org is given
+func (s *MemoryState) planVisibilityGUIDs(planGUID, orgGUID string) []string {
+	guids := []string{}
+	for guid, v := range s.planVisibilities {
+		if v.fields.ServicePlanGUID == planGUID && (len(orgGUID) == 0 || v.fields.OrganizationGUID == orgGUID) {
+			guids = append(guids, guid)
+		}
+	}
+	return guids
+}
+
+func (s *MemoryState) deletePlanVisibilities(planGUID, orgGUID string) {
+	for _, guid := range s.planVisibilityGUIDs(planGUID, orgGUID) {
+		delete(s.planVisibilities, guid)
+	}
+}
+
+func (s *MemoryState) sortedServiceBrokers() []*memoryServiceBroker {
+	brokers := []*memoryServiceBroker{}
+	for _, b := range s.serviceBrokers {
+		brokers = append(brokers, b)
+	}
+	sort.Slice(brokers, func(i, j int) bool { return brokers[i].seq < brokers[j].seq })
+	return brokers
+}
+
+func (s *MemoryState) sortedPlanVisibilities() []*memoryPlanVisibility {
+	visibilities := []*memoryPlanVisibility{}
+	for _, v := range s.planVisibilities {
+		visibilities = append(visibilities, v)
+	}
+	sort.Slice(visibilities, func(i, j int) bool { return visibilities[i].seq < visibilities[j].seq })
+	return visibilities
+}
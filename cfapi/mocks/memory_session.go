@@ -0,0 +1,402 @@
+package mock_test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"code.cloudfoundry.org/cli/cf/api"
+	"code.cloudfoundry.org/cli/cf/api/appevents"
+	"code.cloudfoundry.org/cli/cf/api/applicationbits"
+	"code.cloudfoundry.org/cli/cf/api/applications"
+	"code.cloudfoundry.org/cli/cf/api/environmentvariablegroups"
+	"code.cloudfoundry.org/cli/cf/api/featureflags"
+	"code.cloudfoundry.org/cli/cf/api/organizations"
+	"code.cloudfoundry.org/cli/cf/api/quotas"
+	"code.cloudfoundry.org/cli/cf/api/securitygroups"
+	"code.cloudfoundry.org/cli/cf/api/spacequotas"
+	"code.cloudfoundry.org/cli/cf/api/spaces"
+	"code.cloudfoundry.org/cli/cf/api/stacks"
+	"code.cloudfoundry.org/cli/cf/errors"
+	"code.cloudfoundry.org/cli/cf/i18n"
+	"code.cloudfoundry.org/cli/cf/models"
+	"github.com/mevansam/cf-cli-api/cfapi"
+)
+
+// MemorySessionProvider - Creates in-memory sessions backed by a shared state
+type MemorySessionProvider struct {
+	State *MemoryState
+}
+
+// NewCfSession -
+func (p *MemorySessionProvider) NewCfSession(
+	apiEndPoint string,
+	userName string,
+	password string,
+	orgName string,
+	spaceName string,
+	sslDisabled bool,
+	logger *cfapi.Logger) (cfapi.CfSession, error) {
+
+	session := NewMemorySession(p.State, logger)
+	session.MockGetSessionUsername = func() string {
+		return userName
+	}
+	if err := session.SetSessionTarget(orgName, spaceName); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// NewCfSessionFromFilepath - Returns a session without a target
+// as in-memory sessions do not persist their configuration
+func (p *MemorySessionProvider) NewCfSessionFromFilepath(
+	configPath string,
+	sslDisabled bool,
+	logger *cfapi.Logger) (cfapi.CfSession, error) {
+
+	return NewMemorySession(p.State, logger), nil
+}
+
+// NewMemorySession - Returns a mock session whose repositories are backed
+// by the given state instead of stubs. Individual functions of the
+// returned session can still be replaced to inject failures.
+func NewMemorySession(state *MemoryState, logger *cfapi.Logger) *MockSession {
+
+	if i18n.T == nil {
+		i18n.T = i18n.Init(&mockLocale{})
+	}
+
+	var (
+		org   models.OrganizationFields
+		space models.SpaceFields
+
+		session *MockSession
+	)
+
+	session = &MockSession{
+		Logger: logger,
+
+		MockHasTarget: func() bool {
+			return len(org.GUID) > 0 && len(space.GUID) > 0
+		},
+		MockSetSessionTarget: func(orgName, spaceName string) error {
+			state.mutex.Lock()
+			defer state.mutex.Unlock()
+
+			o := state.findOrg(orgName)
+			if o == nil {
+				return errors.NewModelNotFoundError("Organization", orgName)
+			}
+			sp := state.findSpace(o.fields.GUID, spaceName)
+			if sp == nil {
+				return fmt.Errorf("Unable to initialize session target as space '%s' was not found.", spaceName)
+			}
+			org, space = o.fields, sp.fields
+			return nil
+		},
+		MockGetSessionUsername: func() string {
+			return "admin"
+		},
+		MockGetSessionOrg: func() models.OrganizationFields {
+			return org
+		},
+		MockSetSessionOrg: func(o models.OrganizationFields) {
+			org = o
+		},
+		MockGetSessionSpace: func() models.SpaceFields {
+			return space
+		},
+		MockSetSessionSpace: func(sp models.SpaceFields) {
+			space = sp
+		},
+
+		MockOrganizations: func() organizations.OrganizationRepository {
+			return state.organizations()
+		},
+		MockSpaces: func() spaces.SpaceRepository {
+			return state.spaceRepository(func() string { return org.GUID })
+		},
+		MockServices: func() api.ServiceRepository {
+			return state.serviceRepository(func() string { return space.GUID })
+		},
+		MockServicePlans: func() api.ServicePlanRepository {
+			return state.servicePlanRepository()
+		},
+		MockServiceSummary: func() api.ServiceSummaryRepository {
+			return state.serviceSummaryRepository(func() string { return space.GUID })
+		},
+		MockUserProvidedServices: func() api.UserProvidedServiceInstanceRepository {
+			return state.userProvidedServiceRepository(func() string { return space.GUID })
+		},
+		MockServiceKeys: func() api.ServiceKeyRepository {
+			return state.serviceKeyRepository()
+		},
+		MockServiceBindings: func() api.ServiceBindingRepository {
+			return state.serviceBindingRepository()
+		},
+		MockAppSummary: func() api.AppSummaryRepository {
+			return state.appSummaryRepository(func() string { return space.GUID })
+		},
+		MockApplications: func() applications.Repository {
+			return state.applicationRepository(func() string { return space.GUID })
+		},
+		MockApplicationBits: func() applicationbits.Repository {
+			return state.applicationBitsRepository()
+		},
+		MockAppEvents: func() appevents.Repository {
+			return state.appEventsRepository()
+		},
+		MockRoutes: func() api.RouteRepository {
+			return state.routeRepository(func() string { return space.GUID })
+		},
+		MockDomains: func() api.DomainRepository {
+			return state.domainRepository()
+		},
+		MockQuotas: func() quotas.QuotaRepository {
+			return state.quotaRepository()
+		},
+		MockSpaceQuotas: func() spacequotas.SpaceQuotaRepository {
+			return state.spaceQuotaRepository(func() string { return org.GUID })
+		},
+		MockSecurityGroups: func() securitygroups.SecurityGroupRepo {
+			return state.securityGroupRepository()
+		},
+		MockBuildpacks: func() api.BuildpackRepository {
+			return state.buildpackRepository()
+		},
+		MockStacks: func() stacks.StackRepository {
+			return state.stackRepository()
+		},
+		MockServiceBrokers: func() api.ServiceBrokerRepository {
+			return state.serviceBrokerRepository()
+		},
+		MockServicePlanVisibilities: func() api.ServicePlanVisibilityRepository {
+			return state.servicePlanVisibilityRepository()
+		},
+		MockRouterGroups: func() api.RoutingAPIRepository {
+			return state.routerGroupRepository()
+		},
+		MockFeatureFlags: func() featureflags.FeatureFlagRepository {
+			return state.featureFlagRepository()
+		},
+		MockEnvironmentVariableGroups: func() environmentvariablegroups.Repository {
+			return state.environmentVariableGroupsRepository()
+		},
+
+		MockGetAllEventsInSpace: func(from time.Time, inclusive bool) (map[string]cfapi.CfEvent, error) {
+			events := make(map[string]cfapi.CfEvent)
+			for _, e := range state.eventsAfter(from, inclusive, func(e *memoryEvent) bool {
+				return e.SpaceGUID == space.GUID
+			}) {
+				event, exists := events[e.Actee]
+				if !exists {
+					event = cfapi.CfEvent{GUID: e.Actee, Name: e.ActeeName, Type: e.ActeeType}
+				}
+				event.EventList = append(event.EventList, eventFields(e))
+				events[e.Actee] = event
+			}
+			return events, nil
+		},
+		MockGetAllEventsForApp: func(appGUID string, from time.Time, inclusive bool) (event cfapi.CfEvent, err error) {
+			for _, e := range state.eventsAfter(from, inclusive, func(e *memoryEvent) bool {
+				return e.Actee == appGUID
+			}) {
+				if len(event.GUID) == 0 {
+					event = cfapi.CfEvent{GUID: e.Actee, Name: e.ActeeName, Type: e.ActeeType}
+				}
+				event.EventList = append(event.EventList, eventFields(e))
+			}
+			return event, nil
+		},
+
+		MockGetServiceCredentials: func(binding models.ServiceBindingFields) (*cfapi.ServiceBindingDetail, error) {
+			state.mutex.Lock()
+			defer state.mutex.Unlock()
+
+			b, ok := state.serviceBindings[binding.GUID]
+			if !ok {
+				return nil, errors.NewModelNotFoundError("Service Binding", binding.GUID)
+			}
+			detail := &cfapi.ServiceBindingDetail{}
+			detail.Entity.AppGUID = b.appGUID
+			detail.Entity.ServiceInstanceGUID = b.instanceGUID
+			if si, ok := state.serviceInstances[b.instanceGUID]; ok {
+				detail.Entity.Credentials = si.credentials
+			}
+			return detail, nil
+		},
+		MockGetAppEnvironment: func(appGUID string) (*cfapi.AppEnvironment, error) {
+			state.mutex.Lock()
+			defer state.mutex.Unlock()
+
+			a, ok := state.apps[appGUID]
+			if !ok {
+				return nil, errors.NewModelNotFoundError("App", appGUID)
+			}
+			return state.appEnvironment(a), nil
+		},
+		MockDownloadAppContent: func(appGUID string, outputFile *os.File, asDroplet bool) error {
+			state.mutex.Lock()
+			app, ok := state.apps[appGUID]
+			var content []byte
+			if ok {
+				if asDroplet {
+					content = app.droplet
+				} else {
+					content = app.bits
+				}
+			}
+			state.mutex.Unlock()
+
+			if content == nil {
+				return fmt.Errorf("Unable to download content of app with GUID '%s' as it was not found.", appGUID)
+			}
+			if _, err := outputFile.Write(content); err != nil {
+				return err
+			}
+			if asDroplet {
+				reportProgress(session, cfapi.ProgressDownloadDroplet, appGUID, len(content))
+			} else {
+				reportProgress(session, cfapi.ProgressDownloadBits, appGUID, len(content))
+			}
+			return nil
+		},
+		MockUploadDroplet: func(appGUID string, droplet *os.File) error {
+			if _, err := droplet.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			content, err := ioutil.ReadAll(droplet)
+			if err != nil {
+				return err
+			}
+
+			state.mutex.Lock()
+			defer state.mutex.Unlock()
+
+			app, ok := state.apps[appGUID]
+			if !ok {
+				return errors.NewModelNotFoundError("App", appGUID)
+			}
+			app.droplet = content
+			app.fields.PackageState = "STAGED"
+
+			reportProgress(session, cfapi.ProgressUploadDroplet, appGUID, len(content))
+			return nil
+		},
+
+		// Jobs are not modelled by the state as all operations
+		// of its repositories complete synchronously
+		MockWaitForJob: func(jobGUID string, timeout time.Duration) error {
+			return errors.NewModelNotFoundError("Job", jobGUID)
+		},
+		MockWaitForServiceInstance: func(serviceInstanceGUID string, timeout time.Duration) (models.LastOperationFields, error) {
+			state.mutex.Lock()
+			defer state.mutex.Unlock()
+
+			si, ok := state.serviceInstances[serviceInstanceGUID]
+			if !ok {
+				return models.LastOperationFields{Type: "delete", State: "succeeded"}, nil
+			}
+			if si.fields.LastOperation.State == "in progress" {
+				state.completeServiceOperation(si)
+			}
+			lastOperation := si.fields.LastOperation
+			if lastOperation.State == "failed" {
+				return lastOperation, &cfapi.AsyncFailedError{
+					Operation: fmt.Sprintf("%s of service instance with GUID '%s'", lastOperation.Type, serviceInstanceGUID),
+					Reason:    lastOperation.Description,
+				}
+			}
+			return lastOperation, nil
+		},
+
+		MockGetAppInstances: func(appGUID string) ([]cfapi.AppInstance, error) {
+			state.mutex.Lock()
+			defer state.mutex.Unlock()
+
+			a, ok := state.apps[appGUID]
+			if !ok {
+				return nil, errors.NewModelNotFoundError("App", appGUID)
+			}
+			return state.appInstances(a)
+		},
+		MockWaitForAppInstances: func(appGUID string, running int, timeout time.Duration) ([]cfapi.AppInstance, error) {
+			instances, err := session.GetAppInstances(appGUID)
+			if err != nil {
+				return nil, err
+			}
+			count, crashed := 0, []cfapi.AppInstance{}
+			for _, i := range instances {
+				if i.State == cfapi.AppInstanceRunning {
+					count++
+				} else {
+					crashed = append(crashed, i)
+				}
+			}
+			if count >= running {
+				return instances, nil
+			}
+			if len(crashed) > 0 {
+				return instances, &cfapi.AppInstancesCrashedError{AppGUID: appGUID, Crashed: crashed}
+			}
+			return instances, &cfapi.AsyncTimeoutError{
+				Operation: fmt.Sprintf("%d instance(s) of app with GUID '%s' to be running", running, appGUID),
+				Timeout:   timeout,
+			}
+		},
+	}
+	state.v3Session(session)
+	state.lifecycleSession(session)
+	state.logsSession(session)
+	state.quotaUsageSession(session)
+	state.securityGroupsSession(session)
+	state.rolesSession(session)
+	state.orgsSession(session)
+	state.buildpacksSession(session)
+	state.foundationSettingsSession(session)
+	state.serviceBrokersSession(session)
+	state.domainsSession(session)
+	state.routesSession(session)
+	return session
+}
+
+// reportProgress - Reports the completed transfer of
+// app content of the given size in one step
+func reportProgress(session *MockSession, operation cfapi.ProgressOperation, appGUID string, size int) {
+	if session.Progress != nil {
+		session.Progress.Progress(operation, appGUID, int64(size), int64(size))
+	}
+}
+
+// eventsAfter - Returns the events matching the filter
+// from the given time in the order they occurred
+func (s *MemoryState) eventsAfter(from time.Time, inclusive bool, filter func(*memoryEvent) bool) []*memoryEvent {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	events := []*memoryEvent{}
+	for _, e := range s.events {
+		if (e.Timestamp.After(from) || (inclusive && e.Timestamp.Equal(from))) && filter(e) {
+			events = append(events, e)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+	return events
+}
+
+func eventFields(e *memoryEvent) models.EventFields {
+	return models.EventFields{
+		GUID:        e.guid,
+		Name:        e.Type,
+		Timestamp:   e.Timestamp,
+		Actor:       "user-guid",
+		ActorName:   "admin",
+		Description: cfapi.EventDescription(e.Metadata),
+	}
+}
@@ -0,0 +1,1270 @@
+package mock_test
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/cli/cf/errors"
+	"code.cloudfoundry.org/cli/cf/models"
+	"github.com/mevansam/cf-cli-api/cfapi"
+)
+
+// MemoryState - The Cloud Controller state backing in-memory sessions.
+// All repositories of the sessions created for a state share it, so a
+// change made via one repository is visible via all others.
+type MemoryState struct {
+	mutex    sync.Mutex
+	sequence int
+
+	orgs             map[string]*memoryOrg
+	spaces           map[string]*memorySpace
+	domains          map[string]*memoryDomain
+	apps             map[string]*memoryApp
+	routes           map[string]*memoryRoute
+	services         map[string]*memoryService
+	plans            map[string]*memoryPlan
+	serviceInstances map[string]*memoryServiceInstance
+	serviceBindings  map[string]*memoryServiceBinding
+	serviceKeys      map[string]*memoryServiceKey
+	events           []*memoryEvent
+	quotas           map[string]*memoryQuota
+	spaceQuotas      map[string]*memorySpaceQuota
+	securityGroups   map[string]*memorySecurityGroup
+	users            map[string]*memoryUser
+
+	// users by role of orgs and spaces by org or space GUID
+	roles map[string]map[models.Role]map[string]bool
+	// isolation segments by GUID
+	isolationSegments map[string]*memoryIsolationSegment
+	// buildpacks and stacks by GUID
+	buildpacks map[string]*memoryBuildpack
+	stacks     map[string]*memoryStack
+
+	// feature flags by name and the variables of
+	// the environment variable groups by group
+	featureFlags map[string]bool
+	envGroups    map[string]map[string]interface{}
+
+	// service brokers and the access of orgs to plans by GUID
+	// and the catalogs served by brokers by broker URL
+	serviceBrokers   map[string]*memoryServiceBroker
+	planVisibilities map[string]*memoryPlanVisibility
+	brokerCatalogs   map[string][]brokerCatalogOffering
+
+	// router groups of the routing API by GUID
+	routerGroups map[string]*memoryRouterGroup
+
+	// logs of apps by app GUID in the order they were logged
+	logs map[string][]cfapi.AppLog
+
+	// versions of the APIs the state is served by
+	// and the v3 builds and deployments created
+	apiInfo     cfapi.APIInfo
+	builds      map[string]cfapi.V3Build
+	deployments map[string]cfapi.V3Deployment
+
+	// whether operations on managed service instances
+	// stay in progress until a session waits for them
+	asyncServiceOperations bool
+	// description of the next service instance operation which is to fail
+	serviceOperationFailure string
+	// reason and description of the next staging of an app which is to fail
+	stagingFailureReason      string
+	stagingFailureDescription string
+}
+
+// MemoryEvent - An audit event to add to the state
+type MemoryEvent struct {
+	Type      string
+	Actee     string
+	ActeeType string
+	ActeeName string
+	SpaceGUID string
+	Timestamp time.Time
+	Metadata  map[string]interface{}
+}
+
+type memoryOrg struct {
+	seq       int
+	fields    models.OrganizationFields
+	quotaGUID string
+
+	defaultIsolationSegmentGUID string
+}
+
+type memorySpace struct {
+	seq     int
+	fields  models.SpaceFields
+	orgGUID string
+
+	spaceQuotaGUID       string
+	isolationSegmentGUID string
+}
+
+type memoryQuota struct {
+	seq    int
+	fields models.QuotaFields
+}
+
+type memorySpaceQuota struct {
+	seq    int
+	fields models.SpaceQuota
+}
+
+type memorySecurityGroup struct {
+	seq    int
+	fields models.SecurityGroupFields
+
+	// spaces the group is bound to by lifecycle
+	spaces map[cfapi.SecurityGroupLifecycle]map[string]bool
+}
+
+type memoryIsolationSegment struct {
+	seq  int
+	guid string
+	name string
+
+	// orgs entitled to use the segment
+	orgs map[string]bool
+}
+
+type memoryBuildpack struct {
+	seq    int
+	fields models.Buildpack
+	bits   []byte
+}
+
+type memoryStack struct {
+	seq    int
+	fields models.Stack
+}
+
+type memoryRouterGroup struct {
+	seq    int
+	fields models.RouterGroup
+}
+
+type memoryServiceBroker struct {
+	seq    int
+	fields models.ServiceBroker
+}
+
+type memoryPlanVisibility struct {
+	seq    int
+	fields models.ServicePlanVisibilityFields
+}
+
+// brokerCatalogOffering - A service of the catalog served by a broker
+type brokerCatalogOffering struct {
+	label string
+	plans []string
+}
+
+type memoryUser struct {
+	seq      int
+	guid     string
+	username string
+	origin   string
+}
+
+type memoryDomain struct {
+	seq    int
+	fields models.DomainFields
+
+	// orgs a private domain is shared with
+	sharedWith map[string]bool
+}
+
+type memoryApp struct {
+	seq     int
+	fields  models.ApplicationFields
+	bits    []byte
+	droplet []byte
+
+	// reasons of the instances which crashed by their index
+	crashed map[int]string
+	// description of the failure of the app's last staging
+	stagingFailedDescription string
+}
+
+type memoryRoute struct {
+	seq        int
+	guid       string
+	host       string
+	path       string
+	port       int
+	domainGUID string
+	spaceGUID  string
+	appGUIDs   []string
+
+	// app ports of the mapped apps by app GUID
+	// for apps not mapped to the default port
+	appPorts map[string]int
+}
+
+type memoryService struct {
+	seq    int
+	fields models.ServiceOfferingFields
+}
+
+type memoryPlan struct {
+	seq    int
+	fields models.ServicePlanFields
+}
+
+type memoryServiceInstance struct {
+	seq          int
+	fields       models.ServiceInstanceFields
+	spaceGUID    string
+	planGUID     string
+	credentials  map[string]interface{}
+	userProvided bool
+
+	// failure description of an operation in progress which is to fail
+	failure string
+}
+
+type memoryServiceBinding struct {
+	seq          int
+	guid         string
+	instanceGUID string
+	appGUID      string
+}
+
+type memoryServiceKey struct {
+	seq          int
+	guid         string
+	name         string
+	instanceGUID string
+}
+
+type memoryEvent struct {
+	seq  int
+	guid string
+	MemoryEvent
+}
+
+// NewMemoryState - Creates an empty state
+func NewMemoryState() *MemoryState {
+	return &MemoryState{
+		orgs:             make(map[string]*memoryOrg),
+		spaces:           make(map[string]*memorySpace),
+		domains:          make(map[string]*memoryDomain),
+		apps:             make(map[string]*memoryApp),
+		routes:           make(map[string]*memoryRoute),
+		services:         make(map[string]*memoryService),
+		plans:            make(map[string]*memoryPlan),
+		serviceInstances: make(map[string]*memoryServiceInstance),
+		serviceBindings:  make(map[string]*memoryServiceBinding),
+		serviceKeys:      make(map[string]*memoryServiceKey),
+		quotas:           map[string]*memoryQuota{defaultQuotaGUID: {fields: defaultQuota()}},
+		spaceQuotas:      make(map[string]*memorySpaceQuota),
+		securityGroups:   make(map[string]*memorySecurityGroup),
+		users:            make(map[string]*memoryUser),
+		roles:            make(map[string]map[models.Role]map[string]bool),
+		logs:             make(map[string][]cfapi.AppLog),
+
+		isolationSegments: make(map[string]*memoryIsolationSegment),
+		buildpacks:        make(map[string]*memoryBuildpack),
+		stacks:            make(map[string]*memoryStack),
+
+		featureFlags: defaultFeatureFlags(),
+		envGroups:    map[string]map[string]interface{}{"running": {}, "staging": {}},
+
+		serviceBrokers:   make(map[string]*memoryServiceBroker),
+		planVisibilities: make(map[string]*memoryPlanVisibility),
+		brokerCatalogs:   make(map[string][]brokerCatalogOffering),
+
+		routerGroups: make(map[string]*memoryRouterGroup),
+
+		apiInfo:     cfapi.APIInfo{V2Version: "2.100.0", V3Version: "3.35.0"},
+		builds:      make(map[string]cfapi.V3Build),
+		deployments: make(map[string]cfapi.V3Deployment),
+	}
+}
+
+// SetAPIInfo - Sets the versions of the APIs sessions of the state
+// report. An empty v3 version makes sessions appear to be v2 only.
+func (s *MemoryState) SetAPIInfo(info cfapi.APIInfo) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.apiInfo = info
+}
+
+// AddOrg -
+func (s *MemoryState) AddOrg(name string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.addOrg(name).fields.GUID
+}
+
+// AddSpace -
+func (s *MemoryState) AddSpace(orgGUID, name string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.addSpace(orgGUID, name).fields.GUID
+}
+
+// AddSharedDomain -
+func (s *MemoryState) AddSharedDomain(name string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.addDomain(name, "").fields.GUID
+}
+
+// AddPrivateDomain -
+func (s *MemoryState) AddPrivateDomain(orgGUID, name string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.addDomain(name, orgGUID).fields.GUID
+}
+
+// AddApp - Adds an app to a space. The GUID, name and space
+// of the given fields are set by the state.
+func (s *MemoryState) AddApp(spaceGUID, name string, fields models.ApplicationFields) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	fields.Name = name
+	fields.SpaceGUID = spaceGUID
+	return s.addApp(fields).fields.GUID
+}
+
+// SetAppBits - Sets the bits of an app as if they were uploaded
+func (s *MemoryState) SetAppBits(appGUID string, content []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if app, ok := s.apps[appGUID]; ok {
+		app.bits = content
+	}
+}
+
+// SetAppDroplet - Sets the droplet of an app as if it was staged
+func (s *MemoryState) SetAppDroplet(appGUID string, content []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if app, ok := s.apps[appGUID]; ok {
+		app.droplet = content
+		app.fields.PackageState = "STAGED"
+	}
+}
+
+// AddRoute - Adds a route. A port of 0 creates an HTTP route.
+func (s *MemoryState) AddRoute(spaceGUID, domainGUID, host, path string, port int) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.addRoute(spaceGUID, domainGUID, host, path, port).guid
+}
+
+// MapRoute -
+func (s *MemoryState) MapRoute(routeGUID, appGUID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if route, ok := s.routes[routeGUID]; ok {
+		route.appGUIDs = append(route.appGUIDs, appGUID)
+	}
+}
+
+// AddServiceOffering - Adds a service with the given plans. Returns
+// the GUID of the service and the GUIDs of its plans in order.
+func (s *MemoryState) AddServiceOffering(label string, plans ...string) (string, []string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	service := &memoryService{
+		seq: s.nextSeq(),
+		fields: models.ServiceOfferingFields{
+			GUID:        s.newGUID("service"),
+			Label:       label,
+			Description: fmt.Sprintf("%s service", label),
+		},
+	}
+	s.services[service.fields.GUID] = service
+
+	planGUIDs := []string{}
+	for _, p := range plans {
+		plan := &memoryPlan{
+			seq: s.nextSeq(),
+			fields: models.ServicePlanFields{
+				GUID:                s.newGUID("service-plan"),
+				Name:                p,
+				Description:         fmt.Sprintf("%s plan", p),
+				Free:                true,
+				Public:              true,
+				Active:              true,
+				ServiceOfferingGUID: service.fields.GUID,
+			},
+		}
+		s.plans[plan.fields.GUID] = plan
+		planGUIDs = append(planGUIDs, plan.fields.GUID)
+	}
+	return service.fields.GUID, planGUIDs
+}
+
+// AddServiceInstance - Adds a managed service instance. The given
+// credentials are returned by bindings and keys of the instance.
+func (s *MemoryState) AddServiceInstance(spaceGUID, name, planGUID string, credentials map[string]interface{}) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.addServiceInstance(spaceGUID, name, planGUID, credentials, false).fields.GUID
+}
+
+// SetAsyncServiceOperations - Sets whether creating or updating managed
+// service instances leaves their last operation in progress until a
+// session waits for it. Apps cannot be bound to such instances.
+func (s *MemoryState) SetAsyncServiceOperations(async bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.asyncServiceOperations = async
+}
+
+// FailNextServiceOperation - Causes the next operation on a managed
+// service instance to fail with the given description
+func (s *MemoryState) FailNextServiceOperation(description string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.serviceOperationFailure = description
+}
+
+// SetServiceInstanceLastOperation - Sets the last operation of a
+// service instance as if an earlier operation on it had completed
+func (s *MemoryState) SetServiceInstanceLastOperation(instanceGUID string, lastOperation models.LastOperationFields) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if si, ok := s.serviceInstances[instanceGUID]; ok {
+		si.fields.LastOperation = lastOperation
+	}
+}
+
+// AddUserProvidedService -
+func (s *MemoryState) AddUserProvidedService(spaceGUID, name string, credentials map[string]interface{}) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.addServiceInstance(spaceGUID, name, "", credentials, true).fields.GUID
+}
+
+// BindService -
+func (s *MemoryState) BindService(instanceGUID, appGUID string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.addServiceBinding(instanceGUID, appGUID).guid
+}
+
+// AddServiceKey -
+func (s *MemoryState) AddServiceKey(instanceGUID, name string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.addServiceKey(instanceGUID, name).guid
+}
+
+// AddEvent -
+func (s *MemoryState) AddEvent(event MemoryEvent) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	e := &memoryEvent{
+		seq:         s.nextSeq(),
+		guid:        s.newGUID("event"),
+		MemoryEvent: event,
+	}
+	s.events = append(s.events, e)
+	return e.guid
+}
+
+// FailNextStaging - Causes the next staging of an app to fail with the
+// given reason i.e. "BuildpackCompileFailed" and description
+func (s *MemoryState) FailNextStaging(reason, description string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.stagingFailureReason = reason
+	s.stagingFailureDescription = description
+}
+
+// CrashAppInstance - Makes the instance of an app with the given
+// index report that it crashed for the given reason
+func (s *MemoryState) CrashAppInstance(appGUID string, index int, reason string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if app, ok := s.apps[appGUID]; ok {
+		if app.crashed == nil {
+			app.crashed = make(map[int]string)
+		}
+		app.crashed[index] = reason
+	}
+}
+
+// FindApp - Returns the app with the given name in a space
+func (s *MemoryState) FindApp(spaceGUID, name string) (models.Application, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if app := s.findApp(spaceGUID, name); app != nil {
+		return s.application(app), true
+	}
+	return models.Application{}, false
+}
+
+// AppBits -
+func (s *MemoryState) AppBits(appGUID string) ([]byte, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if app, ok := s.apps[appGUID]; ok && app.bits != nil {
+		return app.bits, true
+	}
+	return nil, false
+}
+
+// AppDroplet -
+func (s *MemoryState) AppDroplet(appGUID string) ([]byte, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if app, ok := s.apps[appGUID]; ok && app.droplet != nil {
+		return app.droplet, true
+	}
+	return nil, false
+}
+
+// FindServiceInstance - Returns the managed or user provided
+// service instance with the given name in a space
+func (s *MemoryState) FindServiceInstance(spaceGUID, name string) (models.ServiceInstance, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if instance := s.findServiceInstance(spaceGUID, name); instance != nil {
+		return s.serviceInstance(instance), true
+	}
+	return models.ServiceInstance{}, false
+}
+
+// Credentials - Returns the credentials of a service instance
+func (s *MemoryState) Credentials(instanceGUID string) map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if instance, ok := s.serviceInstances[instanceGUID]; ok {
+		return instance.credentials
+	}
+	return nil
+}
+
+// ServiceKeys - Returns the keys of a service instance
+func (s *MemoryState) ServiceKeys(instanceGUID string) []models.ServiceKeyFields {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.serviceKeyFields(instanceGUID)
+}
+
+// Count - Returns the number of resources in a collection, i.e.
+// "apps", "routes", "service_instances", "service_bindings" or
+// "service_keys".
+func (s *MemoryState) Count(collection string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch collection {
+	case "organizations":
+		return len(s.orgs)
+	case "spaces":
+		return len(s.spaces)
+	case "domains":
+		return len(s.domains)
+	case "apps":
+		return len(s.apps)
+	case "routes":
+		return len(s.routes)
+	case "service_instances":
+		return len(s.serviceInstances)
+	case "service_bindings":
+		return len(s.serviceBindings)
+	case "service_keys":
+		return len(s.serviceKeys)
+	case "events":
+		return len(s.events)
+	case "quota_definitions":
+		return len(s.quotas)
+	case "space_quota_definitions":
+		return len(s.spaceQuotas)
+	case "security_groups":
+		return len(s.securityGroups)
+	case "users":
+		return len(s.users)
+	case "isolation_segments":
+		return len(s.isolationSegments)
+	case "buildpacks":
+		return len(s.buildpacks)
+	case "stacks":
+		return len(s.stacks)
+	case "services":
+		return len(s.services)
+	case "service_plans":
+		return len(s.plans)
+	case "service_brokers":
+		return len(s.serviceBrokers)
+	case "service_plan_visibilities":
+		return len(s.planVisibilities)
+	case "router_groups":
+		return len(s.routerGroups)
+	}
+	return 0
+}
+
+// State mutations. The mutex must be held when calling the following.
+
+func (s *MemoryState) nextSeq() int {
+	s.sequence++
+	return s.sequence
+}
+
+func (s *MemoryState) newGUID(kind string) string {
+	return fmt.Sprintf("%s-%d", kind, s.nextSeq())
+}
+
+func (s *MemoryState) addOrg(name string) *memoryOrg {
+	org := &memoryOrg{
+		seq:       s.nextSeq(),
+		fields:    models.OrganizationFields{GUID: s.newGUID("org"), Name: name},
+		quotaGUID: defaultQuotaGUID,
+	}
+	s.orgs[org.fields.GUID] = org
+	return org
+}
+
+func (s *MemoryState) addSpace(orgGUID, name string) *memorySpace {
+	space := &memorySpace{
+		seq:     s.nextSeq(),
+		fields:  models.SpaceFields{GUID: s.newGUID("space"), Name: name},
+		orgGUID: orgGUID,
+	}
+	s.spaces[space.fields.GUID] = space
+	return space
+}
+
+func (s *MemoryState) addDomain(name, orgGUID string) *memoryDomain {
+	domain := &memoryDomain{
+		seq: s.nextSeq(),
+		fields: models.DomainFields{
+			GUID:                   s.newGUID("domain"),
+			Name:                   name,
+			OwningOrganizationGUID: orgGUID,
+			Shared:                 len(orgGUID) == 0,
+		},
+		sharedWith: make(map[string]bool),
+	}
+	s.domains[domain.fields.GUID] = domain
+	return domain
+}
+
+func (s *MemoryState) addApp(fields models.ApplicationFields) *memoryApp {
+	fields.GUID = s.newGUID("app")
+	if len(fields.State) == 0 {
+		fields.State = "STOPPED"
+	}
+	if fields.InstanceCount == 0 {
+		fields.InstanceCount = 1
+	}
+	if fields.Memory == 0 {
+		fields.Memory = 1024
+	}
+	if fields.DiskQuota == 0 {
+		fields.DiskQuota = 1024
+	}
+	if len(fields.PackageState) == 0 {
+		fields.PackageState = "PENDING"
+	}
+	app := &memoryApp{seq: s.nextSeq(), fields: fields}
+	s.apps[fields.GUID] = app
+	return app
+}
+
+func (s *MemoryState) addRoute(spaceGUID, domainGUID, host, path string, port int) *memoryRoute {
+	route := &memoryRoute{
+		seq:        s.nextSeq(),
+		guid:       s.newGUID("route"),
+		host:       host,
+		path:       path,
+		port:       port,
+		domainGUID: domainGUID,
+		spaceGUID:  spaceGUID,
+		appPorts:   make(map[string]int),
+	}
+	s.routes[route.guid] = route
+	return route
+}
+
+func (s *MemoryState) addServiceInstance(spaceGUID, name, planGUID string,
+	credentials map[string]interface{}, userProvided bool) *memoryServiceInstance {
+
+	if credentials == nil {
+		credentials = map[string]interface{}{}
+	}
+	instance := &memoryServiceInstance{
+		seq: s.nextSeq(),
+		fields: models.ServiceInstanceFields{
+			GUID: s.newGUID("service-instance"),
+			Name: name,
+			LastOperation: models.LastOperationFields{
+				Type:  "create",
+				State: "succeeded",
+			},
+		},
+		spaceGUID:    spaceGUID,
+		planGUID:     planGUID,
+		credentials:  credentials,
+		userProvided: userProvided,
+	}
+	s.serviceInstances[instance.fields.GUID] = instance
+	return instance
+}
+
+// startServiceOperation - Sets the last operation of a managed service
+// instance to an operation of the given type which has just started
+func (s *MemoryState) startServiceOperation(si *memoryServiceInstance, opType string) {
+
+	si.fields.LastOperation = models.LastOperationFields{Type: opType, State: "succeeded"}
+	si.failure, s.serviceOperationFailure = s.serviceOperationFailure, ""
+
+	if s.asyncServiceOperations {
+		si.fields.LastOperation.State = "in progress"
+	} else {
+		s.completeServiceOperation(si)
+	}
+}
+
+// completeServiceOperation - Completes the operation in progress of a
+// service instance failing it if a failure was set when it started
+func (s *MemoryState) completeServiceOperation(si *memoryServiceInstance) {
+
+	if len(si.failure) > 0 {
+		si.fields.LastOperation.State = "failed"
+		si.fields.LastOperation.Description = si.failure
+		si.failure = ""
+		return
+	}
+	si.fields.LastOperation.State = "succeeded"
+}
+
+func (s *MemoryState) addServiceBinding(instanceGUID, appGUID string) *memoryServiceBinding {
+	binding := &memoryServiceBinding{
+		seq:          s.nextSeq(),
+		guid:         s.newGUID("service-binding"),
+		instanceGUID: instanceGUID,
+		appGUID:      appGUID,
+	}
+	s.serviceBindings[binding.guid] = binding
+	return binding
+}
+
+func (s *MemoryState) addServiceKey(instanceGUID, name string) *memoryServiceKey {
+	key := &memoryServiceKey{
+		seq:          s.nextSeq(),
+		guid:         s.newGUID("service-key"),
+		name:         name,
+		instanceGUID: instanceGUID,
+	}
+	s.serviceKeys[key.guid] = key
+	return key
+}
+
+// deleteApp - Deletes an app with its route mappings and service bindings
+func (s *MemoryState) deleteApp(appGUID string) {
+	for _, r := range s.routes {
+		r.appGUIDs = removeString(r.appGUIDs, appGUID)
+		delete(r.appPorts, appGUID)
+	}
+	for guid, b := range s.serviceBindings {
+		if b.appGUID == appGUID {
+			delete(s.serviceBindings, guid)
+		}
+	}
+	delete(s.apps, appGUID)
+}
+
+// deleteRoute - Deletes a route and its mappings
+func (s *MemoryState) deleteRoute(routeGUID string) {
+	delete(s.routes, routeGUID)
+}
+
+// State queries. The mutex must be held when calling the following.
+
+func (s *MemoryState) findOrg(name string) *memoryOrg {
+	for _, o := range s.orgs {
+		if o.fields.Name == name {
+			return o
+		}
+	}
+	return nil
+}
+
+func (s *MemoryState) findSpace(orgGUID, name string) *memorySpace {
+	for _, sp := range s.spaces {
+		if sp.orgGUID == orgGUID && sp.fields.Name == name {
+			return sp
+		}
+	}
+	return nil
+}
+
+func (s *MemoryState) findApp(spaceGUID, name string) *memoryApp {
+	for _, a := range s.apps {
+		if a.fields.SpaceGUID == spaceGUID && a.fields.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+func (s *MemoryState) findRoute(host, domainGUID, path string, port int) *memoryRoute {
+	for _, r := range s.routes {
+		if r.host == host && r.domainGUID == domainGUID && r.path == path && r.port == port {
+			return r
+		}
+	}
+	return nil
+}
+
+func (s *MemoryState) findServiceInstance(spaceGUID, name string) *memoryServiceInstance {
+	for _, si := range s.serviceInstances {
+		if si.spaceGUID == spaceGUID && si.fields.Name == name {
+			return si
+		}
+	}
+	return nil
+}
+
+func (s *MemoryState) findServiceKey(instanceGUID, name string) *memoryServiceKey {
+	for _, k := range s.serviceKeys {
+		if k.instanceGUID == instanceGUID && k.name == name {
+			return k
+		}
+	}
+	return nil
+}
+
+func (s *MemoryState) findDomain(name string) *memoryDomain {
+	for _, d := range s.domains {
+		if d.fields.Name == name {
+			return d
+		}
+	}
+	return nil
+}
+
+// orgDomains - Returns the private domains visible to an org followed
+// by the shared domains in the order they were created
+func (s *MemoryState) orgDomains(orgGUID string) []models.DomainFields {
+
+	private, shared := []*memoryDomain{}, []*memoryDomain{}
+	for _, d := range s.domains {
+		switch {
+		case d.fields.Shared:
+			shared = append(shared, d)
+		case d.fields.OwningOrganizationGUID == orgGUID || d.sharedWith[orgGUID]:
+			private = append(private, d)
+		}
+	}
+	sort.Slice(private, func(i, j int) bool { return private[i].seq < private[j].seq })
+	sort.Slice(shared, func(i, j int) bool { return shared[i].seq < shared[j].seq })
+
+	domains := []models.DomainFields{}
+	for _, d := range append(private, shared...) {
+		domains = append(domains, d.fields)
+	}
+	return domains
+}
+
+func (s *MemoryState) orgSpaces(orgGUID string) []models.SpaceFields {
+
+	spaces := []*memorySpace{}
+	for _, sp := range s.spaces {
+		if sp.orgGUID == orgGUID {
+			spaces = append(spaces, sp)
+		}
+	}
+	sort.Slice(spaces, func(i, j int) bool { return spaces[i].seq < spaces[j].seq })
+
+	fields := []models.SpaceFields{}
+	for _, sp := range spaces {
+		fields = append(fields, sp.fields)
+	}
+	return fields
+}
+
+func (s *MemoryState) spaceApps(spaceGUID string) []*memoryApp {
+
+	apps := []*memoryApp{}
+	for _, a := range s.apps {
+		if a.fields.SpaceGUID == spaceGUID {
+			apps = append(apps, a)
+		}
+	}
+	sort.Slice(apps, func(i, j int) bool { return apps[i].seq < apps[j].seq })
+	return apps
+}
+
+func (s *MemoryState) spaceRoutes(spaceGUID string) []*memoryRoute {
+
+	routes := []*memoryRoute{}
+	for _, r := range s.routes {
+		if len(spaceGUID) == 0 || r.spaceGUID == spaceGUID {
+			routes = append(routes, r)
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].seq < routes[j].seq })
+	return routes
+}
+
+func (s *MemoryState) spaceServiceInstances(spaceGUID string) []*memoryServiceInstance {
+
+	instances := []*memoryServiceInstance{}
+	for _, si := range s.serviceInstances {
+		if len(spaceGUID) == 0 || si.spaceGUID == spaceGUID {
+			instances = append(instances, si)
+		}
+	}
+	sort.Slice(instances, func(i, j int) bool { return instances[i].seq < instances[j].seq })
+	return instances
+}
+
+func (s *MemoryState) instanceBindings(instanceGUID string) []*memoryServiceBinding {
+
+	bindings := []*memoryServiceBinding{}
+	for _, b := range s.serviceBindings {
+		if b.instanceGUID == instanceGUID {
+			bindings = append(bindings, b)
+		}
+	}
+	sort.Slice(bindings, func(i, j int) bool { return bindings[i].seq < bindings[j].seq })
+	return bindings
+}
+
+func (s *MemoryState) serviceKeyFields(instanceGUID string) []models.ServiceKeyFields {
+
+	keys := []*memoryServiceKey{}
+	for _, k := range s.serviceKeys {
+		if k.instanceGUID == instanceGUID {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].seq < keys[j].seq })
+
+	fields := []models.ServiceKeyFields{}
+	for _, k := range keys {
+		fields = append(fields, s.serviceKey(k).Fields)
+	}
+	return fields
+}
+
+func (s *MemoryState) offerings(label string) models.ServiceOfferings {
+
+	services := []*memoryService{}
+	for _, sv := range s.services {
+		if len(label) == 0 || sv.fields.Label == label {
+			services = append(services, sv)
+		}
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].seq < services[j].seq })
+
+	offerings := models.ServiceOfferings{}
+	for _, sv := range services {
+		offerings = append(offerings, models.ServiceOffering{
+			ServiceOfferingFields: sv.fields,
+			Plans:                 s.servicePlans(sv.fields.GUID),
+		})
+	}
+	return offerings
+}
+
+func (s *MemoryState) servicePlans(serviceGUID string) []models.ServicePlanFields {
+
+	plans := []*memoryPlan{}
+	for _, p := range s.plans {
+		if p.fields.ServiceOfferingGUID == serviceGUID {
+			plans = append(plans, p)
+		}
+	}
+	sort.Slice(plans, func(i, j int) bool { return plans[i].seq < plans[j].seq })
+
+	fields := []models.ServicePlanFields{}
+	for _, p := range plans {
+		fields = append(fields, p.fields)
+	}
+	return fields
+}
+
+// Models of the state as returned by the CLI repositories.
+// The mutex must be held when calling the following.
+
+func (s *MemoryState) routeFields(r *memoryRoute) models.Route {
+
+	route := models.Route{
+		GUID: r.guid,
+		Host: r.host,
+		Path: r.path,
+		Port: r.port,
+		Apps: []models.ApplicationFields{},
+	}
+	if d, ok := s.domains[r.domainGUID]; ok {
+		route.Domain = d.fields
+	}
+	if sp, ok := s.spaces[r.spaceGUID]; ok {
+		route.Space = sp.fields
+	}
+	for _, g := range r.appGUIDs {
+		if a, ok := s.apps[g]; ok {
+			route.Apps = append(route.Apps, a.fields)
+		}
+	}
+	return route
+}
+
+func (s *MemoryState) application(a *memoryApp) models.Application {
+
+	app := models.Application{
+		ApplicationFields: a.fields,
+		Routes:            []models.RouteSummary{},
+		Services:          []models.ServicePlanSummary{},
+	}
+	if app.State == "STARTED" {
+		app.RunningInstances = app.InstanceCount
+	} else {
+		app.RunningInstances = 0
+	}
+	for _, r := range s.spaceRoutes("") {
+		if containsString(r.appGUIDs, a.fields.GUID) {
+			route := s.routeFields(r)
+			app.Routes = append(app.Routes, models.RouteSummary{
+				GUID:   route.GUID,
+				Host:   route.Host,
+				Domain: route.Domain,
+				Path:   route.Path,
+				Port:   route.Port,
+			})
+		}
+	}
+	for _, si := range s.spaceServiceInstances(a.fields.SpaceGUID) {
+		for _, b := range s.instanceBindings(si.fields.GUID) {
+			if b.appGUID == a.fields.GUID {
+				app.Services = append(app.Services, models.ServicePlanSummary{
+					GUID: si.fields.GUID,
+					Name: si.fields.Name,
+				})
+			}
+		}
+	}
+	return app
+}
+
+func (s *MemoryState) serviceInstance(si *memoryServiceInstance) models.ServiceInstance {
+
+	instance := models.ServiceInstance{
+		ServiceInstanceFields: si.fields,
+		ServiceBindings:       []models.ServiceBindingFields{},
+		ServiceKeys:           s.serviceKeyFields(si.fields.GUID),
+	}
+	instance.ApplicationNames = []string{}
+	for _, b := range s.instanceBindings(si.fields.GUID) {
+		instance.ServiceBindings = append(instance.ServiceBindings, s.serviceBinding(b))
+		if a, ok := s.apps[b.appGUID]; ok {
+			instance.ApplicationNames = append(instance.ApplicationNames, a.fields.Name)
+		}
+	}
+	if p, ok := s.plans[si.planGUID]; ok {
+		instance.ServicePlan = p.fields
+		if sv, ok := s.services[p.fields.ServiceOfferingGUID]; ok {
+			instance.ServiceOffering = sv.fields
+		}
+	}
+	return instance
+}
+
+func (s *MemoryState) serviceBinding(b *memoryServiceBinding) models.ServiceBindingFields {
+	return models.ServiceBindingFields{
+		GUID:    b.guid,
+		URL:     fmt.Sprintf("/v2/service_bindings/%s", b.guid),
+		AppGUID: b.appGUID,
+	}
+}
+
+func (s *MemoryState) serviceKey(k *memoryServiceKey) models.ServiceKey {
+
+	key := models.ServiceKey{
+		Fields: models.ServiceKeyFields{
+			GUID:                k.guid,
+			Name:                k.name,
+			URL:                 fmt.Sprintf("/v2/service_keys/%s", k.guid),
+			ServiceInstanceGUID: k.instanceGUID,
+			ServiceInstanceURL:  fmt.Sprintf("/v2/service_instances/%s", k.instanceGUID),
+		},
+	}
+	if si, ok := s.serviceInstances[k.instanceGUID]; ok {
+		key.Credentials = si.credentials
+	}
+	return key
+}
+
+func (s *MemoryState) userProvidedService(si *memoryServiceInstance) models.UserProvidedService {
+	return models.UserProvidedService{
+		Name:            si.fields.Name,
+		Credentials:     si.credentials,
+		SpaceGUID:       si.spaceGUID,
+		SysLogDrainURL:  si.fields.SysLogDrainURL,
+		RouteServiceURL: si.fields.RouteServiceURL,
+	}
+}
+
+func (s *MemoryState) appEnvironment(a *memoryApp) *cfapi.AppEnvironment {
+
+	env := &cfapi.AppEnvironment{
+		Environment:     map[string]interface{}{},
+		StagingEnvGroup: copyEnvGroup(s.envGroups["staging"]),
+		RunningEnvGroup: copyEnvGroup(s.envGroups["running"]),
+		System:          map[string]interface{}{},
+		Application:     map[string]interface{}{},
+		VCAPServices:    cfapi.VCAPServices{},
+	}
+	for k, v := range a.fields.EnvironmentVars {
+		env.Environment[k] = v
+	}
+
+	for _, si := range s.spaceServiceInstances(a.fields.SpaceGUID) {
+		for _, b := range s.instanceBindings(si.fields.GUID) {
+			if b.appGUID != a.fields.GUID {
+				continue
+			}
+			service := cfapi.VCAPService{
+				Name:         si.fields.Name,
+				InstanceName: si.fields.Name,
+				Label:        "user-provided",
+				Tags:         append([]string{}, si.fields.Tags...),
+				Credentials:  si.credentials,
+				SyslogDrain:  si.fields.SysLogDrainURL,
+			}
+			if p, ok := s.plans[si.planGUID]; ok {
+				service.Plan = p.fields.Name
+				if sv, ok := s.services[p.fields.ServiceOfferingGUID]; ok {
+					service.Label = sv.fields.Label
+				}
+			}
+			env.VCAPServices[service.Label] = append(env.VCAPServices[service.Label], service)
+		}
+	}
+
+	uris := []string{}
+	for _, r := range s.spaceRoutes("") {
+		if containsString(r.appGUIDs, a.fields.GUID) {
+			route := s.routeFields(r)
+			uri := route.Domain.Name
+			if len(route.Host) > 0 {
+				uri = route.Host + "." + uri
+			}
+			uris = append(uris, uri+route.Path)
+		}
+	}
+	env.VCAPApplication = cfapi.VCAPApplication{
+		ApplicationID:   a.fields.GUID,
+		ApplicationName: a.fields.Name,
+		ApplicationURIs: uris,
+		Name:            a.fields.Name,
+		URIs:            uris,
+		SpaceID:         a.fields.SpaceGUID,
+		Limits: cfapi.VCAPApplicationLimits{
+			Disk: a.fields.DiskQuota,
+			FDs:  16384,
+			Mem:  a.fields.Memory,
+		},
+	}
+	if sp, ok := s.spaces[a.fields.SpaceGUID]; ok {
+		env.VCAPApplication.SpaceName = sp.fields.Name
+		env.VCAPApplication.OrganizationID = sp.orgGUID
+		if o, ok := s.orgs[sp.orgGUID]; ok {
+			env.VCAPApplication.OrganizationName = o.fields.Name
+		}
+	}
+
+	env.System["VCAP_SERVICES"] = env.VCAPServices
+	env.Application["VCAP_APPLICATION"] = env.VCAPApplication
+	return env
+}
+
+// stageApp - Stages the package of an app unless
+// staging was made to fail by FailNextStaging
+func (s *MemoryState) stageApp(a *memoryApp) {
+
+	s.addLog(a.fields.GUID, cfapi.AppLog{SourceType: "STG", InstanceID: "0", Message: "Staging app and tracing logs..."})
+	if len(s.stagingFailureReason) > 0 {
+		s.addLog(a.fields.GUID, cfapi.AppLog{SourceType: "STG", InstanceID: "0", Message: s.stagingFailureDescription, IsError: true})
+		a.fields.PackageState = cfapi.PackageFailed
+		a.fields.StagingFailedReason = s.stagingFailureReason
+		a.stagingFailedDescription = s.stagingFailureDescription
+		s.stagingFailureReason, s.stagingFailureDescription = "", ""
+		return
+	}
+	s.addLog(a.fields.GUID, cfapi.AppLog{SourceType: "STG", InstanceID: "0", Message: "Uploading droplet..."})
+	a.fields.PackageState = cfapi.PackageStaged
+	a.fields.StagingFailedReason = ""
+	a.stagingFailedDescription = ""
+}
+
+// appInstances - Returns the instances of a started app. All instances
+// run as soon as the app is started unless they were made to crash.
+func (s *MemoryState) appInstances(a *memoryApp) ([]cfapi.AppInstance, error) {
+
+	if a.fields.State != "STARTED" {
+		return nil, errors.NewHTTPError(400, errors.InstancesError, fmt.Sprintf(
+			"Instances error: Request failed for app: %s as the app is in stopped state.", a.fields.Name))
+	}
+	if a.fields.PackageState == cfapi.PackageFailed {
+		return nil, errors.NewHTTPError(400, "170001", fmt.Sprintf(
+			"Staging error: %s", a.fields.StagingFailedReason))
+	}
+	if a.fields.PackageState != "STAGED" && len(a.fields.DockerImage) == 0 {
+		return nil, errors.NewHTTPError(400, errors.NotStaged, "App has not finished staging")
+	}
+
+	since := a.fields.PackageUpdatedAt
+	if since == nil {
+		now := time.Now().UTC()
+		since = &now
+	}
+	instances := []cfapi.AppInstance{}
+	for i := 0; i < a.fields.InstanceCount; i++ {
+		instance := cfapi.AppInstance{
+			Index: i,
+			State: cfapi.AppInstanceRunning,
+			Since: *since,
+		}
+		if reason, ok := a.crashed[i]; ok {
+			instance.State = cfapi.AppInstanceCrashed
+			instance.Details = reason
+		} else {
+			instance.Uptime = time.Since(*since)
+			instance.MemQuota = a.fields.Memory * 1024 * 1024
+			instance.DiskQuota = a.fields.DiskQuota * 1024 * 1024
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(values []string, value string) []string {
+	result := []string{}
+	for _, v := range values {
+		if v != value {
+			result = append(result, v)
+		}
+	}
+	return result
+}
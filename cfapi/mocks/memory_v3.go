@@ -0,0 +1,278 @@
+package mock_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/cf/errors"
+	"github.com/mevansam/cf-cli-api/cfapi"
+)
+
+// v3Session - Backs the v3 API functions of the given session by the
+// state. Each app has at most one package holding its bits and one
+// droplet whose GUIDs are derived from the app's GUID.
+func (s *MemoryState) v3Session(session *MockSession) {
+
+	session.MockGetAPIInfo = func() (cfapi.APIInfo, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		return s.apiInfo, nil
+	}
+
+	session.MockGetV3App = func(appGUID string) (cfapi.V3App, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		a, ok := s.apps[appGUID]
+		if !ok {
+			return cfapi.V3App{}, v3NotFound("App", appGUID)
+		}
+		return v3App(a), nil
+	}
+	session.MockGetV3AppsInSpace = func(spaceGUID string) ([]cfapi.V3App, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		apps := []cfapi.V3App{}
+		for _, a := range s.spaceApps(spaceGUID) {
+			apps = append(apps, v3App(a))
+		}
+		sort.Slice(apps, func(i, j int) bool { return apps[i].Name < apps[j].Name })
+		return apps, nil
+	}
+	session.MockGetV3Packages = func(appGUID string) ([]cfapi.V3Package, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		a, ok := s.apps[appGUID]
+		if !ok {
+			return nil, v3NotFound("App", appGUID)
+		}
+		packages := []cfapi.V3Package{}
+		if a.bits != nil {
+			packages = append(packages, cfapi.V3Package{
+				GUID:     "package-" + appGUID,
+				Type:     "bits",
+				State:    "READY",
+				Checksum: v3Checksum(a.bits),
+			})
+		}
+		return packages, nil
+	}
+	session.MockGetV3Droplets = func(appGUID string) ([]cfapi.V3Droplet, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		a, ok := s.apps[appGUID]
+		if !ok {
+			return nil, v3NotFound("App", appGUID)
+		}
+		droplets := []cfapi.V3Droplet{}
+		if a.droplet != nil {
+			droplets = append(droplets, v3Droplet(a))
+		}
+		return droplets, nil
+	}
+	session.MockGetV3CurrentDroplet = func(appGUID string) (cfapi.V3Droplet, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		a, ok := s.apps[appGUID]
+		if !ok || a.droplet == nil {
+			return cfapi.V3Droplet{}, v3NotFound("Droplet", appGUID)
+		}
+		return v3Droplet(a), nil
+	}
+	session.MockSetV3CurrentDroplet = func(appGUID, dropletGUID string) error {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		a, ok := s.apps[appGUID]
+		if !ok {
+			return v3NotFound("App", appGUID)
+		}
+		if a.droplet == nil || dropletGUID != "droplet-"+appGUID {
+			return v3NotFound("Droplet", dropletGUID)
+		}
+		return nil
+	}
+
+	session.MockCreateV3Build = func(packageGUID string) (cfapi.V3Build, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		a, ok := s.apps[strings.TrimPrefix(packageGUID, "package-")]
+		if !ok || a.bits == nil {
+			return cfapi.V3Build{}, v3NotFound("Package", packageGUID)
+		}
+		a.droplet = a.bits
+		a.fields.PackageState = "STAGED"
+
+		build := cfapi.V3Build{
+			GUID:        s.newGUID("build"),
+			State:       "STAGED",
+			PackageGUID: packageGUID,
+			DropletGUID: "droplet-" + a.fields.GUID,
+			CreatedAt:   time.Now().UTC(),
+		}
+		s.builds[build.GUID] = build
+		return build, nil
+	}
+	session.MockGetV3Build = func(buildGUID string) (cfapi.V3Build, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		build, ok := s.builds[buildGUID]
+		if !ok {
+			return cfapi.V3Build{}, v3NotFound("Build", buildGUID)
+		}
+		return build, nil
+	}
+
+	session.MockGetV3Processes = func(appGUID string) ([]cfapi.V3Process, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		a, ok := s.apps[appGUID]
+		if !ok {
+			return nil, v3NotFound("App", appGUID)
+		}
+		healthCheckType := a.fields.HealthCheckType
+		if len(healthCheckType) == 0 {
+			healthCheckType = "port"
+		}
+		return []cfapi.V3Process{
+			{
+				GUID:            appGUID,
+				Type:            "web",
+				Command:         a.fields.Command,
+				Instances:       a.fields.InstanceCount,
+				MemoryInMB:      int(a.fields.Memory),
+				DiskInMB:        int(a.fields.DiskQuota),
+				HealthCheckType: healthCheckType,
+			},
+		}, nil
+	}
+
+	session.MockCreateV3Deployment = func(appGUID, dropletGUID string) (cfapi.V3Deployment, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		a, ok := s.apps[appGUID]
+		if !ok {
+			return cfapi.V3Deployment{}, v3NotFound("App", appGUID)
+		}
+		if len(dropletGUID) == 0 {
+			dropletGUID = "droplet-" + appGUID
+		}
+		if a.droplet == nil || dropletGUID != "droplet-"+appGUID {
+			return cfapi.V3Deployment{}, v3NotFound("Droplet", dropletGUID)
+		}
+		a.fields.State = "STARTED"
+		a.crashed = nil
+
+		deployment := cfapi.V3Deployment{
+			GUID:         s.newGUID("deployment"),
+			State:        "DEPLOYED",
+			StatusValue:  "FINALIZED",
+			StatusReason: "DEPLOYED",
+			AppGUID:      appGUID,
+			DropletGUID:  dropletGUID,
+			CreatedAt:    time.Now().UTC(),
+		}
+		s.deployments[deployment.GUID] = deployment
+		return deployment, nil
+	}
+	session.MockGetV3Deployment = func(deploymentGUID string) (cfapi.V3Deployment, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		deployment, ok := s.deployments[deploymentGUID]
+		if !ok {
+			return cfapi.V3Deployment{}, v3NotFound("Deployment", deploymentGUID)
+		}
+		return deployment, nil
+	}
+
+	session.MockGetV3AuditEvents = func(filter cfapi.V3AuditEventFilter) ([]cfapi.V3AuditEvent, error) {
+
+		matches := func(values []string, value string) bool {
+			if len(values) == 0 {
+				return true
+			}
+			for _, v := range values {
+				if v == value {
+					return true
+				}
+			}
+			return false
+		}
+		memoryEvents := s.eventsAfter(filter.From, filter.Inclusive, func(e *memoryEvent) bool {
+			return matches(filter.SpaceGUIDs, e.SpaceGUID) &&
+				matches(filter.TargetGUIDs, e.Actee) &&
+				matches(filter.Types, e.Type)
+		})
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		events := []cfapi.V3AuditEvent{}
+		for _, e := range memoryEvents {
+			event := cfapi.V3AuditEvent{
+				GUID:       e.guid,
+				Type:       e.Type,
+				CreatedAt:  e.Timestamp,
+				ActorGUID:  "user-guid",
+				ActorType:  "user",
+				ActorName:  "admin",
+				TargetGUID: e.Actee,
+				TargetType: e.ActeeType,
+				TargetName: e.ActeeName,
+				SpaceGUID:  e.SpaceGUID,
+				Data:       e.Metadata,
+			}
+			if sp, ok := s.spaces[e.SpaceGUID]; ok {
+				event.OrgGUID = sp.orgGUID
+			}
+			events = append(events, event)
+		}
+		return events, nil
+	}
+}
+
+// v3NotFound - Returns the error the v3 API responds with
+// when a resource with the given GUID does not exist
+func v3NotFound(resource, guid string) error {
+	return errors.NewHTTPError(404, "10010", fmt.Sprintf("%s not found: %s", resource, guid))
+}
+
+func v3App(a *memoryApp) cfapi.V3App {
+	lifecycleType := "buildpack"
+	if len(a.fields.DockerImage) > 0 {
+		lifecycleType = "docker"
+	}
+	return cfapi.V3App{
+		GUID:          a.fields.GUID,
+		Name:          a.fields.Name,
+		State:         strings.ToUpper(a.fields.State),
+		LifecycleType: lifecycleType,
+		SpaceGUID:     a.fields.SpaceGUID,
+	}
+}
+
+func v3Droplet(a *memoryApp) cfapi.V3Droplet {
+	return cfapi.V3Droplet{
+		GUID:     "droplet-" + a.fields.GUID,
+		State:    "STAGED",
+		Checksum: v3Checksum(a.droplet),
+	}
+}
+
+func v3Checksum(content []byte) cfapi.V3Checksum {
+	sum := sha256.Sum256(content)
+	return cfapi.V3Checksum{Type: "sha256", Value: hex.EncodeToString(sum[:])}
+}
@@ -8,8 +8,14 @@ import (
 	"code.cloudfoundry.org/cli/cf/api/appevents"
 	"code.cloudfoundry.org/cli/cf/api/applicationbits"
 	"code.cloudfoundry.org/cli/cf/api/applications"
+	"code.cloudfoundry.org/cli/cf/api/environmentvariablegroups"
+	"code.cloudfoundry.org/cli/cf/api/featureflags"
 	"code.cloudfoundry.org/cli/cf/api/organizations"
+	"code.cloudfoundry.org/cli/cf/api/quotas"
+	"code.cloudfoundry.org/cli/cf/api/securitygroups"
+	"code.cloudfoundry.org/cli/cf/api/spacequotas"
 	"code.cloudfoundry.org/cli/cf/api/spaces"
+	"code.cloudfoundry.org/cli/cf/api/stacks"
 	"code.cloudfoundry.org/cli/cf/i18n"
 	"code.cloudfoundry.org/cli/cf/models"
 	"github.com/mevansam/cf-cli-api/cfapi"
@@ -22,7 +28,8 @@ type MockSessionProvider struct {
 
 // MockSession -
 type MockSession struct {
-	Logger *cfapi.Logger
+	Logger   *cfapi.Logger
+	Progress cfapi.ProgressReporter
 
 	MockHasTarget            func() bool
 	MockSetSessionTarget     func(string, string) error
@@ -45,13 +52,93 @@ type MockSession struct {
 	MockAppEvents            func() appevents.Repository
 	MockRoutes               func() api.RouteRepository
 	MockDomains              func() api.DomainRepository
+	MockQuotas               func() quotas.QuotaRepository
+	MockSpaceQuotas          func() spacequotas.SpaceQuotaRepository
+	MockSecurityGroups       func() securitygroups.SecurityGroupRepo
+	MockBuildpacks           func() api.BuildpackRepository
+	MockStacks               func() stacks.StackRepository
+
+	MockFeatureFlags              func() featureflags.FeatureFlagRepository
+	MockEnvironmentVariableGroups func() environmentvariablegroups.Repository
+
+	MockServiceBrokers          func() api.ServiceBrokerRepository
+	MockServicePlanVisibilities func() api.ServicePlanVisibilityRepository
+	MockRouterGroups            func() api.RoutingAPIRepository
 
 	MockGetAllEventsInSpace func(time.Time, bool) (map[string]cfapi.CfEvent, error)
 	MockGetAllEventsForApp  func(string, time.Time, bool) (cfapi.CfEvent, error)
 
 	MockGetServiceCredentials func(models.ServiceBindingFields) (*cfapi.ServiceBindingDetail, error)
+	MockGetAppEnvironment     func(string) (*cfapi.AppEnvironment, error)
+	MockGetOrgQuotaUsage      func(string) (cfapi.QuotaUsage, error)
+	MockGetSpaceQuotaUsage    func(string) (cfapi.QuotaUsage, error)
 	MockDownloadAppContent    func(string, *os.File, bool) error
-	MockUploadDroplet         func(string, string, *os.File) error
+	MockUploadDroplet         func(string, *os.File) error
+	MockUploadBuildpack       func(string, *os.File) error
+
+	MockBindSecurityGroupToSpace     func(string, string, cfapi.SecurityGroupLifecycle) error
+	MockUnbindSecurityGroupFromSpace func(string, string, cfapi.SecurityGroupLifecycle) error
+	MockGetSpaceSecurityGroups       func(string, cfapi.SecurityGroupLifecycle) ([]models.SecurityGroupFields, error)
+
+	MockGetOrgUsers    func(string) ([]cfapi.UserRoles, error)
+	MockGetSpaceUsers  func(string) ([]cfapi.UserRoles, error)
+	MockSetOrgRole     func(string, string, string, models.Role) error
+	MockUnsetOrgRole   func(string, string, string, models.Role) error
+	MockSetSpaceRole   func(string, string, string, models.Role) error
+	MockUnsetSpaceRole func(string, string, string, models.Role) error
+
+	MockEnsureOrg          func(string, cfapi.OrgOptions) (models.OrganizationFields, bool, error)
+	MockEnsureSpace        func(string, string, cfapi.SpaceOptions) (models.SpaceFields, bool, error)
+	MockPreviewOrgDelete   func(string) (cfapi.DeletePreview, error)
+	MockPreviewSpaceDelete func(string) (cfapi.DeletePreview, error)
+	MockDeleteOrg          func(string) error
+	MockDeleteSpace        func(string) error
+
+	MockSnapshotFoundationSettings func() (cfapi.FoundationSettings, error)
+
+	MockGetServiceBrokerCatalog  func(string) (models.ServiceBroker, error)
+	MockEnableServicePlanAccess  func(string, string) error
+	MockDisableServicePlanAccess func(string, string) error
+
+	MockCreatePrivateDomain        func(string, string) (models.DomainFields, error)
+	MockCreateSharedDomain         func(string, string) (models.DomainFields, error)
+	MockDeleteDomain               func(models.DomainFields) error
+	MockGetPrivateDomainSharedOrgs func(string) ([]models.OrganizationFields, error)
+	MockSetPrivateDomainSharedOrgs func(string, []string) error
+
+	MockCreateRoute      func(string, models.DomainFields, string, string, int, bool) (models.Route, error)
+	MockRouteReserved    func(models.DomainFields, string, string, int) (bool, error)
+	MockMapRoute         func(string, string, int) error
+	MockGetRouteMappings func(string) ([]cfapi.RouteMapping, error)
+
+	MockWaitForJob             func(string, time.Duration) error
+	MockWaitForServiceInstance func(string, time.Duration) (models.LastOperationFields, error)
+
+	MockGetAppInstances     func(string) ([]cfapi.AppInstance, error)
+	MockWaitForAppInstances func(string, int, time.Duration) ([]cfapi.AppInstance, error)
+
+	MockStartApp   func(string, time.Duration) ([]cfapi.AppInstance, error)
+	MockStopApp    func(string, time.Duration) error
+	MockRestartApp func(string, time.Duration) ([]cfapi.AppInstance, error)
+	MockRestageApp func(string, time.Duration) ([]cfapi.AppInstance, error)
+	MockScaleApp   func(string, cfapi.AppScale, time.Duration) ([]cfapi.AppInstance, error)
+
+	MockGetRecentLogs func(string, int) ([]cfapi.AppLog, error)
+	MockStreamLogs    func(string, <-chan struct{}) (<-chan cfapi.AppLog, <-chan error)
+
+	MockGetAPIInfo          func() (cfapi.APIInfo, error)
+	MockGetV3App            func(string) (cfapi.V3App, error)
+	MockGetV3AppsInSpace    func(string) ([]cfapi.V3App, error)
+	MockGetV3Packages       func(string) ([]cfapi.V3Package, error)
+	MockGetV3Droplets       func(string) ([]cfapi.V3Droplet, error)
+	MockGetV3CurrentDroplet func(string) (cfapi.V3Droplet, error)
+	MockSetV3CurrentDroplet func(string, string) error
+	MockCreateV3Build       func(string) (cfapi.V3Build, error)
+	MockGetV3Build          func(string) (cfapi.V3Build, error)
+	MockGetV3Processes      func(string) ([]cfapi.V3Process, error)
+	MockCreateV3Deployment  func(string, string) (cfapi.V3Deployment, error)
+	MockGetV3Deployment     func(string) (cfapi.V3Deployment, error)
+	MockGetV3AuditEvents    func(cfapi.V3AuditEventFilter) ([]cfapi.V3AuditEvent, error)
 }
 
 // mockLocale -
@@ -101,6 +188,11 @@ func (m *MockSession) GetSessionLogger() *cfapi.Logger {
 	return m.Logger
 }
 
+// SetProgressReporter -
+func (m *MockSession) SetProgressReporter(reporter cfapi.ProgressReporter) {
+	m.Progress = reporter
+}
+
 // HasTarget -
 func (m *MockSession) HasTarget() bool {
 	return m.MockHasTarget()
@@ -201,11 +293,61 @@ func (m *MockSession) Domains() api.DomainRepository {
 	return m.MockDomains()
 }
 
+// Quotas -
+func (m *MockSession) Quotas() quotas.QuotaRepository {
+	return m.MockQuotas()
+}
+
+// SpaceQuotas -
+func (m *MockSession) SpaceQuotas() spacequotas.SpaceQuotaRepository {
+	return m.MockSpaceQuotas()
+}
+
+// SecurityGroups -
+func (m *MockSession) SecurityGroups() securitygroups.SecurityGroupRepo {
+	return m.MockSecurityGroups()
+}
+
+// Buildpacks -
+func (m *MockSession) Buildpacks() api.BuildpackRepository {
+	return m.MockBuildpacks()
+}
+
+// Stacks -
+func (m *MockSession) Stacks() stacks.StackRepository {
+	return m.MockStacks()
+}
+
+// FeatureFlags -
+func (m *MockSession) FeatureFlags() featureflags.FeatureFlagRepository {
+	return m.MockFeatureFlags()
+}
+
+// EnvironmentVariableGroups -
+func (m *MockSession) EnvironmentVariableGroups() environmentvariablegroups.Repository {
+	return m.MockEnvironmentVariableGroups()
+}
+
 // ServiceBindings -
 func (m *MockSession) ServiceBindings() api.ServiceBindingRepository {
 	return m.MockServiceBindings()
 }
 
+// ServiceBrokers -
+func (m *MockSession) ServiceBrokers() api.ServiceBrokerRepository {
+	return m.MockServiceBrokers()
+}
+
+// ServicePlanVisibilities -
+func (m *MockSession) ServicePlanVisibilities() api.ServicePlanVisibilityRepository {
+	return m.MockServicePlanVisibilities()
+}
+
+// RouterGroups -
+func (m *MockSession) RouterGroups() api.RoutingAPIRepository {
+	return m.MockRouterGroups()
+}
+
 // GetAllEventsInSpace -
 func (m *MockSession) GetAllEventsInSpace(from time.Time, inclusive bool) (events map[string]cfapi.CfEvent, err error) {
 	events, err = m.MockGetAllEventsInSpace(from, inclusive)
@@ -223,12 +365,293 @@ func (m *MockSession) GetServiceCredentials(serviceBinding models.ServiceBinding
 	return m.MockGetServiceCredentials(serviceBinding)
 }
 
+// GetAppEnvironment -
+func (m *MockSession) GetAppEnvironment(appGUID string) (*cfapi.AppEnvironment, error) {
+	return m.MockGetAppEnvironment(appGUID)
+}
+
+// GetOrgQuotaUsage -
+func (m *MockSession) GetOrgQuotaUsage(orgGUID string) (cfapi.QuotaUsage, error) {
+	return m.MockGetOrgQuotaUsage(orgGUID)
+}
+
+// GetSpaceQuotaUsage -
+func (m *MockSession) GetSpaceQuotaUsage(spaceGUID string) (cfapi.QuotaUsage, error) {
+	return m.MockGetSpaceQuotaUsage(spaceGUID)
+}
+
+// BindSecurityGroupToSpace -
+func (m *MockSession) BindSecurityGroupToSpace(securityGroupGUID, spaceGUID string, lifecycle cfapi.SecurityGroupLifecycle) error {
+	return m.MockBindSecurityGroupToSpace(securityGroupGUID, spaceGUID, lifecycle)
+}
+
+// UnbindSecurityGroupFromSpace -
+func (m *MockSession) UnbindSecurityGroupFromSpace(securityGroupGUID, spaceGUID string, lifecycle cfapi.SecurityGroupLifecycle) error {
+	return m.MockUnbindSecurityGroupFromSpace(securityGroupGUID, spaceGUID, lifecycle)
+}
+
+// GetSpaceSecurityGroups -
+func (m *MockSession) GetSpaceSecurityGroups(spaceGUID string, lifecycle cfapi.SecurityGroupLifecycle) ([]models.SecurityGroupFields, error) {
+	return m.MockGetSpaceSecurityGroups(spaceGUID, lifecycle)
+}
+
+// GetOrgUsers -
+func (m *MockSession) GetOrgUsers(orgGUID string) ([]cfapi.UserRoles, error) {
+	return m.MockGetOrgUsers(orgGUID)
+}
+
+// GetSpaceUsers -
+func (m *MockSession) GetSpaceUsers(spaceGUID string) ([]cfapi.UserRoles, error) {
+	return m.MockGetSpaceUsers(spaceGUID)
+}
+
+// SetOrgRole -
+func (m *MockSession) SetOrgRole(orgGUID, username, origin string, role models.Role) error {
+	return m.MockSetOrgRole(orgGUID, username, origin, role)
+}
+
+// UnsetOrgRole -
+func (m *MockSession) UnsetOrgRole(orgGUID, username, origin string, role models.Role) error {
+	return m.MockUnsetOrgRole(orgGUID, username, origin, role)
+}
+
+// SetSpaceRole -
+func (m *MockSession) SetSpaceRole(spaceGUID, username, origin string, role models.Role) error {
+	return m.MockSetSpaceRole(spaceGUID, username, origin, role)
+}
+
+// UnsetSpaceRole -
+func (m *MockSession) UnsetSpaceRole(spaceGUID, username, origin string, role models.Role) error {
+	return m.MockUnsetSpaceRole(spaceGUID, username, origin, role)
+}
+
+// EnsureOrg -
+func (m *MockSession) EnsureOrg(name string, options cfapi.OrgOptions) (models.OrganizationFields, bool, error) {
+	return m.MockEnsureOrg(name, options)
+}
+
+// EnsureSpace -
+func (m *MockSession) EnsureSpace(orgGUID, name string, options cfapi.SpaceOptions) (models.SpaceFields, bool, error) {
+	return m.MockEnsureSpace(orgGUID, name, options)
+}
+
+// PreviewOrgDelete -
+func (m *MockSession) PreviewOrgDelete(orgGUID string) (cfapi.DeletePreview, error) {
+	return m.MockPreviewOrgDelete(orgGUID)
+}
+
+// PreviewSpaceDelete -
+func (m *MockSession) PreviewSpaceDelete(spaceGUID string) (cfapi.DeletePreview, error) {
+	return m.MockPreviewSpaceDelete(spaceGUID)
+}
+
+// DeleteOrg -
+func (m *MockSession) DeleteOrg(orgGUID string) error {
+	return m.MockDeleteOrg(orgGUID)
+}
+
+// DeleteSpace -
+func (m *MockSession) DeleteSpace(spaceGUID string) error {
+	return m.MockDeleteSpace(spaceGUID)
+}
+
+// SnapshotFoundationSettings -
+func (m *MockSession) SnapshotFoundationSettings() (cfapi.FoundationSettings, error) {
+	return m.MockSnapshotFoundationSettings()
+}
+
+// GetServiceBrokerCatalog -
+func (m *MockSession) GetServiceBrokerCatalog(brokerGUID string) (models.ServiceBroker, error) {
+	return m.MockGetServiceBrokerCatalog(brokerGUID)
+}
+
+// EnableServicePlanAccess -
+func (m *MockSession) EnableServicePlanAccess(planGUID, orgGUID string) error {
+	return m.MockEnableServicePlanAccess(planGUID, orgGUID)
+}
+
+// DisableServicePlanAccess -
+func (m *MockSession) DisableServicePlanAccess(planGUID, orgGUID string) error {
+	return m.MockDisableServicePlanAccess(planGUID, orgGUID)
+}
+
+// CreatePrivateDomain -
+func (m *MockSession) CreatePrivateDomain(orgGUID, name string) (models.DomainFields, error) {
+	return m.MockCreatePrivateDomain(orgGUID, name)
+}
+
+// CreateSharedDomain -
+func (m *MockSession) CreateSharedDomain(name, routerGroupName string) (models.DomainFields, error) {
+	return m.MockCreateSharedDomain(name, routerGroupName)
+}
+
+// DeleteDomain -
+func (m *MockSession) DeleteDomain(domain models.DomainFields) error {
+	return m.MockDeleteDomain(domain)
+}
+
+// GetPrivateDomainSharedOrgs -
+func (m *MockSession) GetPrivateDomainSharedOrgs(domainGUID string) ([]models.OrganizationFields, error) {
+	return m.MockGetPrivateDomainSharedOrgs(domainGUID)
+}
+
+// SetPrivateDomainSharedOrgs -
+func (m *MockSession) SetPrivateDomainSharedOrgs(domainGUID string, orgGUIDs []string) error {
+	return m.MockSetPrivateDomainSharedOrgs(domainGUID, orgGUIDs)
+}
+
+// CreateRoute -
+func (m *MockSession) CreateRoute(spaceGUID string, domain models.DomainFields,
+	host, path string, port int, randomPort bool) (models.Route, error) {
+	return m.MockCreateRoute(spaceGUID, domain, host, path, port, randomPort)
+}
+
+// RouteReserved -
+func (m *MockSession) RouteReserved(domain models.DomainFields, host, path string, port int) (bool, error) {
+	return m.MockRouteReserved(domain, host, path, port)
+}
+
+// MapRoute -
+func (m *MockSession) MapRoute(routeGUID, appGUID string, appPort int) error {
+	return m.MockMapRoute(routeGUID, appGUID, appPort)
+}
+
+// GetRouteMappings -
+func (m *MockSession) GetRouteMappings(routeGUID string) ([]cfapi.RouteMapping, error) {
+	return m.MockGetRouteMappings(routeGUID)
+}
+
 // DownloadAppContent -
 func (m *MockSession) DownloadAppContent(appGUID string, outputFile *os.File, asDroplet bool) error {
 	return m.MockDownloadAppContent(appGUID, outputFile, asDroplet)
 }
 
 // UploadDroplet -
-func (m *MockSession) UploadDroplet(appGUID string, contentType string, dropletUploadRequest *os.File) error {
-	return m.MockUploadDroplet(appGUID, contentType, dropletUploadRequest)
+func (m *MockSession) UploadDroplet(appGUID string, droplet *os.File) error {
+	return m.MockUploadDroplet(appGUID, droplet)
+}
+
+// UploadBuildpack -
+func (m *MockSession) UploadBuildpack(buildpackGUID string, buildpack *os.File) error {
+	return m.MockUploadBuildpack(buildpackGUID, buildpack)
+}
+
+// WaitForJob -
+func (m *MockSession) WaitForJob(jobGUID string, timeout time.Duration) error {
+	return m.MockWaitForJob(jobGUID, timeout)
+}
+
+// WaitForServiceInstance -
+func (m *MockSession) WaitForServiceInstance(serviceInstanceGUID string, timeout time.Duration) (models.LastOperationFields, error) {
+	return m.MockWaitForServiceInstance(serviceInstanceGUID, timeout)
+}
+
+// GetAppInstances -
+func (m *MockSession) GetAppInstances(appGUID string) ([]cfapi.AppInstance, error) {
+	return m.MockGetAppInstances(appGUID)
+}
+
+// WaitForAppInstances -
+func (m *MockSession) WaitForAppInstances(appGUID string, running int, timeout time.Duration) ([]cfapi.AppInstance, error) {
+	return m.MockWaitForAppInstances(appGUID, running, timeout)
+}
+
+// StartApp -
+func (m *MockSession) StartApp(appGUID string, timeout time.Duration) ([]cfapi.AppInstance, error) {
+	return m.MockStartApp(appGUID, timeout)
+}
+
+// StopApp -
+func (m *MockSession) StopApp(appGUID string, timeout time.Duration) error {
+	return m.MockStopApp(appGUID, timeout)
+}
+
+// RestartApp -
+func (m *MockSession) RestartApp(appGUID string, timeout time.Duration) ([]cfapi.AppInstance, error) {
+	return m.MockRestartApp(appGUID, timeout)
+}
+
+// RestageApp -
+func (m *MockSession) RestageApp(appGUID string, timeout time.Duration) ([]cfapi.AppInstance, error) {
+	return m.MockRestageApp(appGUID, timeout)
+}
+
+// ScaleApp -
+func (m *MockSession) ScaleApp(appGUID string, scale cfapi.AppScale, timeout time.Duration) ([]cfapi.AppInstance, error) {
+	return m.MockScaleApp(appGUID, scale, timeout)
+}
+
+// GetRecentLogs -
+func (m *MockSession) GetRecentLogs(appGUID string, limit int) ([]cfapi.AppLog, error) {
+	return m.MockGetRecentLogs(appGUID, limit)
+}
+
+// StreamLogs -
+func (m *MockSession) StreamLogs(appGUID string, stop <-chan struct{}) (<-chan cfapi.AppLog, <-chan error) {
+	return m.MockStreamLogs(appGUID, stop)
+}
+
+// GetAPIInfo -
+func (m *MockSession) GetAPIInfo() (cfapi.APIInfo, error) {
+	return m.MockGetAPIInfo()
+}
+
+// GetV3App -
+func (m *MockSession) GetV3App(appGUID string) (cfapi.V3App, error) {
+	return m.MockGetV3App(appGUID)
+}
+
+// GetV3AppsInSpace -
+func (m *MockSession) GetV3AppsInSpace(spaceGUID string) ([]cfapi.V3App, error) {
+	return m.MockGetV3AppsInSpace(spaceGUID)
+}
+
+// GetV3Packages -
+func (m *MockSession) GetV3Packages(appGUID string) ([]cfapi.V3Package, error) {
+	return m.MockGetV3Packages(appGUID)
+}
+
+// GetV3Droplets -
+func (m *MockSession) GetV3Droplets(appGUID string) ([]cfapi.V3Droplet, error) {
+	return m.MockGetV3Droplets(appGUID)
+}
+
+// GetV3CurrentDroplet -
+func (m *MockSession) GetV3CurrentDroplet(appGUID string) (cfapi.V3Droplet, error) {
+	return m.MockGetV3CurrentDroplet(appGUID)
+}
+
+// SetV3CurrentDroplet -
+func (m *MockSession) SetV3CurrentDroplet(appGUID, dropletGUID string) error {
+	return m.MockSetV3CurrentDroplet(appGUID, dropletGUID)
+}
+
+// CreateV3Build -
+func (m *MockSession) CreateV3Build(packageGUID string) (cfapi.V3Build, error) {
+	return m.MockCreateV3Build(packageGUID)
+}
+
+// GetV3Build -
+func (m *MockSession) GetV3Build(buildGUID string) (cfapi.V3Build, error) {
+	return m.MockGetV3Build(buildGUID)
+}
+
+// GetV3Processes -
+func (m *MockSession) GetV3Processes(appGUID string) ([]cfapi.V3Process, error) {
+	return m.MockGetV3Processes(appGUID)
+}
+
+// CreateV3Deployment -
+func (m *MockSession) CreateV3Deployment(appGUID, dropletGUID string) (cfapi.V3Deployment, error) {
+	return m.MockCreateV3Deployment(appGUID, dropletGUID)
+}
+
+// GetV3Deployment -
+func (m *MockSession) GetV3Deployment(deploymentGUID string) (cfapi.V3Deployment, error) {
+	return m.MockGetV3Deployment(deploymentGUID)
+}
+
+// GetV3AuditEvents -
+func (m *MockSession) GetV3AuditEvents(filter cfapi.V3AuditEventFilter) ([]cfapi.V3AuditEvent, error) {
+	return m.MockGetV3AuditEvents(filter)
 }
@@ -1,5 +1,13 @@
 package cfapi
 
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/cf/models"
+)
+
 // Model structs not present in CF CLI API
 
 // ServiceBindingDetail -
@@ -10,3 +18,360 @@ type ServiceBindingDetail struct {
 		Credentials         map[string]interface{} `json:"credentials,omitempty"`
 	} `json:"entity,omitempty"`
 }
+
+// AppEnvironment - The environment an app's instances run with. The
+// system and application provided variables are parsed into typed
+// VCAP_SERVICES and VCAP_APPLICATION values. SystemRedacted is set
+// when the user may not read the system provided variables.
+type AppEnvironment struct {
+	Environment     map[string]interface{}
+	StagingEnvGroup map[string]interface{}
+	RunningEnvGroup map[string]interface{}
+
+	System      map[string]interface{}
+	Application map[string]interface{}
+
+	VCAPServices    VCAPServices
+	VCAPApplication VCAPApplication
+	SystemRedacted  bool
+}
+
+// VCAPServices - The service instances bound to an
+// app indexed by the label of their service offering
+type VCAPServices map[string][]VCAPService
+
+// Find - Returns the bound service instance with the given name
+func (v VCAPServices) Find(name string) (VCAPService, bool) {
+	for _, instances := range v {
+		for _, instance := range instances {
+			if instance.Name == name {
+				return instance, true
+			}
+		}
+	}
+	return VCAPService{}, false
+}
+
+// VCAPService - A service instance bound to an app
+type VCAPService struct {
+	Name         string                 `json:"name"`
+	InstanceName string                 `json:"instance_name,omitempty"`
+	BindingName  string                 `json:"binding_name,omitempty"`
+	Label        string                 `json:"label"`
+	Provider     string                 `json:"provider,omitempty"`
+	Plan         string                 `json:"plan"`
+	Tags         []string               `json:"tags"`
+	Credentials  map[string]interface{} `json:"credentials"`
+	SyslogDrain  string                 `json:"syslog_drain_url,omitempty"`
+}
+
+// VCAPApplication - The description of an app its instances are given
+type VCAPApplication struct {
+	ApplicationID      string                `json:"application_id"`
+	ApplicationName    string                `json:"application_name"`
+	ApplicationURIs    []string              `json:"application_uris"`
+	ApplicationVersion string                `json:"application_version"`
+	Name               string                `json:"name"`
+	URIs               []string              `json:"uris"`
+	Version            string                `json:"version"`
+	SpaceID            string                `json:"space_id"`
+	SpaceName          string                `json:"space_name"`
+	OrganizationID     string                `json:"organization_id"`
+	OrganizationName   string                `json:"organization_name"`
+	CFAPI              string                `json:"cf_api"`
+	Limits             VCAPApplicationLimits `json:"limits"`
+}
+
+// VCAPApplicationLimits -
+type VCAPApplicationLimits struct {
+	Disk int64 `json:"disk"`
+	FDs  int64 `json:"fds"`
+	Mem  int64 `json:"mem"`
+}
+
+// AppInstance - The state and the resource usage of an instance of
+// an app. Usage is only reported for instances which are running.
+type AppInstance struct {
+	Index   int
+	State   string
+	Since   time.Time
+	Uptime  time.Duration
+	Details string
+
+	Host string
+	Port int
+
+	CPUUsage  float64 // fraction of a CPU core
+	MemUsage  int64   // in bytes
+	MemQuota  int64
+	DiskUsage int64
+	DiskQuota int64
+}
+
+// AppScale - The number of instances and the quotas to scale an
+// app to. Nil values leave the current settings unchanged.
+type AppScale struct {
+	Instances  *int
+	MemoryInMB *int64
+	DiskInMB   *int64
+}
+
+// AppLog - A line logged by an app or by a platform component on
+// behalf of the app i.e. its staging output or router access logs
+type AppLog struct {
+	Timestamp  time.Time
+	SourceType string // i.e. "APP/PROC/WEB", "STG", "RTR", "API" or "CELL"
+	InstanceID string
+	Message    string
+	IsError    bool // whether it was written to stderr
+}
+
+// String - Formats the log line the way the CF CLI does
+func (l AppLog) String() string {
+	source := l.SourceType
+	if len(l.InstanceID) > 0 {
+		source += "/" + l.InstanceID
+	}
+	stream := "OUT"
+	if l.IsError {
+		stream = "ERR"
+	}
+	return fmt.Sprintf("%s [%s] %s %s", l.Timestamp.Local().Format("2006-01-02T15:04:05.00-0700"), source, stream, l.Message)
+}
+
+// QuotaUsage - The memory, app instances, routes and service instances
+// used by an org or a space and the limits of its quota. A limit of -1
+// does not limit usage.
+type QuotaUsage struct {
+	QuotaGUID string
+	QuotaName string
+
+	MemoryUsed          int64 // in Megabytes used by the instances of started apps
+	MemoryLimit         int64
+	InstanceMemoryLimit int64
+
+	AppInstancesUsed int
+	AppInstanceLimit int
+
+	RoutesUsed  int
+	RoutesLimit int
+
+	ServicesUsed  int
+	ServicesLimit int
+
+	ReservedRoutePortsUsed  int
+	ReservedRoutePortsLimit int
+}
+
+// Exceeded - Returns the names of the limits the usage exceeds
+func (u QuotaUsage) Exceeded() []string {
+
+	exceeded := []string{}
+	check := func(name string, used, limit int64) {
+		if limit != Unlimited && used > limit {
+			exceeded = append(exceeded, name)
+		}
+	}
+	check("memory", u.MemoryUsed, u.MemoryLimit)
+	check("app instances", int64(u.AppInstancesUsed), int64(u.AppInstanceLimit))
+	check("routes", int64(u.RoutesUsed), int64(u.RoutesLimit))
+	check("services", int64(u.ServicesUsed), int64(u.ServicesLimit))
+	check("reserved route ports", int64(u.ReservedRoutePortsUsed), int64(u.ReservedRoutePortsLimit))
+	return exceeded
+}
+
+// UserRoles - A user and the roles of the user in an org or space
+type UserRoles struct {
+	GUID     string
+	Username string
+	Roles    []models.Role
+}
+
+// HasRole -
+func (u UserRoles) HasRole(role models.Role) bool {
+	for _, r := range u.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// UserRole - A role to grant to the user with the given username
+// and origin. An empty origin is the default origin.
+type UserRole struct {
+	Username string
+	Origin   string
+	Role     models.Role
+}
+
+// OrgOptions - The quota, the default isolation segment and the roles
+// of users an org is ensured to have. Empty values are left unchanged.
+type OrgOptions struct {
+	QuotaName            string
+	IsolationSegmentName string
+	Roles                []UserRole
+}
+
+// SpaceOptions - The space quota, the isolation segment and the roles
+// of users a space is ensured to have. Empty values are left unchanged.
+type SpaceOptions struct {
+	SpaceQuotaName       string
+	IsolationSegmentName string
+	Roles                []UserRole
+}
+
+// DeletePreview - The names of the resources which are removed along
+// with an org or space. Routes are named by their URL. When deleting an
+// org the apps and service instances are named "<space>/<name>".
+type DeletePreview struct {
+	Spaces           []string
+	Apps             []string
+	ServiceInstances []string
+	Routes           []string
+	PrivateDomains   []string
+	SpaceQuotas      []string
+}
+
+// IsEmpty -
+func (p DeletePreview) IsEmpty() bool {
+	return len(p.Spaces)+len(p.Apps)+len(p.ServiceInstances)+
+		len(p.Routes)+len(p.PrivateDomains)+len(p.SpaceQuotas) == 0
+}
+
+// String - Lists the resources one per line prefixed with their kind
+func (p DeletePreview) String() string {
+
+	lines := []string{}
+	add := func(kind string, names []string) {
+		for _, n := range names {
+			lines = append(lines, kind+" "+n)
+		}
+	}
+	add("space", p.Spaces)
+	add("app", p.Apps)
+	add("service instance", p.ServiceInstances)
+	add("route", p.Routes)
+	add("private domain", p.PrivateDomains)
+	add("space quota", p.SpaceQuotas)
+	return strings.Join(lines, "\n")
+}
+
+// APIInfo - Versions of the Cloud Controller APIs served by a foundation
+// as advertised by the root of its API endpoint and the endpoints of the
+// log services of the foundation
+type APIInfo struct {
+	V2Version string
+	V3Version string
+
+	DopplerURL  string
+	LogCacheURL string
+}
+
+// SupportsV3 -
+func (i APIInfo) SupportsV3() bool {
+	return len(i.V3Version) > 0
+}
+
+// V3App -
+type V3App struct {
+	GUID          string
+	Name          string
+	State         string
+	LifecycleType string
+	SpaceGUID     string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// V3Checksum - Checksum of the content of a package or droplet
+type V3Checksum struct {
+	Type  string
+	Value string
+}
+
+// V3Package -
+type V3Package struct {
+	GUID      string
+	Type      string
+	State     string
+	Checksum  V3Checksum
+	CreatedAt time.Time
+}
+
+// V3Droplet -
+type V3Droplet struct {
+	GUID       string
+	State      string
+	Checksum   V3Checksum
+	Stack      string
+	Buildpacks []string
+	CreatedAt  time.Time
+}
+
+// V3Build -
+type V3Build struct {
+	GUID        string
+	State       string
+	Error       string
+	PackageGUID string
+	DropletGUID string
+	CreatedAt   time.Time
+}
+
+// V3Process -
+type V3Process struct {
+	GUID            string
+	Type            string
+	Command         string
+	Instances       int
+	MemoryInMB      int
+	DiskInMB        int
+	HealthCheckType string
+}
+
+// V3Deployment -
+type V3Deployment struct {
+	GUID         string
+	State        string
+	StatusValue  string
+	StatusReason string
+	AppGUID      string
+	DropletGUID  string
+	CreatedAt    time.Time
+}
+
+// V3AuditEvent -
+type V3AuditEvent struct {
+	GUID       string
+	Type       string
+	CreatedAt  time.Time
+	ActorGUID  string
+	ActorType  string
+	ActorName  string
+	TargetGUID string
+	TargetType string
+	TargetName string
+	SpaceGUID  string
+	OrgGUID    string
+	Data       map[string]interface{}
+}
+
+// V3AuditEventFilter - Selects the audit events to return. Empty
+// lists and a zero time do not restrict the events returned.
+type V3AuditEventFilter struct {
+	SpaceGUIDs  []string
+	TargetGUIDs []string
+	Types       []string
+
+	From      time.Time
+	Inclusive bool
+}
+
+// RouteMapping - The mapping of a route to an app and the port of the
+// app's instances the route's requests or connections are forwarded to
+type RouteMapping struct {
+	RouteGUID string
+	AppGUID   string
+	AppPort   int
+}
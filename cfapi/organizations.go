@@ -0,0 +1,286 @@
+package cfapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"code.cloudfoundry.org/cli/cf/api/resources"
+	"code.cloudfoundry.org/cli/cf/errors"
+	"code.cloudfoundry.org/cli/cf/models"
+)
+
+type v3IsolationSegmentResource struct {
+	GUID string `json:"guid"`
+	Name string `json:"name"`
+}
+
+// EnsureOrg - Creates the org with the given name if it does not exist
+// and ensures it has the quota, the default isolation segment and the
+// user roles given by the options. Returns whether the org was created.
+func (s *CfCliSession) EnsureOrg(name string, options OrgOptions) (org models.OrganizationFields, created bool, err error) {
+
+	var quota models.QuotaFields
+	if len(options.QuotaName) > 0 {
+		if quota, err = s.Quotas().FindByName(options.QuotaName); err != nil {
+			return
+		}
+	}
+	var segmentGUID string
+	if len(options.IsolationSegmentName) > 0 {
+		if segmentGUID, err = s.isolationSegmentGUID(options.IsolationSegmentName); err != nil {
+			return
+		}
+	}
+
+	repo := s.Organizations()
+	o, err := repo.FindByName(name)
+	if err != nil {
+		if _, ok := err.(*errors.ModelNotFoundError); !ok {
+			return
+		}
+		if err = repo.Create(models.Organization{
+			OrganizationFields: models.OrganizationFields{Name: name},
+		}); err != nil {
+			return
+		}
+		if o, err = repo.FindByName(name); err != nil {
+			return
+		}
+		created = true
+		s.logger.DebugMessage("Created org '%s' with GUID '%s'.", name, o.GUID)
+	}
+	org = o.OrganizationFields
+
+	if len(quota.GUID) > 0 {
+		if err = s.Quotas().AssignQuotaToOrg(org.GUID, quota.GUID); err != nil {
+			return
+		}
+	}
+	if len(segmentGUID) > 0 {
+		if err = s.v3Request("POST", fmt.Sprintf("/v3/isolation_segments/%s/relationships/organizations", segmentGUID),
+			map[string]interface{}{
+				"data": []map[string]string{{"guid": org.GUID}},
+			}, &struct{}{}); err != nil {
+			return
+		}
+		if err = s.v3Request("PATCH", fmt.Sprintf("/v3/organizations/%s/relationships/default_isolation_segment", org.GUID),
+			map[string]interface{}{
+				"data": map[string]string{"guid": segmentGUID},
+			}, &v3RelationshipResource{}); err != nil {
+			return
+		}
+	}
+	for _, r := range options.Roles {
+		if err = s.SetOrgRole(org.GUID, r.Username, r.Origin, r.Role); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// EnsureSpace - Creates the space with the given name in an org if it
+// does not exist and ensures it has the space quota, the isolation
+// segment and the user roles given by the options. The org must be
+// entitled to use the isolation segment. Returns whether the space
+// was created.
+func (s *CfCliSession) EnsureSpace(orgGUID, name string, options SpaceOptions) (space models.SpaceFields, created bool, err error) {
+
+	var quotaGUID string
+	if len(options.SpaceQuotaName) > 0 {
+		var quota models.SpaceQuota
+		if quota, err = s.SpaceQuotas().FindByNameAndOrgGUID(options.SpaceQuotaName, orgGUID); err != nil {
+			return
+		}
+		quotaGUID = quota.GUID
+	}
+
+	var segmentGUID string
+	if len(options.IsolationSegmentName) > 0 {
+		if segmentGUID, err = s.isolationSegmentGUID(options.IsolationSegmentName); err != nil {
+			return
+		}
+	}
+
+	repo := s.Spaces()
+	sp, err := repo.FindByNameInOrg(name, orgGUID)
+	if err != nil {
+		if _, ok := err.(*errors.ModelNotFoundError); !ok {
+			return
+		}
+		if sp, err = repo.Create(name, orgGUID, quotaGUID); err != nil {
+			return
+		}
+		created = true
+		s.logger.DebugMessage("Created space '%s' with GUID '%s'.", name, sp.GUID)
+	} else if len(quotaGUID) > 0 {
+		if err = s.SpaceQuotas().AssociateSpaceWithQuota(sp.GUID, quotaGUID); err != nil {
+			return
+		}
+	}
+	space = sp.SpaceFields
+
+	if len(segmentGUID) > 0 {
+		if err = s.v3Request("PATCH", fmt.Sprintf("/v3/spaces/%s/relationships/isolation_segment", space.GUID),
+			map[string]interface{}{
+				"data": map[string]string{"guid": segmentGUID},
+			}, &v3RelationshipResource{}); err != nil {
+			return
+		}
+	}
+	for _, r := range options.Roles {
+		if err = s.SetSpaceRole(space.GUID, r.Username, r.Origin, r.Role); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// PreviewOrgDelete - Returns the spaces and the resources in them and
+// the private domains and space quotas owned by an org which are
+// removed when the org is deleted
+func (s *CfCliSession) PreviewOrgDelete(orgGUID string) (DeletePreview, error) {
+
+	preview := newDeletePreview()
+	endpoint := s.config.APIEndpoint()
+
+	spaces := []models.SpaceFields{}
+	if err := s.ccGateway.ListPaginatedResources(endpoint,
+		fmt.Sprintf("/v2/organizations/%s/spaces", orgGUID), resources.SpaceResource{},
+		func(resource interface{}) bool {
+			spaces = append(spaces, resource.(resources.SpaceResource).ToFields())
+			return true
+		}); err != nil {
+		return preview, err
+	}
+	for _, sp := range spaces {
+		preview.Spaces = append(preview.Spaces, sp.Name)
+		if err := s.addSpaceDeletePreview(sp.GUID, sp.Name+"/", &preview); err != nil {
+			return preview, err
+		}
+	}
+
+	if err := s.ccGateway.ListPaginatedResources(endpoint,
+		fmt.Sprintf("/v2/organizations/%s/private_domains", orgGUID), resources.DomainResource{},
+		func(resource interface{}) bool {
+			// shared private domains are listed as well
+			if domain := resource.(resources.DomainResource).ToFields(); domain.OwningOrganizationGUID == orgGUID {
+				preview.PrivateDomains = append(preview.PrivateDomains, domain.Name)
+			}
+			return true
+		}); err != nil {
+		return preview, err
+	}
+	err := s.ccGateway.ListPaginatedResources(endpoint,
+		fmt.Sprintf("/v2/organizations/%s/space_quota_definitions", orgGUID), resources.SpaceQuotaResource{},
+		func(resource interface{}) bool {
+			preview.SpaceQuotas = append(preview.SpaceQuotas, resource.(resources.SpaceQuotaResource).ToModel().Name)
+			return true
+		})
+	return preview, err
+}
+
+// PreviewSpaceDelete - Returns the apps, service instances
+// and routes which are removed when a space is deleted
+func (s *CfCliSession) PreviewSpaceDelete(spaceGUID string) (DeletePreview, error) {
+
+	preview := newDeletePreview()
+	space := resources.SpaceResource{}
+	if err := s.ccGateway.GetResource(
+		fmt.Sprintf("%s/v2/spaces/%s", s.config.APIEndpoint(), spaceGUID), &space); err != nil {
+		return preview, err
+	}
+	err := s.addSpaceDeletePreview(spaceGUID, "", &preview)
+	return preview, err
+}
+
+// DeleteOrg - Deletes an org along with all resources previewed by
+// PreviewOrgDelete. The session no longer targets the org if it did.
+func (s *CfCliSession) DeleteOrg(orgGUID string) error {
+
+	if err := s.Organizations().Delete(orgGUID); err != nil {
+		return err
+	}
+	if s.GetSessionOrg().GUID == orgGUID {
+		s.SetSessionOrg(models.OrganizationFields{})
+		s.SetSessionSpace(models.SpaceFields{})
+	}
+	return nil
+}
+
+// DeleteSpace - Deletes a space along with all resources previewed by
+// PreviewSpaceDelete. The session no longer targets the space if it did.
+func (s *CfCliSession) DeleteSpace(spaceGUID string) error {
+
+	if err := s.Spaces().Delete(spaceGUID); err != nil {
+		return err
+	}
+	if s.GetSessionSpace().GUID == spaceGUID {
+		s.SetSessionSpace(models.SpaceFields{})
+	}
+	return nil
+}
+
+// addSpaceDeletePreview - Adds the apps, service instances and routes of a
+// space to a preview. Apps and service instances are named with the prefix.
+func (s *CfCliSession) addSpaceDeletePreview(spaceGUID, prefix string, preview *DeletePreview) error {
+
+	endpoint := s.config.APIEndpoint()
+
+	if err := s.ccGateway.ListPaginatedResources(endpoint,
+		fmt.Sprintf("/v2/spaces/%s/apps", spaceGUID), resources.ApplicationResource{},
+		func(resource interface{}) bool {
+			preview.Apps = append(preview.Apps, prefix+resource.(resources.ApplicationResource).ToFields().Name)
+			return true
+		}); err != nil {
+		return err
+	}
+	if err := s.ccGateway.ListPaginatedResources(endpoint,
+		fmt.Sprintf("/v2/spaces/%s/service_instances?return_user_provided_service_instances=true", spaceGUID),
+		resources.ServiceInstanceResource{},
+		func(resource interface{}) bool {
+			preview.ServiceInstances = append(preview.ServiceInstances,
+				prefix+resource.(resources.ServiceInstanceResource).ToFields().Name)
+			return true
+		}); err != nil {
+		return err
+	}
+	return s.ccGateway.ListPaginatedResources(endpoint,
+		fmt.Sprintf("/v2/spaces/%s/routes?inline-relations-depth=1", spaceGUID), resources.RouteResource{},
+		func(resource interface{}) bool {
+			preview.Routes = append(preview.Routes, resource.(resources.RouteResource).ToModel().URL())
+			return true
+		})
+}
+
+// isolationSegmentGUID - Returns the GUID of the isolation segment with the given name
+func (s *CfCliSession) isolationSegmentGUID(name string) (guid string, err error) {
+
+	err = s.listV3Resources("/v3/isolation_segments?names="+url.QueryEscape(name),
+		func(data json.RawMessage) error {
+			segment := v3IsolationSegmentResource{}
+			if err := json.Unmarshal(data, &segment); err != nil {
+				return err
+			}
+			if segment.Name == name {
+				guid = segment.GUID
+			}
+			return nil
+		})
+	if err == nil && len(guid) == 0 {
+		err = errors.NewModelNotFoundError("Isolation Segment", name)
+	}
+	return
+}
+
+// newDeletePreview - Returns a preview with empty lists
+func newDeletePreview() DeletePreview {
+	return DeletePreview{
+		Spaces:           []string{},
+		Apps:             []string{},
+		ServiceInstances: []string{},
+		Routes:           []string{},
+		PrivateDomains:   []string{},
+		SpaceQuotas:      []string{},
+	}
+}
@@ -0,0 +1,224 @@
+package cfapi
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/ioprogress"
+)
+
+// ProgressOperation - The transfer of app content progress is reported for
+type ProgressOperation string
+
+// Transfers of app content
+const (
+	ProgressDownloadBits    ProgressOperation = "download-bits"
+	ProgressDownloadDroplet ProgressOperation = "download-droplet"
+	ProgressUploadDroplet   ProgressOperation = "upload-droplet"
+
+	// the GUID reported is that of the buildpack
+	ProgressUploadBuildpack ProgressOperation = "upload-buildpack"
+)
+
+// ProgressReporter - Receives the progress of transfers of app content.
+// The total is -1 if the size of the content is not known. Reporters
+// may be shared by sessions so implementations need to be safe for
+// concurrent use.
+type ProgressReporter interface {
+	Progress(operation ProgressOperation, appGUID string, done, total int64)
+}
+
+// terminalProgressReporter - Draws a progress bar for each transfer
+type terminalProgressReporter struct {
+	mutex    sync.Mutex
+	out      io.Writer
+	interval time.Duration
+
+	// time each transfer in progress was last drawn
+	lastDraw map[string]time.Time
+	// length of the last line drawn
+	lastLen int
+}
+
+// NewTerminalProgressReporter - Returns a reporter which draws a progress
+// bar to the given writer. Bars of concurrent transfers overwrite each
+// other so this reporter is meant for interactive single transfers.
+func NewTerminalProgressReporter(out io.Writer) ProgressReporter {
+	return &terminalProgressReporter{
+		out:      out,
+		interval: time.Second,
+		lastDraw: make(map[string]time.Time),
+	}
+}
+
+// Progress -
+func (r *terminalProgressReporter) Progress(operation ProgressOperation, appGUID string, done, total int64) {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := string(operation) + "/" + appGUID
+	finished := total >= 0 && done >= total
+
+	if last, ok := r.lastDraw[key]; ok && !finished && time.Since(last) < r.interval {
+		return
+	}
+
+	var line string
+	if total < 0 {
+		line = fmt.Sprintf("  %s", ioprogress.DrawTextFormatBytes(done, done))
+	} else {
+		line = drawProgressBar()(done, total)
+	}
+	if len(line) < r.lastLen {
+		line += strings.Repeat(" ", r.lastLen-len(line))
+	}
+	r.lastLen = len(line)
+
+	if finished {
+		fmt.Fprintf(r.out, "\r%s\n", line)
+		delete(r.lastDraw, key)
+		r.lastLen = 0
+	} else {
+		fmt.Fprintf(r.out, "\r%s", line)
+		r.lastDraw[key] = time.Now()
+	}
+}
+
+// logProgressReporter - Logs the progress of each transfer in steps
+type logProgressReporter struct {
+	mutex  sync.Mutex
+	logger *Logger
+	step   int64
+
+	// last percentage logged for each transfer in progress
+	lastPercent map[string]int64
+}
+
+// NewLogProgressReporter - Returns a reporter which logs a line each time a
+// transfer has progressed by the given percentage. Transfers of content
+// with an unknown size are logged when they start and when they end.
+func NewLogProgressReporter(logger *Logger, step int) ProgressReporter {
+	if step <= 0 || step > 100 {
+		step = 10
+	}
+	return &logProgressReporter{
+		logger:      logger,
+		step:        int64(step),
+		lastPercent: make(map[string]int64),
+	}
+}
+
+// Progress -
+func (r *logProgressReporter) Progress(operation ProgressOperation, appGUID string, done, total int64) {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := string(operation) + "/" + appGUID
+
+	percent := int64(-1)
+	if total > 0 {
+		percent = done * 100 / total
+		percent -= percent % r.step
+	} else if total == 0 {
+		percent = 100
+	}
+
+	last, inProgress := r.lastPercent[key]
+	if inProgress && percent == last {
+		return
+	}
+	if percent == 100 {
+		delete(r.lastPercent, key)
+	} else {
+		r.lastPercent[key] = percent
+	}
+
+	keysAndValues := []interface{}{
+		"operation", string(operation),
+		"app_guid", appGUID,
+		"bytes", done,
+	}
+	if percent >= 0 {
+		keysAndValues = append(keysAndValues, "total", total, "percent", percent)
+	}
+	r.logger.Info("Transfer progress", keysAndValues...)
+}
+
+// silentProgressReporter -
+type silentProgressReporter struct{}
+
+// NewSilentProgressReporter - Returns a reporter which discards all progress
+func NewSilentProgressReporter() ProgressReporter {
+	return silentProgressReporter{}
+}
+
+// Progress -
+func (silentProgressReporter) Progress(operation ProgressOperation, appGUID string, done, total int64) {
+}
+
+// progressReader - Reports the progress of reading app content. Seeking
+// resets the progress to the new offset so request bodies can be rewound.
+type progressReader struct {
+	reader   io.Reader
+	reporter ProgressReporter
+
+	operation ProgressOperation
+	appGUID   string
+
+	done, total int64
+}
+
+// newProgressReader - Returns a reader reporting progress for content of the
+// given total size of which the given number of bytes have been transferred
+func newProgressReader(reader io.Reader, reporter ProgressReporter,
+	operation ProgressOperation, appGUID string, done, total int64) *progressReader {
+
+	return &progressReader{
+		reader:    reader,
+		reporter:  reporter,
+		operation: operation,
+		appGUID:   appGUID,
+		done:      done,
+		total:     total,
+	}
+}
+
+// Read -
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.done += int64(n)
+		r.reporter.Progress(r.operation, r.appGUID, r.done, r.total)
+	}
+	return n, err
+}
+
+// Seek -
+func (r *progressReader) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := r.reader.(io.Seeker)
+	if !ok {
+		return 0, fmt.Errorf("Unable to seek as the content being transferred is not seekable.")
+	}
+	next, err := seeker.Seek(offset, whence)
+	if err == nil {
+		r.done = next
+	}
+	return next, err
+}
+
+// drawProgressBar -
+func drawProgressBar() ioprogress.DrawTextFormatFunc {
+
+	bar := ioprogress.DrawTextFormatBar(60)
+	return func(progress, total int64) string {
+		return fmt.Sprintf(
+			"  %s %s",
+			bar(progress, total),
+			ioprogress.DrawTextFormatBytes(progress, total))
+	}
+}
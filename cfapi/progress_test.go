@@ -0,0 +1,57 @@
+package cfapi_test
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/mevansam/cf-cli-api/cfapi"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Progress Reporter Tests", func() {
+
+	It("Should draw a bar that ends with a new line when the transfer completes", func() {
+
+		out := bytes.Buffer{}
+		reporter := cfapi.NewTerminalProgressReporter(&out)
+
+		reporter.Progress(cfapi.ProgressDownloadBits, "app-1", 0, 2000)
+		reporter.Progress(cfapi.ProgressDownloadBits, "app-1", 1000, 2000)
+		reporter.Progress(cfapi.ProgressDownloadBits, "app-1", 2000, 2000)
+
+		lines := strings.Split(out.String(), "\r")
+		Expect(len(lines)).To(Equal(3))
+		Expect(lines[1]).To(HavePrefix("  ["))
+		Expect(lines[2]).To(ContainSubstring("2 KB/2 KB"))
+		Expect(lines[2]).To(HaveSuffix("\n"))
+	})
+
+	It("Should log a line each time a transfer progressed by the step", func() {
+
+		records := []cfapi.LogRecord{}
+		logger := cfapi.NewLogger(false, "false")
+		logger.SetSink(cfapi.LogSinkFunc(func(record cfapi.LogRecord) error {
+			records = append(records, record)
+			return nil
+		}))
+		reporter := cfapi.NewLogProgressReporter(logger, 25)
+
+		for done := int64(0); done <= 1000; done += 100 {
+			reporter.Progress(cfapi.ProgressUploadDroplet, "app-1", done, 1000)
+		}
+		Expect(len(records)).To(Equal(5))
+
+		percents := []interface{}{}
+		for _, r := range records {
+			Expect(r.Message).To(Equal("Transfer progress"))
+			Expect(r.Fields).To(ContainElement(cfapi.LogField{Key: "operation", Value: "upload-droplet"}))
+			for _, f := range r.Fields {
+				if f.Key == "percent" {
+					percents = append(percents, f.Value)
+				}
+			}
+		}
+		Expect(percents).To(Equal([]interface{}{int64(0), int64(25), int64(50), int64(75), int64(100)}))
+	})
+})
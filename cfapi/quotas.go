@@ -0,0 +1,180 @@
+package cfapi
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"code.cloudfoundry.org/cli/cf/api/quotas"
+	"code.cloudfoundry.org/cli/cf/api/resources"
+	"code.cloudfoundry.org/cli/cf/api/spacequotas"
+)
+
+// Unlimited - The value of a quota limit which does not limit usage
+const Unlimited = -1
+
+type orgQuotaGUIDResource struct {
+	Entity struct {
+		QuotaDefinitionGUID string `json:"quota_definition_guid"`
+	} `json:"entity"`
+}
+
+type spaceQuotaGUIDResource struct {
+	Entity struct {
+		SpaceQuotaDefinitionGUID string `json:"space_quota_definition_guid"`
+	} `json:"entity"`
+}
+
+type quotaUsageAppResource struct {
+	resources.Resource
+	Entity struct {
+		State     string `json:"state"`
+		Memory    int64  `json:"memory"`
+		Instances int    `json:"instances"`
+	}
+}
+
+type quotaUsageRouteResource struct {
+	resources.Resource
+	Entity struct {
+		Port *int `json:"port"`
+	}
+}
+
+// Quotas -
+func (s *CfCliSession) Quotas() quotas.QuotaRepository {
+	return quotas.NewCloudControllerQuotaRepository(s.config, s.ccGateway)
+}
+
+// SpaceQuotas -
+func (s *CfCliSession) SpaceQuotas() spacequotas.SpaceQuotaRepository {
+	return spacequotas.NewCloudControllerSpaceQuotaRepository(s.config, s.ccGateway)
+}
+
+// GetOrgQuotaUsage - Returns the usage of all spaces of an
+// org and the limits of the quota assigned to the org
+func (s *CfCliSession) GetOrgQuotaUsage(orgGUID string) (usage QuotaUsage, err error) {
+
+	org := orgQuotaGUIDResource{}
+	if err = s.ccGateway.GetResource(
+		fmt.Sprintf("%s/v2/organizations/%s", s.config.APIEndpoint(), orgGUID), &org); err != nil {
+		return
+	}
+	quota := resources.QuotaResource{}
+	if err = s.ccGateway.GetResource(
+		fmt.Sprintf("%s/v2/quota_definitions/%s", s.config.APIEndpoint(), org.Entity.QuotaDefinitionGUID), &quota); err != nil {
+		return
+	}
+	fields := quota.ToFields()
+	usage = QuotaUsage{
+		QuotaGUID:               fields.GUID,
+		QuotaName:               fields.Name,
+		MemoryLimit:             fields.MemoryLimit,
+		InstanceMemoryLimit:     fields.InstanceMemoryLimit,
+		AppInstanceLimit:        fields.AppInstanceLimit,
+		RoutesLimit:             fields.RoutesLimit,
+		ServicesLimit:           fields.ServicesLimit,
+		ReservedRoutePortsLimit: reservedRoutePortsLimit(fields.ReservedRoutePorts),
+	}
+
+	spaceGUIDs := []string{}
+	if err = s.ccGateway.ListPaginatedResources(s.config.APIEndpoint(),
+		fmt.Sprintf("/v2/organizations/%s/spaces", orgGUID), resources.SpaceResource{},
+		func(resource interface{}) bool {
+			spaceGUIDs = append(spaceGUIDs, resource.(resources.SpaceResource).Metadata.GUID)
+			return true
+		}); err != nil {
+		return
+	}
+	for _, spaceGUID := range spaceGUIDs {
+		if err = s.addSpaceUsage(spaceGUID, &usage); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// GetSpaceQuotaUsage - Returns the usage of a space and the limits of
+// the space quota assigned to it. All limits are unlimited if the
+// space has no space quota.
+func (s *CfCliSession) GetSpaceQuotaUsage(spaceGUID string) (usage QuotaUsage, err error) {
+
+	space := spaceQuotaGUIDResource{}
+	if err = s.ccGateway.GetResource(
+		fmt.Sprintf("%s/v2/spaces/%s", s.config.APIEndpoint(), spaceGUID), &space); err != nil {
+		return
+	}
+	usage = QuotaUsage{
+		MemoryLimit:             Unlimited,
+		InstanceMemoryLimit:     Unlimited,
+		AppInstanceLimit:        Unlimited,
+		RoutesLimit:             Unlimited,
+		ServicesLimit:           Unlimited,
+		ReservedRoutePortsLimit: Unlimited,
+	}
+	if quotaGUID := space.Entity.SpaceQuotaDefinitionGUID; len(quotaGUID) > 0 {
+		quota := resources.SpaceQuotaResource{}
+		if err = s.ccGateway.GetResource(
+			fmt.Sprintf("%s/v2/space_quota_definitions/%s", s.config.APIEndpoint(), quotaGUID), &quota); err != nil {
+			return
+		}
+		model := quota.ToModel()
+		usage = QuotaUsage{
+			QuotaGUID:               model.GUID,
+			QuotaName:               model.Name,
+			MemoryLimit:             model.MemoryLimit,
+			InstanceMemoryLimit:     model.InstanceMemoryLimit,
+			AppInstanceLimit:        model.AppInstanceLimit,
+			RoutesLimit:             model.RoutesLimit,
+			ServicesLimit:           model.ServicesLimit,
+			ReservedRoutePortsLimit: reservedRoutePortsLimit(model.ReservedRoutePortsLimit),
+		}
+	}
+	err = s.addSpaceUsage(spaceGUID, &usage)
+	return
+}
+
+// addSpaceUsage - Adds the memory and the instances of the started apps
+// and the routes and managed service instances of a space to the usage
+func (s *CfCliSession) addSpaceUsage(spaceGUID string, usage *QuotaUsage) error {
+
+	endpoint := s.config.APIEndpoint()
+
+	if err := s.ccGateway.ListPaginatedResources(endpoint,
+		fmt.Sprintf("/v2/spaces/%s/apps", spaceGUID), quotaUsageAppResource{},
+		func(resource interface{}) bool {
+			app := resource.(quotaUsageAppResource)
+			if app.Entity.State == AppStarted {
+				usage.MemoryUsed += app.Entity.Memory * int64(app.Entity.Instances)
+				usage.AppInstancesUsed += app.Entity.Instances
+			}
+			return true
+		}); err != nil {
+		return err
+	}
+	if err := s.ccGateway.ListPaginatedResources(endpoint,
+		fmt.Sprintf("/v2/spaces/%s/routes", spaceGUID), quotaUsageRouteResource{},
+		func(resource interface{}) bool {
+			usage.RoutesUsed++
+			if port := resource.(quotaUsageRouteResource).Entity.Port; port != nil && *port > 0 {
+				usage.ReservedRoutePortsUsed++
+			}
+			return true
+		}); err != nil {
+		return err
+	}
+	return s.ccGateway.ListPaginatedResources(endpoint,
+		fmt.Sprintf("/v2/spaces/%s/service_instances", spaceGUID), resources.ServiceInstanceResource{},
+		func(resource interface{}) bool {
+			usage.ServicesUsed++
+			return true
+		})
+}
+
+// reservedRoutePortsLimit - Quotas which do not
+// limit reserved route ports may omit the limit
+func reservedRoutePortsLimit(limit json.Number) int {
+	if n, err := limit.Int64(); err == nil {
+		return int(n)
+	}
+	return Unlimited
+}
@@ -0,0 +1,394 @@
+package cfapi
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"code.cloudfoundry.org/cli/cf/trace"
+)
+
+// RedactedValue - Replacement for values of sensitive keys
+const RedactedValue = "[PRIVATE DATA HIDDEN]"
+
+// DefaultSensitiveKeys - Keys whose values are masked in debug logs and
+// HTTP traces. Matching is case insensitive. The 'credentials' key masks
+// the credentials blocks of service keys, bindings and user-provided
+// services. The signature keys mask the query parameters of the signed
+// URLs blobstores redirect downloads to.
+var DefaultSensitiveKeys = []string{
+	"authorization",
+	"access_token",
+	"refresh_token",
+	"token",
+	"password",
+	"passwd",
+	"secret",
+	"client_secret",
+	"private_key",
+	"credentials",
+	"signature",
+	"sig",
+	"x-amz-signature",
+	"x-amz-credential",
+	"x-amz-security-token",
+}
+
+// Redactor - Masks the values of sensitive keys in arbitrary
+// values and in text such as HTTP request and response dumps
+type Redactor struct {
+	mutex sync.RWMutex
+	keys  map[string]bool
+
+	formPattern *regexp.Regexp
+}
+
+var (
+	headerPattern  = regexp.MustCompile(`(?im)^(\s*(?:authorization|proxy-authorization|set-cookie|cookie):[ \t]*)([^\r\n]*)`)
+	jsonKeyPattern = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"\s*:\s*`)
+)
+
+// NewRedactor -
+func NewRedactor(keys ...string) *Redactor {
+	r := &Redactor{}
+	r.SetSensitiveKeys(keys...)
+	return r
+}
+
+// SetSensitiveKeys - Replaces the list of keys whose values are masked
+func (r *Redactor) SetSensitiveKeys(keys ...string) {
+
+	keyMap := make(map[string]bool)
+	quoted := []string{}
+	for _, k := range keys {
+		k = strings.ToLower(k)
+		if len(k) > 0 && !keyMap[k] {
+			keyMap[k] = true
+			quoted = append(quoted, regexp.QuoteMeta(k))
+		}
+	}
+
+	var formPattern *regexp.Regexp
+	if len(quoted) > 0 {
+		formPattern = regexp.MustCompile(`(?i)(^|[?&\s])(` + strings.Join(quoted, "|") + `)=[^&\s]*`)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.keys = keyMap
+	r.formPattern = formPattern
+}
+
+// AddSensitiveKeys - Adds to the list of keys whose values are masked
+func (r *Redactor) AddSensitiveKeys(keys ...string) {
+	r.SetSensitiveKeys(append(r.SensitiveKeys(), keys...)...)
+}
+
+// SensitiveKeys -
+func (r *Redactor) SensitiveKeys() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	keys := []string{}
+	for k := range r.keys {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// IsSensitive -
+func (r *Redactor) IsSensitive(key string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.keys[strings.ToLower(key)]
+}
+
+// RedactText - Masks authorization headers, form encoded values and
+// JSON attributes of sensitive keys in the given text
+func (r *Redactor) RedactText(text string) string {
+
+	r.mutex.RLock()
+	formPattern := r.formPattern
+	r.mutex.RUnlock()
+
+	text = headerPattern.ReplaceAllString(text, "${1}"+RedactedValue)
+	if formPattern != nil {
+		text = formPattern.ReplaceAllString(text, "${1}${2}="+RedactedValue)
+	}
+	return r.redactJSON(text)
+}
+
+// RedactURL - Masks the values of query parameters of sensitive keys
+// in the given URL. The order of the parameters is preserved.
+func (r *Redactor) RedactURL(rawURL string) string {
+
+	i := strings.Index(rawURL, "?")
+	if i < 0 {
+		return rawURL
+	}
+	params := strings.Split(rawURL[i+1:], "&")
+	for j, param := range params {
+		name := strings.SplitN(param, "=", 2)[0]
+		key, err := url.QueryUnescape(name)
+		if err != nil {
+			key = name
+		}
+		if r.IsSensitive(key) {
+			params[j] = name + "=" + url.QueryEscape(RedactedValue)
+		}
+	}
+	return rawURL[:i+1] + strings.Join(params, "&")
+}
+
+// redactJSON - Replaces the values of sensitive JSON attributes. Text
+// that is not JSON is left as is as only quoted keys are matched.
+func (r *Redactor) redactJSON(text string) string {
+
+	var (
+		result bytes.Buffer
+		last   int
+	)
+
+	matches := jsonKeyPattern.FindAllStringSubmatchIndex(text, -1)
+	for _, m := range matches {
+		if m[0] < last {
+			// key is within a value already redacted
+			continue
+		}
+		if !r.IsSensitive(text[m[2]:m[3]]) {
+			continue
+		}
+		end := jsonValueEnd(text, m[1])
+		if end == m[1] {
+			continue
+		}
+		result.WriteString(text[last:m[1]])
+		result.WriteString(`"` + RedactedValue + `"`)
+		last = end
+	}
+	result.WriteString(text[last:])
+	return result.String()
+}
+
+// jsonValueEnd - Returns the index after the JSON
+// value that starts at the given position
+func jsonValueEnd(text string, start int) int {
+
+	if start >= len(text) {
+		return start
+	}
+
+	switch text[start] {
+	case '"':
+		for i := start + 1; i < len(text); i++ {
+			switch text[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1
+			}
+		}
+		return len(text)
+
+	case '{', '[':
+		depth := 0
+		inString := false
+		for i := start; i < len(text); i++ {
+			c := text[i]
+			if inString {
+				if c == '\\' {
+					i++
+				} else if c == '"' {
+					inString = false
+				}
+				continue
+			}
+			switch c {
+			case '"':
+				inString = true
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+		}
+		return len(text)
+
+	default:
+		i := start
+		for i < len(text) && !strings.ContainsRune(",}] \t\r\n", rune(text[i])) {
+			i++
+		}
+		return i
+	}
+}
+
+// RedactValue - Returns a copy of the given value with the values of
+// sensitive map keys and struct fields masked. Struct fields are
+// matched by field name as well as by their json tag.
+func (r *Redactor) RedactValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return r.redactValue(reflect.ValueOf(v), 0).Interface()
+}
+
+// maxRedactDepth - guards against cyclic structures
+const maxRedactDepth = 32
+
+func (r *Redactor) redactValue(v reflect.Value, depth int) reflect.Value {
+
+	if depth > maxRedactDepth {
+		return v
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		elem := r.redactValue(v.Elem(), depth+1)
+		p := reflect.New(v.Type().Elem())
+		p.Elem().Set(elem)
+		return p
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		elem := r.redactValue(v.Elem(), depth+1)
+		i := reflect.New(v.Type()).Elem()
+		i.Set(elem)
+		return i
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		m := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for _, k := range v.MapKeys() {
+			if k.Kind() == reflect.String && r.IsSensitive(k.String()) {
+				m.SetMapIndex(k, maskValue(v.MapIndex(k)))
+			} else {
+				m.SetMapIndex(k, r.redactValue(v.MapIndex(k), depth+1))
+			}
+		}
+		return m
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		s := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			s.Index(i).Set(r.redactValue(v.Index(i), depth+1))
+		}
+		return s
+
+	case reflect.Array:
+		a := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			a.Index(i).Set(r.redactValue(v.Index(i), depth+1))
+		}
+		return a
+
+	case reflect.Struct:
+		s := reflect.New(v.Type()).Elem()
+		s.Set(v)
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if len(f.PkgPath) > 0 {
+				// unexported fields are copied as is
+				continue
+			}
+			if r.IsSensitive(f.Name) || r.IsSensitive(strings.Split(f.Tag.Get("json"), ",")[0]) {
+				s.Field(i).Set(maskValue(v.Field(i)))
+			} else {
+				s.Field(i).Set(r.redactValue(v.Field(i), depth+1))
+			}
+		}
+		return s
+	}
+	return v
+}
+
+// maskValue - Returns a masked value of the same type. Strings are
+// replaced, maps of strings or interfaces retain their keys with
+// masked values and all other types are zeroed.
+func maskValue(v reflect.Value) reflect.Value {
+
+	t := v.Type()
+	switch {
+	case t.Kind() == reflect.String:
+		return reflect.ValueOf(RedactedValue).Convert(t)
+
+	case t.Kind() == reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		masked := maskValue(v.Elem())
+		if !masked.Type().AssignableTo(t) {
+			return reflect.Zero(t)
+		}
+		i := reflect.New(t).Elem()
+		i.Set(masked)
+		return i
+
+	case t.Kind() == reflect.Map && !v.IsNil():
+		if !reflect.TypeOf(RedactedValue).ConvertibleTo(t.Elem()) &&
+			!reflect.TypeOf(RedactedValue).AssignableTo(t.Elem()) {
+			return reflect.Zero(t)
+		}
+		m := reflect.MakeMapWithSize(t, v.Len())
+		for _, k := range v.MapKeys() {
+			m.SetMapIndex(k, maskValue(reflect.ValueOf(RedactedValue)).Convert(t.Elem()))
+		}
+		return m
+
+	case t.Kind() == reflect.Ptr && !v.IsNil():
+		p := reflect.New(t.Elem())
+		p.Elem().Set(maskValue(v.Elem()))
+		return p
+	}
+	return reflect.Zero(t)
+}
+
+// redactingPrinter - Trace printer that masks sensitive
+// data before writing to the underlying printer
+type redactingPrinter struct {
+	printer  trace.Printer
+	redactor *Redactor
+}
+
+// NewRedactingPrinter -
+func NewRedactingPrinter(printer trace.Printer, redactor *Redactor) trace.Printer {
+	return &redactingPrinter{printer: printer, redactor: redactor}
+}
+
+// Print -
+func (p *redactingPrinter) Print(v ...interface{}) {
+	p.printer.Print(p.redactor.RedactText(fmt.Sprint(v...)))
+}
+
+// Printf -
+func (p *redactingPrinter) Printf(format string, v ...interface{}) {
+	p.printer.Print(p.redactor.RedactText(fmt.Sprintf(format, v...)))
+}
+
+// Println -
+func (p *redactingPrinter) Println(v ...interface{}) {
+	p.printer.Print(p.redactor.RedactText(fmt.Sprintln(v...)))
+}
+
+// WritesToConsole -
+func (p *redactingPrinter) WritesToConsole() bool {
+	return p.printer.WritesToConsole()
+}
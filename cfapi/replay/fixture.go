@@ -0,0 +1,95 @@
+// Package replay records the HTTP interactions of a CF session with a
+// Cloud Controller to fixture files and replays them deterministically
+// in tests.
+//
+// A fixture is recorded by pointing a session at the URL of a Recorder,
+// which proxies all requests to a real Cloud Controller. Redirects and the
+// UAA and blobstore endpoints advertised by the API are routed via the
+// recorder so that downloads and token refreshes are captured too. Tokens,
+// GUIDs and the values of sensitive keys are scrubbed before the
+// interactions are saved. A Replayer serves the saved interactions in
+// place of the Cloud Controller.
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"strings"
+)
+
+// APIPlaceholder - Replaces the API endpoint URL in recorded
+// fixtures. It is substituted with the replayer's URL on replay.
+const APIPlaceholder = "{{api}}"
+
+// BodyEncodingBase64 - Encoding of recorded bodies that are not text
+const BodyEncodingBase64 = "base64"
+
+// Fixture - A recorded sequence of HTTP interactions
+type Fixture struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction - A recorded request and its response
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request - A recorded request. The URL is the path and query
+// of the request relative to the API endpoint.
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// Response - A recorded response
+type Response struct {
+	StatusCode int                 `json:"status"`
+	Headers    map[string][]string `json:"headers,omitempty"`
+	Body       string              `json:"body,omitempty"`
+	Encoding   string              `json:"encoding,omitempty"`
+}
+
+// LoadFixture -
+func LoadFixture(path string) (*Fixture, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fixture := &Fixture{}
+	if err = json.Unmarshal(data, fixture); err != nil {
+		return nil, err
+	}
+	return fixture, nil
+}
+
+// Save - Saves the fixture as indented JSON
+func (f *Fixture) Save(path string) error {
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(f); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buffer.Bytes(), 0644)
+}
+
+// requestKey - Returns the method, path and normalized query of a request
+// URL. Query parameters are sorted so that their order does not matter.
+func requestKey(method, requestURL string) (string, string) {
+
+	path, query := requestURL, ""
+	if i := strings.Index(requestURL, "?"); i >= 0 {
+		path, query = requestURL[:i], requestURL[i+1:]
+	}
+	if values, err := url.ParseQuery(query); err == nil {
+		query = values.Encode()
+	}
+	return method + " " + path, query
+}
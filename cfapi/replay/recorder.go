@@ -0,0 +1,266 @@
+package replay
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/mevansam/cf-cli-api/cfapi"
+)
+
+// upstreamPrefix - Path prefix of requests the recorder forwards to a host
+// other than the API endpoint such as the UAA or the blobstore. The path
+// continues with the scheme and host of the upstream server.
+const upstreamPrefix = "/__upstream/"
+
+// recordedHeaders - Response headers saved to fixtures
+var recordedHeaders = []string{
+	"Content-Type",
+	"Content-Disposition",
+	"Location",
+	"Www-Authenticate",
+	"X-Cf-Warnings",
+}
+
+// Recorder - A proxy to a Cloud Controller that records all
+// interactions of the clients pointed at its URL
+type Recorder struct {
+	server   *httptest.Server
+	target   *url.URL
+	client   *http.Client
+	scrubber *Scrubber
+
+	mutex   sync.Mutex
+	fixture Fixture
+}
+
+// NewRecorder - Starts a recorder proxying requests to the given API
+// endpoint. The values of the redactor's sensitive keys are masked in
+// the recorded interactions. If the redactor is nil the default
+// sensitive keys are masked.
+func NewRecorder(apiEndpoint string, sslDisabled bool, redactor *cfapi.Redactor) (*Recorder, error) {
+
+	target, err := url.Parse(strings.TrimRight(apiEndpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+	r := &Recorder{
+		target: target,
+		client: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: sslDisabled},
+			},
+			// Redirects are returned to the client
+			// so that they are recorded as well
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+	r.server = httptest.NewServer(r)
+	r.scrubber = NewScrubber(redactor, r.server.URL, target.String())
+	return r, nil
+}
+
+// URL - The API endpoint clients should use to have their requests recorded
+func (r *Recorder) URL() string {
+	return r.server.URL
+}
+
+// Scrubber - Returns the scrubber of the recorder. Its GUID method
+// returns the GUID a real GUID has been replaced with.
+func (r *Recorder) Scrubber() *Scrubber {
+	return r.scrubber
+}
+
+// Fixture - Returns the interactions recorded so far
+func (r *Recorder) Fixture() *Fixture {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return &Fixture{Interactions: append([]Interaction{}, r.fixture.Interactions...)}
+}
+
+// Save - Saves the interactions recorded so far to a fixture file
+func (r *Recorder) Save(path string) error {
+	return r.Fixture().Save(path)
+}
+
+// Close -
+func (r *Recorder) Close() {
+	r.server.Close()
+}
+
+// ServeHTTP - Forwards a request upstream and records the interaction
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+
+	upstream := r.upstreamURL(req.URL)
+
+	requestBody, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	upstreamReq, err := http.NewRequest(req.Method, upstream, bytes.NewReader(requestBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	for name, values := range req.Header {
+		upstreamReq.Header[name] = values
+	}
+
+	resp, err := r.client.Do(upstreamReq)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if location := resp.Header.Get("Location"); len(location) > 0 {
+		resp.Header.Set("Location", r.proxyURL(location))
+	}
+	if req.URL.Path == "/v2/info" {
+		responseBody = r.proxyEndpoints(responseBody)
+	}
+
+	for name, values := range resp.Header {
+		if !strings.EqualFold(name, "Content-Length") {
+			w.Header()[name] = values
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = w.Write(responseBody)
+
+	r.record(req, requestBody, resp, responseBody)
+}
+
+// upstreamURL - Returns the URL a request to the recorder is forwarded to
+func (r *Recorder) upstreamURL(u *url.URL) string {
+
+	requestURI := u.RequestURI()
+	if strings.HasPrefix(u.Path, upstreamPrefix) {
+		parts := strings.SplitN(strings.TrimPrefix(requestURI, upstreamPrefix), "/", 3)
+		if len(parts) == 3 {
+			return fmt.Sprintf("%s://%s/%s", parts[0], parts[1], parts[2])
+		}
+		if len(parts) == 2 {
+			return fmt.Sprintf("%s://%s", parts[0], parts[1])
+		}
+	}
+	return r.target.String() + requestURI
+}
+
+// proxyURL - Returns the URL via the recorder of an upstream URL
+func (r *Recorder) proxyURL(upstream string) string {
+
+	u, err := url.Parse(upstream)
+	if err != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") {
+		return upstream
+	}
+	requestURI := u.RequestURI()
+	if requestURI == "/" {
+		requestURI = ""
+	}
+	if u.Host == r.target.Host {
+		return r.server.URL + requestURI
+	}
+	return fmt.Sprintf("%s%s%s/%s%s", r.server.URL, upstreamPrefix, u.Scheme, u.Host, requestURI)
+}
+
+// proxyEndpoints - Routes the HTTP endpoints advertised
+// by the API info, such as the UAA, via the recorder
+func (r *Recorder) proxyEndpoints(body []byte) []byte {
+
+	info := make(map[string]interface{})
+	if err := json.Unmarshal(body, &info); err != nil {
+		return body
+	}
+	for k, v := range info {
+		if s, ok := v.(string); ok && strings.HasSuffix(k, "_endpoint") {
+			info[k] = r.proxyURL(s)
+		}
+	}
+	if data, err := json.Marshal(info); err == nil {
+		return data
+	}
+	return body
+}
+
+// record - Scrubs an interaction and adds it to the fixture
+func (r *Recorder) record(req *http.Request, requestBody []byte, resp *http.Response, responseBody []byte) {
+
+	interaction := Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    r.scrubber.ScrubText(req.URL.RequestURI()),
+			Body:   r.scrubBody(requestBody, req.Header.Get("Content-Type")),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Headers:    make(map[string][]string),
+		},
+	}
+
+	for _, name := range recordedHeaders {
+		for _, v := range resp.Header[http.CanonicalHeaderKey(name)] {
+			interaction.Response.Headers[name] = append(interaction.Response.Headers[name], r.scrubber.ScrubHeader(name, v))
+		}
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if len(responseBody) > 0 {
+		if isText(contentType) && utf8.Valid(responseBody) {
+			interaction.Response.Body = r.scrubBody(responseBody, contentType)
+		} else {
+			interaction.Response.Body = base64.StdEncoding.EncodeToString(responseBody)
+			interaction.Response.Encoding = BodyEncodingBase64
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.fixture.Interactions = append(r.fixture.Interactions, interaction)
+}
+
+// scrubBody - Scrubs a text body. Request bodies that are not
+// text, such as uploads, are recorded by their size only.
+func (r *Recorder) scrubBody(body []byte, contentType string) string {
+
+	contentType = strings.ToLower(contentType)
+	switch {
+	case len(body) == 0:
+		return ""
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		return r.scrubber.ScrubForm(string(body))
+	case !isText(contentType):
+		return fmt.Sprintf("[%d bytes of %s]", len(body), contentType)
+	case strings.Contains(contentType, "json") || len(contentType) == 0:
+		return r.scrubber.ScrubJSON(body)
+	}
+	return r.scrubber.ScrubText(string(body))
+}
+
+// isText - Returns whether a body of the given content type is text.
+// CC and UAA bodies without a content type are JSON.
+func isText(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	return len(contentType) == 0 ||
+		strings.HasPrefix(contentType, "text/") ||
+		strings.Contains(contentType, "json") ||
+		strings.HasPrefix(contentType, "application/x-www-form-urlencoded") ||
+		strings.HasPrefix(contentType, "application/xml")
+}
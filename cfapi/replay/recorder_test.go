@@ -0,0 +1,189 @@
+package replay_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mevansam/cf-cli-api/cfapi/fakecc"
+	"github.com/mevansam/cf-cli-api/cfapi/replay"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Record and Replay Tests", func() {
+
+	var (
+		err     error
+		tempDir string
+
+		cc       *fakecc.FakeCC
+		recorder *replay.Recorder
+
+		appGUID, bindingGUID string
+	)
+
+	get := func(endpoint, path, token string) (*http.Response, []byte) {
+		req, err := http.NewRequest("GET", endpoint+path, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		req.Header.Set("Authorization", token)
+
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ShouldNot(HaveOccurred())
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		Expect(err).ShouldNot(HaveOccurred())
+		return resp, body
+	}
+
+	login := func(endpoint string) string {
+		_, body := get(endpoint, "/v2/info", "")
+		info := make(map[string]interface{})
+		Expect(json.Unmarshal(body, &info)).To(Succeed())
+
+		resp, err := http.PostForm(info["token_endpoint"].(string)+"/oauth/token", url.Values{
+			"grant_type": {"password"},
+			"username":   {"admin"},
+			"password":   {"admin-password"},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		defer resp.Body.Close()
+
+		token := make(map[string]interface{})
+		Expect(json.NewDecoder(resp.Body).Decode(&token)).To(Succeed())
+		return "bearer " + token["access_token"].(string)
+	}
+
+	BeforeEach(func() {
+		tempDir, err = ioutil.TempDir("", "replay")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		cc = fakecc.New()
+		cc.AddUser("admin", "admin-password")
+
+		spaceGUID := cc.AddSpace(cc.AddOrg("org1"), "space1")
+		_, plans := cc.AddServiceOffering("mysql", "small")
+		instanceGUID := cc.AddServiceInstance(spaceGUID, "db", plans[0], map[string]interface{}{
+			"username": "dbuser",
+			"password": "dbpassword",
+		})
+		appGUID = cc.AddApp(spaceGUID, "app1", nil)
+		cc.SetAppPackage(appGUID, []byte{0x50, 0x4b, 0x03, 0x04, 0xff, 0xfe})
+		bindingGUID = cc.BindService(instanceGUID, appGUID)
+
+		recorder, err = replay.NewRecorder(cc.URL(), false, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		recorder.Close()
+		cc.Close()
+		os.RemoveAll(tempDir)
+	})
+
+	It("records scrubbed interactions via the recorder", func() {
+		token := login(recorder.URL())
+
+		resp, body := get(recorder.URL(), "/v2/service_bindings/"+bindingGUID, token)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(string(body)).To(ContainSubstring("dbpassword"))
+
+		resp, body = get(recorder.URL(), fmt.Sprintf("/v2/apps/%s/download", appGUID), token)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(body).To(Equal([]byte{0x50, 0x4b, 0x03, 0x04, 0xff, 0xfe}))
+
+		fixture := recorder.Fixture()
+		requests := []string{}
+		for _, i := range fixture.Interactions {
+			requests = append(requests, i.Request.Method+" "+i.Request.URL)
+		}
+		scrubbedApp := recorder.Scrubber().GUID(appGUID)
+		scrubbedBinding := recorder.Scrubber().GUID(bindingGUID)
+		Expect(requests).To(Equal([]string{
+			"GET /v2/info",
+			"POST /oauth/token",
+			"GET /v2/service_bindings/" + scrubbedBinding,
+			"GET /v2/apps/" + scrubbedApp + "/download",
+			"GET /blobstore/packages/" + scrubbedApp,
+		}))
+
+		path := filepath.Join(tempDir, "fixture.json")
+		Expect(recorder.Save(path)).To(Succeed())
+		data, err := ioutil.ReadFile(path)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		saved := string(data)
+		Expect(saved).ToNot(ContainSubstring(appGUID))
+		Expect(saved).ToNot(ContainSubstring(cc.URL()))
+		Expect(saved).ToNot(ContainSubstring(recorder.URL()))
+		Expect(saved).ToNot(ContainSubstring("admin-password"))
+		Expect(saved).ToNot(ContainSubstring("dbpassword"))
+		Expect(saved).ToNot(ContainSubstring(strings.Split(token, ".")[1]))
+		Expect(saved).To(ContainSubstring(replay.APIPlaceholder))
+
+		binding := make(map[string]interface{})
+		Expect(json.Unmarshal([]byte(fixture.Interactions[2].Response.Body), &binding)).To(Succeed())
+		credentials := binding["entity"].(map[string]interface{})["credentials"]
+		Expect(credentials).To(HaveKeyWithValue("username", "[PRIVATE DATA HIDDEN]"))
+		Expect(credentials).To(HaveKeyWithValue("password", "[PRIVATE DATA HIDDEN]"))
+	})
+
+	It("replays recorded interactions", func() {
+		token := login(recorder.URL())
+		get(recorder.URL(), "/v2/service_bindings/"+bindingGUID, token)
+		get(recorder.URL(), fmt.Sprintf("/v2/apps/%s/download", appGUID), token)
+
+		path := filepath.Join(tempDir, "fixture.json")
+		Expect(recorder.Save(path)).To(Succeed())
+		scrubbedApp := recorder.Scrubber().GUID(appGUID)
+		scrubbedBinding := recorder.Scrubber().GUID(bindingGUID)
+		cc.Close()
+
+		replayer, err := replay.LoadReplayer(path)
+		Expect(err).ShouldNot(HaveOccurred())
+		defer replayer.Close()
+
+		token = login(replayer.URL())
+		Expect(token).To(Equal("bearer " + replay.ScrubbedToken))
+
+		resp, body := get(replayer.URL(), "/v2/service_bindings/"+scrubbedBinding, token)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(string(body)).To(ContainSubstring(`"app_guid":"` + scrubbedApp + `"`))
+
+		resp, body = get(replayer.URL(), fmt.Sprintf("/v2/apps/%s/download", scrubbedApp), token)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(body).To(Equal([]byte{0x50, 0x4b, 0x03, 0x04, 0xff, 0xfe}))
+		Expect(replayer.Unused()).To(BeEmpty())
+
+		resp, _ = get(replayer.URL(), "/v2/apps/unknown", token)
+		Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		Expect(replayer.Unmatched()).To(Equal([]string{"GET /v2/apps/unknown"}))
+	})
+
+	It("matches requests with differing queries by path", func() {
+		fixture := &replay.Fixture{Interactions: []replay.Interaction{
+			{
+				Request:  replay.Request{Method: "GET", URL: "/v2/events?q=timestamp>2017-01-01&results-per-page=100"},
+				Response: replay.Response{StatusCode: 200, Body: `{"total_results":1}`},
+			},
+			{
+				Request:  replay.Request{Method: "GET", URL: "/v2/events?results-per-page=100&q=timestamp>2018-01-01"},
+				Response: replay.Response{StatusCode: 200, Body: `{"total_results":2}`},
+			},
+		}}
+		replayer := replay.NewReplayer(fixture)
+		defer replayer.Close()
+
+		_, body := get(replayer.URL(), "/v2/events?results-per-page=100&q=timestamp%3E2018-01-01", "")
+		Expect(string(body)).To(Equal(`{"total_results":2}`))
+		_, body = get(replayer.URL(), "/v2/events?results-per-page=100&q=timestamp%3E2019-01-01", "")
+		Expect(string(body)).To(Equal(`{"total_results":1}`))
+		_, body = get(replayer.URL(), "/v2/events?results-per-page=100&q=timestamp%3E2018-01-01", "")
+		Expect(string(body)).To(Equal(`{"total_results":2}`))
+	})
+})
@@ -0,0 +1,155 @@
+package replay
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// Replayer - Serves the recorded interactions of a fixture in place of
+// the Cloud Controller. A request is answered by the first unused
+// interaction with the same method, path and query. Failing that the
+// first unused interaction with the same method and path is used so that
+// queries with varying values such as timestamps still match. Requests
+// matching only interactions that have been used are answered by the
+// last of these, so repeated requests such as token refreshes replay.
+type Replayer struct {
+	server  *httptest.Server
+	fixture *Fixture
+
+	mutex     sync.Mutex
+	used      []bool
+	unmatched []string
+}
+
+// NewReplayer - Starts a replayer for the given fixture
+func NewReplayer(fixture *Fixture) *Replayer {
+	r := &Replayer{
+		fixture: fixture,
+		used:    make([]bool, len(fixture.Interactions)),
+	}
+	r.server = httptest.NewServer(r)
+	return r
+}
+
+// LoadReplayer - Starts a replayer for the fixture at the given path
+func LoadReplayer(path string) (*Replayer, error) {
+	fixture, err := LoadFixture(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayer(fixture), nil
+}
+
+// URL - The API endpoint to point sessions at
+func (r *Replayer) URL() string {
+	return r.server.URL
+}
+
+// Unmatched - Returns the requests for which no
+// interaction was recorded in the order received
+func (r *Replayer) Unmatched() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string{}, r.unmatched...)
+}
+
+// Unused - Returns the requests of the recorded
+// interactions that have not been replayed
+func (r *Replayer) Unused() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	unused := []string{}
+	for i, interaction := range r.fixture.Interactions {
+		if !r.used[i] {
+			unused = append(unused, interaction.Request.Method+" "+interaction.Request.URL)
+		}
+	}
+	return unused
+}
+
+// Close -
+func (r *Replayer) Close() {
+	r.server.Close()
+}
+
+// ServeHTTP -
+func (r *Replayer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+
+	interaction, ok := r.match(req.Method, req.URL.RequestURI())
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, `{"code":10000,"description":"No recorded interaction for %s %s","error_code":"CF-NotFound"}`,
+			req.Method, req.URL.Path)
+		return
+	}
+
+	var body []byte
+	if interaction.Response.Encoding == BodyEncodingBase64 {
+		decoded, err := base64.StdEncoding.DecodeString(interaction.Response.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		body = decoded
+	} else {
+		body = []byte(r.expand(interaction.Response.Body))
+	}
+
+	for name, values := range interaction.Response.Headers {
+		for _, v := range values {
+			w.Header().Add(name, r.expand(v))
+		}
+	}
+	w.WriteHeader(interaction.Response.StatusCode)
+	_, _ = w.Write(body)
+}
+
+// match - Returns the interaction to replay for a request
+func (r *Replayer) match(method, requestURI string) (Interaction, bool) {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	path, query := requestKey(method, requestURI)
+
+	exact, samePath, last := -1, -1, -1
+	for i, interaction := range r.fixture.Interactions {
+		p, q := requestKey(interaction.Request.Method, interaction.Request.URL)
+		if p != path {
+			continue
+		}
+		if r.used[i] {
+			if q == query || last == -1 {
+				last = i
+			}
+			continue
+		}
+		if q == query {
+			exact = i
+			break
+		}
+		if samePath == -1 {
+			samePath = i
+		}
+	}
+
+	for _, i := range []int{exact, samePath, last} {
+		if i >= 0 {
+			r.used[i] = true
+			return r.fixture.Interactions[i], true
+		}
+	}
+	r.unmatched = append(r.unmatched, method+" "+requestURI)
+	return Interaction{}, false
+}
+
+// expand - Replaces the API placeholder with the URL of the replayer
+func (r *Replayer) expand(text string) string {
+	return strings.Replace(text, APIPlaceholder, r.server.URL, -1)
+}
@@ -0,0 +1,202 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mevansam/cf-cli-api/cfapi"
+)
+
+// ScrubbedToken - Replaces access, refresh and ID tokens in recorded
+// fixtures. It is an unsigned JWT for the user 'admin' so that
+// clients which decode the token still work on replay.
+var ScrubbedToken = strings.Join([]string{
+	base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)),
+	base64.RawURLEncoding.EncodeToString([]byte(
+		`{"user_id":"scrubbed-user","user_name":"admin","email":"admin","origin":"uaa","exp":4102444800}`)),
+	"",
+}, ".")
+
+// tokenKeys - JSON attributes holding tokens
+var tokenKeys = map[string]bool{
+	"access_token":  true,
+	"refresh_token": true,
+	"id_token":      true,
+}
+
+var guidPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// Scrubber - Removes tokens, secrets and GUIDs from recorded interactions.
+// GUIDs are replaced consistently in the order they are first seen so
+// that a fixture remains coherent and stable across recordings.
+type Scrubber struct {
+	mutex    sync.Mutex
+	redactor *cfapi.Redactor
+	urls     []string
+	guids    map[string]string
+}
+
+// NewScrubber - Creates a scrubber masking the values of the sensitive keys
+// of the given redactor. Occurrences of the given URLs are replaced by the
+// API placeholder.
+func NewScrubber(redactor *cfapi.Redactor, apiURLs ...string) *Scrubber {
+	if redactor == nil {
+		redactor = cfapi.NewRedactor(cfapi.DefaultSensitiveKeys...)
+	}
+	urls := []string{}
+	for _, u := range apiURLs {
+		if len(u) > 0 {
+			urls = append(urls, strings.TrimRight(u, "/"))
+		}
+	}
+	return &Scrubber{redactor: redactor, urls: urls, guids: make(map[string]string)}
+}
+
+// GUID - Returns the scrubbed replacement of a GUID
+func (s *Scrubber) GUID(guid string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	guid = strings.ToLower(guid)
+	scrubbed, ok := s.guids[guid]
+	if !ok {
+		scrubbed = fmt.Sprintf("00000000-0000-4000-8000-%012d", len(s.guids)+1)
+		s.guids[guid] = scrubbed
+	}
+	return scrubbed
+}
+
+// ScrubText - Replaces API URLs and GUIDs in the given text
+func (s *Scrubber) ScrubText(text string) string {
+	for _, u := range s.urls {
+		text = strings.Replace(text, u, APIPlaceholder, -1)
+	}
+	return guidPattern.ReplaceAllStringFunc(text, s.GUID)
+}
+
+// ScrubHeader - Returns the scrubbed value of a header
+func (s *Scrubber) ScrubHeader(name, value string) string {
+	if strings.EqualFold(name, "Authorization") {
+		return "bearer " + ScrubbedToken
+	}
+	if s.redactor.IsSensitive(name) {
+		return cfapi.RedactedValue
+	}
+	return s.ScrubText(value)
+}
+
+// ScrubForm - Scrubs a form encoded body
+func (s *Scrubber) ScrubForm(body string) string {
+
+	values, err := url.ParseQuery(body)
+	if err != nil {
+		return s.ScrubText(body)
+	}
+	for k, vv := range values {
+		for i := range vv {
+			switch {
+			case tokenKeys[strings.ToLower(k)]:
+				vv[i] = ScrubbedToken
+			case s.redactor.IsSensitive(k):
+				vv[i] = cfapi.RedactedValue
+			default:
+				vv[i] = s.ScrubText(vv[i])
+			}
+		}
+	}
+	return values.Encode()
+}
+
+// ScrubJSON - Scrubs a JSON body. Sensitive values are masked without
+// changing the structure of the document so that it can still be
+// decoded by clients. Bodies that are not valid JSON are scrubbed
+// as text.
+func (s *Scrubber) ScrubJSON(body []byte) string {
+
+	var document interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return s.ScrubText(string(body))
+	}
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(s.scrubValue(document)); err != nil {
+		return s.ScrubText(string(body))
+	}
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
+
+// scrubValue -
+func (s *Scrubber) scrubValue(v interface{}) interface{} {
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		// keys are visited in order so that GUIDs
+		// are numbered the same on every recording
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		scrubbed := make(map[string]interface{})
+		for _, k := range keys {
+			child := value[k]
+			key := s.ScrubText(k)
+			switch {
+			case tokenKeys[strings.ToLower(k)]:
+				if _, ok := child.(string); ok {
+					scrubbed[key] = ScrubbedToken
+					continue
+				}
+				scrubbed[key] = mask(child)
+			case s.redactor.IsSensitive(k):
+				scrubbed[key] = mask(child)
+			default:
+				scrubbed[key] = s.scrubValue(child)
+			}
+		}
+		return scrubbed
+	case []interface{}:
+		scrubbed := make([]interface{}, len(value))
+		for i, child := range value {
+			scrubbed[i] = s.scrubValue(child)
+		}
+		return scrubbed
+	case string:
+		return s.ScrubText(value)
+	}
+	return v
+}
+
+// mask - Masks all values within a JSON value keeping the keys of objects
+func mask(v interface{}) interface{} {
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		masked := make(map[string]interface{})
+		for k, child := range value {
+			masked[k] = mask(child)
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(value))
+		for i, child := range value {
+			masked[i] = mask(child)
+		}
+		return masked
+	case nil:
+		return nil
+	}
+	return cfapi.RedactedValue
+}
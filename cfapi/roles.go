@@ -0,0 +1,177 @@
+package cfapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"code.cloudfoundry.org/cli/cf/api/resources"
+	"code.cloudfoundry.org/cli/cf/models"
+)
+
+// OrgRoles - The roles users may have in an org by the
+// relation of the org listing the users having the role
+var OrgRoles = map[models.Role]string{
+	models.RoleOrgUser:        "users",
+	models.RoleOrgManager:     "managers",
+	models.RoleBillingManager: "billing_managers",
+	models.RoleOrgAuditor:     "auditors",
+}
+
+// SpaceRoles - The roles users may have in a space by the
+// relation of the space listing the users having the role
+var SpaceRoles = map[models.Role]string{
+	models.RoleSpaceManager:   "managers",
+	models.RoleSpaceDeveloper: "developers",
+	models.RoleSpaceAuditor:   "auditors",
+}
+
+type userRoleRequest struct {
+	Username string `json:"username"`
+	Origin   string `json:"origin,omitempty"`
+}
+
+type spaceOrgResource struct {
+	Entity struct {
+		OrganizationGUID string `json:"organization_guid"`
+	} `json:"entity"`
+}
+
+// GetOrgUsers - Returns the users of an org with their roles in the org
+func (s *CfCliSession) GetOrgUsers(orgGUID string) ([]UserRoles, error) {
+	return s.listUserRoles("organizations", orgGUID, OrgRoles)
+}
+
+// GetSpaceUsers - Returns the users having a role in a space with their roles
+func (s *CfCliSession) GetSpaceUsers(spaceGUID string) ([]UserRoles, error) {
+	return s.listUserRoles("spaces", spaceGUID, SpaceRoles)
+}
+
+// SetOrgRole - Grants a role in an org to the user with the given
+// username from the given origin i.e. "uaa" or "ldap". An empty
+// origin selects the default origin of the foundation. The user
+// is made a user of the org if not already.
+func (s *CfCliSession) SetOrgRole(orgGUID, username, origin string, role models.Role) error {
+
+	relation, ok := OrgRoles[role]
+	if !ok {
+		return fmt.Errorf("Unable to grant role '%s' as it is not an org role.", role.ToString())
+	}
+	if role != models.RoleOrgUser {
+		if err := s.putUserRole("organizations", orgGUID, OrgRoles[models.RoleOrgUser], username, origin); err != nil {
+			return err
+		}
+	}
+	return s.putUserRole("organizations", orgGUID, relation, username, origin)
+}
+
+// UnsetOrgRole - Revokes a role in an org from the user with the given
+// username and origin. Revoking the user role removes the user from the
+// org which fails while the user has other roles in the org or its spaces.
+func (s *CfCliSession) UnsetOrgRole(orgGUID, username, origin string, role models.Role) error {
+
+	relation, ok := OrgRoles[role]
+	if !ok {
+		return fmt.Errorf("Unable to revoke role '%s' as it is not an org role.", role.ToString())
+	}
+	return s.removeUserRole("organizations", orgGUID, relation, username, origin)
+}
+
+// SetSpaceRole - Grants a role in a space to the user with the given
+// username and origin. The user is made a user of the space's org
+// if not already.
+func (s *CfCliSession) SetSpaceRole(spaceGUID, username, origin string, role models.Role) error {
+
+	relation, ok := SpaceRoles[role]
+	if !ok {
+		return fmt.Errorf("Unable to grant role '%s' as it is not a space role.", role.ToString())
+	}
+	space := spaceOrgResource{}
+	if err := s.ccGateway.GetResource(
+		fmt.Sprintf("%s/v2/spaces/%s", s.config.APIEndpoint(), spaceGUID), &space); err != nil {
+		return err
+	}
+	if err := s.putUserRole("organizations", space.Entity.OrganizationGUID,
+		OrgRoles[models.RoleOrgUser], username, origin); err != nil {
+		return err
+	}
+	return s.putUserRole("spaces", spaceGUID, relation, username, origin)
+}
+
+// UnsetSpaceRole - Revokes a role in a space from the
+// user with the given username and origin
+func (s *CfCliSession) UnsetSpaceRole(spaceGUID, username, origin string, role models.Role) error {
+
+	relation, ok := SpaceRoles[role]
+	if !ok {
+		return fmt.Errorf("Unable to revoke role '%s' as it is not a space role.", role.ToString())
+	}
+	return s.removeUserRole("spaces", spaceGUID, relation, username, origin)
+}
+
+// listUserRoles - Lists the users of each role of an org or space
+// and merges them into the roles of each user ordered by username
+func (s *CfCliSession) listUserRoles(collection, guid string, roles map[models.Role]string) ([]UserRoles, error) {
+
+	ordered := []models.Role{}
+	for role := range roles {
+		ordered = append(ordered, role)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i] < ordered[j] })
+
+	users := make(map[string]*UserRoles)
+	for _, role := range ordered {
+		if err := s.ccGateway.ListPaginatedResources(s.config.APIEndpoint(),
+			fmt.Sprintf("/v2/%s/%s/%s", collection, guid, roles[role]), resources.UserResource{},
+			func(resource interface{}) bool {
+				fields := resource.(resources.UserResource).ToFields()
+				user, ok := users[fields.GUID]
+				if !ok {
+					user = &UserRoles{GUID: fields.GUID, Username: fields.Username}
+					users[fields.GUID] = user
+				}
+				user.Roles = append(user.Roles, role)
+				return true
+			}); err != nil {
+			return nil, err
+		}
+	}
+	return sortedUserRoles(users), nil
+}
+
+func (s *CfCliSession) putUserRole(collection, guid, relation, username, origin string) error {
+
+	body, err := json.Marshal(userRoleRequest{Username: username, Origin: origin})
+	if err != nil {
+		return err
+	}
+	return s.ccGateway.UpdateResource(s.config.APIEndpoint(),
+		fmt.Sprintf("/v2/%s/%s/%s", collection, guid, relation), bytes.NewReader(body))
+}
+
+func (s *CfCliSession) removeUserRole(collection, guid, relation, username, origin string) error {
+
+	body, err := json.Marshal(userRoleRequest{Username: username, Origin: origin})
+	if err != nil {
+		return err
+	}
+	return s.ccGateway.CreateResource(s.config.APIEndpoint(),
+		fmt.Sprintf("/v2/%s/%s/%s/remove", collection, guid, relation), bytes.NewReader(body))
+}
+
+// sortedUserRoles - Returns the given users ordered by username
+func sortedUserRoles(users map[string]*UserRoles) []UserRoles {
+
+	result := []UserRoles{}
+	for _, u := range users {
+		result = append(result, *u)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Username == result[j].Username {
+			return result[i].GUID < result[j].GUID
+		}
+		return result[i].Username < result[j].Username
+	})
+	return result
+}
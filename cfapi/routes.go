@@ -0,0 +1,125 @@
+package cfapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"code.cloudfoundry.org/cli/cf/api/resources"
+	"code.cloudfoundry.org/cli/cf/errors"
+	"code.cloudfoundry.org/cli/cf/models"
+)
+
+// TCPRouterGroupType - The router group type of domains whose routes are TCP routes
+const TCPRouterGroupType = "tcp"
+
+type routeMappingResource struct {
+	resources.Resource
+	Entity struct {
+		AppGUID   string `json:"app_guid"`
+		RouteGUID string `json:"route_guid"`
+		AppPort   *int   `json:"app_port,omitempty"`
+	}
+}
+
+type routeMappingRequest struct {
+	AppGUID   string `json:"app_guid"`
+	RouteGUID string `json:"route_guid"`
+	AppPort   int    `json:"app_port,omitempty"`
+}
+
+// CreateRoute - Creates a route in a space. Routes on TCP domains have
+// neither a host nor a path and are created with the given port or with
+// a random port. Routes on HTTP domains may have a host and a context
+// path but no port.
+func (s *CfCliSession) CreateRoute(spaceGUID string, domain models.DomainFields,
+	host, path string, port int, randomPort bool) (models.Route, error) {
+
+	if domain.RouterGroupType == TCPRouterGroupType {
+		if len(host) > 0 || len(path) > 0 {
+			return models.Route{}, fmt.Errorf("Unable to create a route with a host or path on TCP domain '%s'.", domain.Name)
+		}
+		if port == 0 && !randomPort {
+			return models.Route{}, fmt.Errorf("Unable to create a route without a port on TCP domain '%s'.", domain.Name)
+		}
+	} else if port != 0 || randomPort {
+		return models.Route{}, fmt.Errorf("Unable to create a route with a port on HTTP domain '%s'.", domain.Name)
+	}
+
+	route, err := s.Routes().CreateInSpace(host, path, domain.GUID, spaceGUID, port, randomPort)
+	if err != nil {
+		return route, err
+	}
+	s.logger.DebugMessage("Created route '%s' with GUID '%s'.", route.URL(), route.GUID)
+	return route, nil
+}
+
+// RouteReserved - Returns whether a route with the given host, path and
+// port exists on a domain in any org. A port of 0 is not matched.
+func (s *CfCliSession) RouteReserved(domain models.DomainFields, host, path string, port int) (bool, error) {
+
+	u, err := url.Parse(s.config.APIEndpoint())
+	if err != nil {
+		return false, err
+	}
+	u.Path = fmt.Sprintf("/v2/routes/reserved/domain/%s", domain.GUID)
+	if len(host) > 0 {
+		u.Path += fmt.Sprintf("/host/%s", host)
+	}
+	q := u.Query()
+	if len(path) > 0 {
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		q.Set("path", path)
+	}
+	if port != 0 {
+		q.Set("port", fmt.Sprintf("%d", port))
+	}
+	u.RawQuery = q.Encode()
+
+	var response interface{}
+	if err = s.ccGateway.GetResource(u.String(), &response); err != nil {
+		if _, ok := err.(*errors.HTTPNotFoundError); ok {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// MapRoute - Maps a route to an app forwarding its requests or connections
+// to the given port of the app's instances. A port of 0 maps the route to
+// the app's default port.
+func (s *CfCliSession) MapRoute(routeGUID, appGUID string, appPort int) error {
+
+	body, err := json.Marshal(routeMappingRequest{AppGUID: appGUID, RouteGUID: routeGUID, AppPort: appPort})
+	if err != nil {
+		return err
+	}
+	if err = s.ccGateway.CreateResource(s.config.APIEndpoint(), "/v2/route_mappings", bytes.NewReader(body)); err != nil {
+		return err
+	}
+	s.logger.DebugMessage("Mapped route '%s' to app '%s' on port %d.", routeGUID, appGUID, appPort)
+	return nil
+}
+
+// GetRouteMappings - Returns the mappings of a route to apps
+func (s *CfCliSession) GetRouteMappings(routeGUID string) ([]RouteMapping, error) {
+
+	mappings := []RouteMapping{}
+	err := s.ccGateway.ListPaginatedResources(s.config.APIEndpoint(),
+		fmt.Sprintf("/v2/routes/%s/route_mappings", routeGUID), routeMappingResource{},
+		func(resource interface{}) bool {
+			r := resource.(routeMappingResource)
+			mapping := RouteMapping{RouteGUID: r.Entity.RouteGUID, AppGUID: r.Entity.AppGUID}
+			if r.Entity.AppPort != nil {
+				mapping.AppPort = *r.Entity.AppPort
+			}
+			mappings = append(mappings, mapping)
+			return true
+		})
+	return mappings, err
+}
@@ -0,0 +1,177 @@
+package cfapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"code.cloudfoundry.org/cli/cf/api/resources"
+	"code.cloudfoundry.org/cli/cf/api/securitygroups"
+	"code.cloudfoundry.org/cli/cf/models"
+)
+
+// SecurityGroupLifecycle - The phase of the lifecycle of the apps
+// in a space whose network traffic a security group applies to
+type SecurityGroupLifecycle string
+
+const (
+	// SecurityGroupRunning - Security groups applying to running app instances
+	SecurityGroupRunning = SecurityGroupLifecycle("running")
+	// SecurityGroupStaging - Security groups applying to staging apps
+	SecurityGroupStaging = SecurityGroupLifecycle("staging")
+)
+
+// securityGroupRuleProtocols - Protocols a security group rule may allow
+var securityGroupRuleProtocols = []string{"tcp", "udp", "icmp", "all"}
+
+// SecurityGroups -
+func (s *CfCliSession) SecurityGroups() securitygroups.SecurityGroupRepo {
+	return securitygroups.NewSecurityGroupRepo(s.config, s.ccGateway)
+}
+
+// BindSecurityGroupToSpace - Applies a security group to the
+// apps in a space for the given phase of their lifecycle
+func (s *CfCliSession) BindSecurityGroupToSpace(securityGroupGUID, spaceGUID string, lifecycle SecurityGroupLifecycle) error {
+
+	path, err := securityGroupSpacePath(securityGroupGUID, spaceGUID, lifecycle)
+	if err != nil {
+		return err
+	}
+	return s.ccGateway.UpdateResource(s.config.APIEndpoint(), path, strings.NewReader(""))
+}
+
+// UnbindSecurityGroupFromSpace - Removes a security group from the
+// apps in a space for the given phase of their lifecycle
+func (s *CfCliSession) UnbindSecurityGroupFromSpace(securityGroupGUID, spaceGUID string, lifecycle SecurityGroupLifecycle) error {
+
+	path, err := securityGroupSpacePath(securityGroupGUID, spaceGUID, lifecycle)
+	if err != nil {
+		return err
+	}
+	return s.ccGateway.DeleteResource(s.config.APIEndpoint(), path)
+}
+
+// GetSpaceSecurityGroups - Returns the security groups bound to a
+// space for the given phase of the lifecycle of its apps. Security
+// groups applying to all spaces are not included.
+func (s *CfCliSession) GetSpaceSecurityGroups(spaceGUID string, lifecycle SecurityGroupLifecycle) ([]models.SecurityGroupFields, error) {
+
+	var relation string
+	switch lifecycle {
+	case SecurityGroupRunning:
+		relation = "security_groups"
+	case SecurityGroupStaging:
+		relation = "staging_security_groups"
+	default:
+		return nil, fmt.Errorf("Unknown security group lifecycle '%s'.", lifecycle)
+	}
+
+	groups := []models.SecurityGroupFields{}
+	err := s.ccGateway.ListPaginatedResources(s.config.APIEndpoint(),
+		fmt.Sprintf("/v2/spaces/%s/%s", spaceGUID, relation), resources.SecurityGroupResource{},
+		func(resource interface{}) bool {
+			groups = append(groups, resource.(resources.SecurityGroupResource).ToFields())
+			return true
+		})
+	return groups, err
+}
+
+func securityGroupSpacePath(securityGroupGUID, spaceGUID string, lifecycle SecurityGroupLifecycle) (string, error) {
+	switch lifecycle {
+	case SecurityGroupRunning:
+		return fmt.Sprintf("/v2/security_groups/%s/spaces/%s", securityGroupGUID, spaceGUID), nil
+	case SecurityGroupStaging:
+		return fmt.Sprintf("/v2/security_groups/%s/staging_spaces/%s", securityGroupGUID, spaceGUID), nil
+	}
+	return "", fmt.Errorf("Unknown security group lifecycle '%s'.", lifecycle)
+}
+
+// ParseSecurityGroupRules - Parses the rules of a security group
+// from a JSON array of rule objects as accepted by the CF CLI. Each
+// rule must name a protocol and a destination.
+func ParseSecurityGroupRules(rulesJSON []byte) ([]map[string]interface{}, error) {
+
+	rules := []map[string]interface{}{}
+	if err := json.Unmarshal(rulesJSON, &rules); err != nil {
+		return nil, fmt.Errorf("Unable to parse security group rules: %s", err.Error())
+	}
+	for i, rule := range rules {
+		valid := false
+		for _, p := range securityGroupRuleProtocols {
+			valid = valid || rule["protocol"] == p
+		}
+		if !valid {
+			return nil, fmt.Errorf("Security group rule %d has an invalid protocol '%v'. It must be one of %s.",
+				i, rule["protocol"], strings.Join(securityGroupRuleProtocols, ", "))
+		}
+		if destination, _ := rule["destination"].(string); len(destination) == 0 {
+			return nil, fmt.Errorf("Security group rule %d is missing a destination.", i)
+		}
+	}
+	return rules, nil
+}
+
+// SecurityGroupRulesDiff - The rules added to and removed from a
+// security group when its rules are replaced by the desired rules
+type SecurityGroupRulesDiff struct {
+	Added   []map[string]interface{}
+	Removed []map[string]interface{}
+}
+
+// DiffSecurityGroupRules - Compares the current rules of a security group
+// with the desired rules. Rules are equal if all their attributes are
+// equal. The order of the rules does not matter.
+func DiffSecurityGroupRules(current, desired []map[string]interface{}) SecurityGroupRulesDiff {
+
+	diff := SecurityGroupRulesDiff{
+		Added:   []map[string]interface{}{},
+		Removed: []map[string]interface{}{},
+	}
+
+	unmatched := make(map[string]int)
+	for _, rule := range current {
+		unmatched[ruleKey(rule)]++
+	}
+	for _, rule := range desired {
+		key := ruleKey(rule)
+		if unmatched[key] > 0 {
+			unmatched[key]--
+		} else {
+			diff.Added = append(diff.Added, rule)
+		}
+	}
+	for _, rule := range current {
+		key := ruleKey(rule)
+		if unmatched[key] > 0 {
+			unmatched[key]--
+			diff.Removed = append(diff.Removed, rule)
+		}
+	}
+	return diff
+}
+
+// HasChanges -
+func (d SecurityGroupRulesDiff) HasChanges() bool {
+	return len(d.Added) > 0 || len(d.Removed) > 0
+}
+
+// String - Renders the removed rules prefixed with '-' followed by
+// the added rules prefixed with '+', one rule per line
+func (d SecurityGroupRulesDiff) String() string {
+
+	lines := []string{}
+	for _, rule := range d.Removed {
+		lines = append(lines, "- "+ruleKey(rule))
+	}
+	for _, rule := range d.Added {
+		lines = append(lines, "+ "+ruleKey(rule))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ruleKey - Renders a rule as JSON. Its attributes are
+// sorted so that equal rules have equal keys.
+func ruleKey(rule map[string]interface{}) string {
+	data, _ := json.Marshal(rule)
+	return string(data)
+}
@@ -0,0 +1,58 @@
+package cfapi_test
+
+import (
+	"github.com/mevansam/cf-cli-api/cfapi"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Security Group Rule Tests", func() {
+
+	Context("Parsing rules", func() {
+
+		It("Should parse rules in the format of the CF CLI", func() {
+			rules, err := cfapi.ParseSecurityGroupRules([]byte(`[
+				{"protocol": "tcp", "destination": "10.0.0.0/8", "ports": "443,8443"},
+				{"protocol": "icmp", "destination": "0.0.0.0/0", "type": 0, "code": 0}
+			]`))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(len(rules)).To(Equal(2))
+			Expect(rules[0]["ports"]).To(Equal("443,8443"))
+		})
+		It("Should reject malformed rules", func() {
+			_, err := cfapi.ParseSecurityGroupRules([]byte(`{"protocol": "tcp"}`))
+			Expect(err).To(HaveOccurred())
+
+			_, err = cfapi.ParseSecurityGroupRules([]byte(`[{"protocol": "http", "destination": "10.0.0.1"}]`))
+			Expect(err).To(HaveOccurred())
+
+			_, err = cfapi.ParseSecurityGroupRules([]byte(`[{"protocol": "udp"}]`))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Diffing rules", func() {
+
+		It("Should report the rules added and removed regardless of their order", func() {
+			current := []map[string]interface{}{
+				{"protocol": "tcp", "destination": "10.0.0.0/8", "ports": "443"},
+				{"protocol": "udp", "destination": "10.0.0.2", "ports": "53"},
+			}
+			desired, err := cfapi.ParseSecurityGroupRules([]byte(`[
+				{"destination": "10.0.0.2", "ports": "53", "protocol": "udp"},
+				{"protocol": "tcp", "destination": "10.0.0.0/8", "ports": "443,8443"}
+			]`))
+			Expect(err).ShouldNot(HaveOccurred())
+
+			diff := cfapi.DiffSecurityGroupRules(current, desired)
+			Expect(diff.HasChanges()).To(BeTrue())
+			Expect(diff.Added).To(Equal([]map[string]interface{}{desired[1]}))
+			Expect(diff.Removed).To(Equal([]map[string]interface{}{current[0]}))
+			Expect(diff.String()).To(Equal(
+				`- {"destination":"10.0.0.0/8","ports":"443","protocol":"tcp"}` + "\n" +
+					`+ {"destination":"10.0.0.0/8","ports":"443,8443","protocol":"tcp"}`))
+
+			Expect(cfapi.DiffSecurityGroupRules(current, current).HasChanges()).To(BeFalse())
+		})
+	})
+})
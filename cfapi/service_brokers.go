@@ -0,0 +1,135 @@
+package cfapi
+
+import (
+	"fmt"
+	"sort"
+
+	"code.cloudfoundry.org/cli/cf/api"
+	"code.cloudfoundry.org/cli/cf/api/resources"
+	"code.cloudfoundry.org/cli/cf/models"
+)
+
+// ServiceBrokers -
+func (s *CfCliSession) ServiceBrokers() api.ServiceBrokerRepository {
+	return api.NewCloudControllerServiceBrokerRepository(s.config, s.ccGateway)
+}
+
+// ServicePlanVisibilities -
+func (s *CfCliSession) ServicePlanVisibilities() api.ServicePlanVisibilityRepository {
+	return api.NewCloudControllerServicePlanVisibilityRepository(s.config, s.ccGateway)
+}
+
+// GetServiceBrokerCatalog - Returns a broker with the services of its
+// catalog and their plans. The names of the orgs having access to a plan
+// which is not public are listed in order by the OrgNames of the plan.
+func (s *CfCliSession) GetServiceBrokerCatalog(brokerGUID string) (models.ServiceBroker, error) {
+
+	broker, err := s.ServiceBrokers().FindByGUID(brokerGUID)
+	if err != nil {
+		return broker, err
+	}
+	if broker.Services, err = s.Services().ListServicesFromBroker(brokerGUID); err != nil {
+		return broker, err
+	}
+
+	orgNames := make(map[string]string)
+	for i, service := range broker.Services {
+		plans, err := s.ServicePlans().Search(map[string]string{"service_guid": service.GUID})
+		if err != nil {
+			return broker, err
+		}
+		for j, plan := range plans {
+			if plan.Public {
+				continue
+			}
+			visibilities, err := s.ServicePlanVisibilities().Search(map[string]string{"service_plan_guid": plan.GUID})
+			if err != nil {
+				return broker, err
+			}
+			plans[j].OrgNames = []string{}
+			for _, v := range visibilities {
+				name, ok := orgNames[v.OrganizationGUID]
+				if !ok {
+					org := resources.OrganizationResource{}
+					if err = s.ccGateway.GetResource(
+						fmt.Sprintf("%s/v2/organizations/%s", s.config.APIEndpoint(), v.OrganizationGUID), &org); err != nil {
+						return broker, err
+					}
+					name = org.Entity.Name
+					orgNames[v.OrganizationGUID] = name
+				}
+				plans[j].OrgNames = append(plans[j].OrgNames, name)
+			}
+			sort.Strings(plans[j].OrgNames)
+		}
+		broker.Services[i].Plans = plans
+	}
+	return broker, nil
+}
+
+// EnableServicePlanAccess - Gives an org access to a service plan. If
+// no org is given the plan is made public giving all orgs access to it.
+func (s *CfCliSession) EnableServicePlanAccess(planGUID, orgGUID string) error {
+
+	plan, err := s.getServicePlan(planGUID)
+	if err != nil || plan.Public {
+		return err
+	}
+	if len(orgGUID) == 0 {
+		s.logger.DebugMessage("Making service plan '%s' public.", plan.Name)
+		return s.ServicePlans().Update(plan, plan.ServiceOfferingGUID, true)
+	}
+
+	visibilities, err := s.ServicePlanVisibilities().Search(
+		map[string]string{"service_plan_guid": planGUID, "organization_guid": orgGUID})
+	if err != nil || len(visibilities) > 0 {
+		return err
+	}
+	s.logger.DebugMessage("Giving org '%s' access to service plan '%s'.", orgGUID, plan.Name)
+	return s.ServicePlanVisibilities().Create(planGUID, orgGUID)
+}
+
+// DisableServicePlanAccess - Revokes the access of an org to a service
+// plan. Access cannot be revoked from a single org while the plan is
+// public. If no org is given the plan is made private and the access
+// of all orgs is revoked.
+func (s *CfCliSession) DisableServicePlanAccess(planGUID, orgGUID string) error {
+
+	plan, err := s.getServicePlan(planGUID)
+	if err != nil {
+		return err
+	}
+
+	query := map[string]string{"service_plan_guid": planGUID}
+	if len(orgGUID) > 0 {
+		if plan.Public {
+			return fmt.Errorf("Unable to revoke access of org '%s' to service plan '%s' as the plan is public.",
+				orgGUID, plan.Name)
+		}
+		query["organization_guid"] = orgGUID
+	} else if plan.Public {
+		s.logger.DebugMessage("Making service plan '%s' private.", plan.Name)
+		if err = s.ServicePlans().Update(plan, plan.ServiceOfferingGUID, false); err != nil {
+			return err
+		}
+	}
+
+	visibilities, err := s.ServicePlanVisibilities().Search(query)
+	if err != nil {
+		return err
+	}
+	for _, v := range visibilities {
+		if err = s.ServicePlanVisibilities().Delete(v.GUID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getServicePlan -
+func (s *CfCliSession) getServicePlan(planGUID string) (models.ServicePlanFields, error) {
+	plan := resources.ServicePlanResource{}
+	err := s.ccGateway.GetResource(
+		fmt.Sprintf("%s/v2/service_plans/%s", s.config.APIEndpoint(), planGUID), &plan)
+	return plan.ToFields(), err
+}
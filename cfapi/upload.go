@@ -0,0 +1,166 @@
+package cfapi
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code.cloudfoundry.org/cli/cf/api/resources"
+)
+
+// UploadDroplet - Uploads the given droplet file as the droplet of an app
+// and waits for the job processing it to finish. The multipart form
+// expected by the Cloud Controller is streamed from the file so the
+// droplet is not copied before it is uploaded.
+func (s *CfCliSession) UploadDroplet(appGUID string, droplet *os.File) error {
+
+	stream, err := newFileUploadStream(droplet, "droplet", filepath.Base(droplet.Name()),
+		func(r io.Reader, size int64) io.Reader {
+			return newProgressReader(r, s.progress, ProgressUploadDroplet, appGUID, 0, size)
+		})
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	url := fmt.Sprintf("%s/v2/apps/%s/droplet/upload", s.config.APIEndpoint(), appGUID)
+	request, err := s.ccGateway.NewRequest("PUT", url, s.config.AccessToken(), stream)
+	if err != nil {
+		return err
+	}
+	request.HTTPReq.Header.Set("Content-Type", stream.ContentType())
+	request.HTTPReq.ContentLength = stream.ContentLength()
+
+	job := resources.Resource{}
+	if _, err = s.ccGateway.PerformRequestForJSONResponse(request, &job); err != nil {
+		return err
+	}
+	s.logger.DebugMessage("Response from droplet upload: %# v", job)
+
+	// The droplet is processed by a job which
+	// needs to finish before the app can start
+	if len(job.Metadata.GUID) == 0 || !strings.Contains(job.Metadata.URL, "/jobs/") {
+		return nil
+	}
+	return s.WaitForJob(job.Metadata.GUID, AsyncTimeout)
+}
+
+// fileUploadStream - Streams a file wrapped in a multipart form
+// through a pipe. As the size of the form is computed up front the upload
+// is sent with a content length instead of being chunked. Seeking to the
+// start restarts the stream so the gateway can resend the request after
+// refreshing an expired token.
+type fileUploadStream struct {
+	file *os.File
+	size int64
+	wrap func(r io.Reader, size int64) io.Reader
+
+	contentType string
+	head, tail  []byte
+
+	reader *io.PipeReader
+	done   chan struct{}
+}
+
+// newFileUploadStream - Returns a stream of the multipart form uploading the
+// given file as the form field with the given name and filename. The reader
+// of the file's content is wrapped by the given function each time the
+// stream starts.
+func newFileUploadStream(file *os.File, field, filename string,
+	wrap func(r io.Reader, size int64) io.Reader) (*fileUploadStream, error) {
+
+	fileStats, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	// Render the form around the file's
+	// content to determine its total length
+	form := bytes.Buffer{}
+	writer := multipart.NewWriter(&form)
+	if _, err = writer.CreateFormFile(field, filename); err != nil {
+		return nil, err
+	}
+	headLength := form.Len()
+	if err = writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return &fileUploadStream{
+		file:        file,
+		size:        fileStats.Size(),
+		wrap:        wrap,
+		contentType: writer.FormDataContentType(),
+		head:        form.Bytes()[:headLength],
+		tail:        form.Bytes()[headLength:],
+	}, nil
+}
+
+// ContentType -
+func (s *fileUploadStream) ContentType() string {
+	return s.contentType
+}
+
+// ContentLength -
+func (s *fileUploadStream) ContentLength() int64 {
+	return int64(len(s.head)) + s.size + int64(len(s.tail))
+}
+
+// Read -
+func (s *fileUploadStream) Read(p []byte) (int, error) {
+	if s.reader == nil {
+		if err := s.start(); err != nil {
+			return 0, err
+		}
+	}
+	return s.reader.Read(p)
+}
+
+// Seek - Only seeking to the start of the stream is supported
+func (s *fileUploadStream) Seek(offset int64, whence int) (int64, error) {
+	if offset != 0 || whence != io.SeekStart {
+		return 0, fmt.Errorf("Unable to seek file upload stream to an offset other than its start.")
+	}
+	s.Close()
+	return 0, nil
+}
+
+// Close - Stops the stream in progress
+func (s *fileUploadStream) Close() error {
+	if s.reader != nil {
+		s.reader.CloseWithError(io.ErrClosedPipe)
+		<-s.done
+		s.reader = nil
+	}
+	return nil
+}
+
+// start - Starts writing the form to a new pipe
+func (s *fileUploadStream) start() error {
+
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	reader, writer := io.Pipe()
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		_, err := writer.Write(s.head)
+		if err == nil {
+			_, err = io.Copy(writer, s.wrap(io.LimitReader(s.file, s.size), s.size))
+		}
+		if err == nil {
+			_, err = writer.Write(s.tail)
+		}
+		writer.CloseWithError(err)
+	}()
+
+	s.reader, s.done = reader, done
+	return nil
+}
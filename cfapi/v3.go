@@ -0,0 +1,515 @@
+package cfapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/cf/errors"
+)
+
+// Resources returned by the Cloud Controller's v3 API
+
+type apiRootResource struct {
+	Links struct {
+		CloudControllerV2 *apiRootLink `json:"cloud_controller_v2"`
+		CloudControllerV3 *apiRootLink `json:"cloud_controller_v3"`
+		Logging           *apiRootLink `json:"logging"`
+		LogCache          *apiRootLink `json:"log_cache"`
+	} `json:"links"`
+}
+
+type apiRootLink struct {
+	Href string `json:"href"`
+	Meta struct {
+		Version string `json:"version"`
+	} `json:"meta"`
+}
+
+type v3PageResource struct {
+	Pagination struct {
+		Next *struct {
+			Href string `json:"href"`
+		} `json:"next"`
+	} `json:"pagination"`
+	Resources []json.RawMessage `json:"resources"`
+}
+
+type v3GUIDResource struct {
+	GUID string `json:"guid"`
+}
+
+type v3RelationshipResource struct {
+	Data *v3GUIDResource `json:"data"`
+}
+
+type v3ChecksumResource struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+func (r *v3ChecksumResource) toModel() V3Checksum {
+	if r == nil {
+		return V3Checksum{}
+	}
+	return V3Checksum{Type: r.Type, Value: r.Value}
+}
+
+type v3AppResource struct {
+	GUID      string    `json:"guid"`
+	Name      string    `json:"name"`
+	State     string    `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Lifecycle struct {
+		Type string `json:"type"`
+	} `json:"lifecycle"`
+	Relationships struct {
+		Space v3RelationshipResource `json:"space"`
+	} `json:"relationships"`
+}
+
+// ToModel -
+func (r v3AppResource) ToModel() V3App {
+	app := V3App{
+		GUID:          r.GUID,
+		Name:          r.Name,
+		State:         r.State,
+		LifecycleType: r.Lifecycle.Type,
+		CreatedAt:     r.CreatedAt,
+		UpdatedAt:     r.UpdatedAt,
+	}
+	if r.Relationships.Space.Data != nil {
+		app.SpaceGUID = r.Relationships.Space.Data.GUID
+	}
+	return app
+}
+
+type v3PackageResource struct {
+	GUID      string    `json:"guid"`
+	Type      string    `json:"type"`
+	State     string    `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      struct {
+		Checksum *v3ChecksumResource `json:"checksum"`
+	} `json:"data"`
+}
+
+// ToModel -
+func (r v3PackageResource) ToModel() V3Package {
+	return V3Package{
+		GUID:      r.GUID,
+		Type:      r.Type,
+		State:     r.State,
+		Checksum:  r.Data.Checksum.toModel(),
+		CreatedAt: r.CreatedAt,
+	}
+}
+
+type v3DropletResource struct {
+	GUID       string              `json:"guid"`
+	State      string              `json:"state"`
+	Checksum   *v3ChecksumResource `json:"checksum"`
+	Stack      string              `json:"stack"`
+	CreatedAt  time.Time           `json:"created_at"`
+	Buildpacks []struct {
+		Name string `json:"name"`
+	} `json:"buildpacks"`
+}
+
+// ToModel -
+func (r v3DropletResource) ToModel() V3Droplet {
+	droplet := V3Droplet{
+		GUID:      r.GUID,
+		State:     r.State,
+		Checksum:  r.Checksum.toModel(),
+		Stack:     r.Stack,
+		CreatedAt: r.CreatedAt,
+	}
+	for _, b := range r.Buildpacks {
+		droplet.Buildpacks = append(droplet.Buildpacks, b.Name)
+	}
+	return droplet
+}
+
+type v3BuildResource struct {
+	GUID      string          `json:"guid"`
+	State     string          `json:"state"`
+	Error     *string         `json:"error"`
+	CreatedAt time.Time       `json:"created_at"`
+	Package   *v3GUIDResource `json:"package"`
+	Droplet   *v3GUIDResource `json:"droplet"`
+}
+
+// ToModel -
+func (r v3BuildResource) ToModel() V3Build {
+	build := V3Build{
+		GUID:      r.GUID,
+		State:     r.State,
+		CreatedAt: r.CreatedAt,
+	}
+	if r.Error != nil {
+		build.Error = *r.Error
+	}
+	if r.Package != nil {
+		build.PackageGUID = r.Package.GUID
+	}
+	if r.Droplet != nil {
+		build.DropletGUID = r.Droplet.GUID
+	}
+	return build
+}
+
+type v3ProcessResource struct {
+	GUID        string  `json:"guid"`
+	Type        string  `json:"type"`
+	Command     *string `json:"command"`
+	Instances   int     `json:"instances"`
+	MemoryInMB  int     `json:"memory_in_mb"`
+	DiskInMB    int     `json:"disk_in_mb"`
+	HealthCheck struct {
+		Type string `json:"type"`
+	} `json:"health_check"`
+}
+
+// ToModel -
+func (r v3ProcessResource) ToModel() V3Process {
+	process := V3Process{
+		GUID:            r.GUID,
+		Type:            r.Type,
+		Instances:       r.Instances,
+		MemoryInMB:      r.MemoryInMB,
+		DiskInMB:        r.DiskInMB,
+		HealthCheckType: r.HealthCheck.Type,
+	}
+	if r.Command != nil {
+		process.Command = *r.Command
+	}
+	return process
+}
+
+type v3DeploymentResource struct {
+	GUID      string    `json:"guid"`
+	State     string    `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+	Status    struct {
+		Value  string `json:"value"`
+		Reason string `json:"reason"`
+	} `json:"status"`
+	Droplet       *v3GUIDResource `json:"droplet"`
+	Relationships struct {
+		App v3RelationshipResource `json:"app"`
+	} `json:"relationships"`
+}
+
+// ToModel -
+func (r v3DeploymentResource) ToModel() V3Deployment {
+	deployment := V3Deployment{
+		GUID:         r.GUID,
+		State:        r.State,
+		StatusValue:  r.Status.Value,
+		StatusReason: r.Status.Reason,
+		CreatedAt:    r.CreatedAt,
+	}
+	if r.Droplet != nil {
+		deployment.DropletGUID = r.Droplet.GUID
+	}
+	if r.Relationships.App.Data != nil {
+		deployment.AppGUID = r.Relationships.App.Data.GUID
+	}
+	return deployment
+}
+
+type v3AuditEventResource struct {
+	GUID      string    `json:"guid"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Actor     struct {
+		GUID string `json:"guid"`
+		Type string `json:"type"`
+		Name string `json:"name"`
+	} `json:"actor"`
+	Target struct {
+		GUID string `json:"guid"`
+		Type string `json:"type"`
+		Name string `json:"name"`
+	} `json:"target"`
+	Data         map[string]interface{} `json:"data"`
+	Space        *v3GUIDResource        `json:"space"`
+	Organization *v3GUIDResource        `json:"organization"`
+}
+
+// ToModel -
+func (r v3AuditEventResource) ToModel() V3AuditEvent {
+	event := V3AuditEvent{
+		GUID:       r.GUID,
+		Type:       r.Type,
+		CreatedAt:  r.CreatedAt,
+		ActorGUID:  r.Actor.GUID,
+		ActorType:  r.Actor.Type,
+		ActorName:  r.Actor.Name,
+		TargetGUID: r.Target.GUID,
+		TargetType: r.Target.Type,
+		TargetName: r.Target.Name,
+		Data:       r.Data,
+	}
+	if r.Space != nil {
+		event.SpaceGUID = r.Space.GUID
+	}
+	if r.Organization != nil {
+		event.OrgGUID = r.Organization.GUID
+	}
+	return event
+}
+
+// GetAPIInfo - Returns the versions of the Cloud Controller APIs advertised
+// by the root of the API endpoint. Foundations whose API root is not found
+// predate the v3 API so only the v2 API is assumed to be served by them.
+// The log cache endpoint is derived from the doppler endpoint if the root
+// does not advertise it.
+func (s *CfCliSession) GetAPIInfo() (APIInfo, error) {
+
+	s.apiInfoMutex.Lock()
+	defer s.apiInfoMutex.Unlock()
+
+	if s.apiInfo != nil {
+		return *s.apiInfo, nil
+	}
+
+	info := APIInfo{}
+	root := apiRootResource{}
+	if err := s.ccGateway.GetResource(s.config.APIEndpoint()+"/", &root); err != nil {
+		if _, ok := err.(*errors.HTTPNotFoundError); !ok {
+			return info, err
+		}
+		info.V2Version = s.config.APIVersion()
+	} else {
+		if root.Links.CloudControllerV2 != nil {
+			info.V2Version = root.Links.CloudControllerV2.Meta.Version
+		}
+		if root.Links.CloudControllerV3 != nil {
+			info.V3Version = root.Links.CloudControllerV3.Meta.Version
+		}
+		if root.Links.Logging != nil {
+			info.DopplerURL = root.Links.Logging.Href
+		}
+		if root.Links.LogCache != nil {
+			info.LogCacheURL = root.Links.LogCache.Href
+		}
+	}
+	if len(info.DopplerURL) == 0 {
+		info.DopplerURL = s.config.DopplerEndpoint()
+	}
+	if len(info.LogCacheURL) == 0 {
+		info.LogCacheURL = logCacheURLFromDoppler(info.DopplerURL)
+	}
+	s.logger.DebugMessage("Cloud Controller API versions: %# v", info)
+
+	s.apiInfo = &info
+	return info, nil
+}
+
+// GetV3App -
+func (s *CfCliSession) GetV3App(appGUID string) (V3App, error) {
+	app := v3AppResource{}
+	err := s.ccGateway.GetResource(fmt.Sprintf("%s/v3/apps/%s", s.config.APIEndpoint(), appGUID), &app)
+	return app.ToModel(), err
+}
+
+// GetV3AppsInSpace -
+func (s *CfCliSession) GetV3AppsInSpace(spaceGUID string) (apps []V3App, err error) {
+	apps = []V3App{}
+	err = s.listV3Resources(fmt.Sprintf("/v3/apps?space_guids=%s&order_by=name&per_page=100", spaceGUID),
+		func(data json.RawMessage) error {
+			app := v3AppResource{}
+			if err := json.Unmarshal(data, &app); err != nil {
+				return err
+			}
+			apps = append(apps, app.ToModel())
+			return nil
+		})
+	return
+}
+
+// GetV3Packages - Returns the packages of an app with the latest first
+func (s *CfCliSession) GetV3Packages(appGUID string) (packages []V3Package, err error) {
+	packages = []V3Package{}
+	err = s.listV3Resources(fmt.Sprintf("/v3/apps/%s/packages?order_by=-created_at&per_page=100", appGUID),
+		func(data json.RawMessage) error {
+			pkg := v3PackageResource{}
+			if err := json.Unmarshal(data, &pkg); err != nil {
+				return err
+			}
+			packages = append(packages, pkg.ToModel())
+			return nil
+		})
+	return
+}
+
+// GetV3Droplets - Returns the droplets of an app with the latest first
+func (s *CfCliSession) GetV3Droplets(appGUID string) (droplets []V3Droplet, err error) {
+	droplets = []V3Droplet{}
+	err = s.listV3Resources(fmt.Sprintf("/v3/apps/%s/droplets?order_by=-created_at&per_page=100", appGUID),
+		func(data json.RawMessage) error {
+			droplet := v3DropletResource{}
+			if err := json.Unmarshal(data, &droplet); err != nil {
+				return err
+			}
+			droplets = append(droplets, droplet.ToModel())
+			return nil
+		})
+	return
+}
+
+// GetV3CurrentDroplet - Returns the droplet an app runs
+func (s *CfCliSession) GetV3CurrentDroplet(appGUID string) (V3Droplet, error) {
+	droplet := v3DropletResource{}
+	err := s.ccGateway.GetResource(fmt.Sprintf("%s/v3/apps/%s/droplets/current", s.config.APIEndpoint(), appGUID), &droplet)
+	return droplet.ToModel(), err
+}
+
+// SetV3CurrentDroplet - Sets the droplet an app runs once it is restarted
+func (s *CfCliSession) SetV3CurrentDroplet(appGUID, dropletGUID string) error {
+	return s.v3Request("PATCH", fmt.Sprintf("/v3/apps/%s/relationships/current_droplet", appGUID),
+		map[string]interface{}{
+			"data": map[string]string{"guid": dropletGUID},
+		}, &v3RelationshipResource{})
+}
+
+// CreateV3Build - Starts staging the given package
+func (s *CfCliSession) CreateV3Build(packageGUID string) (V3Build, error) {
+	build := v3BuildResource{}
+	err := s.v3Request("POST", "/v3/builds",
+		map[string]interface{}{
+			"package": map[string]string{"guid": packageGUID},
+		}, &build)
+	return build.ToModel(), err
+}
+
+// GetV3Build -
+func (s *CfCliSession) GetV3Build(buildGUID string) (V3Build, error) {
+	build := v3BuildResource{}
+	err := s.ccGateway.GetResource(fmt.Sprintf("%s/v3/builds/%s", s.config.APIEndpoint(), buildGUID), &build)
+	return build.ToModel(), err
+}
+
+// GetV3Processes -
+func (s *CfCliSession) GetV3Processes(appGUID string) (processes []V3Process, err error) {
+	processes = []V3Process{}
+	err = s.listV3Resources(fmt.Sprintf("/v3/apps/%s/processes?per_page=100", appGUID),
+		func(data json.RawMessage) error {
+			process := v3ProcessResource{}
+			if err := json.Unmarshal(data, &process); err != nil {
+				return err
+			}
+			processes = append(processes, process.ToModel())
+			return nil
+		})
+	return
+}
+
+// CreateV3Deployment - Starts a rolling deployment of the given
+// droplet of an app. An empty droplet GUID deploys the current one.
+func (s *CfCliSession) CreateV3Deployment(appGUID, dropletGUID string) (V3Deployment, error) {
+	request := map[string]interface{}{
+		"relationships": map[string]interface{}{
+			"app": map[string]interface{}{
+				"data": map[string]string{"guid": appGUID},
+			},
+		},
+	}
+	if len(dropletGUID) > 0 {
+		request["droplet"] = map[string]string{"guid": dropletGUID}
+	}
+	deployment := v3DeploymentResource{}
+	err := s.v3Request("POST", "/v3/deployments", request, &deployment)
+	return deployment.ToModel(), err
+}
+
+// GetV3Deployment -
+func (s *CfCliSession) GetV3Deployment(deploymentGUID string) (V3Deployment, error) {
+	deployment := v3DeploymentResource{}
+	err := s.ccGateway.GetResource(fmt.Sprintf("%s/v3/deployments/%s", s.config.APIEndpoint(), deploymentGUID), &deployment)
+	return deployment.ToModel(), err
+}
+
+// GetV3AuditEvents - Returns the audit events selected by
+// the filter in the order they occurred
+func (s *CfCliSession) GetV3AuditEvents(filter V3AuditEventFilter) (events []V3AuditEvent, err error) {
+
+	query := url.Values{}
+	query.Set("order_by", "created_at")
+	query.Set("per_page", "100")
+	if len(filter.SpaceGUIDs) > 0 {
+		query.Set("space_guids", strings.Join(filter.SpaceGUIDs, ","))
+	}
+	if len(filter.TargetGUIDs) > 0 {
+		query.Set("target_guids", strings.Join(filter.TargetGUIDs, ","))
+	}
+	if len(filter.Types) > 0 {
+		query.Set("types", strings.Join(filter.Types, ","))
+	}
+	if !filter.From.IsZero() {
+		if filter.Inclusive {
+			query.Set("created_ats[gte]", filter.From.UTC().Format(time.RFC3339))
+		} else {
+			query.Set("created_ats[gt]", filter.From.UTC().Format(time.RFC3339))
+		}
+	}
+
+	events = []V3AuditEvent{}
+	err = s.listV3Resources("/v3/audit_events?"+query.Encode(),
+		func(data json.RawMessage) error {
+			event := v3AuditEventResource{}
+			if err := json.Unmarshal(data, &event); err != nil {
+				return err
+			}
+			events = append(events, event.ToModel())
+			return nil
+		})
+	return
+}
+
+// listV3Resources - Calls the given function with each resource
+// of all pages of the v3 list at the given path
+func (s *CfCliSession) listV3Resources(path string, cb func(json.RawMessage) error) error {
+
+	next := s.config.APIEndpoint() + path
+	for len(next) > 0 {
+
+		page := v3PageResource{}
+		if err := s.ccGateway.GetResource(next, &page); err != nil {
+			return err
+		}
+		for _, data := range page.Resources {
+			if err := cb(data); err != nil {
+				return err
+			}
+		}
+		next = ""
+		if page.Pagination.Next != nil {
+			next = page.Pagination.Next.Href
+		}
+	}
+	return nil
+}
+
+// v3Request - Sends the given request body to a v3 endpoint
+// and reads the resource returned in the response
+func (s *CfCliSession) v3Request(method, path string, body, resource interface{}) error {
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	request, err := s.ccGateway.NewRequest(method,
+		s.config.APIEndpoint()+path, s.config.AccessToken(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	_, err = s.ccGateway.PerformRequestForJSONResponse(request, resource)
+	return err
+}
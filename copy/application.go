@@ -1,9 +1,6 @@
 package copy
 
 import (
-	"io"
-	"io/ioutil"
-	"mime/multipart"
 	"os"
 	"path/filepath"
 
@@ -123,34 +120,12 @@ func (d *AppDroplet) Upload(session cfapi.CfSession, params models.AppParams) (a
 		return
 	}
 
-	dropletUploadRequest, err := ioutil.TempFile("", ".droplet")
-	if err != nil {
-		return
-	}
 	file, err := os.Open(d.filePath)
 	if err != nil {
 		return
 	}
-	defer func() {
-		file.Close()
-		dropletUploadRequest.Close()
-		os.Remove(dropletUploadRequest.Name())
-	}()
-
-	writer := multipart.NewWriter(dropletUploadRequest)
-	part, err := writer.CreateFormFile("droplet", filepath.Base(d.filePath))
-	if err != nil {
-		return
-	}
-	_, err = io.Copy(part, file)
-	if err != nil {
-		return
-	}
-	err = writer.Close()
-	if err != nil {
-		return
-	}
+	defer file.Close()
 
-	session.UploadDroplet(app.GUID, writer.FormDataContentType(), dropletUploadRequest)
+	err = session.UploadDroplet(app.GUID, file)
 	return
 }
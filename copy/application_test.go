@@ -149,9 +149,11 @@ var _ = Describe("Application Tests", func() {
 				}
 				return nil
 			}
-			session.MockUploadDroplet = func(appGUID string, contentType string, dropletUploadRequest *os.File) error {
+			session.MockUploadDroplet = func(appGUID string, droplet *os.File) error {
 				Expect(appGUID).To(Equal("wxyz"))
-				Expect(contentType).Should(MatchRegexp("multipart/form-data; boundary=[a-f0-9]+"))
+				content, err := ioutil.ReadAll(droplet)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(string(content)).To(Equal("application droplet contents"))
 				return nil
 			}
 
@@ -186,19 +186,9 @@ func (am *CfCliApplicationsManager) DoCopy(
 			}
 		}
 
-		state = "started"
-		params = models.AppParams{State: &state}
-
-		err = utils.Retry(300000, 5000, func() (bool, error) {
-			am.logger.DebugMessage("Starting application %s.", destApp.Name)
-			_, err = am.destCCSession.Applications().Update(destApp.GUID, models.AppParams{State: &state})
-			if err != nil {
-				am.logger.DebugMessage("Request to start application %s returned error: %s.", destApp.Name, err.Error())
-				return false, err
-			}
-			return true, nil
-		})
-		if err != nil {
+		am.logger.DebugMessage("Starting application %s.", destApp.Name)
+		if _, err = am.destCCSession.StartApp(destApp.GUID, cfapi.AsyncTimeout); err != nil {
+			am.logRecentLogs(destApp)
 			return
 		}
 
@@ -219,7 +209,12 @@ func (am *CfCliApplicationsManager) DoCopy(
 				"Using host part of route %s to source app to derive a unique route to the copied app.",
 				r.URL())
 
-			if appHostTmpl != nil {
+			if r.Port != 0 {
+
+				// TCP routes are identified by their domain and port only
+				host = ""
+
+			} else if appHostTmpl != nil {
 
 				appHostVars["app"] = a.App().Name
 				appHostVars["host"] = r.Host
@@ -272,24 +267,63 @@ func (am *CfCliApplicationsManager) DoCopy(
 
 			} else {
 				domain, err = am.destCCSession.Domains().FirstOrDefault(orgGUID, &appRouteDomain)
+				if err != nil {
+					return
+				}
 			}
 
-			route, err = am.destCCSession.Routes().Find(host, domain, "", 0)
+			if (domain.RouterGroupType == cfapi.TCPRouterGroupType) != (r.Port != 0) {
+				am.logger.UI.Say(
+					"  unable to create dest route to match source route %s as domain %s is of a different type",
+					terminal.WarningColor(r.URL()), domain.Name)
+				continue
+			}
+
+			route, err = am.destCCSession.Routes().Find(host, domain, r.Path, r.Port)
 			if err != nil {
 				if _, ok := err.(*errors.ModelNotFoundError); !ok {
 					return
 				}
-			} else {
+			} else if route.Space.GUID == destSpace.GUID {
 				err = am.destCCSession.Routes().Delete(route.GUID)
 				if err != nil {
 					return
 				}
+			} else if r.Port == 0 {
+				am.logger.UI.Say(
+					"  unable to create dest route to match source route %s as it is held by another space",
+					terminal.WarningColor(r.URL()))
+				continue
+			}
+
+			// A TCP route keeps the port of the source route unless the
+			// port is reserved at the destination by a route of another
+			// space in which case the route is created with a random port
+
+			port, randomPort := r.Port, false
+			if port != 0 {
+				if randomPort, err = am.destCCSession.RouteReserved(domain, "", "", port); err != nil {
+					return
+				}
+				if randomPort {
+					am.logger.DebugMessage(
+						"Port %d of source route %s is reserved at destination. Creating route with a random port.",
+						port, r.URL())
+					port = 0
+				}
 			}
-			route, err = am.destCCSession.Routes().Create(host, domain, "", 0, false)
+			route, err = am.destCCSession.CreateRoute(destSpace.GUID, domain, host, r.Path, port, randomPort)
 			if err != nil {
 				return
 			}
-			am.destCCSession.Routes().Bind(route.GUID, destApp.GUID)
+
+			var appPort int
+			if appPort, err = am.sourceAppPort(r.GUID, a.App().GUID); err != nil {
+				return
+			}
+			if err = am.destCCSession.MapRoute(route.GUID, destApp.GUID, appPort); err != nil {
+				return
+			}
 			am.logger.UI.Say("  bound route %s", terminal.HeaderColor(route.URL()))
 		}
 	}
@@ -300,3 +334,35 @@ func (am *CfCliApplicationsManager) DoCopy(
 func (am *CfCliApplicationsManager) Close() {
 	os.RemoveAll(am.downloadPath)
 }
+
+// sourceAppPort - Returns the port of the source app's instances
+// the given source route is mapped to
+func (am *CfCliApplicationsManager) sourceAppPort(routeGUID, appGUID string) (int, error) {
+
+	mappings, err := am.srcCCSession.GetRouteMappings(routeGUID)
+	if err != nil {
+		return 0, err
+	}
+	for _, m := range mappings {
+		if m.AppGUID == appGUID {
+			return m.AppPort, nil
+		}
+	}
+	return 0, nil
+}
+
+// logRecentLogs - Shows the recent logs of an application which failed
+// to start as they explain why its staging or its instances failed
+func (am *CfCliApplicationsManager) logRecentLogs(app models.Application) {
+
+	logs, err := am.destCCSession.GetRecentLogs(app.GUID, 100)
+	if err != nil {
+		am.logger.DebugMessage("Unable to retrieve the recent logs of application %s: %s", app.Name, err.Error())
+		return
+	}
+	am.logger.UI.Say("  recent logs of application %s which failed to start:",
+		terminal.EntityNameColor(app.Name))
+	for _, l := range logs {
+		am.logger.UI.Say("    %s", l.String())
+	}
+}
@@ -1,22 +1,14 @@
 package copy_test
 
 import (
-	"bytes"
 	"fmt"
-	"io"
+	"io/ioutil"
 	"os"
 	"time"
 
-	"code.cloudfoundry.org/cli/cf/api"
-	"code.cloudfoundry.org/cli/cf/api/applicationbits"
-	"code.cloudfoundry.org/cli/cf/api/applications"
-	"code.cloudfoundry.org/cli/cf/api/resources"
-	"code.cloudfoundry.org/cli/cf/errors"
 	"code.cloudfoundry.org/cli/cf/models"
 	"github.com/mevansam/cf-cli-api/cfapi"
-	. "github.com/mevansam/cf-cli-api/cfapi/mocks"
 	"github.com/mevansam/cf-cli-api/copy"
-	"github.com/mevansam/cf-cli-api/utils"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -28,14 +20,60 @@ var _ = Describe("Application Manager Tests", func() {
 		logger *cfapi.Logger
 
 		am copy.ApplicationsManager
-		sc copy.ServiceCollection
+		sc *mockServiceCollection
+
+		oldDestApp1GUID string
+		srcAppContent   map[string]string
 	)
 
 	BeforeEach(func() {
 		logger = cfapi.NewLogger(true, "true")
+		newCopySessions(logger)
+
+		timestamp := time.Now().Format(time.RFC3339)
+		srcAppContent = map[string]string{
+			"app1": fmt.Sprintf("application bits content for app1: %s", timestamp),
+			"app2": fmt.Sprintf("application bits content for app2: %s", timestamp),
+		}
+
+		// Source space
+
+		srcDefaultDomain := srcState.AddSharedDomain("acme-src.com")
+		srcTestDomain := srcState.AddPrivateDomain(srcOrgGUID, "acme-test.com")
+
+		app1 := srcState.AddApp(srcSpaceGUID, "app1", models.ApplicationFields{})
+		srcState.SetAppBits(app1, []byte(srcAppContent["app1"]))
+		srcState.MapRoute(srcState.AddRoute(srcSpaceGUID, srcDefaultDomain, "app1", "", 0), app1)
+		srcState.MapRoute(srcState.AddRoute(srcSpaceGUID, srcTestDomain, "foo1", "", 0), app1)
+
+		app2 := srcState.AddApp(srcSpaceGUID, "app2", models.ApplicationFields{})
+		srcState.SetAppBits(app2, []byte(srcAppContent["app2"]))
+		srcState.MapRoute(srcState.AddRoute(srcSpaceGUID, srcDefaultDomain, "app2", "", 0), app2)
+
+		// Destination space with a previous copy of app1
+
+		destDefaultDomain := destState.AddSharedDomain("acme-dest.com")
+		destTestDomain := destState.AddPrivateDomain(destOrgGUID, "acme-test.com")
+
+		oldDestApp1GUID = destState.AddApp(destSpaceGUID, "app1", models.ApplicationFields{})
+		destState.MapRoute(destState.AddRoute(destSpaceGUID, destDefaultDomain, "app1", "", 0), oldDestApp1GUID)
+		destState.AddRoute(destSpaceGUID, destTestDomain, "foo1", "", 0)
+
+		sc = &mockServiceCollection{
+			bindings: map[string][]string{
+				"app1": []string{
+					destState.AddUserProvidedService(destSpaceGUID, "ups1", nil),
+					destState.AddUserProvidedService(destSpaceGUID, "ups2", nil),
+				},
+			},
+		}
+		sc.bindings["app2"] = []string{
+			sc.bindings["app1"][1],
+			destState.AddUserProvidedService(destSpaceGUID, "ups3", nil),
+			destState.AddUserProvidedService(destSpaceGUID, "ups4", nil),
+		}
 
 		am = copy.NewCfCliApplicationsManager()
-		sc = &mockServiceCollection{}
 
 		err = am.Init(srcSession, destSession, logger)
 		if err != nil {
@@ -48,164 +86,6 @@ var _ = Describe("Application Manager Tests", func() {
 	Context("Copy Applications", func() {
 		It("Should copy applications from source to destination sessions.", func() {
 
-			srcSession.MockAppSummary = func() api.AppSummaryRepository {
-				return &FakeAppSummaryRepository{
-					GetSummariesInCurrentSpaceStub: func() (apps []models.Application, apiErr error) {
-						return srcApps, nil
-					},
-				}
-			}
-			srcSession.MockDownloadAppContent = func(appGUID string, outputFile *os.File, asDroplet bool) (err error) {
-				Expect(asDroplet).Should(BeFalse())
-				Expect(outputFile).ShouldNot(BeNil())
-				_, validAppGUID := utils.ContainsInStrings([]string{appGUID}, []string{srcApps[0].GUID, srcApps[1].GUID})
-				Expect(validAppGUID).Should(BeTrue())
-				_, err = outputFile.WriteString(srcAppContent[appGUID])
-				return
-			}
-			srcSession.MockDomains = func() api.DomainRepository {
-				return &FakeDomainRepository{
-					FirstOrDefaultStub: func(orgGUID string, name *string) (models.DomainFields, error) {
-						Expect(orgGUID).To(Equal(srcOrg.GUID))
-						return srcDefaultDomain, nil
-					},
-				}
-			}
-			destSession.MockApplications = func() applications.Repository {
-				return &FakeApplicationsRepository{
-					CreateStub: func(params models.AppParams) (app models.Application, apiErr error) {
-						Expect(params.GUID).Should(BeNil())
-						switch {
-						case *params.Name == srcApps[0].Name:
-							app.GUID = newDestAppGUIDs[0]
-						case *params.Name == srcApps[1].Name:
-							app.GUID = newDestAppGUIDs[1]
-						default:
-							Fail("Invalid app name received for download test")
-						}
-						app.Name = *params.Name
-						app.Routes = []models.RouteSummary{}
-						destApps[app.GUID] = app
-						return
-					},
-					ReadStub: func(name string) (app models.Application, err error) {
-						for _, a := range destApps {
-							if name == a.Name {
-								app = a
-								return
-							}
-						}
-						err = &errors.ModelNotFoundError{}
-						return
-					},
-					UpdateStub: func(appGUID string, params models.AppParams) (app models.Application, err error) {
-						_, validAppGUID := utils.ContainsInStrings([]string{appGUID}, newDestAppGUIDs)
-						Expect(validAppGUID).Should(BeTrue())
-						Expect(*params.State).To(Equal("started"))
-						return
-					},
-					DeleteStub: func(appGUID string) (err error) {
-						app := destApps[appGUID]
-						Expect(app).ShouldNot(BeNil())
-						Expect(app.GUID).To(Equal("app-2000"))
-						delete(destApps, appGUID)
-						return
-					},
-				}
-			}
-			destSession.MockRoutes = func() api.RouteRepository {
-				return &FakeRouteRepository{
-					FindStub: func(host string, domain models.DomainFields, path string, port int) (route models.Route, err error) {
-						for _, r := range destRoutes {
-							if host == r.Host && domain.Name == r.Domain.Name {
-								route = models.Route{
-									GUID:   r.GUID,
-									Host:   r.Host,
-									Domain: r.Domain,
-								}
-								return
-							}
-						}
-						err = &errors.ModelNotFoundError{}
-						return
-					},
-					CreateStub: func(host string, domain models.DomainFields, path string, port int, useRandomPort bool) (route models.Route, err error) {
-						newRouteGUID := fmt.Sprintf("route-%d", destRouteGUIDCounter)
-						destRouteGUIDCounter++
-						destRoutes[newRouteGUID] = models.RouteSummary{
-							GUID:   newRouteGUID,
-							Host:   host,
-							Domain: domain,
-						}
-						route = models.Route{
-							GUID:   newRouteGUID,
-							Host:   host,
-							Domain: domain,
-						}
-						return
-					},
-					BindStub: func(routeGUID, appGUID string) (err error) {
-						route, exists := destRoutes[routeGUID]
-						Expect(exists).Should(BeTrue())
-						app, exists := destApps[appGUID]
-						Expect(exists).Should(BeTrue())
-						app.Routes = append(app.Routes, route)
-						destApps[appGUID] = app
-						return
-					},
-					DeleteStub: func(routeGUID string) (err error) {
-						_, exists := destRoutes[routeGUID]
-						Expect(exists).Should(BeTrue())
-						_, validRouteGUID := utils.ContainsInStrings([]string{routeGUID}, []string{"route-2000", "route-2001"})
-						Expect(validRouteGUID).Should(BeTrue())
-						delete(destRoutes, routeGUID)
-						return
-					},
-				}
-			}
-			destSession.MockApplicationBits = func() applicationbits.Repository {
-				return &FakeApplicationBitsRepository{
-					UploadBitsStub: func(appGUID string, zipFile *os.File, presentFiles []resources.AppFileResource) (apiErr error) {
-						b := new(bytes.Buffer)
-						_, err := io.Copy(b, zipFile)
-						if err != nil {
-							return err
-						}
-						Expect(string(b.Bytes())).To(Equal(destAppContent[appGUID]))
-						return
-					},
-				}
-			}
-			destSession.MockDomains = func() api.DomainRepository {
-				return &FakeDomainRepository{
-					FirstOrDefaultStub: func(orgGUID string, name *string) (models.DomainFields, error) {
-						Expect(orgGUID).To(Equal(destOrg.GUID))
-						return destDefaultDomain, nil
-					},
-					ListDomainsForOrgStub: func(orgGUID string, cb func(models.DomainFields) bool) error {
-						for _, d := range destDomains {
-							if !cb(d) {
-								return nil
-							}
-						}
-						return nil
-					},
-				}
-			}
-			destSession.MockServiceBindings = func() api.ServiceBindingRepository {
-				return &FakeServiceBindingRepository{
-					CreateStub: func(instanceGUID string, appGUID string, paramsMap map[string]interface{}) error {
-						app, exists := destApps[appGUID]
-						Expect(exists).Should(BeTrue())
-						bindings, exists := destAppServiceBindings[app.Name]
-						Expect(exists).Should(BeTrue())
-						_, svcExists := utils.ContainsInStrings([]string{instanceGUID}, bindings)
-						Expect(svcExists).Should(BeTrue())
-						return nil
-					},
-				}
-			}
-
 			ac, err := am.ApplicationsToBeCopied([]string{"app1", "app2"}, false)
 			if err != nil {
 				Fail(err.Error())
@@ -215,139 +95,138 @@ var _ = Describe("Application Manager Tests", func() {
 				Fail(err.Error())
 			}
 
-			app1, exists := destApps["app-2001"]
+			Expect(destState.Count("apps")).To(Equal(2))
+			Expect(destState.Count("routes")).To(Equal(3))
+
+			app1, exists := destState.FindApp(destSpaceGUID, "app1")
 			Expect(exists).Should(BeTrue())
-			Expect(app1.GUID).To(Equal("app-2001"))
-			Expect(app1.Name).To(Equal("app1"))
+			Expect(app1.GUID).ToNot(Equal(oldDestApp1GUID))
+			Expect(app1.State).To(Equal("STARTED"))
 			Expect(len(app1.Routes)).To(Equal(2))
-			Expect(app1.Routes[0].GUID).To(Equal("route-2002"))
 			Expect(app1.Routes[0].URL()).To(Equal("app1.acme-dest.com"))
-			Expect(app1.Routes[1].GUID).To(Equal("route-2003"))
 			Expect(app1.Routes[1].URL()).To(Equal("foo1.acme-test.com"))
+			Expect(len(app1.Services)).To(Equal(2))
+
+			bits, _ := destState.AppBits(app1.GUID)
+			Expect(string(bits)).To(Equal(srcAppContent["app1"]))
 
-			app2, exists := destApps["app-2002"]
+			app2, exists := destState.FindApp(destSpaceGUID, "app2")
 			Expect(exists).Should(BeTrue())
-			Expect(app2.GUID).To(Equal("app-2002"))
-			Expect(app2.Name).To(Equal("app2"))
+			Expect(app2.State).To(Equal("STARTED"))
 			Expect(len(app2.Routes)).To(Equal(1))
-			Expect(app2.Routes[0].GUID).To(Equal("route-2004"))
 			Expect(app2.Routes[0].URL()).To(Equal("app2.acme-dest.com"))
+			Expect(len(app2.Services)).To(Equal(3))
+
+			bits, _ = destState.AppBits(app2.GUID)
+			Expect(string(bits)).To(Equal(srcAppContent["app2"]))
+		})
+
+		It("Should copy applications without routes held by another space at the destination.", func() {
+
+			destDefaultDomain, err := destSession.Domains().FindSharedByName("acme-dest.com")
+			Expect(err).ShouldNot(HaveOccurred())
+			otherSpaceGUID := destState.AddSpace(destOrgGUID, "other-space")
+			destState.AddRoute(otherSpaceGUID, destDefaultDomain.GUID, "app2", "", 0)
+
+			ac, err := am.ApplicationsToBeCopied([]string{"app1", "app2"}, false)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(am.DoCopy(ac, sc, "", "")).To(Succeed())
+
+			app1, exists := destState.FindApp(destSpaceGUID, "app1")
+			Expect(exists).Should(BeTrue())
+			Expect(len(app1.Routes)).To(Equal(2))
+
+			app2, exists := destState.FindApp(destSpaceGUID, "app2")
+			Expect(exists).Should(BeTrue())
+			Expect(app2.State).To(Equal("STARTED"))
+			Expect(len(app2.Routes)).To(Equal(0))
+
+			route, err := destSession.Routes().Find("app2", destDefaultDomain, "", 0)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(route.Space.GUID).To(Equal(otherSpaceGUID))
+		})
+
+		It("Should show the recent logs of an application which failed to start.", func() {
+
+			outFile, err := ioutil.TempFile("", "copy")
+			Expect(err).ShouldNot(HaveOccurred())
+			defer os.Remove(outFile.Name())
+			defer outFile.Close()
+
+			Expect(am.Init(srcSession, destSession, cfapi.NewFileLogger(false, "false", os.Stdin, outFile))).To(Succeed())
+			destState.FailNextStaging("BuildpackCompileFailed", "Failed to compile droplet")
+
+			ac, err := am.ApplicationsToBeCopied([]string{"app2"}, false)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(am.DoCopy(ac, sc, "", "")).ShouldNot(Succeed())
+
+			output, err := ioutil.ReadFile(outFile.Name())
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(string(output)).To(ContainSubstring("recent logs of application app2 which failed to start"))
+			Expect(string(output)).To(ContainSubstring("Failed to compile droplet"))
+		})
+
+		It("Should copy route paths, TCP route ports and app ports to the destination.", func() {
+
+			srcState.AddRouterGroup("default-tcp", "tcp")
+			srcTCPDomain, err := srcSession.CreateSharedDomain("tcp.acme-src.com", "default-tcp")
+			Expect(err).ShouldNot(HaveOccurred())
+			srcDefaultDomain, err := srcSession.Domains().FindSharedByName("acme-src.com")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			destState.AddRouterGroup("default-tcp", "tcp")
+			destTCPDomain, err := destSession.CreateSharedDomain("tcp.acme-dest.com", "default-tcp")
+			Expect(err).ShouldNot(HaveOccurred())
+			destState.AddRoute(destState.AddSpace(destOrgGUID, "other-space"), destTCPDomain.GUID, "", "", 5001)
+
+			app3 := srcState.AddApp(srcSpaceGUID, "app3", models.ApplicationFields{})
+			srcState.SetAppBits(app3, []byte("application bits content for app3"))
+			for _, r := range []struct {
+				domain models.DomainFields
+				host   string
+				path   string
+				port   int
+			}{
+				{srcDefaultDomain, "app3", "/api", 0},
+				{srcTCPDomain, "", "", 5000},
+				{srcTCPDomain, "", "", 5001},
+			} {
+				route, err := srcSession.CreateRoute(srcSpaceGUID, r.domain, r.host, r.path, r.port, false)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(srcSession.MapRoute(route.GUID, app3, 9000)).To(Succeed())
+			}
+
+			ac, err := am.ApplicationsToBeCopied([]string{"app3"}, false)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(am.DoCopy(ac, sc, "", "")).To(Succeed())
+
+			app, exists := destState.FindApp(destSpaceGUID, "app3")
+			Expect(exists).Should(BeTrue())
+			Expect(len(app.Routes)).To(Equal(3))
+			Expect(app.Routes[0].URL()).To(Equal("app3.acme-dest.com/api"))
+			Expect(app.Routes[1].URL()).To(Equal("tcp.acme-dest.com:5000"))
+			Expect(app.Routes[2].Domain.Name).To(Equal("tcp.acme-dest.com"))
+			Expect(app.Routes[2].Port).ToNot(BeZero())
+			Expect(app.Routes[2].Port).ToNot(Equal(5001))
+
+			for _, r := range app.Routes {
+				mappings, err := destSession.GetRouteMappings(r.GUID)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(mappings).To(Equal([]cfapi.RouteMapping{
+					{RouteGUID: r.GUID, AppGUID: app.GUID, AppPort: 9000},
+				}))
+			}
 		})
 	})
 })
 
 // mockServiceCollection -
 type mockServiceCollection struct {
+	bindings map[string][]string
 }
 
 // AppBindings -
-func (sc mockServiceCollection) AppBindings(appName string) (bindings []string, ok bool) {
-	bindings, ok = destAppServiceBindings[appName]
+func (sc *mockServiceCollection) AppBindings(appName string) (bindings []string, ok bool) {
+	bindings, ok = sc.bindings[appName]
 	return
 }
-
-// Test Model
-
-var timestamp = time.Now().Format(time.RFC3339)
-
-var srcApps = []models.Application{
-	models.Application{
-		ApplicationFields: models.ApplicationFields{
-			Name: "app1",
-			GUID: "app-1000",
-		},
-		Routes: []models.RouteSummary{
-			srcRoutes["route-1000"],
-			srcRoutes["route-1002"],
-		},
-	},
-	models.Application{
-		ApplicationFields: models.ApplicationFields{
-			Name: "app2",
-			GUID: "app-1001",
-		},
-		Routes: []models.RouteSummary{
-			srcRoutes["route-1001"],
-		},
-	},
-}
-var srcRoutes = map[string]models.RouteSummary{
-	"route-1000": models.RouteSummary{
-		GUID:   "route-1000",
-		Host:   "app1",
-		Domain: srcDefaultDomain,
-	},
-	"route-1001": models.RouteSummary{
-		GUID:   "route-1001",
-		Host:   "app2",
-		Domain: srcDefaultDomain,
-	},
-	"route-1002": models.RouteSummary{
-		GUID:   "route-1002",
-		Host:   "foo1",
-		Domain: srcDomains["domain-1001"],
-	},
-}
-var srcDomains = map[string]models.DomainFields{
-	"domain-1000": models.DomainFields{
-		GUID: "domain-1000",
-		Name: "acme-src.com",
-	},
-	"domain-1001": models.DomainFields{
-		GUID: "domain-1001",
-		Name: "acme-test.com",
-	},
-}
-var srcDefaultDomain = srcDomains["domain-1000"]
-
-var destApps = map[string]models.Application{
-	"app-2000": models.Application{
-		ApplicationFields: models.ApplicationFields{
-			Name: "app1",
-			GUID: "app-2000",
-		},
-		Routes: []models.RouteSummary{
-			destRoutes["route-2000"],
-		},
-	},
-}
-var destRoutes = map[string]models.RouteSummary{
-	"route-2000": models.RouteSummary{
-		GUID:   "route-2000",
-		Host:   "app1",
-		Domain: destDefaultDomain,
-	},
-	"route-2001": models.RouteSummary{
-		GUID:   "route-2001",
-		Host:   "foo1",
-		Domain: destDomains["domain-2001"],
-	},
-}
-var destDomains = map[string]models.DomainFields{
-	"domain-2000": models.DomainFields{
-		GUID: "domain-2000",
-		Name: "acme-dest.com",
-	},
-	"domain-2001": models.DomainFields{
-		GUID: "domain-2001",
-		Name: "acme-test.com",
-	},
-}
-var destDefaultDomain = destDomains["domain-2000"]
-
-var newDestAppGUIDs = []string{"app-2001", "app-2002"}
-var destRouteGUIDCounter = 2002
-
-var srcAppContent = map[string]string{
-	"app-1000": fmt.Sprintf("application bits content for app1: %s", timestamp),
-	"app-1001": fmt.Sprintf("application bits content for app3: %s", timestamp),
-}
-var destAppContent = map[string]string{
-	newDestAppGUIDs[0]: srcAppContent["app-1000"],
-	newDestAppGUIDs[1]: srcAppContent["app-1001"],
-}
-
-var destAppServiceBindings = map[string][]string{
-	"app1": []string{"svc-2000", "svc-2001"},
-	"app2": []string{"svc-2001", "svc-2002", "svc-2003"},
-}
@@ -1,7 +1,7 @@
 package copy_test
 
 import (
-	"code.cloudfoundry.org/cli/cf/models"
+	"github.com/mevansam/cf-cli-api/cfapi"
 	. "github.com/mevansam/cf-cli-api/cfapi/mocks"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -14,38 +14,34 @@ func TestCmd(t *testing.T) {
 	RunSpecs(t, "Copy Managers Test Suite")
 }
 
-var srcSession = &MockSession{
-	MockGetSessionOrg: func() models.OrganizationFields {
-		return srcOrg
-	},
-	MockGetSessionSpace: func() models.SpaceFields {
-		return srcSpace
-	},
-}
+// The source and destination sessions target spaces in
+// separate in-memory states as they would be when copying
+// between Cloud Foundry deployments.
+var (
+	srcState, destState     *MemoryState
+	srcSession, destSession *MockSession
 
-var destSession = &MockSession{
+	srcOrgGUID, srcSpaceGUID   string
+	destOrgGUID, destSpaceGUID string
+)
 
-	MockGetSessionOrg: func() models.OrganizationFields {
-		return destOrg
-	},
-	MockGetSessionSpace: func() models.SpaceFields {
-		return destSpace
-	},
-}
+// newCopySessions - Resets the source and destination states
+// and creates sessions targeting their spaces
+func newCopySessions(logger *cfapi.Logger) {
 
-var srcOrg = models.OrganizationFields{
-	GUID: "org-1000",
-	Name: "source-org",
-}
-var srcSpace = models.SpaceFields{
-	GUID: "space-1000",
-	Name: "source-space",
-}
-var destOrg = models.OrganizationFields{
-	GUID: "org-2000",
-	Name: "dest-org",
-}
-var destSpace = models.SpaceFields{
-	GUID: "space-2000",
-	Name: "dest-space",
+	srcState = NewMemoryState()
+	srcOrgGUID = srcState.AddOrg("source-org")
+	srcSpaceGUID = srcState.AddSpace(srcOrgGUID, "source-space")
+
+	destState = NewMemoryState()
+	destOrgGUID = destState.AddOrg("dest-org")
+	destSpaceGUID = destState.AddSpace(destOrgGUID, "dest-space")
+
+	srcSession = NewMemorySession(srcState, logger)
+	err := srcSession.SetSessionTarget("source-org", "source-space")
+	Expect(err).ShouldNot(HaveOccurred())
+
+	destSession = NewMemorySession(destState, logger)
+	err = destSession.SetSessionTarget("dest-org", "dest-space")
+	Expect(err).ShouldNot(HaveOccurred())
 }
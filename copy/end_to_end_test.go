@@ -0,0 +1,107 @@
+package copy_test
+
+import (
+	"time"
+
+	"github.com/mevansam/cf-cli-api/cfapi"
+	"github.com/mevansam/cf-cli-api/cfapi/fakecc"
+	"github.com/mevansam/cf-cli-api/copy"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Copy End To End Tests", func() {
+
+	var (
+		err    error
+		logger *cfapi.Logger
+
+		srcFake, destFake           *fakecc.FakeCC
+		srcCfSession, destCfSession cfapi.CfSession
+
+		srcCredentials map[string]interface{}
+	)
+
+	BeforeEach(func() {
+		cfapi.AsyncPollingInterval = time.Millisecond
+		logger = cfapi.NewLogger(false, "false")
+
+		// Source foundation
+
+		srcFake = fakecc.New()
+		srcFake.AddUser("admin", "admin-password")
+		srcSpaceGUID := srcFake.AddSpace(srcFake.AddOrg("source-org"), "source-space")
+		srcDomainGUID := srcFake.AddSharedDomain("acme-src.com")
+
+		appGUID := srcFake.AddApp(srcSpaceGUID, "app1", map[string]interface{}{"state": "STARTED"})
+		srcFake.SetAppPackage(appGUID, []byte("app1 bits"))
+		srcFake.MapRoute(srcFake.AddRoute(srcSpaceGUID, srcDomainGUID, "app1", "", 0), appGUID)
+
+		_, mysqlPlans := srcFake.AddServiceOffering("p-mysql", "100mb")
+		_, redisPlans := srcFake.AddServiceOffering("p-redis", "small")
+		srcCredentials = map[string]interface{}{"hostname": "10.0.0.10", "password": "mysql-password"}
+		srcFake.BindService(srcFake.AddServiceInstance(srcSpaceGUID, "svc1", mysqlPlans[0], srcCredentials), appGUID)
+		srcFake.BindService(srcFake.AddServiceInstance(srcSpaceGUID, "svc2", redisPlans[0], nil), appGUID)
+
+		// Destination foundation
+
+		destFake = fakecc.New()
+		destFake.AddUser("admin", "admin-password")
+		destFake.AddSpace(destFake.AddOrg("dest-org"), "dest-space")
+		destFake.AddSharedDomain("acme-dest.com")
+		destFake.AddServiceOffering("p-redis", "small")
+
+		srcCfSession, err = cfapi.NewCfCliSessionProvider().NewCfSession(srcFake.URL(),
+			"admin", "admin-password", "source-org", "source-space", true, logger)
+		Expect(err).ShouldNot(HaveOccurred())
+		destCfSession, err = cfapi.NewCfCliSessionProvider().NewCfSession(destFake.URL(),
+			"admin", "admin-password", "dest-org", "dest-space", true, logger)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		srcCfSession.Close()
+		destCfSession.Close()
+		srcFake.Close()
+		destFake.Close()
+	})
+
+	It("Should copy applications and their services between Cloud Controller sessions.", func() {
+
+		sm := copy.NewCfCliServicesManager()
+		Expect(sm.Init(srcCfSession, destCfSession, "__%s_copy", logger)).To(Succeed())
+		sc, err := sm.ServicesToBeCopied([]string{"app1"}, []string{"svc1"}, []string{})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(sm.DoCopy(sc, true)).To(Succeed())
+
+		am := copy.NewCfCliApplicationsManager()
+		Expect(am.Init(srcCfSession, destCfSession, logger)).To(Succeed())
+		defer am.Close()
+		ac, err := am.ApplicationsToBeCopied([]string{"app1"}, false)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(am.DoCopy(ac, sc, "", "")).To(Succeed())
+
+		appGUID, exists := destFake.FindByName("apps", "app1")
+		Expect(exists).Should(BeTrue())
+		bits, _ := destFake.AppPackage(appGUID)
+		Expect(string(bits)).To(Equal("app1 bits"))
+		app, _ := destFake.Entity("apps", appGUID)
+		Expect(app["state"]).To(Equal("STARTED"))
+
+		summaries, err := destCfSession.AppSummary().GetSummariesInCurrentSpace()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(len(summaries)).To(Equal(1))
+		Expect(len(summaries[0].Routes)).To(Equal(1))
+		Expect(summaries[0].Routes[0].URL()).To(Equal("app1.acme-dest.com"))
+
+		upsGUID, exists := destFake.FindByName("user_provided_service_instances", "svc1")
+		Expect(exists).Should(BeTrue())
+		ups, _ := destFake.Entity("user_provided_service_instances", upsGUID)
+		Expect(ups["credentials"]).To(Equal(srcCredentials))
+
+		instance, err := destCfSession.Services().FindInstanceByName("svc2")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(instance.ServicePlan.Name).To(Equal("small"))
+
+		Expect(destFake.Count("service_bindings")).To(Equal(2))
+	})
+})
@@ -209,6 +209,10 @@ func (sm *CfCliServicesManager) DoCopy(services ServiceCollection, recreate bool
 			if err != nil {
 				return
 			}
+			_, err = sm.destCCSession.WaitForServiceInstance(serviceInstance.GUID, cfapi.AsyncTimeout)
+			if err != nil {
+				return
+			}
 		}
 		if serviceExists && !recreate {
 			sm.logger.UI.Say("+ existing service %s will be reused.",
@@ -273,6 +277,18 @@ func (sm *CfCliServicesManager) DoCopy(services ServiceCollection, recreate bool
 		if err != nil {
 			return
 		}
+		if !serviceInstance.IsUserProvided() && !(serviceExists && !recreate) {
+
+			// Apps can only be bound to the service instance once the
+			// broker has completed provisioning it. Reused instances are
+			// bound as they are whatever their last operation was.
+
+			sm.logger.DebugMessage("Waiting for last operation on service instance %s at destination to complete.", serviceInstance.Name)
+			_, err = sm.destCCSession.WaitForServiceInstance(serviceInstance.GUID, cfapi.AsyncTimeout)
+			if err != nil {
+				return
+			}
+		}
 		sc.destServiceInstanceMap[serviceInstance.Name] = serviceInstance
 
 		for _, g := range rebindAppGUIDS {